@@ -4,16 +4,23 @@ import (
 	"context"
 	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"cf-dns-bot/external_resource/cloudflare"
+	"cf-dns-bot/external_resource/cloudflare/cloudflaretest"
 	"cf-dns-bot/internal/domain"
 	"cf-dns-bot/internal/handler"
 	"cf-dns-bot/internal/handler/telegram"
@@ -25,22 +32,28 @@ import (
 
 // MCPHTTPServer implements the MCPHTTPServerController interface
 type MCPHTTPServer struct {
-	dnsUsecase    usecase.DNSUsecase
-	apiKeyStorage storage.APIKeyStorage
-	configStorage telegram.ConfigStorage
-	server        *http.Server
-	port          string
-	running       bool
-	mu            sync.RWMutex
+	dnsUsecase        usecase.DNSUsecase
+	zoneImportUsecase usecase.ZoneImportUsecase
+	zoneSyncUsecase   usecase.ZoneSyncUsecase
+	auditStorage      storage.AuditStorage
+	apiKeyStorage     storage.APIKeyStorage
+	configStorage     telegram.ConfigStorage
+	server            *http.Server
+	port              string
+	running           bool
+	mu                sync.RWMutex
 }
 
 // NewMCPHTTPServer creates a new MCP HTTP server controller
-func NewMCPHTTPServer(dnsUsecase usecase.DNSUsecase, apiKeyStorage storage.APIKeyStorage, configStorage telegram.ConfigStorage) *MCPHTTPServer {
+func NewMCPHTTPServer(dnsUsecase usecase.DNSUsecase, zoneImportUsecase usecase.ZoneImportUsecase, zoneSyncUsecase usecase.ZoneSyncUsecase, auditStorage storage.AuditStorage, apiKeyStorage storage.APIKeyStorage, configStorage telegram.ConfigStorage) *MCPHTTPServer {
 	return &MCPHTTPServer{
-		dnsUsecase:    dnsUsecase,
-		apiKeyStorage: apiKeyStorage,
-		configStorage: configStorage,
-		port:          "8875",
+		dnsUsecase:        dnsUsecase,
+		zoneImportUsecase: zoneImportUsecase,
+		zoneSyncUsecase:   zoneSyncUsecase,
+		auditStorage:      auditStorage,
+		apiKeyStorage:     apiKeyStorage,
+		configStorage:     configStorage,
+		port:              "8875",
 	}
 }
 
@@ -149,6 +162,11 @@ func (s *MCPHTTPServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 	envKeys := loadAPIKeys()
 	apiKeys = append(apiKeys, envKeys...)
 
+	// Changes made without API keys configured are attributed to an anonymous client
+	actor := domain.Actor{Name: "anonymous", Channel: domain.ChannelMCPHTTP}
+	account := ""                 // keys may be restricted to a Cloudflare account
+	var scope storage.APIKeyScope // and to zones, records and tools
+
 	// API Key Authentication
 	if len(apiKeys) > 0 {
 		authHeader := r.Header.Get("Authorization")
@@ -157,7 +175,7 @@ func (s *MCPHTTPServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"jsonrpc": "2.0",
 				"error": map[string]interface{}{
-					"code":    -32001,
+					"code":    handler.RPCUnauthorized,
 					"message": "Missing Authorization header",
 				},
 			})
@@ -170,7 +188,7 @@ func (s *MCPHTTPServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"jsonrpc": "2.0",
 				"error": map[string]interface{}{
-					"code":    -32001,
+					"code":    handler.RPCUnauthorized,
 					"message": "Invalid Authorization format. Use: Bearer <token>",
 				},
 			})
@@ -183,13 +201,18 @@ func (s *MCPHTTPServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"jsonrpc": "2.0",
 				"error": map[string]interface{}{
-					"code":    -32001,
+					"code":    handler.RPCUnauthorized,
 					"message": "Invalid API key",
 				},
 			})
 			return
 		}
+		actor = domain.Actor{ID: keyPrefix(apiKey), Name: "API key " + keyPrefix(apiKey), Channel: domain.ChannelMCPHTTP}
+		account = s.apiKeyStorage.GetAPIKeyAccount(apiKey)
+		scope = s.apiKeyStorage.GetAPIKeyScope(apiKey)
 	}
+	ctx := usecase.WithAccount(usecase.WithActor(r.Context(), actor), account)
+	ctx = handler.WithKeyScope(ctx, scope)
 
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -239,9 +262,9 @@ func (s *MCPHTTPServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 			},
 		}
 	case "tools/list":
-		result = getToolsList()
+		result = scopedToolsList(scope)
 	case "tools/call":
-		result, err = handleToolCall(s.dnsUsecase, req.Params)
+		result, err = s.handleToolCall(ctx, req.Params)
 	default:
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -261,10 +284,7 @@ func (s *MCPHTTPServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		response["error"] = map[string]interface{}{
-			"code":    -32603,
-			"message": err.Error(),
-		}
+		response["error"] = rpcError(err)
 	} else {
 		response["result"] = result
 	}
@@ -273,13 +293,52 @@ func (s *MCPHTTPServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// rpcError converts a tool error to a JSON-RPC error. The data names the
+// kind of error; validation errors also name the offending field.
+func rpcError(err error) map[string]interface{} {
+	data := map[string]interface{}{"kind": handler.ErrorKind(err)}
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		data["field"] = validationErr.Field
+		data["reason"] = validationErr.Message
+	}
+
+	return map[string]interface{}{
+		"code":    handler.RPCCode(err),
+		"message": err.Error(),
+		"data":    data,
+	}
+}
+
 func main() {
+	fakeCloudflare := flag.Bool("fake-cloudflare", false, "serve demo zones from an in-memory fake of Cloudflare instead of the real API")
+	flag.Parse()
+	if *fakeCloudflare {
+		os.Setenv("FAKE_CLOUDFLARE", "true")
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Point the Cloudflare client at a fake API with demo zones; bot data is
+	// kept apart from that of the real zones
+	var credentials []cloudflare.Credentials
+	if cfg.FakeCloudflare {
+		fake := httptest.NewServer(cloudflaretest.NewServer(cloudflaretest.NewDemoStore()))
+		defer fake.Close()
+
+		credentials = []cloudflare.Credentials{{
+			Account:  "demo",
+			APIToken: "fake-token",
+			BaseURL:  fake.URL + "/client/v4",
+		}}
+		cfg.DataDir = filepath.Join(cfg.DataDir, "fake")
+		log.Printf("Using fake Cloudflare API at %s; changes are lost on exit and data is kept in %s", fake.URL, cfg.DataDir)
+	}
+
 	// Initialize storage
 	configStorage := storage.NewJSONStorageWithAPIKeys(cfg.DataDir)
 
@@ -297,13 +356,20 @@ func main() {
 		}
 	}
 
-	// Initialize Cloudflare client
-	var cfClient cloudflare.Client
-	if cfg.UseAPIToken() {
-		cfClient, err = cloudflare.NewClient(cfg.CloudflareAPIToken)
-	} else {
-		cfClient, err = cloudflare.NewClientWithKey(cfg.CloudflareAPIKey, cfg.CloudflareEmail)
+	// Initialize a Cloudflare client per account; each paces and retries
+	// its calls so bursts do not exhaust the account's API rate limit.
+	// The fake stands in for every real account.
+	if !cfg.FakeCloudflare {
+		for _, account := range cfg.CloudflareAccounts {
+			credentials = append(credentials, cloudflare.Credentials{
+				Account:  account.Name,
+				APIToken: account.APIToken,
+				APIKey:   account.APIKey,
+				Email:    account.Email,
+			})
+		}
 	}
+	cfClient, err := cloudflare.NewAccountsClient(credentials, cloudflare.DefaultResilienceConfig())
 	if err != nil {
 		log.Fatalf("Failed to create Cloudflare client: %v", err)
 	}
@@ -314,21 +380,38 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to connect to Cloudflare: %v", err)
 	}
-	log.Printf("Connected to Cloudflare. Found %d zones.", len(zones))
-
-	// Initialize repositories
-	zoneRepo := repository.NewZoneRepository(cfClient)
-	dnsRepo := repository.NewDNSRepository(cfClient)
-
-	// Initialize usecase
-	dnsUsecase := usecase.NewDNSUsecase(zoneRepo, dnsRepo, configStorage)
+	log.Printf("Connected to Cloudflare. Found %d zones in %d account(s).", len(zones), len(credentials))
+
+	// Initialize repositories behind a cache shared by the bot and MCP server
+	cache := repository.NewCachedRepository(repository.NewZoneRepository(cfClient), repository.NewDNSRepository(cfClient), repository.CacheConfig{
+		ZoneTTL:   cfg.CacheZoneTTL,
+		RecordTTL: cfg.CacheRecordTTL,
+	})
+
+	// Initialize usecase; every change is recorded in the audit log
+	auditStorage := storage.NewAuditStorage(cfg.DataDir)
+	dnsUsecase := usecase.NewAuditedDNSUsecase(usecase.NewDNSUsecase(cache, cache, configStorage), auditStorage)
+	zoneImportUsecase := usecase.NewZoneImportUsecase(dnsUsecase)
+	zoneSyncUsecase := usecase.NewZoneSyncUsecase(dnsUsecase)
+	snapshotUsecase := usecase.NewSnapshotUsecase(dnsUsecase, storage.NewSnapshotStorage(cfg.DataDir), usecase.SnapshotRetention{
+		Keep:   cfg.SnapshotKeep,
+		MaxAge: cfg.SnapshotMaxAge,
+	})
+
+	// Changes in the bot that the policy holds wait for a second user
+	approvalUsecase := usecase.NewApprovalUsecase(dnsUsecase, snapshotUsecase, zoneImportUsecase, usecase.ApprovalPolicy{
+		Deletes:        cfg.ApprovalDeletes,
+		BulkChanges:    cfg.ApprovalBulkChanges,
+		ProtectedZones: cfg.ApprovalProtectedZones,
+		Timeout:        cfg.ApprovalTimeout,
+	})
 
 	// Create MCP HTTP server controller
-	mcpHTTPController := NewMCPHTTPServer(dnsUsecase, configStorage, configStorage)
+	mcpHTTPController := NewMCPHTTPServer(dnsUsecase, zoneImportUsecase, zoneSyncUsecase, auditStorage, configStorage, configStorage)
 
 	// Initialize Telegram bot handler with all dependencies
 	// configStorage implements CombinedStorage which includes AllowedUserStorage
-	botHandler := telegram.NewBot(dnsUsecase, cfg.TelegramBotToken, storageConfig.AllowedUsers, configStorage, configStorage, mcpHTTPController, configStorage, configStorage)
+	botHandler := telegram.NewBot(dnsUsecase, zoneImportUsecase, snapshotUsecase, approvalUsecase, cfg.TelegramBotToken, storageConfig.AllowedUsers, configStorage, configStorage, mcpHTTPController, configStorage, configStorage, auditStorage, cache)
 
 	// Start bot in a goroutine
 	go func() {
@@ -345,6 +428,15 @@ func main() {
 		}
 	}()
 
+	// Take zone snapshots in the background
+	snapshotCtx, stopSnapshots := context.WithCancel(context.Background())
+	defer stopSnapshots()
+	if cfg.SnapshotInterval > 0 {
+		go runSnapshotter(snapshotCtx, snapshotUsecase, cfg.SnapshotInterval)
+	} else {
+		log.Println("[Snapshot] Scheduled snapshots disabled")
+	}
+
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -353,6 +445,7 @@ func main() {
 	<-sigChan
 
 	log.Println("Shutting down...")
+	stopSnapshots()
 
 	// Stop MCP HTTP server
 	if err := mcpHTTPController.Stop(); err != nil {
@@ -367,6 +460,29 @@ func main() {
 	log.Println("Bot stopped.")
 }
 
+// runSnapshotter snapshots all zones on startup and then on every interval
+// until ctx is cancelled
+func runSnapshotter(ctx context.Context, snapshotUsecase usecase.SnapshotUsecase, interval time.Duration) {
+	log.Printf("[Snapshot] Taking zone snapshots every %s", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		taken, err := snapshotUsecase.TakeSnapshots(ctx)
+		if err != nil {
+			log.Printf("[Snapshot] Completed with errors, %d new snapshot(s): %v", taken, err)
+		} else {
+			log.Printf("[Snapshot] Completed, %d new snapshot(s)", taken)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // loadAPIKeys loads API keys from environment
 func loadAPIKeys() []string {
 	keys := []string{}
@@ -384,6 +500,15 @@ func loadAPIKeys() []string {
 	return keys
 }
 
+// keyPrefix returns the first characters of an API key, enough to tell
+// keys apart in the audit log without revealing them
+func keyPrefix(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return key[:8] + "…"
+}
+
 // isValidAPIKey checks if the provided key is valid
 func isValidAPIKey(validKeys []string, key string) bool {
 	for _, validKey := range validKeys {
@@ -394,6 +519,73 @@ func isValidAPIKey(validKeys []string, key string) bool {
 	return false
 }
 
+// tagsSchema describes the tags argument of record tools
+func tagsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "string"},
+		"description": "Tags as name:value, e.g. owner:dns-team; on update an empty list removes all tags",
+	}
+}
+
+// recordFilterSchema describes the arguments of list_records
+func recordFilterSchema() map[string]interface{} {
+	str := func(description string) map[string]interface{} {
+		return map[string]interface{}{"type": "string", "description": description}
+	}
+	return map[string]interface{}{
+		"zone_name":        str("The zone/domain name (e.g., example.com)"),
+		"name":             str("Only records with exactly this name"),
+		"type":             map[string]interface{}{"type": "string", "enum": domain.RecordTypes, "description": "Only records of this type"},
+		"content":          str("Only records with exactly this content"),
+		"name_contains":    str("Only records whose name contains this text"),
+		"name_starts_with": str("Only records whose name starts with this text"),
+		"name_ends_with":   str("Only records whose name ends with this text"),
+		"proxied":          map[string]interface{}{"type": "boolean", "description": "Only proxied, or only unproxied records"},
+		"comment":          str("Only records with exactly this comment"),
+		"tag":              str("Only records with this tag (name, or name:value)"),
+		"match":            map[string]interface{}{"type": "string", "enum": []string{"all", "any"}, "description": "Whether records must match all filters (default) or any of them; the name_* filters always apply"},
+		"order":            map[string]interface{}{"type": "string", "enum": domain.RecordOrders, "description": "Field to order the records by"},
+		"direction":        map[string]interface{}{"type": "string", "enum": []string{"asc", "desc"}},
+		"page":             map[string]interface{}{"type": "number", "description": "1-based page; leave out to list every record"},
+		"per_page":         map[string]interface{}{"type": "number", "description": "Records per page (default 100)"},
+	}
+}
+
+// recordDataSchema describes the structured data of compound records, with
+// one shape per record type
+func recordDataSchema() map[string]interface{} {
+	str := map[string]interface{}{"type": "string"}
+	num := map[string]interface{}{"type": "number"}
+	shape := func(title string, properties map[string]interface{}, required ...string) map[string]interface{} {
+		return map[string]interface{}{"title": title, "type": "object", "properties": properties, "required": required}
+	}
+
+	return map[string]interface{}{
+		"type":        "object",
+		"description": "Structured data instead of content for SRV, CAA, TLSA, SSHFP, URI, LOC, HTTPS, SVCB, NAPTR, DS, CERT and SMIMEA records",
+		"oneOf": []map[string]interface{}{
+			shape("SRV", map[string]interface{}{"service": str, "proto": str, "weight": num, "port": num, "target": str}, "weight", "port", "target"),
+			shape("CAA", map[string]interface{}{"flags": num, "tag": str, "value": str}, "tag", "value"),
+			shape("TLSA", map[string]interface{}{"usage": num, "selector": num, "matching_type": num, "certificate": str}, "usage", "selector", "matching_type", "certificate"),
+			shape("SSHFP", map[string]interface{}{"algorithm": num, "type": num, "fingerprint": str}, "algorithm", "type", "fingerprint"),
+			shape("URI", map[string]interface{}{"weight": num, "target": str}, "weight", "target"),
+			shape("LOC", map[string]interface{}{
+				"lat_degrees": num, "lat_minutes": num, "lat_seconds": num, "lat_direction": str,
+				"long_degrees": num, "long_minutes": num, "long_seconds": num, "long_direction": str,
+				"altitude": num, "size": num, "precision_horz": num, "precision_vert": num,
+			}, "lat_degrees", "lat_direction", "long_degrees", "long_direction"),
+			shape("HTTPS/SVCB", map[string]interface{}{"priority": num, "target": str, "value": str}, "priority", "target"),
+			shape("NAPTR", map[string]interface{}{
+				"order": num, "preference": num, "flags": str, "service": str, "regex": str, "replacement": str,
+			}, "order", "preference", "replacement"),
+			shape("DS", map[string]interface{}{"key_tag": num, "algorithm": num, "digest_type": num, "digest": str}, "key_tag", "algorithm", "digest_type", "digest"),
+			shape("CERT", map[string]interface{}{"type": num, "key_tag": num, "algorithm": num, "certificate": str}, "type", "key_tag", "algorithm", "certificate"),
+			shape("SMIMEA", map[string]interface{}{"usage": num, "selector": num, "matching_type": num, "certificate": str}, "usage", "selector", "matching_type", "certificate"),
+		},
+	}
+}
+
 // getToolsList returns list of available tools
 func getToolsList() map[string]interface{} {
 	return map[string]interface{}{
@@ -408,21 +600,30 @@ func getToolsList() map[string]interface{} {
 			},
 			{
 				"name":        "list_records",
-				"description": "List all DNS records for a specific zone",
+				"description": "List the DNS records of a zone. All filters are optional and matched by Cloudflare; use page and per_page for large zones",
+				"inputSchema": map[string]interface{}{
+					"type":       "object",
+					"properties": recordFilterSchema(),
+					"required":   []string{"zone_name"},
+				},
+			},
+			{
+				"name":        "search_records",
+				"description": "Search every zone for records whose name or content contains the query, ignoring case. An IP address matches whole addresses only, including within content such as SPF. Results are grouped by zone",
 				"inputSchema": map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
-						"zone_name": map[string]interface{}{
+						"query": map[string]interface{}{
 							"type":        "string",
-							"description": "The zone/domain name",
+							"description": "Part of a record name or content, or an IP address (e.g., '192.0.2.10', 'api.', 'mail')",
 						},
 					},
-					"required": []string{"zone_name"},
+					"required": []string{"query"},
 				},
 			},
 			{
 				"name":        "get_record",
-				"description": "Get details of a specific DNS record",
+				"description": "Get details of a specific DNS record. Identify the record by record_id, or by record_name plus type/content when several records share a name",
 				"inputSchema": map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -430,12 +631,24 @@ func getToolsList() map[string]interface{} {
 							"type":        "string",
 							"description": "The zone/domain name",
 						},
+						"record_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The record ID",
+						},
 						"record_name": map[string]interface{}{
 							"type":        "string",
 							"description": "The full record name",
 						},
+						"type": map[string]interface{}{
+							"type":        "string",
+							"description": "Record type, to narrow down records sharing a name",
+						},
+						"content": map[string]interface{}{
+							"type":        "string",
+							"description": "Record content, to narrow down records sharing a name and type",
+						},
 					},
-					"required": []string{"zone_name", "record_name"},
+					"required": []string{"zone_name"},
 				},
 			},
 			{
@@ -446,41 +659,53 @@ func getToolsList() map[string]interface{} {
 					"properties": map[string]interface{}{
 						"zone_name": map[string]interface{}{"type": "string"},
 						"name":      map[string]interface{}{"type": "string"},
-						"type":      map[string]interface{}{"type": "string"},
+						"type":      map[string]interface{}{"type": "string", "enum": domain.RecordTypes},
 						"content":   map[string]interface{}{"type": "string"},
 						"ttl":       map[string]interface{}{"type": "number"},
 						"proxied":   map[string]interface{}{"type": "boolean"},
+						"priority":  map[string]interface{}{"type": "number", "description": "Priority for MX, SRV and URI records (required)"},
+						"data":      recordDataSchema(),
+						"comment":   map[string]interface{}{"type": "string", "description": "Free-text note, e.g. owner or ticket number"},
+						"tags":      tagsSchema(),
 					},
-					"required": []string{"zone_name", "name", "type", "content"},
+					"required": []string{"zone_name", "name", "type"},
 				},
 			},
 			{
 				"name":        "update_record",
-				"description": "Update an existing DNS record",
+				"description": "Update an existing DNS record. Identify the record by record_id, or by name plus type/match_content when several records share a name. Only the given fields change; omitted fields keep their current values",
 				"inputSchema": map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
-						"zone_name": map[string]interface{}{"type": "string"},
-						"record_id": map[string]interface{}{"type": "string"},
-						"name":      map[string]interface{}{"type": "string"},
-						"type":      map[string]interface{}{"type": "string"},
-						"content":   map[string]interface{}{"type": "string"},
-						"ttl":       map[string]interface{}{"type": "number"},
-						"proxied":   map[string]interface{}{"type": "boolean"},
+						"zone_name":     map[string]interface{}{"type": "string"},
+						"record_id":     map[string]interface{}{"type": "string"},
+						"name":          map[string]interface{}{"type": "string"},
+						"type":          map[string]interface{}{"type": "string"},
+						"match_content": map[string]interface{}{"type": "string", "description": "Current content of the record to update"},
+						"content":       map[string]interface{}{"type": "string"},
+						"ttl":           map[string]interface{}{"type": "number"},
+						"proxied":       map[string]interface{}{"type": "boolean"},
+						"priority":      map[string]interface{}{"type": "number"},
+						"data":          recordDataSchema(),
+						"comment":       map[string]interface{}{"type": "string", "description": "New comment; an empty string removes it"},
+						"tags":          tagsSchema(),
 					},
-					"required": []string{"zone_name", "record_id", "name", "type", "content"},
+					"required": []string{"zone_name"},
 				},
 			},
 			{
 				"name":        "delete_record",
-				"description": "Delete a DNS record",
+				"description": "Delete a DNS record. Identify the record by record_id, or by record_name plus type/content when several records share a name",
 				"inputSchema": map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
 						"zone_name":   map[string]interface{}{"type": "string"},
+						"record_id":   map[string]interface{}{"type": "string"},
 						"record_name": map[string]interface{}{"type": "string"},
+						"type":        map[string]interface{}{"type": "string"},
+						"content":     map[string]interface{}{"type": "string"},
 					},
-					"required": []string{"zone_name", "record_name"},
+					"required": []string{"zone_name"},
 				},
 			},
 			{
@@ -491,38 +716,178 @@ func getToolsList() map[string]interface{} {
 					"properties": map[string]interface{}{
 						"zone_name": map[string]interface{}{"type": "string"},
 						"name":      map[string]interface{}{"type": "string"},
-						"type":      map[string]interface{}{"type": "string"},
+						"type":      map[string]interface{}{"type": "string", "enum": domain.RecordTypes},
 						"content":   map[string]interface{}{"type": "string"},
 						"ttl":       map[string]interface{}{"type": "number"},
 						"proxied":   map[string]interface{}{"type": "boolean"},
+						"priority":  map[string]interface{}{"type": "number", "description": "Priority for MX, SRV and URI records (required)"},
+						"data":      recordDataSchema(),
+						"comment":   map[string]interface{}{"type": "string", "description": "Free-text note, e.g. owner or ticket number"},
+						"tags":      tagsSchema(),
+					},
+					"required": []string{"zone_name", "name", "type"},
+				},
+			},
+			{
+				"name":        "export_zone",
+				"description": "Export all records of a zone as a BIND zone file, JSON or CSV",
+				"inputSchema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"zone_name": map[string]interface{}{"type": "string"},
+						"format":    map[string]interface{}{"type": "string", "enum": []string{"bind", "json", "csv"}, "description": "Export format (default: bind)"},
+					},
+					"required": []string{"zone_name"},
+				},
+			},
+			{
+				"name":        "plan_zone",
+				"description": "Diff a desired record set (YAML or JSON document) against a zone and return a plan of creates, updates and deletes. Nothing is changed until apply_plan is called with the plan ID",
+				"inputSchema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"zone_name":     map[string]interface{}{"type": "string"},
+						"document":      map[string]interface{}{"type": "string", "description": "Desired state: {records: [{name, type, content, ttl, proxied, priority}]} in YAML or JSON"},
+						"allow_deletes": map[string]interface{}{"type": "boolean", "description": "Delete live records missing from the document (default: false)"},
+					},
+					"required": []string{"zone_name", "document"},
+				},
+			},
+			{
+				"name":        "apply_plan",
+				"description": "Apply a plan returned by plan_zone. Fails if the zone changed since the plan was made",
+				"inputSchema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"plan_id":   map[string]interface{}{"type": "string"},
+						"zone_name": map[string]interface{}{"type": "string"},
+					},
+					"required": []string{"plan_id"},
+				},
+			},
+			{
+				"name":        "bulk_create",
+				"description": "Create many DNS records in a zone. Returns the result per record; failures do not stop the remaining records",
+				"inputSchema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"zone_name": map[string]interface{}{"type": "string"},
+						"records": map[string]interface{}{
+							"type":        "array",
+							"description": "Records with name, type, content (or data) and optional ttl, proxied and priority",
+							"items": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"name":     map[string]interface{}{"type": "string"},
+									"type":     map[string]interface{}{"type": "string", "enum": domain.RecordTypes},
+									"content":  map[string]interface{}{"type": "string"},
+									"ttl":      map[string]interface{}{"type": "number"},
+									"proxied":  map[string]interface{}{"type": "boolean"},
+									"priority": map[string]interface{}{"type": "number"},
+									"data":     recordDataSchema(),
+									"comment":  map[string]interface{}{"type": "string"},
+									"tags":     tagsSchema(),
+								},
+								"required": []string{"name", "type"},
+							},
+						},
+					},
+					"required": []string{"zone_name", "records"},
+				},
+			},
+			{
+				"name":        "bulk_delete",
+				"description": "Delete all records in a zone matching a filter. At least one of type, name_pattern or content is required. Use dry_run to list the matches first",
+				"inputSchema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"zone_name":    map[string]interface{}{"type": "string"},
+						"type":         map[string]interface{}{"type": "string", "description": "Record type to match"},
+						"name_pattern": map[string]interface{}{"type": "string", "description": "Glob on the record name, e.g. *.dev or test-*"},
+						"content":      map[string]interface{}{"type": "string", "description": "Exact content to match"},
+						"dry_run":      map[string]interface{}{"type": "boolean", "description": "Only list the matching records"},
+					},
+					"required": []string{"zone_name"},
+				},
+			},
+			{
+				"name":        "bulk_update",
+				"description": "Set the TTL and/or proxied status of all records in a zone matching a filter. Use dry_run to list the matches first",
+				"inputSchema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"zone_name":    map[string]interface{}{"type": "string"},
+						"type":         map[string]interface{}{"type": "string", "description": "Record type to match"},
+						"name_pattern": map[string]interface{}{"type": "string", "description": "Glob on the record name, e.g. *.dev or test-*"},
+						"content":      map[string]interface{}{"type": "string", "description": "Exact content to match"},
+						"ttl":          map[string]interface{}{"type": "number", "description": "New TTL (1 = auto)"},
+						"proxied":      map[string]interface{}{"type": "boolean", "description": "New proxy status"},
+						"dry_run":      map[string]interface{}{"type": "boolean", "description": "Only list the matching records"},
+					},
+					"required": []string{"zone_name"},
+				},
+			},
+			{
+				"name":        "undo_change",
+				"description": "Undo a record change using the change ID returned by create, update, delete and upsert. Fails if the record was modified since",
+				"inputSchema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"change_id": map[string]interface{}{"type": "string", "description": "ID of the change to undo"},
+					},
+					"required": []string{"change_id"},
+				},
+			},
+			{
+				"name":        "import_zone",
+				"description": "Import records from a BIND zone file. Returns a preview of the records to create unless apply is true",
+				"inputSchema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"zone_name": map[string]interface{}{"type": "string"},
+						"zone_file": map[string]interface{}{"type": "string", "description": "Zone file contents in BIND format"},
+						"apply":     map[string]interface{}{"type": "boolean", "description": "Create the records instead of only previewing them"},
 					},
-					"required": []string{"zone_name", "name", "type", "content"},
+					"required": []string{"zone_name", "zone_file"},
 				},
 			},
 		},
 	}
 }
 
+// scopedToolsList returns the tools an API key scope allows
+func scopedToolsList(scope storage.APIKeyScope) map[string]interface{} {
+	list := getToolsList()
+	tools := list["tools"].([]map[string]interface{})
+	list["tools"] = slices.DeleteFunc(tools, func(tool map[string]interface{}) bool {
+		return handler.CheckKeyScope(scope, tool["name"].(string)) != nil
+	})
+	return list
+}
+
 // handleToolCall handles tool execution
-func handleToolCall(dnsUsecase usecase.DNSUsecase, params map[string]interface{}) (interface{}, error) {
+func (s *MCPHTTPServer) handleToolCall(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	if params == nil {
 		return nil, fmt.Errorf("params is required")
 	}
 
 	name, _ := params["name"].(string)
 	arguments, _ := params["arguments"].(map[string]interface{})
+	if err := handler.CheckKeyScope(handler.KeyScopeFromContext(ctx), name); err != nil {
+		return nil, err
+	}
 
-	ctx := context.Background()
+	ctx, change := usecase.WithChangeRef(ctx)
 
 	switch name {
 	case "list_zones":
-		zones, err := dnsUsecase.ListZones(ctx)
+		zones, err := s.dnsUsecase.ListZones(ctx)
 		if err != nil {
 			return nil, err
 		}
 		result := make([]map[string]string, len(zones))
 		for i, z := range zones {
-			result[i] = map[string]string{"id": z.ID, "name": z.Name}
+			result[i] = map[string]string{"id": z.ID, "name": z.Name, "account": z.Account}
 		}
 		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(result)}}}, nil
 
@@ -531,21 +896,28 @@ func handleToolCall(dnsUsecase usecase.DNSUsecase, params map[string]interface{}
 		if zoneName == "" {
 			return nil, fmt.Errorf("zone_name is required")
 		}
-		records, err := dnsUsecase.ListRecords(ctx, zoneName)
+		records, err := s.dnsUsecase.ListRecords(ctx, zoneName, getRecordFilter(arguments))
 		if err != nil {
 			return nil, err
 		}
 		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(records)}}}, nil
 
+	case "search_records":
+		results, err := s.dnsUsecase.SearchRecords(ctx, getString(arguments, "query"))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(results)}}}, nil
+
 	case "get_record":
-		zoneName, _ := arguments["zone_name"].(string)
-		recordName, _ := arguments["record_name"].(string)
-		if zoneName == "" || recordName == "" {
-			return nil, fmt.Errorf("zone_name and record_name are required")
+		zoneName := getString(arguments, "zone_name")
+		selector := getRecordSelector(arguments, "record_name", "content")
+		if zoneName == "" || selector.IsEmpty() {
+			return nil, fmt.Errorf("zone_name and record_id or record_name are required")
 		}
-		record, err := dnsUsecase.GetRecord(ctx, zoneName, recordName)
+		record, err := s.dnsUsecase.GetRecord(ctx, zoneName, selector)
 		if err != nil {
-			if err == domain.ErrRecordNotFound {
+			if errors.Is(err, domain.ErrRecordNotFound) {
 				return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": "Record not found"}}}, nil
 			}
 			return nil, err
@@ -560,36 +932,45 @@ func handleToolCall(dnsUsecase usecase.DNSUsecase, params map[string]interface{}
 			Content:  getString(arguments, "content"),
 			TTL:      getInt(arguments, "ttl"),
 			Proxied:  getBool(arguments, "proxied"),
+			Priority: getPriority(arguments),
+			Data:     getRecordData(arguments),
+			Comment:  getString(arguments, "comment"),
+			Tags:     getStrings(arguments, "tags"),
 		}
-		record, err := dnsUsecase.CreateRecord(ctx, input)
+		record, err := s.dnsUsecase.CreateRecord(ctx, input)
 		if err != nil {
 			return nil, err
 		}
-		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(record)}}}, nil
+		return changeResult(toJSON(record), change), nil
 
 	case "update_record":
 		input := usecase.UpdateRecordInput{
 			ZoneName: getString(arguments, "zone_name"),
-			RecordID: getString(arguments, "record_id"),
-			Name:     getString(arguments, "name"),
-			Type:     getString(arguments, "type"),
-			Content:  getString(arguments, "content"),
-			TTL:      getInt(arguments, "ttl"),
-			Proxied:  getBool(arguments, "proxied"),
+			Selector: getRecordSelector(arguments, "name", "match_content"),
+			Content:  getOptionalString(arguments, "content"),
+			TTL:      getOptionalInt(arguments, "ttl"),
+			Proxied:  getOptionalBool(arguments, "proxied"),
+			Priority: getPriority(arguments),
+			Data:     getRecordData(arguments),
+			Comment:  getOptionalString(arguments, "comment"),
+			Tags:     getStrings(arguments, "tags"),
 		}
-		record, err := dnsUsecase.UpdateRecord(ctx, input)
+		record, err := s.dnsUsecase.UpdateRecord(ctx, input)
 		if err != nil {
 			return nil, err
 		}
-		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(record)}}}, nil
+		return changeResult(toJSON(record), change), nil
 
 	case "delete_record":
 		zoneName := getString(arguments, "zone_name")
-		recordName := getString(arguments, "record_name")
-		if err := dnsUsecase.DeleteRecord(ctx, zoneName, recordName); err != nil {
+		selector := getRecordSelector(arguments, "record_name", "content")
+		if zoneName == "" || selector.IsEmpty() {
+			return nil, fmt.Errorf("zone_name and record_id or record_name are required")
+		}
+		if err := s.dnsUsecase.DeleteRecord(ctx, zoneName, selector); err != nil {
 			return nil, err
 		}
-		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": "Record deleted successfully"}}}, nil
+		return changeResult("Record deleted successfully", change), nil
 
 	case "upsert_record":
 		input := usecase.CreateRecordInput{
@@ -599,18 +980,164 @@ func handleToolCall(dnsUsecase usecase.DNSUsecase, params map[string]interface{}
 			Content:  getString(arguments, "content"),
 			TTL:      getInt(arguments, "ttl"),
 			Proxied:  getBool(arguments, "proxied"),
+			Priority: getPriority(arguments),
+			Data:     getRecordData(arguments),
+			Comment:  getString(arguments, "comment"),
+			Tags:     getStrings(arguments, "tags"),
 		}
-		record, err := dnsUsecase.UpsertRecord(ctx, input)
+		record, err := s.dnsUsecase.UpsertRecord(ctx, input)
 		if err != nil {
 			return nil, err
 		}
-		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(record)}}}, nil
+		return changeResult(toJSON(record), change), nil
+
+	case "export_zone":
+		zoneName := getString(arguments, "zone_name")
+		if zoneName == "" {
+			return nil, fmt.Errorf("zone_name is required")
+		}
+		format, err := usecase.ParseExportFormat(getString(arguments, "format"))
+		if err != nil {
+			return nil, err
+		}
+		data, err := s.dnsUsecase.ExportZone(ctx, zoneName, format)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": string(data)}}}, nil
+
+	case "plan_zone":
+		zoneName := getString(arguments, "zone_name")
+		document := getString(arguments, "document")
+		if zoneName == "" || document == "" {
+			return nil, fmt.Errorf("zone_name and document are required")
+		}
+		doc, err := usecase.ParseZoneDocument([]byte(document))
+		if err != nil {
+			return nil, err
+		}
+		doc.Zone = zoneName
+		plan, err := s.zoneSyncUsecase.Plan(ctx, doc, usecase.SyncOptions{AllowDeletes: getBool(arguments, "allow_deletes")})
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(plan)}}}, nil
+
+	case "apply_plan":
+		planID := getString(arguments, "plan_id")
+		if planID == "" {
+			return nil, fmt.Errorf("plan_id is required")
+		}
+		result, err := s.zoneSyncUsecase.Apply(ctx, getString(arguments, "zone_name"), planID)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(result)}}}, nil
+
+	case "import_zone":
+		zoneName := getString(arguments, "zone_name")
+		zoneFile := getString(arguments, "zone_file")
+		if zoneName == "" || zoneFile == "" {
+			return nil, fmt.Errorf("zone_name and zone_file are required")
+		}
+		plan, err := s.zoneImportUsecase.PreviewImport(ctx, zoneName, strings.NewReader(zoneFile))
+		if err != nil {
+			return nil, err
+		}
+		if !getBool(arguments, "apply") {
+			return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(plan)}}}, nil
+		}
+		result, err := s.zoneImportUsecase.ApplyImport(ctx, plan)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(result)}}}, nil
+
+	case "bulk_create":
+		zoneName := getString(arguments, "zone_name")
+		if zoneName == "" {
+			return nil, fmt.Errorf("zone_name is required")
+		}
+		result, err := s.dnsUsecase.BulkCreate(ctx, zoneName, getRecordInputs(arguments, "records"))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(result)}}}, nil
+
+	case "bulk_delete", "bulk_update":
+		zoneName := getString(arguments, "zone_name")
+		if zoneName == "" {
+			return nil, fmt.Errorf("zone_name is required")
+		}
+		filter := usecase.BulkFilter{
+			Type:        getString(arguments, "type"),
+			NamePattern: getString(arguments, "name_pattern"),
+			Content:     getString(arguments, "content"),
+		}
+		if getBool(arguments, "dry_run") {
+			records, err := s.dnsUsecase.MatchRecords(ctx, zoneName, filter)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(map[string]interface{}{"matched": len(records), "records": records})}}}, nil
+		}
+
+		var result *usecase.BulkResult
+		var err error
+		if name == "bulk_delete" {
+			result, err = s.dnsUsecase.BulkDelete(ctx, zoneName, filter)
+		} else {
+			var changes usecase.BulkChanges
+			if _, ok := arguments["ttl"].(float64); ok {
+				ttl := getInt(arguments, "ttl")
+				changes.TTL = &ttl
+			}
+			if proxied, ok := arguments["proxied"].(bool); ok {
+				changes.Proxied = &proxied
+			}
+			result, err = s.dnsUsecase.BulkUpdate(ctx, zoneName, filter, changes)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(result)}}}, nil
+
+	case "undo_change":
+		changeID := getString(arguments, "change_id")
+		if changeID == "" {
+			return nil, fmt.Errorf("change_id is required")
+		}
+		if s.auditStorage == nil {
+			return nil, fmt.Errorf("undo_change needs the audit log, which is not configured")
+		}
+		entry, err := s.auditStorage.GetAuditEntry(changeID)
+		if err != nil {
+			return nil, err
+		}
+		restored, err := s.dnsUsecase.RollbackChange(ctx, *entry)
+		if err != nil {
+			return nil, err
+		}
+		if restored == nil {
+			return changeResult("Change undone, created record removed", change), nil
+		}
+		return changeResult(toJSON(restored), change), nil
 
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", name)
 	}
 }
 
+// changeResult returns a tool result with the given text and, if the
+// change was logged, its ID for undo_change
+func changeResult(text string, change *usecase.ChangeRef) map[string]interface{} {
+	content := []map[string]interface{}{{"type": "text", "text": text}}
+	if change.ID != "" {
+		content = append(content, map[string]interface{}{"type": "text", "text": "Change ID: " + change.ID + " (pass to undo_change to revert)"})
+	}
+	return map[string]interface{}{"content": content}
+}
+
 // Helper functions
 func toJSON(v interface{}) string {
 	b, _ := json.MarshalIndent(v, "", "  ")
@@ -638,5 +1165,130 @@ func getBool(m map[string]interface{}, key string) bool {
 	return false
 }
 
+// getOptionalString returns a string argument, or nil if it is missing
+func getOptionalString(m map[string]interface{}, key string) *string {
+	v, ok := m[key].(string)
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+// getOptionalInt returns a number argument, or nil if it is missing
+func getOptionalInt(m map[string]interface{}, key string) *int {
+	if _, ok := m[key].(float64); !ok {
+		return nil
+	}
+	v := getInt(m, key)
+	return &v
+}
+
+// getOptionalBool returns a boolean argument, or nil if it is missing
+func getOptionalBool(m map[string]interface{}, key string) *bool {
+	v, ok := m[key].(bool)
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+// getStrings returns a string array argument, or nil if it is missing
+func getStrings(m map[string]interface{}, key string) []string {
+	items, ok := m[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if v, ok := item.(string); ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// getRecordFilter reads the filter arguments of list_records
+func getRecordFilter(m map[string]interface{}) domain.RecordFilter {
+	return domain.RecordFilter{
+		Name:           getString(m, "name"),
+		Type:           getString(m, "type"),
+		Content:        getString(m, "content"),
+		NameContains:   getString(m, "name_contains"),
+		NameStartsWith: getString(m, "name_starts_with"),
+		NameEndsWith:   getString(m, "name_ends_with"),
+		Proxied:        getOptionalBool(m, "proxied"),
+		Comment:        getString(m, "comment"),
+		Tag:            getString(m, "tag"),
+		MatchAny:       getString(m, "match") == "any",
+		Order:          getString(m, "order"),
+		Descending:     getString(m, "direction") == "desc",
+		Page:           getInt(m, "page"),
+		PerPage:        getInt(m, "per_page"),
+	}
+}
+
+// getPriority returns the priority argument, or nil if it is missing
+func getPriority(m map[string]interface{}) *uint16 {
+	if _, ok := m["priority"].(float64); !ok {
+		return nil
+	}
+	priority := uint16(getInt(m, "priority"))
+	return &priority
+}
+
+// getRecordData decodes the data argument of compound records, or returns
+// nil if it is missing. Its keys are Cloudflare's data field names.
+func getRecordData(m map[string]interface{}) *domain.RecordData {
+	raw, ok := m["data"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var data domain.RecordData
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil
+	}
+	return &data
+}
+
+// getRecordInputs reads an array of record objects from tool arguments
+func getRecordInputs(m map[string]interface{}, key string) []usecase.CreateRecordInput {
+	items, _ := m[key].([]interface{})
+	inputs := make([]usecase.CreateRecordInput, 0, len(items))
+	for _, item := range items {
+		r, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		inputs = append(inputs, usecase.CreateRecordInput{
+			Name:     getString(r, "name"),
+			Type:     getString(r, "type"),
+			Content:  getString(r, "content"),
+			TTL:      getInt(r, "ttl"),
+			Proxied:  getBool(r, "proxied"),
+			Priority: getPriority(r),
+			Data:     getRecordData(r),
+			Comment:  getString(r, "comment"),
+			Tags:     getStrings(r, "tags"),
+		})
+	}
+	return inputs
+}
+
+// getRecordSelector builds a record selector from tool arguments.
+// nameKey and contentKey name the arguments holding the record name and
+// the current content, since they differ between tools.
+func getRecordSelector(m map[string]interface{}, nameKey, contentKey string) usecase.RecordSelector {
+	return usecase.RecordSelector{
+		RecordID: getString(m, "record_id"),
+		Name:     getString(m, nameKey),
+		Type:     getString(m, "type"),
+		Content:  getString(m, contentKey),
+	}
+}
+
 // ensure Bot implements handler.BotHandler
 var _ handler.BotHandler = (*telegram.Bot)(nil)
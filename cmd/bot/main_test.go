@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cf-dns-bot/external_resource/cloudflare/cloudflaretest"
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/handler"
+	"cf-dns-bot/internal/repository"
+	"cf-dns-bot/internal/usecase"
+	"cf-dns-bot/pkg/storage"
+)
+
+// newTestMCPServer returns the embedded MCP server on top of an in-memory
+// Cloudflare with the demo zones and an audit log, and the storage of its
+// API keys
+func newTestMCPServer(t *testing.T) (*MCPHTTPServer, storage.CombinedStorage) {
+	t.Helper()
+	t.Setenv("MCP_API_KEY", "")
+	t.Setenv("MCP_API_KEYS", "")
+
+	dir := t.TempDir()
+	configStorage := storage.NewJSONStorageWithAPIKeys(dir)
+	auditStorage := storage.NewAuditStorage(dir)
+	client := cloudflaretest.NewClient(cloudflaretest.NewDemoStore())
+	dnsUsecase := usecase.NewAuditedDNSUsecase(usecase.NewDNSUsecase(repository.NewZoneRepository(client), repository.NewDNSRepository(client), configStorage), auditStorage)
+	server := NewMCPHTTPServer(dnsUsecase, usecase.NewZoneImportUsecase(dnsUsecase), usecase.NewZoneSyncUsecase(dnsUsecase), auditStorage, configStorage, configStorage)
+	return server, configStorage
+}
+
+// rpcResponse is a JSON-RPC response of the embedded MCP server
+type rpcResponse struct {
+	Result map[string]interface{} `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// callRPC sends a JSON-RPC request with the API key and decodes the response
+func callRPC(t *testing.T, s *MCPHTTPServer, key, method string, params map[string]interface{}) (int, rpcResponse) {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatalf("encoding request: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body)))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	rec := httptest.NewRecorder()
+	s.handleRequest(rec, req)
+
+	var response rpcResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	return rec.Code, response
+}
+
+// callTool calls an MCP tool and returns the JSON-RPC error code, or 0
+func callTool(t *testing.T, s *MCPHTTPServer, key, tool string, arguments map[string]interface{}) int {
+	t.Helper()
+	_, response := callRPC(t, s, key, "tools/call", map[string]interface{}{"name": tool, "arguments": arguments})
+	if response.Error != nil {
+		return response.Error.Code
+	}
+	return 0
+}
+
+// toolNames returns the names of the tools listed for the API key
+func toolNames(t *testing.T, s *MCPHTTPServer, key string) []string {
+	t.Helper()
+	_, response := callRPC(t, s, key, "tools/list", nil)
+	tools, _ := response.Result["tools"].([]interface{})
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		name, _ := tool.(map[string]interface{})["name"].(string)
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestMCPRecordTools(t *testing.T) {
+	s, keys := newTestMCPServer(t)
+	if err := keys.AddAPIKey("mcp_test"); err != nil {
+		t.Fatalf("AddAPIKey: %v", err)
+	}
+
+	if status, _ := callRPC(t, s, "", "tools/list", nil); status != http.StatusUnauthorized {
+		t.Errorf("without key: got status %d, want 401", status)
+	}
+	if status, _ := callRPC(t, s, "mcp_wrong", "tools/list", nil); status != http.StatusUnauthorized {
+		t.Errorf("wrong key: got status %d, want 401", status)
+	}
+
+	create := map[string]interface{}{"zone_name": "example.com", "name": "mcp", "type": "A", "content": "192.0.2.100"}
+	if code := callTool(t, s, "mcp_test", "create_record", create); code != 0 {
+		t.Fatalf("create_record: got error code %d", code)
+	}
+	if code := callTool(t, s, "mcp_test", "create_record", create); code != handler.RPCConflict {
+		t.Errorf("duplicate create_record: got code %d, want %d", code, handler.RPCConflict)
+	}
+
+	// api.example.com has an A and an AAAA record
+	ambiguous := map[string]interface{}{"zone_name": "example.com", "record_name": "api"}
+	if code := callTool(t, s, "mcp_test", "delete_record", ambiguous); code != handler.RPCConflict {
+		t.Errorf("ambiguous delete_record: got code %d, want %d", code, handler.RPCConflict)
+	}
+
+	remove := map[string]interface{}{"zone_name": "example.com", "record_name": "mcp", "type": "A"}
+	if code := callTool(t, s, "mcp_test", "delete_record", remove); code != 0 {
+		t.Errorf("delete_record: got error code %d", code)
+	}
+	if code := callTool(t, s, "mcp_test", "delete_record", remove); code != handler.RPCNotFound {
+		t.Errorf("second delete_record: got code %d, want %d", code, handler.RPCNotFound)
+	}
+}
+
+func TestMCPKeyScope(t *testing.T) {
+	s, keys := newTestMCPServer(t)
+	keys.AddAPIKey("mcp_ro")
+	if err := keys.SetAPIKeyScope("mcp_ro", storage.APIKeyScope{ReadOnly: true}); err != nil {
+		t.Fatalf("SetAPIKeyScope: %v", err)
+	}
+	keys.AddAPIKey("mcp_acme")
+	keys.SetAPIKeyScope("mcp_acme", storage.APIKeyScope{Records: []string{"_acme-challenge.*"}})
+
+	for _, name := range toolNames(t, s, "mcp_ro") {
+		if !handler.IsReadOnlyTool(name) {
+			t.Errorf("read-only key lists %s", name)
+		}
+	}
+	create := map[string]interface{}{"zone_name": "example.com", "name": "mcp", "type": "A", "content": "192.0.2.100"}
+	if code := callTool(t, s, "mcp_ro", "create_record", create); code != handler.RPCUnauthorized {
+		t.Errorf("read-only create_record: got code %d, want %d", code, handler.RPCUnauthorized)
+	}
+
+	if code := callTool(t, s, "mcp_acme", "create_record", create); code != handler.RPCUnauthorized {
+		t.Errorf("create_record outside the scope: got code %d, want %d", code, handler.RPCUnauthorized)
+	}
+	challenge := map[string]interface{}{"zone_name": "example.com", "name": "_acme-challenge", "type": "TXT", "content": "token"}
+	if code := callTool(t, s, "mcp_acme", "create_record", challenge); code != 0 {
+		t.Errorf("create_record inside the scope: got error code %d", code)
+	}
+}
+
+// changeID returns the change ID of a tool result
+func changeID(t *testing.T, result map[string]interface{}) string {
+	t.Helper()
+	content, _ := result["content"].([]interface{})
+	for _, item := range content {
+		text, _ := item.(map[string]interface{})["text"].(string)
+		if id, ok := strings.CutPrefix(text, "Change ID: "); ok {
+			return strings.Fields(id)[0]
+		}
+	}
+	t.Fatalf("no change ID in %v", result)
+	return ""
+}
+
+func TestMCPUndoChange(t *testing.T) {
+	s, keys := newTestMCPServer(t)
+	keys.AddAPIKey("mcp_test")
+
+	create := map[string]interface{}{"zone_name": "example.com", "name": "undo", "type": "A", "content": "192.0.2.110"}
+	_, response := callRPC(t, s, "mcp_test", "tools/call", map[string]interface{}{"name": "create_record", "arguments": create})
+	if response.Error != nil {
+		t.Fatalf("create_record: got error %+v", response.Error)
+	}
+	change := changeID(t, response.Result)
+
+	if code := callTool(t, s, "mcp_test", "undo_change", map[string]interface{}{"change_id": change}); code != 0 {
+		t.Fatalf("undo_change: got error code %d", code)
+	}
+	_, err := s.dnsUsecase.GetRecord(context.Background(), "example.com", usecase.RecordSelector{Name: "undo", Type: "A"})
+	if !errors.Is(err, domain.ErrRecordNotFound) {
+		t.Errorf("after undo: got error %v, want the record removed", err)
+	}
+
+	if code := callTool(t, s, "mcp_test", "undo_change", map[string]interface{}{"change_id": "unknown"}); code != handler.RPCNotFound {
+		t.Errorf("undo_change of an unknown change: got code %d, want %d", code, handler.RPCNotFound)
+	}
+}
+
+func TestMCPUndoChangeWithoutAuditLog(t *testing.T) {
+	s, keys := newTestMCPServer(t)
+	keys.AddAPIKey("mcp_test")
+	s.auditStorage = nil
+
+	if code := callTool(t, s, "mcp_test", "undo_change", map[string]interface{}{"change_id": "c1"}); code != handler.RPCInternalError {
+		t.Errorf("got code %d, want %d", code, handler.RPCInternalError)
+	}
+}
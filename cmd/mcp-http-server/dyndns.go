@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/handler"
+	"cf-dns-bot/internal/usecase"
+)
+
+// DynDNS2 return codes, see https://help.dyn.com/remote-access-api/return-codes/
+const (
+	dynDNSGood    = "good"
+	dynDNSNoChg   = "nochg"
+	dynDNSNoHost  = "nohost"
+	dynDNSBadAuth = "badauth"
+	dynDNSNotFQDN = "notfqdn"
+	dynDNSNumHost = "numhost"
+	dynDNSDNSErr  = "dnserr"
+	dynDNSServErr = "911"
+)
+
+// maxDynDNSHosts is the most hostnames accepted in one update
+const maxDynDNSHosts = 20
+
+// handleDynDNSUpdate handles GET /nic/update?hostname=...&myip=... using
+// the dyndns2 protocol spoken by ddclient, routers and NAS boxes.
+// The API key is the basic auth password; the user name is ignored.
+// Without myip the address the request came from is used.
+func (s *Server) handleDynDNSUpdate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	_, apiKey, ok := r.BasicAuth()
+	keyInfo, valid := s.apiKeys.Validate(apiKey)
+	if !ok || !valid {
+		w.Header().Set("WWW-Authenticate", `Basic realm="cf-dns"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintln(w, dynDNSBadAuth)
+		return
+	}
+	// An update upserts records, so the key's scope must allow upsert_record
+	if err := handler.CheckKeyScope(keyInfo.Scope, "upsert_record"); err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintln(w, dynDNSBadAuth)
+		return
+	}
+	ctx := usecase.WithActor(r.Context(), domain.Actor{ID: keyInfo.Name, Name: keyInfo.Name, Channel: domain.ChannelDynDNS})
+	ctx = usecase.WithAccount(ctx, keyInfo.Account)
+	ctx = handler.WithKeyScope(ctx, keyInfo.Scope)
+
+	query := r.URL.Query()
+	hostnames := splitList(query.Get("hostname"))
+	if len(hostnames) == 0 {
+		fmt.Fprintln(w, dynDNSNotFQDN)
+		return
+	}
+	if len(hostnames) > maxDynDNSHosts {
+		fmt.Fprintln(w, dynDNSNumHost)
+		return
+	}
+
+	ips, err := dynDNSAddresses(query.Get("myip"), r.RemoteAddr)
+	if err != nil {
+		s.writeDynDNSLines(w, len(hostnames), dynDNSDNSErr)
+		return
+	}
+
+	zones, err := s.dnsUsecase.ListZones(ctx)
+	if err != nil {
+		log.Printf("[DynDNS] ERROR listing zones: %v", err)
+		s.writeDynDNSLines(w, len(hostnames), dynDNSServErr)
+		return
+	}
+
+	for _, hostname := range hostnames {
+		fmt.Fprintln(w, s.updateDynDNSHost(ctx, zones, strings.ToLower(strings.TrimSuffix(hostname, ".")), ips))
+	}
+}
+
+// updateDynDNSHost points one hostname at the given addresses and returns
+// its dyndns2 result line
+func (s *Server) updateDynDNSHost(ctx context.Context, zones []domain.Zone, hostname string, ips []net.IP) string {
+	if !strings.Contains(hostname, ".") {
+		return dynDNSNotFQDN
+	}
+
+	zoneName := zoneForHost(zones, hostname)
+	if zoneName == "" {
+		return dynDNSNoHost
+	}
+
+	changed := false
+	addresses := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		recordType := "AAAA"
+		if ip.To4() != nil {
+			recordType = "A"
+		}
+		content := ip.String()
+		addresses = append(addresses, content)
+
+		existing, err := s.dnsUsecase.GetRecord(ctx, zoneName, usecase.RecordSelector{Name: hostname, Type: recordType})
+		if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+			log.Printf("[DynDNS] ERROR looking up %s %s: %v", recordType, hostname, err)
+			return dynDNSErrorCode(err)
+		}
+		if existing != nil && existing.Content == content {
+			continue
+		}
+
+		// Keep the TTL and proxy setting of an existing record
+		input := usecase.CreateRecordInput{
+			ZoneName: zoneName,
+			Name:     hostname,
+			Type:     recordType,
+			Content:  content,
+		}
+		if existing != nil {
+			input.TTL = existing.TTL
+			input.Proxied = existing.Proxied
+		}
+		if _, err := s.dnsUsecase.UpsertRecord(ctx, input); err != nil {
+			log.Printf("[DynDNS] ERROR updating %s %s: %v", recordType, hostname, err)
+			return dynDNSErrorCode(err)
+		}
+		changed = true
+	}
+
+	if !changed {
+		return dynDNSNoChg + " " + strings.Join(addresses, ",")
+	}
+	log.Printf("[DynDNS] %s -> %s", hostname, strings.Join(addresses, ","))
+	return dynDNSGood + " " + strings.Join(addresses, ",")
+}
+
+// dynDNSErrorCode returns the result of a failed update. 911 asks the
+// client to retry later, which suits rate limiting and credential trouble
+// on the server's side. Hostnames outside the key's scope are unknown.
+func dynDNSErrorCode(err error) string {
+	if errors.Is(err, domain.ErrUnauthorized) {
+		return dynDNSNoHost
+	}
+	if errors.Is(err, domain.ErrRateLimited) || errors.Is(err, domain.ErrCloudflareAuth) {
+		return dynDNSServErr
+	}
+	return dynDNSDNSErr
+}
+
+// writeDynDNSLines writes the same result for every hostname
+func (s *Server) writeDynDNSLines(w http.ResponseWriter, count int, code string) {
+	for i := 0; i < count; i++ {
+		fmt.Fprintln(w, code)
+	}
+}
+
+// dynDNSAddresses parses myip, which may hold an IPv4 and an IPv6 address
+// separated by a comma, falling back to the client address
+func dynDNSAddresses(myip, remoteAddr string) ([]net.IP, error) {
+	values := splitList(myip)
+	if len(values) == 0 {
+		host, _, err := net.SplitHostPort(remoteAddr)
+		if err != nil {
+			host = remoteAddr
+		}
+		values = []string{host}
+	}
+
+	var ips []net.IP
+	seen := make(map[bool]bool) // one address per family
+	for _, v := range values {
+		ip := net.ParseIP(v)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", v)
+		}
+		isV4 := ip.To4() != nil
+		if seen[isV4] {
+			return nil, fmt.Errorf("more than one address of the same family")
+		}
+		seen[isV4] = true
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// zoneForHost returns the longest zone name the hostname belongs to
+func zoneForHost(zones []domain.Zone, hostname string) string {
+	best := ""
+	for _, zone := range zones {
+		name := strings.ToLower(zone.Name)
+		if (hostname == name || strings.HasSuffix(hostname, "."+name)) && len(name) > len(best) {
+			best = zone.Name
+		}
+	}
+	return best
+}
+
+// splitList splits a comma separated parameter, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
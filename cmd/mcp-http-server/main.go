@@ -5,15 +5,20 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"cf-dns-bot/external_resource/cloudflare"
 	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/handler"
 	"cf-dns-bot/internal/repository"
 	"cf-dns-bot/internal/usecase"
 	"cf-dns-bot/pkg/config"
@@ -27,12 +32,14 @@ type APIKeyStore struct {
 
 // APIKey represents an API key with metadata
 type APIKey struct {
-	Key         string    `json:"key"`
-	Name        string    `json:"name"`
-	CreatedAt   time.Time `json:"created_at"`
-	LastUsedAt  time.Time `json:"last_used_at,omitempty"`
-	UsageCount  int       `json:"usage_count"`
-	Enabled     bool      `json:"enabled"`
+	Key        string              `json:"key"`
+	Name       string              `json:"name"`
+	CreatedAt  time.Time           `json:"created_at"`
+	LastUsedAt time.Time           `json:"last_used_at,omitempty"`
+	UsageCount int                 `json:"usage_count"`
+	Enabled    bool                `json:"enabled"`
+	Account    string              `json:"account,omitempty"` // Cloudflare account the key is restricted to
+	Scope      storage.APIKeyScope `json:"scope"`             // zones, records and operations the key may use
 }
 
 // NewAPIKeyStore creates a new API key store
@@ -93,8 +100,9 @@ func (s *APIKeyStore) IsManagement(key string) bool {
 	return exists && k.Name == "management"
 }
 
-// GenerateKey generates a new API key
-func (s *APIKeyStore) GenerateKey(name string) string {
+// GenerateKey generates a new API key, restricted to a Cloudflare account
+// unless account is empty and to the given scope
+func (s *APIKeyStore) GenerateKey(name, account string, scope storage.APIKeyScope) string {
 	bytes := make([]byte, 32)
 	rand.Read(bytes)
 	key := "mcp_" + hex.EncodeToString(bytes)
@@ -103,6 +111,8 @@ func (s *APIKeyStore) GenerateKey(name string) string {
 		Name:      name,
 		CreatedAt: time.Now(),
 		Enabled:   true,
+		Account:   account,
+		Scope:     scope,
 	}
 	return key
 }
@@ -118,25 +128,31 @@ func (s *APIKeyStore) ListKeys() []*APIKey {
 
 // Server represents the HTTP MCP server
 type Server struct {
-	dnsUsecase usecase.DNSUsecase
-	apiKeys    *APIKeyStore
-	port       string
+	dnsUsecase   usecase.DNSUsecase
+	syncUsecase  usecase.ZoneSyncUsecase
+	auditStorage storage.AuditStorage
+	apiKeys      *APIKeyStore
+	port         string
 }
 
 // NewServer creates a new HTTP MCP server
-func NewServer(dnsUsecase usecase.DNSUsecase, apiKeys *APIKeyStore, port string) *Server {
+func NewServer(dnsUsecase usecase.DNSUsecase, syncUsecase usecase.ZoneSyncUsecase, auditStorage storage.AuditStorage, apiKeys *APIKeyStore, port string) *Server {
 	if port == "" {
 		port = "8080"
 	}
 	return &Server{
-		dnsUsecase: dnsUsecase,
-		apiKeys:    apiKeys,
-		port:       port,
+		dnsUsecase:   dnsUsecase,
+		syncUsecase:  syncUsecase,
+		auditStorage: auditStorage,
+		apiKeys:      apiKeys,
+		port:         port,
 	}
 }
 
-// authMiddleware validates API keys
-func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// authMiddleware validates API keys and checks that the key's scope allows
+// the operation, named like the MCP tool that does the same. Management
+// routes pass an empty operation and check the key themselves.
+func (s *Server) authMiddleware(operation string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -157,9 +173,18 @@ func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			s.writeError(w, http.StatusUnauthorized, "Invalid API key")
 			return
 		}
+		if operation != "" {
+			if err := handler.CheckKeyScope(keyInfo.Scope, operation); err != nil {
+				s.writeUsecaseError(w, err)
+				return
+			}
+		}
 
-		// Store key info in context
+		// Store key info in context and attribute changes to the key
 		ctx := context.WithValue(r.Context(), "api_key", keyInfo)
+		ctx = usecase.WithActor(ctx, domain.Actor{ID: keyInfo.Name, Name: keyInfo.Name, Channel: domain.ChannelREST})
+		ctx = usecase.WithAccount(ctx, keyInfo.Account)
+		ctx = handler.WithKeyScope(ctx, keyInfo.Scope)
 		next(w, r.WithContext(ctx))
 	}
 }
@@ -174,6 +199,34 @@ func (s *Server) writeError(w http.ResponseWriter, status int, message string) {
 	})
 }
 
+// writeInvalidRecord writes a 400 response for a rejected record. When
+// the record failed validation the offending field and reason are included.
+func (s *Server) writeInvalidRecord(w http.ResponseWriter, err error) {
+	response := map[string]interface{}{
+		"success": false,
+		"error":   err.Error(),
+	}
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		response["field"] = validationErr.Field
+		response["reason"] = validationErr.Message
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeUsecaseError writes the response of a failed usecase call, with the
+// status of the domain error it wraps
+func (s *Server) writeUsecaseError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrInvalidRecord) {
+		s.writeInvalidRecord(w, err)
+		return
+	}
+	s.writeError(w, handler.HTTPStatus(err), err.Error())
+}
+
 // writeSuccess writes a success response
 func (s *Server) writeSuccess(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -195,17 +248,27 @@ func (s *Server) RegisterRoutes() {
 	})
 
 	// DNS API routes (require API key)
-	http.HandleFunc("/api/zones", s.authMiddleware(s.handleListZones))
-	http.HandleFunc("/api/records", s.authMiddleware(s.handleListRecords))
-	http.HandleFunc("/api/record", s.authMiddleware(s.handleRecord))
-	http.HandleFunc("/api/record/create", s.authMiddleware(s.handleCreateRecord))
-	http.HandleFunc("/api/record/update", s.authMiddleware(s.handleUpdateRecord))
-	http.HandleFunc("/api/record/delete", s.authMiddleware(s.handleDeleteRecord))
-	http.HandleFunc("/api/record/upsert", s.authMiddleware(s.handleUpsertRecord))
+	http.HandleFunc("/api/zones", s.authMiddleware("list_zones", s.handleListZones))
+	http.HandleFunc("/api/records", s.authMiddleware("list_records", s.handleListRecords))
+	http.HandleFunc("/api/record", s.authMiddleware("get_record", s.handleRecord))
+	http.HandleFunc("/api/record/create", s.authMiddleware("create_record", s.handleCreateRecord))
+	http.HandleFunc("/api/record/update", s.authMiddleware("update_record", s.handleUpdateRecord))
+	http.HandleFunc("/api/record/delete", s.authMiddleware("delete_record", s.handleDeleteRecord))
+	http.HandleFunc("/api/record/upsert", s.authMiddleware("upsert_record", s.handleUpsertRecord))
+	http.HandleFunc("GET /api/zones/{zone}/export", s.authMiddleware("export_zone", s.handleExportZone))
+	http.HandleFunc("POST /api/zones/{zone}/plan", s.authMiddleware("plan_zone", s.handlePlanZone))
+	http.HandleFunc("POST /api/zones/{zone}/apply", s.authMiddleware("apply_plan", s.handleApplyPlan))
+	http.HandleFunc("POST /api/zones/{zone}/bulk/create", s.authMiddleware("bulk_create", s.handleBulkCreate))
+	http.HandleFunc("POST /api/zones/{zone}/bulk/delete", s.authMiddleware("bulk_delete", s.handleBulkDelete))
+	http.HandleFunc("POST /api/zones/{zone}/bulk/update", s.authMiddleware("bulk_update", s.handleBulkUpdate))
+
+	// DynDNS2 update route (API key as basic auth password)
+	http.HandleFunc("/nic/update", s.handleDynDNSUpdate)
 
 	// Management routes (require management key)
-	http.HandleFunc("/admin/keys", s.authMiddleware(s.handleManageKeys))
-	http.HandleFunc("/admin/keys/generate", s.authMiddleware(s.handleGenerateKey))
+	http.HandleFunc("/admin/keys", s.authMiddleware("", s.handleManageKeys))
+	http.HandleFunc("GET /api/audit", s.authMiddleware("", s.handleAuditLog))
+	http.HandleFunc("/admin/keys/generate", s.authMiddleware("", s.handleGenerateKey))
 }
 
 // handleListZones handles GET /api/zones
@@ -215,17 +278,17 @@ func (s *Server) handleListZones(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := r.Context()
 	zones, err := s.dnsUsecase.ListZones(ctx)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		s.writeUsecaseError(w, err)
 		return
 	}
 
 	s.writeSuccess(w, zones)
 }
 
-// handleListRecords handles GET /api/records?zone=example.com
+// handleListRecords handles GET /api/records?zone=example.com[&type=A&name_contains=api&page=1...]
 func (s *Server) handleListRecords(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
@@ -238,38 +301,82 @@ func (s *Server) handleListRecords(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := context.Background()
-	records, err := s.dnsUsecase.ListRecords(ctx, zoneName)
+	filter, err := recordFilterFromQuery(r.URL.Query())
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	records, err := s.dnsUsecase.ListRecords(ctx, zoneName, filter)
+	if err != nil {
+		s.writeUsecaseError(w, err)
 		return
 	}
 
 	s.writeSuccess(w, records)
 }
 
-// handleRecord handles GET /api/record?zone=example.com&name=www.example.com
+// recordFilterFromQuery reads the record filter of /api/records. The
+// parameters have the same names as the list_records tool arguments.
+func recordFilterFromQuery(q url.Values) (domain.RecordFilter, error) {
+	filter := domain.RecordFilter{
+		Name:           q.Get("name"),
+		Type:           strings.ToUpper(q.Get("type")),
+		Content:        q.Get("content"),
+		NameContains:   q.Get("name_contains"),
+		NameStartsWith: q.Get("name_starts_with"),
+		NameEndsWith:   q.Get("name_ends_with"),
+		Comment:        q.Get("comment"),
+		Tag:            q.Get("tag"),
+		MatchAny:       q.Get("match") == "any",
+		Order:          q.Get("order"),
+		Descending:     q.Get("direction") == "desc",
+	}
+
+	if v := q.Get("proxied"); v != "" {
+		proxied, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid 'proxied' query parameter: %q", v)
+		}
+		filter.Proxied = &proxied
+	}
+	for key, field := range map[string]*int{"page": &filter.Page, "per_page": &filter.PerPage} {
+		if v := q.Get(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid '%s' query parameter: %q", key, v)
+			}
+			*field = n
+		}
+	}
+	return filter, nil
+}
+
+// handleRecord handles GET /api/record?zone=example.com&name=www.example.com[&type=A&content=1.2.3.4] or ?zone=example.com&id=<record_id>
 func (s *Server) handleRecord(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	zoneName := r.URL.Query().Get("zone")
-	recordName := r.URL.Query().Get("name")
-	if zoneName == "" || recordName == "" {
-		s.writeError(w, http.StatusBadRequest, "Missing 'zone' or 'name' query parameter")
+	query := r.URL.Query()
+	zoneName := query.Get("zone")
+	selector := usecase.RecordSelector{
+		RecordID: query.Get("id"),
+		Name:     query.Get("name"),
+		Type:     query.Get("type"),
+		Content:  query.Get("content"),
+	}
+	if zoneName == "" || selector.IsEmpty() {
+		s.writeError(w, http.StatusBadRequest, "Missing 'zone' or 'name'/'id' query parameter")
 		return
 	}
 
-	ctx := context.Background()
-	record, err := s.dnsUsecase.GetRecord(ctx, zoneName, recordName)
+	ctx := r.Context()
+	record, err := s.dnsUsecase.GetRecord(ctx, zoneName, selector)
 	if err != nil {
-		if err == domain.ErrRecordNotFound {
-			s.writeError(w, http.StatusNotFound, "Record not found")
-			return
-		}
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		s.writeUsecaseError(w, err)
 		return
 	}
 
@@ -283,26 +390,93 @@ func (s *Server) handleCreateRecord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var input usecase.CreateRecordInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.writeError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
 		return
 	}
 
-	ctx := context.Background()
-	record, err := s.dnsUsecase.CreateRecord(ctx, input)
+	ctx := r.Context()
+	record, err := s.dnsUsecase.CreateRecord(ctx, req.input())
 	if err != nil {
-		if err == domain.ErrDuplicateRecord {
-			s.writeError(w, http.StatusConflict, "Record already exists")
-			return
-		}
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		s.writeUsecaseError(w, err)
 		return
 	}
 
 	s.writeSuccess(w, record)
 }
 
+// createRequest is the body of /api/record/create and /api/record/upsert,
+// and a record of a bulk create. The names are those of the create_record
+// tool arguments.
+type createRequest struct {
+	ZoneName string             `json:"zone_name"`
+	Name     string             `json:"name"`
+	Type     string             `json:"type"`
+	Content  string             `json:"content"`
+	TTL      int                `json:"ttl"`
+	Proxied  bool               `json:"proxied"`
+	Priority *uint16            `json:"priority"`
+	Data     *domain.RecordData `json:"data"`
+	Comment  string             `json:"comment"`
+	Tags     []string           `json:"tags"`
+}
+
+// input returns the usecase input of the request
+func (req createRequest) input() usecase.CreateRecordInput {
+	return usecase.CreateRecordInput{
+		ZoneName: req.ZoneName,
+		Name:     req.Name,
+		Type:     req.Type,
+		Content:  req.Content,
+		TTL:      req.TTL,
+		Proxied:  req.Proxied,
+		Priority: req.Priority,
+		Data:     req.Data,
+		Comment:  req.Comment,
+		Tags:     req.Tags,
+	}
+}
+
+// updateRequest is the body of /api/record/update. The record is picked by
+// record_id, or by name with the optional type and match_content; fields
+// left out keep their current values. The names are those of the
+// update_record tool arguments.
+type updateRequest struct {
+	ZoneName     string             `json:"zone_name"`
+	RecordID     string             `json:"record_id"`
+	Name         string             `json:"name"`
+	Type         string             `json:"type"`
+	MatchContent string             `json:"match_content"`
+	Content      *string            `json:"content"`
+	TTL          *int               `json:"ttl"`
+	Proxied      *bool              `json:"proxied"`
+	Priority     *uint16            `json:"priority"`
+	Data         *domain.RecordData `json:"data"`
+	Comment      *string            `json:"comment"`
+	Tags         []string           `json:"tags"`
+}
+
+// input returns the usecase input of the request
+func (req updateRequest) input() usecase.UpdateRecordInput {
+	return usecase.UpdateRecordInput{
+		ZoneName: req.ZoneName,
+		Selector: usecase.RecordSelector{
+			RecordID: req.RecordID,
+			Name:     req.Name,
+			Type:     req.Type,
+			Content:  req.MatchContent,
+		},
+		Content:  req.Content,
+		TTL:      req.TTL,
+		Proxied:  req.Proxied,
+		Priority: req.Priority,
+		Data:     req.Data,
+		Comment:  req.Comment,
+		Tags:     req.Tags,
+	}
+}
+
 // handleUpdateRecord handles POST /api/record/update
 func (s *Server) handleUpdateRecord(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -310,16 +484,16 @@ func (s *Server) handleUpdateRecord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var input usecase.UpdateRecordInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	var req updateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.writeError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
 		return
 	}
 
-	ctx := context.Background()
-	record, err := s.dnsUsecase.UpdateRecord(ctx, input)
+	ctx := r.Context()
+	record, err := s.dnsUsecase.UpdateRecord(ctx, req.input())
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		s.writeUsecaseError(w, err)
 		return
 	}
 
@@ -335,27 +509,220 @@ func (s *Server) handleDeleteRecord(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
 		ZoneName   string `json:"zone_name"`
+		RecordID   string `json:"record_id"`
 		RecordName string `json:"record_name"`
+		Type       string `json:"type"`
+		Content    string `json:"content"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.writeError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
 		return
 	}
 
-	ctx := context.Background()
-	err := s.dnsUsecase.DeleteRecord(ctx, req.ZoneName, req.RecordName)
+	selector := usecase.RecordSelector{
+		RecordID: req.RecordID,
+		Name:     req.RecordName,
+		Type:     req.Type,
+		Content:  req.Content,
+	}
+	if req.ZoneName == "" || selector.IsEmpty() {
+		s.writeError(w, http.StatusBadRequest, "Missing 'zone_name' or 'record_id'/'record_name'")
+		return
+	}
+
+	ctx := r.Context()
+	err := s.dnsUsecase.DeleteRecord(ctx, req.ZoneName, selector)
 	if err != nil {
-		if err == domain.ErrRecordNotFound {
-			s.writeError(w, http.StatusNotFound, "Record not found")
-			return
-		}
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		s.writeUsecaseError(w, err)
 		return
 	}
 
 	s.writeSuccess(w, map[string]string{"message": "Record deleted successfully"})
 }
 
+// handleExportZone handles GET /api/zones/{zone}/export?format=bind|json|csv
+func (s *Server) handleExportZone(w http.ResponseWriter, r *http.Request) {
+	zoneName := r.PathValue("zone")
+	format, err := usecase.ParseExportFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	data, err := s.dnsUsecase.ExportZone(ctx, zoneName, format)
+	if err != nil {
+		s.writeUsecaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", zoneName+"."+format.FileExtension()))
+	w.Write(data)
+}
+
+// handlePlanZone handles POST /api/zones/{zone}/plan[?allow_deletes=true]
+// The body is the desired zone state in YAML or JSON.
+func (s *Server) handlePlanZone(w http.ResponseWriter, r *http.Request) {
+	zoneName := r.PathValue("zone")
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Failed to read body: "+err.Error())
+		return
+	}
+
+	doc, err := usecase.ParseZoneDocument(body)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if doc.Zone != "" && !strings.EqualFold(doc.Zone, zoneName) {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Document is for zone %s, not %s", doc.Zone, zoneName))
+		return
+	}
+	doc.Zone = zoneName
+
+	options := usecase.SyncOptions{AllowDeletes: r.URL.Query().Get("allow_deletes") == "true"}
+
+	ctx := r.Context()
+	plan, err := s.syncUsecase.Plan(ctx, doc, options)
+	if err != nil {
+		s.writeUsecaseError(w, err)
+		return
+	}
+
+	s.writeSuccess(w, plan)
+}
+
+// handleApplyPlan handles POST /api/zones/{zone}/apply with {"plan_id": "..."}
+func (s *Server) handleApplyPlan(w http.ResponseWriter, r *http.Request) {
+	zoneName := r.PathValue("zone")
+
+	var req struct {
+		PlanID string `json:"plan_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+	if req.PlanID == "" {
+		s.writeError(w, http.StatusBadRequest, "Missing 'plan_id'")
+		return
+	}
+
+	ctx := r.Context()
+	result, err := s.syncUsecase.Apply(ctx, zoneName, req.PlanID)
+	if err != nil {
+		s.writeUsecaseError(w, err)
+		return
+	}
+
+	s.writeSuccess(w, result)
+}
+
+// bulkRequest is the body of the bulk delete and update endpoints
+type bulkRequest struct {
+	Type        string `json:"type"`
+	NamePattern string `json:"name_pattern"`
+	Content     string `json:"content"`
+	DryRun      bool   `json:"dry_run"`
+	TTL         *int   `json:"ttl"`
+	Proxied     *bool  `json:"proxied"`
+}
+
+// filter returns the record filter of the request
+func (req bulkRequest) filter() usecase.BulkFilter {
+	return usecase.BulkFilter{Type: req.Type, NamePattern: req.NamePattern, Content: req.Content}
+}
+
+// handleBulkCreate handles POST /api/zones/{zone}/bulk/create with {"records": [...]}
+func (s *Server) handleBulkCreate(w http.ResponseWriter, r *http.Request) {
+	zoneName := r.PathValue("zone")
+
+	var req struct {
+		Records []createRequest `json:"records"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	inputs := make([]usecase.CreateRecordInput, len(req.Records))
+	for i, record := range req.Records {
+		inputs[i] = record.input()
+	}
+
+	ctx := r.Context()
+	result, err := s.dnsUsecase.BulkCreate(ctx, zoneName, inputs)
+	s.writeBulkResult(w, result, err)
+}
+
+// handleBulkDelete handles POST /api/zones/{zone}/bulk/delete
+func (s *Server) handleBulkDelete(w http.ResponseWriter, r *http.Request) {
+	zoneName := r.PathValue("zone")
+
+	var req bulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	if req.DryRun {
+		s.writeMatches(w, r, zoneName, req.filter())
+		return
+	}
+
+	result, err := s.dnsUsecase.BulkDelete(ctx, zoneName, req.filter())
+	s.writeBulkResult(w, result, err)
+}
+
+// handleBulkUpdate handles POST /api/zones/{zone}/bulk/update
+func (s *Server) handleBulkUpdate(w http.ResponseWriter, r *http.Request) {
+	zoneName := r.PathValue("zone")
+
+	var req bulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	if req.DryRun {
+		s.writeMatches(w, r, zoneName, req.filter())
+		return
+	}
+
+	changes := usecase.BulkChanges{TTL: req.TTL, Proxied: req.Proxied}
+	result, err := s.dnsUsecase.BulkUpdate(ctx, zoneName, req.filter(), changes)
+	s.writeBulkResult(w, result, err)
+}
+
+// writeMatches writes the records a bulk request would touch
+func (s *Server) writeMatches(w http.ResponseWriter, r *http.Request, zoneName string, filter usecase.BulkFilter) {
+	records, err := s.dnsUsecase.MatchRecords(r.Context(), zoneName, filter)
+	if err != nil {
+		s.writeUsecaseError(w, err)
+		return
+	}
+
+	s.writeSuccess(w, map[string]interface{}{
+		"matched": len(records),
+		"records": records,
+	})
+}
+
+// writeBulkResult writes the result of a bulk operation
+func (s *Server) writeBulkResult(w http.ResponseWriter, result *usecase.BulkResult, err error) {
+	if err != nil {
+		s.writeUsecaseError(w, err)
+		return
+	}
+
+	s.writeSuccess(w, result)
+}
+
 // handleUpsertRecord handles POST /api/record/upsert
 func (s *Server) handleUpsertRecord(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -363,16 +730,16 @@ func (s *Server) handleUpsertRecord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var input usecase.CreateRecordInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.writeError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
 		return
 	}
 
-	ctx := context.Background()
-	record, err := s.dnsUsecase.UpsertRecord(ctx, input)
+	ctx := r.Context()
+	record, err := s.dnsUsecase.UpsertRecord(ctx, req.input())
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		s.writeUsecaseError(w, err)
 		return
 	}
 
@@ -397,6 +764,56 @@ func (s *Server) handleManageKeys(w http.ResponseWriter, r *http.Request) {
 	s.writeSuccess(w, keys)
 }
 
+// handleAuditLog handles GET /api/audit?zone=&actor=&since=&until=&limit=&offset=
+// (management only). Times are RFC 3339; entries are returned newest first.
+func (s *Server) handleAuditLog(w http.ResponseWriter, r *http.Request) {
+	// Check if management key
+	keyInfo := r.Context().Value("api_key").(*APIKey)
+	if keyInfo.Name != "management" {
+		s.writeError(w, http.StatusForbidden, "Management key required")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := domain.AuditFilter{
+		ZoneName: query.Get("zone"),
+		Actor:    query.Get("actor"),
+		Limit:    50,
+	}
+
+	for key, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := query.Get(key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid '%s', expected RFC 3339 time", key))
+				return
+			}
+			*dst = t
+		}
+	}
+	for key, dst := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if v := query.Get(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid '%s'", key))
+				return
+			}
+			*dst = n
+		}
+	}
+
+	entries, total, err := s.auditStorage.QueryAuditEntries(filter)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.writeSuccess(w, map[string]interface{}{
+		"entries": entries,
+		"total":   total,
+	})
+}
+
 // handleGenerateKey handles POST /admin/keys/generate (management only)
 func (s *Server) handleGenerateKey(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -412,16 +829,43 @@ func (s *Server) handleGenerateKey(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Name string `json:"name"`
+		Name    string `json:"name"`
+		Account string `json:"account"`
+		storage.APIKeyScope
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		req.Name = "generated-key"
 	}
+	if err := handler.ValidateKeyScope(req.APIKeyScope); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	newKey := s.apiKeys.GenerateKey(req.Name)
-	s.writeSuccess(w, map[string]string{
-		"key":  newKey,
-		"name": req.Name,
+	if req.Account != "" {
+		zones, err := s.dnsUsecase.ListZones(r.Context())
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		known := false
+		for _, z := range zones {
+			if strings.EqualFold(z.Account, req.Account) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown account %q", req.Account))
+			return
+		}
+	}
+
+	newKey := s.apiKeys.GenerateKey(req.Name, req.Account, req.APIKeyScope)
+	s.writeSuccess(w, map[string]interface{}{
+		"key":     newKey,
+		"name":    req.Name,
+		"account": req.Account,
+		"scope":   req.APIKeyScope,
 	})
 }
 
@@ -439,6 +883,9 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if cfg.FakeCloudflare {
+		log.Fatal("FAKE_CLOUDFLARE is only supported by the bot")
+	}
 
 	// Initialize API key store
 	apiKeys := NewAPIKeyStore()
@@ -459,13 +906,18 @@ func main() {
 	// Initialize storage
 	configStorage := storage.NewJSONStorage(cfg.DataDir)
 
-	// Initialize Cloudflare client
-	var cfClient cloudflare.Client
-	if cfg.UseAPIToken() {
-		cfClient, err = cloudflare.NewClient(cfg.CloudflareAPIToken)
-	} else {
-		cfClient, err = cloudflare.NewClientWithKey(cfg.CloudflareAPIKey, cfg.CloudflareEmail)
+	// Initialize a Cloudflare client per account; each paces and retries
+	// its calls so bursts do not exhaust the account's API rate limit
+	var credentials []cloudflare.Credentials
+	for _, account := range cfg.CloudflareAccounts {
+		credentials = append(credentials, cloudflare.Credentials{
+			Account:  account.Name,
+			APIToken: account.APIToken,
+			APIKey:   account.APIKey,
+			Email:    account.Email,
+		})
 	}
+	cfClient, err := cloudflare.NewAccountsClient(credentials, cloudflare.DefaultResilienceConfig())
 	if err != nil {
 		log.Fatalf("Failed to create Cloudflare client: %v", err)
 	}
@@ -474,8 +926,10 @@ func main() {
 	zoneRepo := repository.NewZoneRepository(cfClient)
 	dnsRepo := repository.NewDNSRepository(cfClient)
 
-	// Initialize usecase
-	dnsUsecase := usecase.NewDNSUsecase(zoneRepo, dnsRepo, configStorage)
+	// Initialize usecase; every change is recorded in the audit log
+	auditStorage := storage.NewAuditStorage(cfg.DataDir)
+	dnsUsecase := usecase.NewAuditedDNSUsecase(usecase.NewDNSUsecase(zoneRepo, dnsRepo, configStorage), auditStorage)
+	syncUsecase := usecase.NewZoneSyncUsecase(dnsUsecase)
 
 	// Get port from environment
 	port := os.Getenv("MCP_HTTP_PORT")
@@ -484,7 +938,7 @@ func main() {
 	}
 
 	// Create and start HTTP server
-	server := NewServer(dnsUsecase, apiKeys, port)
+	server := NewServer(dnsUsecase, syncUsecase, auditStorage, apiKeys, port)
 	if err := server.Start(); err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
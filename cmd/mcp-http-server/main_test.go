@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cf-dns-bot/external_resource/cloudflare/cloudflaretest"
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/repository"
+	"cf-dns-bot/internal/usecase"
+	"cf-dns-bot/pkg/storage"
+)
+
+// newTestServer returns a server on top of an in-memory Cloudflare with
+// the demo zones
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	t.Setenv("MCP_MANAGEMENT_KEY", "")
+	t.Setenv("MCP_API_KEYS", "")
+
+	client := cloudflaretest.NewClient(cloudflaretest.NewDemoStore())
+	dnsUsecase := usecase.NewDNSUsecase(repository.NewZoneRepository(client), repository.NewDNSRepository(client), storage.NewJSONStorage(t.TempDir()))
+	return NewServer(dnsUsecase, usecase.NewZoneSyncUsecase(dnsUsecase), nil, NewAPIKeyStore(), "")
+}
+
+// serve sends a request with the API key to a handler behind
+// authMiddleware and decodes the JSON response
+func serve(t *testing.T, s *Server, operation string, next http.HandlerFunc, method, target, key, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	rec := httptest.NewRecorder()
+	s.authMiddleware(operation, next)(rec, req)
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	return rec.Code, response
+}
+
+func TestRESTRecordLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	key := s.apiKeys.GenerateKey("test", "", storage.APIKeyScope{})
+	create := `{"zone_name":"example.com","name":"rest","type":"A","content":"192.0.2.80","ttl":300}`
+
+	if status, _ := serve(t, s, "create_record", s.handleCreateRecord, http.MethodPost, "/api/record/create", "", create); status != http.StatusUnauthorized {
+		t.Errorf("without key: got status %d, want 401", status)
+	}
+
+	status, response := serve(t, s, "create_record", s.handleCreateRecord, http.MethodPost, "/api/record/create", key, create)
+	if status != http.StatusOK || response["success"] != true {
+		t.Fatalf("create: got status %d, response %v", status, response)
+	}
+	if status, _ := serve(t, s, "create_record", s.handleCreateRecord, http.MethodPost, "/api/record/create", key, create); status != http.StatusConflict {
+		t.Errorf("duplicate create: got status %d, want 409", status)
+	}
+
+	update := `{"zone_name":"example.com","name":"rest","type":"A","ttl":600}`
+	status, response = serve(t, s, "update_record", s.handleUpdateRecord, http.MethodPost, "/api/record/update", key, update)
+	if status != http.StatusOK {
+		t.Fatalf("update: got status %d, response %v", status, response)
+	}
+	if data, _ := response["data"].(map[string]interface{}); data["TTL"] != float64(600) {
+		t.Errorf("update: got record %v, want TTL 600", response["data"])
+	}
+
+	// api.example.com has an A and an AAAA record
+	ambiguous := `{"zone_name":"example.com","record_name":"api"}`
+	if status, _ := serve(t, s, "delete_record", s.handleDeleteRecord, http.MethodPost, "/api/record/delete", key, ambiguous); status != http.StatusConflict {
+		t.Errorf("ambiguous delete: got status %d, want 409", status)
+	}
+
+	remove := `{"zone_name":"example.com","record_name":"rest","type":"A"}`
+	if status, response := serve(t, s, "delete_record", s.handleDeleteRecord, http.MethodPost, "/api/record/delete", key, remove); status != http.StatusOK {
+		t.Fatalf("delete: got status %d, response %v", status, response)
+	}
+	if status, _ := serve(t, s, "get_record", s.handleRecord, http.MethodGet, "/api/record?zone=example.com&name=rest&type=A", key, ""); status != http.StatusNotFound {
+		t.Errorf("get after delete: got status %d, want 404", status)
+	}
+}
+
+func TestRESTUpsertAndBulkCreate(t *testing.T) {
+	s := newTestServer(t)
+	key := s.apiKeys.GenerateKey("test", "", storage.APIKeyScope{})
+
+	upsert := `{"zone_name":"example.com","name":"home","type":"A","content":"192.0.2.60","ttl":120,"comment":"router"}`
+	if status, response := serve(t, s, "upsert_record", s.handleUpsertRecord, http.MethodPost, "/api/record/upsert", key, upsert); status != http.StatusOK {
+		t.Fatalf("upsert: got status %d, response %v", status, response)
+	}
+	record, err := s.dnsUsecase.GetRecord(context.Background(), "example.com", usecase.RecordSelector{Name: "home", Type: "A"})
+	if err != nil {
+		t.Fatalf("GetRecord: %v", err)
+	}
+	if record.TTL != 120 || record.Comment != "router" {
+		t.Errorf("got %+v, want TTL 120 and comment router", record)
+	}
+
+	bulk := `{"records":[{"name":"a","type":"A","content":"192.0.2.1","ttl":300},{"name":"b","type":"A","content":"not-an-ip"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/zones/example.com/bulk/create", strings.NewReader(bulk))
+	req.Header.Set("Authorization", "Bearer "+key)
+	req.SetPathValue("zone", "example.com")
+	rec := httptest.NewRecorder()
+	s.authMiddleware("bulk_create", s.handleBulkCreate)(rec, req)
+
+	var response struct {
+		Data usecase.BulkResult `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if rec.Code != http.StatusOK || len(response.Data.Succeeded) != 1 || len(response.Data.Failed) != 1 {
+		t.Fatalf("bulk create: got status %d, result %+v, want one record created and one failed", rec.Code, response.Data)
+	}
+	if got := response.Data.Succeeded[0]; got.Name != "a.example.com" || got.TTL != 300 {
+		t.Errorf("bulk create: got %+v, want a.example.com with TTL 300", got)
+	}
+}
+
+func TestRESTKeyScope(t *testing.T) {
+	s := newTestServer(t)
+	readOnly := s.apiKeys.GenerateKey("viewer", "", storage.APIKeyScope{ReadOnly: true})
+	create := `{"zone_name":"example.com","name":"rest","type":"A","content":"192.0.2.80"}`
+
+	if status, _ := serve(t, s, "create_record", s.handleCreateRecord, http.MethodPost, "/api/record/create", readOnly, create); status != http.StatusForbidden {
+		t.Errorf("read-only create: got status %d, want 403", status)
+	}
+	if status, _ := serve(t, s, "list_zones", s.handleListZones, http.MethodGet, "/api/zones", readOnly, ""); status != http.StatusOK {
+		t.Errorf("read-only list: got status %d, want 200", status)
+	}
+
+	// Zones outside the scope are not listed
+	zoneKey := s.apiKeys.GenerateKey("org", "", storage.APIKeyScope{Zones: []string{"example.org"}})
+	status, response := serve(t, s, "list_zones", s.handleListZones, http.MethodGet, "/api/zones", zoneKey, "")
+	if zones, _ := response["data"].([]interface{}); status != http.StatusOK || len(zones) != 1 {
+		t.Errorf("zone scope: got status %d, zones %v, want example.org only", status, response["data"])
+	}
+}
+
+// dynDNSUpdate sends a dyndns2 update and returns the status and body
+func dynDNSUpdate(s *Server, key, query string) (int, string) {
+	req := httptest.NewRequest(http.MethodGet, "/nic/update?"+query, nil)
+	req.SetBasicAuth("user", key)
+	rec := httptest.NewRecorder()
+	s.handleDynDNSUpdate(rec, req)
+	return rec.Code, strings.TrimSpace(rec.Body.String())
+}
+
+func TestDynDNSUpdate(t *testing.T) {
+	s := newTestServer(t)
+	key := s.apiKeys.GenerateKey("router", "", storage.APIKeyScope{})
+
+	tests := []struct {
+		name   string
+		key    string
+		query  string
+		status int
+		body   string
+	}{
+		{"bad key", "wrong", "hostname=home.example.com&myip=192.0.2.90", http.StatusUnauthorized, "badauth"},
+		{"new host", key, "hostname=home.example.com&myip=192.0.2.90", http.StatusOK, "good 192.0.2.90"},
+		{"same address", key, "hostname=home.example.com&myip=192.0.2.90", http.StatusOK, "nochg 192.0.2.90"},
+		{"new address", key, "hostname=home.example.com&myip=192.0.2.91", http.StatusOK, "good 192.0.2.91"},
+		{"unknown zone", key, "hostname=home.example.net&myip=192.0.2.90", http.StatusOK, "nohost"},
+		{"not a name", key, "hostname=home&myip=192.0.2.90", http.StatusOK, "notfqdn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := dynDNSUpdate(s, tt.key, tt.query)
+			if status != tt.status || body != tt.body {
+				t.Errorf("got %d %q, want %d %q", status, body, tt.status, tt.body)
+			}
+		})
+	}
+
+	record, err := s.dnsUsecase.GetRecord(context.Background(), "example.com", usecase.RecordSelector{Name: "home", Type: "A"})
+	if err != nil {
+		t.Fatalf("GetRecord: %v", err)
+	}
+	if record.Content != "192.0.2.91" {
+		t.Errorf("got address %s, want 192.0.2.91", record.Content)
+	}
+}
+
+func TestDynDNSUpdateOutsideKeyScope(t *testing.T) {
+	s := newTestServer(t)
+	key := s.apiKeys.GenerateKey("router", "", storage.APIKeyScope{Records: []string{"home.example.com"}})
+
+	if _, body := dynDNSUpdate(s, key, "hostname=office.example.com&myip=192.0.2.90"); body != "nohost" {
+		t.Errorf("got %q, want nohost", body)
+	}
+	if _, body := dynDNSUpdate(s, key, "hostname=home.example.com&myip=192.0.2.90"); body != "good 192.0.2.90" {
+		t.Errorf("got %q, want good 192.0.2.90", body)
+	}
+
+	_, err := s.dnsUsecase.GetRecord(context.Background(), "example.com", usecase.RecordSelector{Name: "office", Type: "A"})
+	if !errors.Is(err, domain.ErrRecordNotFound) {
+		t.Errorf("got error %v, want office.example.com not created", err)
+	}
+}
@@ -3,11 +3,15 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"cf-dns-bot/external_resource/cloudflare"
 	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/handler"
 	"cf-dns-bot/internal/repository"
 	"cf-dns-bot/internal/usecase"
 	"cf-dns-bot/pkg/config"
@@ -23,17 +27,25 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if cfg.FakeCloudflare {
+		log.Fatal("FAKE_CLOUDFLARE is only supported by the bot")
+	}
 
 	// Initialize storage
 	configStorage := storage.NewJSONStorage(cfg.DataDir)
 
-	// Initialize Cloudflare client
-	var cfClient cloudflare.Client
-	if cfg.UseAPIToken() {
-		cfClient, err = cloudflare.NewClient(cfg.CloudflareAPIToken)
-	} else {
-		cfClient, err = cloudflare.NewClientWithKey(cfg.CloudflareAPIKey, cfg.CloudflareEmail)
+	// Initialize a Cloudflare client per account; each paces and retries
+	// its calls so bursts do not exhaust the account's API rate limit
+	var credentials []cloudflare.Credentials
+	for _, account := range cfg.CloudflareAccounts {
+		credentials = append(credentials, cloudflare.Credentials{
+			Account:  account.Name,
+			APIToken: account.APIToken,
+			APIKey:   account.APIKey,
+			Email:    account.Email,
+		})
 	}
+	cfClient, err := cloudflare.NewAccountsClient(credentials, cloudflare.DefaultResilienceConfig())
 	if err != nil {
 		log.Fatalf("Failed to create Cloudflare client: %v", err)
 	}
@@ -42,8 +54,11 @@ func main() {
 	zoneRepo := repository.NewZoneRepository(cfClient)
 	dnsRepo := repository.NewDNSRepository(cfClient)
 
-	// Initialize usecase
-	dnsUsecase := usecase.NewDNSUsecase(zoneRepo, dnsRepo, configStorage)
+	// Initialize usecase; every change is recorded in the audit log
+	auditStorage := storage.NewAuditStorage(cfg.DataDir)
+	dnsUsecase := usecase.NewAuditedDNSUsecase(usecase.NewDNSUsecase(zoneRepo, dnsRepo, configStorage), auditStorage)
+	zoneImportUsecase := usecase.NewZoneImportUsecase(dnsUsecase)
+	zoneSyncUsecase := usecase.NewZoneSyncUsecase(dnsUsecase)
 
 	// Create MCP server with tool capabilities enabled
 	s := server.NewMCPServer(
@@ -62,29 +77,24 @@ func main() {
 		},
 	)
 	s.AddTool(listZonesTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-		ctx := context.Background()
+		ctx := toolContext()
 		zones, err := dnsUsecase.ListZones(ctx)
 		if err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
-			}, nil
+			return toolError(err), nil
 		}
 
 		result := make([]map[string]string, len(zones))
 		for i, z := range zones {
 			result[i] = map[string]string{
-				"id":   z.ID,
-				"name": z.Name,
+				"id":      z.ID,
+				"name":    z.Name,
+				"account": z.Account,
 			}
 		}
 
 		jsonData, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
-			}, nil
+			return toolError(err), nil
 		}
 
 		return &mcp.CallToolResult{
@@ -96,33 +106,22 @@ func main() {
 	listRecordsTool := mcp.NewTool("list_records",
 		"List all DNS records for a specific zone. IMPORTANT: Use params.arguments format. Example: {\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"tools/call\",\"params\":{\"name\":\"list_records\",\"arguments\":{\"zone_name\":\"example.com\"}}}",
 		map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"zone_name": map[string]interface{}{
-					"type":        "string",
-					"description": "The zone/domain name (e.g., example.com)",
-				},
-			},
-			"required": []string{"zone_name"},
+			"type":       "object",
+			"properties": recordFilterSchema(),
+			"required":   []string{"zone_name"},
 		},
 	)
 	s.AddTool(listRecordsTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-		ctx := context.Background()
+		ctx := toolContext()
 
 		zoneName, ok := arguments["zone_name"].(string)
 		if !ok || zoneName == "" {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent("Error: zone_name is required")},
-			}, nil
+			return argumentError("zone_name is required"), nil
 		}
 
-		records, err := dnsUsecase.ListRecords(ctx, zoneName)
+		records, err := dnsUsecase.ListRecords(ctx, zoneName, recordFilterFromArguments(arguments))
 		if err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
-			}, nil
+			return toolError(err), nil
 		}
 
 		result := make([]map[string]interface{}, len(records))
@@ -136,14 +135,20 @@ func main() {
 				"proxied":  r.Proxied,
 				"priority": r.Priority,
 			}
+			if r.Data != nil {
+				result[i]["data"] = r.Data
+			}
+			if r.Comment != "" {
+				result[i]["comment"] = r.Comment
+			}
+			if len(r.Tags) > 0 {
+				result[i]["tags"] = r.Tags
+			}
 		}
 
 		jsonData, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
-			}, nil
+			return toolError(err), nil
 		}
 
 		return &mcp.CallToolResult{
@@ -151,9 +156,29 @@ func main() {
 		}, nil
 	})
 
+	// Register tool: search_records
+	searchRecordsTool := mcp.NewTool("search_records",
+		"Search every zone for records whose name or content contains the query, ignoring case. An IP address matches whole addresses only, including within content such as SPF. Results are grouped by zone. IMPORTANT: Use params.arguments format. Example: {\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"tools/call\",\"params\":{\"name\":\"search_records\",\"arguments\":{\"query\":\"192.0.2.10\"}}}",
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Part of a record name or content, or an IP address (e.g., '192.0.2.10', 'api.', 'mail')",
+				},
+			},
+			"required": []string{"query"},
+		},
+	)
+	s.AddTool(searchRecordsTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+		query, _ := arguments["query"].(string)
+		results, err := dnsUsecase.SearchRecords(toolContext(), query)
+		return jsonToolResult(results, err)
+	})
+
 	// Register tool: get_record
 	getRecordTool := mcp.NewTool("get_record",
-		"Get details of a specific DNS record. IMPORTANT: Use params.arguments format. Example: {\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"tools/call\",\"params\":{\"name\":\"get_record\",\"arguments\":{\"zone_name\":\"example.com\",\"record_name\":\"www.example.com\"}}}",
+		"Get details of a specific DNS record. Identify the record by record_id, or by record_name plus type/content when several records share a name. IMPORTANT: Use params.arguments format. Example: {\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"tools/call\",\"params\":{\"name\":\"get_record\",\"arguments\":{\"zone_name\":\"example.com\",\"record_name\":\"www.example.com\",\"type\":\"A\"}}}",
 		map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -161,44 +186,47 @@ func main() {
 					"type":        "string",
 					"description": "The zone/domain name (e.g., example.com)",
 				},
+				"record_id": map[string]interface{}{
+					"type":        "string",
+					"description": "The record ID",
+				},
 				"record_name": map[string]interface{}{
 					"type":        "string",
 					"description": "The full record name (e.g., www.example.com)",
 				},
+				"type": map[string]interface{}{
+					"type":        "string",
+					"description": "Record type, to narrow down records sharing a name",
+				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "Record content, to narrow down records sharing a name and type",
+				},
 			},
-			"required": []string{"zone_name", "record_name"},
+			"required": []string{"zone_name"},
 		},
 	)
 	s.AddTool(getRecordTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-		ctx := context.Background()
+		ctx := toolContext()
 
 		zoneName, ok := arguments["zone_name"].(string)
 		if !ok || zoneName == "" {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent("Error: zone_name is required")},
-			}, nil
+			return argumentError("zone_name is required"), nil
 		}
 
-		recordName, ok := arguments["record_name"].(string)
-		if !ok || recordName == "" {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent("Error: record_name is required")},
-			}, nil
+		selector := recordSelectorFromArguments(arguments, "record_name", "content")
+		if selector.IsEmpty() {
+			return argumentError("record_id or record_name is required"), nil
 		}
 
-		record, err := dnsUsecase.GetRecord(ctx, zoneName, recordName)
+		record, err := dnsUsecase.GetRecord(ctx, zoneName, selector)
 		if err != nil {
-			if err == domain.ErrRecordNotFound {
+			if errors.Is(err, domain.ErrRecordNotFound) {
 				return &mcp.CallToolResult{
 					Content: []interface{}{mcp.NewTextContent("Record not found")},
 				}, nil
 			}
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
-			}, nil
+			return toolError(err), nil
 		}
 
 		result := map[string]interface{}{
@@ -210,13 +238,19 @@ func main() {
 			"proxied":  record.Proxied,
 			"priority": record.Priority,
 		}
+		if record.Data != nil {
+			result["data"] = record.Data
+		}
+		if record.Comment != "" {
+			result["comment"] = record.Comment
+		}
+		if len(record.Tags) > 0 {
+			result["tags"] = record.Tags
+		}
 
 		jsonData, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
-			}, nil
+			return toolError(err), nil
 		}
 
 		return &mcp.CallToolResult{
@@ -240,7 +274,8 @@ func main() {
 				},
 				"type": map[string]interface{}{
 					"type":        "string",
-					"description": "Record type: A, AAAA, CNAME, MX, TXT, NS, SRV, CAA",
+					"description": "Record type",
+					"enum":        domain.RecordTypes,
 				},
 				"content": map[string]interface{}{
 					"type":        "string",
@@ -254,12 +289,22 @@ func main() {
 					"type":        "boolean",
 					"description": "Enable Cloudflare proxy (default: false)",
 				},
+				"priority": map[string]interface{}{
+					"type":        "number",
+					"description": "Priority for MX, SRV and URI records (required)",
+				},
+				"data": recordDataSchema(),
+				"comment": map[string]interface{}{
+					"type":        "string",
+					"description": "Free-text note, e.g. owner or ticket number",
+				},
+				"tags": tagsSchema(),
 			},
-			"required": []string{"zone_name", "name", "type", "content"},
+			"required": []string{"zone_name", "name", "type"},
 		},
 	)
 	s.AddTool(createRecordTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-		ctx := context.Background()
+		ctx, change := usecase.WithChangeRef(toolContext())
 
 		input := usecase.CreateRecordInput{}
 
@@ -281,18 +326,24 @@ func main() {
 		if v, ok := arguments["proxied"].(bool); ok {
 			input.Proxied = v
 		}
+		if v, ok := arguments["priority"].(float64); ok {
+			priority := uint16(v)
+			input.Priority = &priority
+		}
+		input.Data = recordDataFromArguments(arguments)
+		if v, ok := arguments["comment"].(string); ok {
+			input.Comment = v
+		}
+		input.Tags = stringsFromArguments(arguments, "tags")
 
 		record, err := dnsUsecase.CreateRecord(ctx, input)
 		if err != nil {
-			if err == domain.ErrDuplicateRecord {
+			if errors.Is(err, domain.ErrDuplicateRecord) {
 				return &mcp.CallToolResult{
 					Content: []interface{}{mcp.NewTextContent("Record already exists. Use upsert_record to update or update_record to modify.")},
 				}, nil
 			}
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
-			}, nil
+			return toolError(err), nil
 		}
 
 		result := map[string]interface{}{
@@ -303,13 +354,22 @@ func main() {
 			"ttl":     record.TTL,
 			"proxied": record.Proxied,
 		}
+		if record.Data != nil {
+			result["data"] = record.Data
+		}
+		if record.Comment != "" {
+			result["comment"] = record.Comment
+		}
+		if len(record.Tags) > 0 {
+			result["tags"] = record.Tags
+		}
+		if change.ID != "" {
+			result["change_id"] = change.ID
+		}
 
 		jsonData, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
-			}, nil
+			return toolError(err), nil
 		}
 
 		return &mcp.CallToolResult{
@@ -319,7 +379,7 @@ func main() {
 
 	// Register tool: update_record
 	updateRecordTool := mcp.NewTool("update_record",
-		"Update an existing DNS record",
+		"Update an existing DNS record. Identify the record by record_id, or by name plus type/match_content when several records share a name. Only the given fields change; omitted fields keep their current values",
 		map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -339,9 +399,13 @@ func main() {
 					"type":        "string",
 					"description": "Record type",
 				},
+				"match_content": map[string]interface{}{
+					"type":        "string",
+					"description": "Current content of the record, to narrow down records sharing a name and type",
+				},
 				"content": map[string]interface{}{
 					"type":        "string",
-					"description": "The record content",
+					"description": "The new record content",
 				},
 				"ttl": map[string]interface{}{
 					"type":        "number",
@@ -351,43 +415,53 @@ func main() {
 					"type":        "boolean",
 					"description": "Enable Cloudflare proxy",
 				},
+				"priority": map[string]interface{}{
+					"type":        "number",
+					"description": "New priority for MX, SRV and URI records",
+				},
+				"data": recordDataSchema(),
+				"comment": map[string]interface{}{
+					"type":        "string",
+					"description": "New comment; an empty string removes it",
+				},
+				"tags": tagsSchema(),
 			},
-			"required": []string{"zone_name", "record_id", "name", "type", "content"},
+			"required": []string{"zone_name"},
 		},
 	)
 	s.AddTool(updateRecordTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-		ctx := context.Background()
+		ctx, change := usecase.WithChangeRef(toolContext())
 
-		input := usecase.UpdateRecordInput{}
+		input := usecase.UpdateRecordInput{
+			Selector: recordSelectorFromArguments(arguments, "name", "match_content"),
+		}
 
 		if v, ok := arguments["zone_name"].(string); ok {
 			input.ZoneName = v
 		}
-		if v, ok := arguments["record_id"].(string); ok {
-			input.RecordID = v
-		}
-		if v, ok := arguments["name"].(string); ok {
-			input.Name = v
-		}
-		if v, ok := arguments["type"].(string); ok {
-			input.Type = v
-		}
 		if v, ok := arguments["content"].(string); ok {
-			input.Content = v
+			input.Content = &v
 		}
 		if v, ok := arguments["ttl"].(float64); ok {
-			input.TTL = int(v)
+			ttl := int(v)
+			input.TTL = &ttl
 		}
 		if v, ok := arguments["proxied"].(bool); ok {
-			input.Proxied = v
+			input.Proxied = &v
 		}
+		if v, ok := arguments["priority"].(float64); ok {
+			priority := uint16(v)
+			input.Priority = &priority
+		}
+		input.Data = recordDataFromArguments(arguments)
+		if v, ok := arguments["comment"].(string); ok {
+			input.Comment = &v
+		}
+		input.Tags = stringsFromArguments(arguments, "tags")
 
 		record, err := dnsUsecase.UpdateRecord(ctx, input)
 		if err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
-			}, nil
+			return toolError(err), nil
 		}
 
 		result := map[string]interface{}{
@@ -398,13 +472,22 @@ func main() {
 			"ttl":     record.TTL,
 			"proxied": record.Proxied,
 		}
+		if record.Data != nil {
+			result["data"] = record.Data
+		}
+		if record.Comment != "" {
+			result["comment"] = record.Comment
+		}
+		if len(record.Tags) > 0 {
+			result["tags"] = record.Tags
+		}
+		if change.ID != "" {
+			result["change_id"] = change.ID
+		}
 
 		jsonData, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
-			}, nil
+			return toolError(err), nil
 		}
 
 		return &mcp.CallToolResult{
@@ -420,19 +503,31 @@ func main() {
 				"type":        "string",
 				"description": "The zone/domain name (e.g., example.com)",
 			},
+			"record_id": map[string]interface{}{
+				"type":        "string",
+				"description": "The record ID",
+			},
 			"record_name": map[string]interface{}{
 				"type":        "string",
 				"description": "The full record name to delete (e.g., www.example.com or cache.example.com)",
 			},
+			"type": map[string]interface{}{
+				"type":        "string",
+				"description": "Record type, to narrow down records sharing a name",
+			},
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "Record content, to narrow down records sharing a name and type",
+			},
 		},
-		"required": []string{"zone_name", "record_name"},
+		"required": []string{"zone_name"},
 	}
 	deleteRecordTool := mcp.NewTool("delete_record",
-		"Delete a DNS record. IMPORTANT: Use params.arguments format with zone_name and record_name. Example request: {\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"tools/call\",\"params\":{\"name\":\"delete_record\",\"arguments\":{\"zone_name\":\"example.com\",\"record_name\":\"www.example.com\"}}}",
+		"Delete a DNS record. Identify the record by record_id, or by record_name plus type/content when several records share a name. IMPORTANT: Use params.arguments format. Example request: {\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"tools/call\",\"params\":{\"name\":\"delete_record\",\"arguments\":{\"zone_name\":\"example.com\",\"record_name\":\"www.example.com\"}}}",
 		deleteRecordSchema,
 	)
 	s.AddTool(deleteRecordTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-		ctx := context.Background()
+		ctx, change := usecase.WithChangeRef(toolContext())
 
 		// Debug: log all arguments received
 		argsJSON, _ := json.Marshal(arguments)
@@ -441,36 +536,31 @@ func main() {
 		zoneName, ok := arguments["zone_name"].(string)
 		if !ok || zoneName == "" {
 			log.Printf("[delete_record] ERROR: zone_name missing or invalid. Value: %v", arguments["zone_name"])
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent("Error: zone_name is required. Received: " + string(argsJSON))},
-			}, nil
+			return argumentError("zone_name is required. Received: " + string(argsJSON)), nil
 		}
 
-		recordName, ok := arguments["record_name"].(string)
-		if !ok || recordName == "" {
-			log.Printf("[delete_record] ERROR: record_name missing or invalid. Value: %v", arguments["record_name"])
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent("Error: record_name is required. Received: " + string(argsJSON))},
-			}, nil
+		selector := recordSelectorFromArguments(arguments, "record_name", "content")
+		if selector.IsEmpty() {
+			log.Printf("[delete_record] ERROR: record_id and record_name missing. Value: %v", arguments["record_name"])
+			return argumentError("record_id or record_name is required. Received: " + string(argsJSON)), nil
 		}
 
-		err := dnsUsecase.DeleteRecord(ctx, zoneName, recordName)
+		err := dnsUsecase.DeleteRecord(ctx, zoneName, selector)
 		if err != nil {
-			if err == domain.ErrRecordNotFound {
+			if errors.Is(err, domain.ErrRecordNotFound) {
 				return &mcp.CallToolResult{
 					Content: []interface{}{mcp.NewTextContent("Record not found")},
 				}, nil
 			}
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
-			}, nil
+			return toolError(err), nil
 		}
 
+		text := fmt.Sprintf("Record '%s' deleted successfully", describeSelector(selector))
+		if change.ID != "" {
+			text += fmt.Sprintf(" (change ID: %s)", change.ID)
+		}
 		return &mcp.CallToolResult{
-			Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Record '%s' deleted successfully", recordName))},
+			Content: []interface{}{mcp.NewTextContent(text)},
 		}, nil
 	})
 
@@ -490,7 +580,8 @@ func main() {
 				},
 				"type": map[string]interface{}{
 					"type":        "string",
-					"description": "Record type: A, AAAA, CNAME, MX, TXT, NS, SRV, CAA",
+					"description": "Record type",
+					"enum":        domain.RecordTypes,
 				},
 				"content": map[string]interface{}{
 					"type":        "string",
@@ -504,12 +595,22 @@ func main() {
 					"type":        "boolean",
 					"description": "Enable Cloudflare proxy (default: false)",
 				},
+				"priority": map[string]interface{}{
+					"type":        "number",
+					"description": "Priority for MX, SRV and URI records (required)",
+				},
+				"data": recordDataSchema(),
+				"comment": map[string]interface{}{
+					"type":        "string",
+					"description": "Free-text note, e.g. owner or ticket number",
+				},
+				"tags": tagsSchema(),
 			},
-			"required": []string{"zone_name", "name", "type", "content"},
+			"required": []string{"zone_name", "name", "type"},
 		},
 	)
 	s.AddTool(upsertRecordTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-		ctx := context.Background()
+		ctx, change := usecase.WithChangeRef(toolContext())
 
 		input := usecase.CreateRecordInput{}
 
@@ -531,13 +632,19 @@ func main() {
 		if v, ok := arguments["proxied"].(bool); ok {
 			input.Proxied = v
 		}
+		if v, ok := arguments["priority"].(float64); ok {
+			priority := uint16(v)
+			input.Priority = &priority
+		}
+		input.Data = recordDataFromArguments(arguments)
+		if v, ok := arguments["comment"].(string); ok {
+			input.Comment = v
+		}
+		input.Tags = stringsFromArguments(arguments, "tags")
 
 		record, err := dnsUsecase.UpsertRecord(ctx, input)
 		if err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
-			}, nil
+			return toolError(err), nil
 		}
 
 		result := map[string]interface{}{
@@ -548,15 +655,411 @@ func main() {
 			"ttl":     record.TTL,
 			"proxied": record.Proxied,
 		}
+		if record.Data != nil {
+			result["data"] = record.Data
+		}
+		if record.Comment != "" {
+			result["comment"] = record.Comment
+		}
+		if len(record.Tags) > 0 {
+			result["tags"] = record.Tags
+		}
+		if change.ID != "" {
+			result["change_id"] = change.ID
+		}
 
 		jsonData, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
+			return toolError(err), nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []interface{}{mcp.NewTextContent(string(jsonData))},
+		}, nil
+	})
+
+	// Register tool: bulk_create
+	bulkCreateTool := mcp.NewTool("bulk_create",
+		"Create many DNS records in a zone. Returns the result per record; failures do not stop the remaining records",
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"zone_name": map[string]interface{}{
+					"type":        "string",
+					"description": "The zone/domain name",
+				},
+				"records": map[string]interface{}{
+					"type":        "array",
+					"description": "Records with name, type, content (or data) and optional ttl, proxied and priority",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"name":     map[string]interface{}{"type": "string"},
+							"type":     map[string]interface{}{"type": "string", "enum": domain.RecordTypes},
+							"content":  map[string]interface{}{"type": "string"},
+							"ttl":      map[string]interface{}{"type": "number"},
+							"proxied":  map[string]interface{}{"type": "boolean"},
+							"priority": map[string]interface{}{"type": "number"},
+							"data":     recordDataSchema(),
+							"comment":  map[string]interface{}{"type": "string"},
+							"tags":     tagsSchema(),
+						},
+						"required": []string{"name", "type"},
+					},
+				},
+			},
+			"required": []string{"zone_name", "records"},
+		},
+	)
+	s.AddTool(bulkCreateTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+		ctx := toolContext()
+
+		zoneName, _ := arguments["zone_name"].(string)
+		if zoneName == "" {
+			return argumentError("zone_name is required"), nil
+		}
+
+		result, err := dnsUsecase.BulkCreate(ctx, zoneName, recordInputsFromArguments(arguments))
+		return jsonToolResult(result, err)
+	})
+
+	// bulkFilterSchema holds the record filter shared by bulk_delete and bulk_update
+	bulkFilterSchema := map[string]interface{}{
+		"zone_name": map[string]interface{}{
+			"type":        "string",
+			"description": "The zone/domain name",
+		},
+		"type": map[string]interface{}{
+			"type":        "string",
+			"description": "Record type to match",
+		},
+		"name_pattern": map[string]interface{}{
+			"type":        "string",
+			"description": "Glob on the record name, e.g. *.dev or test-*",
+		},
+		"content": map[string]interface{}{
+			"type":        "string",
+			"description": "Exact content to match",
+		},
+		"dry_run": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Only list the matching records",
+		},
+	}
+
+	// Register tool: bulk_delete
+	bulkDeleteTool := mcp.NewTool("bulk_delete",
+		"Delete all records in a zone matching a filter. At least one of type, name_pattern or content is required. Use dry_run to list the matches first",
+		map[string]interface{}{
+			"type":       "object",
+			"properties": bulkFilterSchema,
+			"required":   []string{"zone_name"},
+		},
+	)
+	s.AddTool(bulkDeleteTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+		ctx := toolContext()
+
+		zoneName, _ := arguments["zone_name"].(string)
+		if zoneName == "" {
+			return argumentError("zone_name is required"), nil
+		}
+
+		filter := bulkFilterFromArguments(arguments)
+		if dryRun, _ := arguments["dry_run"].(bool); dryRun {
+			records, err := dnsUsecase.MatchRecords(ctx, zoneName, filter)
+			return jsonToolResult(map[string]interface{}{"matched": len(records), "records": records}, err)
+		}
+
+		result, err := dnsUsecase.BulkDelete(ctx, zoneName, filter)
+		return jsonToolResult(result, err)
+	})
+
+	// Register tool: bulk_update
+	bulkUpdateProperties := map[string]interface{}{
+		"ttl": map[string]interface{}{
+			"type":        "number",
+			"description": "New TTL (1 = auto)",
+		},
+		"proxied": map[string]interface{}{
+			"type":        "boolean",
+			"description": "New proxy status",
+		},
+	}
+	for k, v := range bulkFilterSchema {
+		bulkUpdateProperties[k] = v
+	}
+	bulkUpdateTool := mcp.NewTool("bulk_update",
+		"Set the TTL and/or proxied status of all records in a zone matching a filter. Use dry_run to list the matches first",
+		map[string]interface{}{
+			"type":       "object",
+			"properties": bulkUpdateProperties,
+			"required":   []string{"zone_name"},
+		},
+	)
+	s.AddTool(bulkUpdateTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+		ctx := toolContext()
+
+		zoneName, _ := arguments["zone_name"].(string)
+		if zoneName == "" {
+			return argumentError("zone_name is required"), nil
+		}
+
+		filter := bulkFilterFromArguments(arguments)
+		if dryRun, _ := arguments["dry_run"].(bool); dryRun {
+			records, err := dnsUsecase.MatchRecords(ctx, zoneName, filter)
+			return jsonToolResult(map[string]interface{}{"matched": len(records), "records": records}, err)
+		}
+
+		var changes usecase.BulkChanges
+		if v, ok := arguments["ttl"].(float64); ok {
+			ttl := int(v)
+			changes.TTL = &ttl
+		}
+		if v, ok := arguments["proxied"].(bool); ok {
+			changes.Proxied = &v
+		}
+
+		result, err := dnsUsecase.BulkUpdate(ctx, zoneName, filter, changes)
+		return jsonToolResult(result, err)
+	})
+
+	// Register tool: undo_change
+	undoChangeTool := mcp.NewTool("undo_change",
+		"Undo a record change using the change_id returned by create, update, delete and upsert. Fails if the record was modified since",
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"change_id": map[string]interface{}{
+					"type":        "string",
+					"description": "ID of the change to undo",
+				},
+			},
+			"required": []string{"change_id"},
+		},
+	)
+	s.AddTool(undoChangeTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+		ctx := toolContext()
+
+		changeID, _ := arguments["change_id"].(string)
+		if changeID == "" {
+			return argumentError("change_id is required"), nil
+		}
+
+		entry, err := auditStorage.GetAuditEntry(changeID)
+		if err != nil {
+			return toolError(err), nil
+		}
+
+		restored, err := dnsUsecase.RollbackChange(ctx, *entry)
+		if err != nil {
+			return toolError(err), nil
+		}
+
+		if restored == nil {
 			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []interface{}{mcp.NewTextContent(fmt.Sprintf("Error: %v", err))},
+				Content: []interface{}{mcp.NewTextContent("Change undone, created record removed")},
 			}, nil
 		}
 
+		jsonData, err := json.MarshalIndent(restored, "", "  ")
+		if err != nil {
+			return toolError(err), nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []interface{}{mcp.NewTextContent(string(jsonData))},
+		}, nil
+	})
+
+	// Register tool: export_zone
+	exportZoneTool := mcp.NewTool("export_zone",
+		"Export all records of a zone as a BIND zone file, JSON or CSV",
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"zone_name": map[string]interface{}{
+					"type":        "string",
+					"description": "The zone/domain name",
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"bind", "json", "csv"},
+					"description": "Export format (default: bind)",
+				},
+			},
+			"required": []string{"zone_name"},
+		},
+	)
+	s.AddTool(exportZoneTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+		ctx := toolContext()
+
+		zoneName, _ := arguments["zone_name"].(string)
+		formatName, _ := arguments["format"].(string)
+		if zoneName == "" {
+			return argumentError("zone_name is required"), nil
+		}
+
+		format, err := usecase.ParseExportFormat(formatName)
+		if err != nil {
+			return toolError(err), nil
+		}
+
+		data, err := dnsUsecase.ExportZone(ctx, zoneName, format)
+		if err != nil {
+			return toolError(err), nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []interface{}{mcp.NewTextContent(string(data))},
+		}, nil
+	})
+
+	// Register tool: plan_zone
+	planZoneTool := mcp.NewTool("plan_zone",
+		"Diff a desired record set (YAML or JSON document) against a zone and return a plan of creates, updates and deletes. Nothing is changed until apply_plan is called with the plan ID",
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"zone_name": map[string]interface{}{
+					"type":        "string",
+					"description": "The zone/domain name",
+				},
+				"document": map[string]interface{}{
+					"type":        "string",
+					"description": "Desired state: {records: [{name, type, content, ttl, proxied, priority}]} in YAML or JSON",
+				},
+				"allow_deletes": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Delete live records missing from the document (default: false)",
+				},
+			},
+			"required": []string{"zone_name", "document"},
+		},
+	)
+	s.AddTool(planZoneTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+		ctx := toolContext()
+
+		zoneName, _ := arguments["zone_name"].(string)
+		document, _ := arguments["document"].(string)
+		allowDeletes, _ := arguments["allow_deletes"].(bool)
+		if zoneName == "" || document == "" {
+			return argumentError("zone_name and document are required"), nil
+		}
+
+		doc, err := usecase.ParseZoneDocument([]byte(document))
+		if err != nil {
+			return toolError(err), nil
+		}
+		doc.Zone = zoneName
+
+		plan, err := zoneSyncUsecase.Plan(ctx, doc, usecase.SyncOptions{AllowDeletes: allowDeletes})
+		if err != nil {
+			return toolError(err), nil
+		}
+
+		jsonData, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return toolError(err), nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []interface{}{mcp.NewTextContent(string(jsonData))},
+		}, nil
+	})
+
+	// Register tool: apply_plan
+	applyPlanTool := mcp.NewTool("apply_plan",
+		"Apply a plan returned by plan_zone. Fails if the zone changed since the plan was made",
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"plan_id": map[string]interface{}{
+					"type":        "string",
+					"description": "The plan ID returned by plan_zone",
+				},
+				"zone_name": map[string]interface{}{
+					"type":        "string",
+					"description": "The zone/domain name the plan was made for",
+				},
+			},
+			"required": []string{"plan_id"},
+		},
+	)
+	s.AddTool(applyPlanTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+		ctx := toolContext()
+
+		planID, _ := arguments["plan_id"].(string)
+		zoneName, _ := arguments["zone_name"].(string)
+		if planID == "" {
+			return argumentError("plan_id is required"), nil
+		}
+
+		result, err := zoneSyncUsecase.Apply(ctx, zoneName, planID)
+		if err != nil {
+			return toolError(err), nil
+		}
+
+		jsonData, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return toolError(err), nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []interface{}{mcp.NewTextContent(string(jsonData))},
+		}, nil
+	})
+
+	// Register tool: import_zone
+	importZoneTool := mcp.NewTool("import_zone",
+		"Import records from a BIND zone file. Returns a preview of the records to create unless apply is true",
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"zone_name": map[string]interface{}{
+					"type":        "string",
+					"description": "The zone/domain name",
+				},
+				"zone_file": map[string]interface{}{
+					"type":        "string",
+					"description": "Zone file contents in BIND format",
+				},
+				"apply": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Create the records instead of only previewing them (default: false)",
+				},
+			},
+			"required": []string{"zone_name", "zone_file"},
+		},
+	)
+	s.AddTool(importZoneTool, func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+		ctx := toolContext()
+
+		zoneName, _ := arguments["zone_name"].(string)
+		zoneFile, _ := arguments["zone_file"].(string)
+		apply, _ := arguments["apply"].(bool)
+		if zoneName == "" || zoneFile == "" {
+			return argumentError("zone_name and zone_file are required"), nil
+		}
+
+		plan, err := zoneImportUsecase.PreviewImport(ctx, zoneName, strings.NewReader(zoneFile))
+		if err != nil {
+			return toolError(err), nil
+		}
+
+		var output interface{} = plan
+		if apply {
+			result, err := zoneImportUsecase.ApplyImport(ctx, plan)
+			if err != nil {
+				return toolError(err), nil
+			}
+			output = result
+		}
+
+		jsonData, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return toolError(err), nil
+		}
+
 		return &mcp.CallToolResult{
 			Content: []interface{}{mcp.NewTextContent(string(jsonData))},
 		}, nil
@@ -568,3 +1071,283 @@ func main() {
 		log.Fatalf("Server error: %v", err)
 	}
 }
+
+// toolContext returns the context for a tool call. Changes made over stdio
+// are attributed to the local user running the server.
+func toolContext() context.Context {
+	return usecase.WithActor(context.Background(), domain.Actor{
+		ID:      os.Getenv("USER"),
+		Name:    "stdio MCP client",
+		Channel: domain.ChannelMCPStdio,
+	})
+}
+
+// toolError returns err as a tool error. The error is returned as JSON
+// with the JSON-RPC code and kind the bot's MCP server answers with, and
+// validation errors name the offending field so clients can correct the
+// input.
+func toolError(err error) *mcp.CallToolResult {
+	payload := map[string]interface{}{
+		"error": err.Error(),
+		"code":  handler.RPCCode(err),
+		"kind":  handler.ErrorKind(err),
+	}
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		payload["field"] = validationErr.Field
+		payload["reason"] = validationErr.Message
+	}
+	return errorResult(payload)
+}
+
+// argumentError returns a tool error for missing or invalid arguments
+func argumentError(message string) *mcp.CallToolResult {
+	return errorResult(map[string]interface{}{
+		"error": message,
+		"code":  handler.RPCInvalidParams,
+		"kind":  handler.RPCKind(handler.RPCInvalidParams),
+	})
+}
+
+// errorResult returns the payload of an error as a tool error
+func errorResult(payload map[string]interface{}) *mcp.CallToolResult {
+	jsonData, _ := json.MarshalIndent(payload, "", "  ")
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []interface{}{mcp.NewTextContent(string(jsonData))},
+	}
+}
+
+// jsonToolResult returns v as indented JSON, or err as a tool error
+func jsonToolResult(v interface{}, err error) (*mcp.CallToolResult, error) {
+	if err != nil {
+		return toolError(err), nil
+	}
+
+	jsonData, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return toolError(err), nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []interface{}{mcp.NewTextContent(string(jsonData))},
+	}, nil
+}
+
+// bulkFilterFromArguments builds a bulk filter from tool arguments
+func bulkFilterFromArguments(arguments map[string]interface{}) usecase.BulkFilter {
+	filter := usecase.BulkFilter{}
+	if v, ok := arguments["type"].(string); ok {
+		filter.Type = v
+	}
+	if v, ok := arguments["name_pattern"].(string); ok {
+		filter.NamePattern = v
+	}
+	if v, ok := arguments["content"].(string); ok {
+		filter.Content = v
+	}
+	return filter
+}
+
+// stringsFromArguments returns a string array argument, or nil if it is
+// missing
+func stringsFromArguments(arguments map[string]interface{}, key string) []string {
+	items, ok := arguments[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if v, ok := item.(string); ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// recordFilterSchema describes the arguments of list_records
+func recordFilterSchema() map[string]interface{} {
+	str := func(description string) map[string]interface{} {
+		return map[string]interface{}{"type": "string", "description": description}
+	}
+	return map[string]interface{}{
+		"zone_name":        str("The zone/domain name (e.g., example.com)"),
+		"name":             str("Only records with exactly this name"),
+		"type":             map[string]interface{}{"type": "string", "enum": domain.RecordTypes, "description": "Only records of this type"},
+		"content":          str("Only records with exactly this content"),
+		"name_contains":    str("Only records whose name contains this text"),
+		"name_starts_with": str("Only records whose name starts with this text"),
+		"name_ends_with":   str("Only records whose name ends with this text"),
+		"proxied":          map[string]interface{}{"type": "boolean", "description": "Only proxied, or only unproxied records"},
+		"comment":          str("Only records with exactly this comment"),
+		"tag":              str("Only records with this tag (name, or name:value)"),
+		"match":            map[string]interface{}{"type": "string", "enum": []string{"all", "any"}, "description": "Whether records must match all filters (default) or any of them; the name_* filters always apply"},
+		"order":            map[string]interface{}{"type": "string", "enum": domain.RecordOrders, "description": "Field to order the records by"},
+		"direction":        map[string]interface{}{"type": "string", "enum": []string{"asc", "desc"}},
+		"page":             map[string]interface{}{"type": "number", "description": "1-based page; leave out to list every record"},
+		"per_page":         map[string]interface{}{"type": "number", "description": "Records per page (default 100)"},
+	}
+}
+
+// recordFilterFromArguments reads the filter arguments of list_records
+func recordFilterFromArguments(arguments map[string]interface{}) domain.RecordFilter {
+	filter := domain.RecordFilter{}
+	for key, field := range map[string]*string{
+		"name":             &filter.Name,
+		"type":             &filter.Type,
+		"content":          &filter.Content,
+		"name_contains":    &filter.NameContains,
+		"name_starts_with": &filter.NameStartsWith,
+		"name_ends_with":   &filter.NameEndsWith,
+		"comment":          &filter.Comment,
+		"tag":              &filter.Tag,
+		"order":            &filter.Order,
+	} {
+		if v, ok := arguments[key].(string); ok {
+			*field = v
+		}
+	}
+	if v, ok := arguments["proxied"].(bool); ok {
+		filter.Proxied = &v
+	}
+	filter.MatchAny = arguments["match"] == "any"
+	filter.Descending = arguments["direction"] == "desc"
+	if v, ok := arguments["page"].(float64); ok {
+		filter.Page = int(v)
+	}
+	if v, ok := arguments["per_page"].(float64); ok {
+		filter.PerPage = int(v)
+	}
+	return filter
+}
+
+// tagsSchema describes the tags argument of record tools
+func tagsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "string"},
+		"description": "Tags as name:value, e.g. owner:dns-team; on update an empty list removes all tags",
+	}
+}
+
+// recordDataFromArguments decodes the data argument of compound records,
+// or returns nil if it is missing. Its keys are Cloudflare's data field names.
+func recordDataFromArguments(arguments map[string]interface{}) *domain.RecordData {
+	raw, ok := arguments["data"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var data domain.RecordData
+	if err := json.Unmarshal(jsonData, &data); err != nil {
+		return nil
+	}
+	return &data
+}
+
+// recordDataSchema describes the structured data of compound records, with
+// one shape per record type
+func recordDataSchema() map[string]interface{} {
+	str := map[string]interface{}{"type": "string"}
+	num := map[string]interface{}{"type": "number"}
+	shape := func(title string, properties map[string]interface{}, required ...string) map[string]interface{} {
+		return map[string]interface{}{"title": title, "type": "object", "properties": properties, "required": required}
+	}
+
+	return map[string]interface{}{
+		"type":        "object",
+		"description": "Structured data instead of content for SRV, CAA, TLSA, SSHFP, URI, LOC, HTTPS, SVCB, NAPTR, DS, CERT and SMIMEA records",
+		"oneOf": []map[string]interface{}{
+			shape("SRV", map[string]interface{}{"service": str, "proto": str, "weight": num, "port": num, "target": str}, "weight", "port", "target"),
+			shape("CAA", map[string]interface{}{"flags": num, "tag": str, "value": str}, "tag", "value"),
+			shape("TLSA", map[string]interface{}{"usage": num, "selector": num, "matching_type": num, "certificate": str}, "usage", "selector", "matching_type", "certificate"),
+			shape("SSHFP", map[string]interface{}{"algorithm": num, "type": num, "fingerprint": str}, "algorithm", "type", "fingerprint"),
+			shape("URI", map[string]interface{}{"weight": num, "target": str}, "weight", "target"),
+			shape("LOC", map[string]interface{}{
+				"lat_degrees": num, "lat_minutes": num, "lat_seconds": num, "lat_direction": str,
+				"long_degrees": num, "long_minutes": num, "long_seconds": num, "long_direction": str,
+				"altitude": num, "size": num, "precision_horz": num, "precision_vert": num,
+			}, "lat_degrees", "lat_direction", "long_degrees", "long_direction"),
+			shape("HTTPS/SVCB", map[string]interface{}{"priority": num, "target": str, "value": str}, "priority", "target"),
+			shape("NAPTR", map[string]interface{}{
+				"order": num, "preference": num, "flags": str, "service": str, "regex": str, "replacement": str,
+			}, "order", "preference", "replacement"),
+			shape("DS", map[string]interface{}{"key_tag": num, "algorithm": num, "digest_type": num, "digest": str}, "key_tag", "algorithm", "digest_type", "digest"),
+			shape("CERT", map[string]interface{}{"type": num, "key_tag": num, "algorithm": num, "certificate": str}, "type", "key_tag", "algorithm", "certificate"),
+			shape("SMIMEA", map[string]interface{}{"usage": num, "selector": num, "matching_type": num, "certificate": str}, "usage", "selector", "matching_type", "certificate"),
+		},
+	}
+}
+
+// recordInputsFromArguments reads the records array of bulk_create
+func recordInputsFromArguments(arguments map[string]interface{}) []usecase.CreateRecordInput {
+	items, _ := arguments["records"].([]interface{})
+	inputs := make([]usecase.CreateRecordInput, 0, len(items))
+	for _, item := range items {
+		r, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		input := usecase.CreateRecordInput{}
+		if v, ok := r["name"].(string); ok {
+			input.Name = v
+		}
+		if v, ok := r["type"].(string); ok {
+			input.Type = v
+		}
+		if v, ok := r["content"].(string); ok {
+			input.Content = v
+		}
+		if v, ok := r["ttl"].(float64); ok {
+			input.TTL = int(v)
+		}
+		if v, ok := r["proxied"].(bool); ok {
+			input.Proxied = v
+		}
+		if v, ok := r["priority"].(float64); ok {
+			priority := uint16(v)
+			input.Priority = &priority
+		}
+		input.Data = recordDataFromArguments(r)
+		if v, ok := r["comment"].(string); ok {
+			input.Comment = v
+		}
+		input.Tags = stringsFromArguments(r, "tags")
+		inputs = append(inputs, input)
+	}
+	return inputs
+}
+
+// recordSelectorFromArguments builds a record selector from tool arguments.
+// nameKey and contentKey name the arguments holding the record name and
+// the current content, since they differ between tools.
+func recordSelectorFromArguments(arguments map[string]interface{}, nameKey, contentKey string) usecase.RecordSelector {
+	selector := usecase.RecordSelector{}
+	if v, ok := arguments["record_id"].(string); ok {
+		selector.RecordID = v
+	}
+	if v, ok := arguments[nameKey].(string); ok {
+		selector.Name = v
+	}
+	if v, ok := arguments["type"].(string); ok {
+		selector.Type = v
+	}
+	if v, ok := arguments[contentKey].(string); ok {
+		selector.Content = v
+	}
+	return selector
+}
+
+// describeSelector returns a short human readable description of a selector
+func describeSelector(selector usecase.RecordSelector) string {
+	if selector.RecordID != "" {
+		return selector.RecordID
+	}
+	if selector.Type != "" {
+		return fmt.Sprintf("%s (%s)", selector.Name, selector.Type)
+	}
+	return selector.Name
+}
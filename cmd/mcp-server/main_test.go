@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/handler"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// errorPayload decodes the JSON of a tool error
+func errorPayload(t *testing.T, result *mcp.CallToolResult) map[string]interface{} {
+	t.Helper()
+	if !result.IsError || len(result.Content) != 1 {
+		t.Fatalf("got %+v, want one error content", result)
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatalf("got content %T, want text", result.Content[0])
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(text.Text), &payload); err != nil {
+		t.Fatalf("decoding %q: %v", text.Text, err)
+	}
+	return payload
+}
+
+func TestToolError(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		code  int
+		kind  string
+		field string
+	}{
+		{"not found", fmt.Errorf("zone example.net: %w", domain.ErrZoneNotFound), handler.RPCNotFound, "not_found", ""},
+		{"conflict", domain.ErrDuplicateRecord, handler.RPCConflict, "conflict", ""},
+		{"rate limited", domain.ErrRateLimited, handler.RPCRateLimited, "rate_limited", ""},
+		{"validation", &domain.ValidationError{Field: "ttl", Message: "too short"}, handler.RPCInvalidParams, "invalid_params", "ttl"},
+		{"other", errors.New("boom"), handler.RPCInternalError, "internal_error", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := errorPayload(t, toolError(tt.err))
+			if payload["code"] != float64(tt.code) || payload["kind"] != tt.kind {
+				t.Errorf("got code %v kind %v, want %d %s", payload["code"], payload["kind"], tt.code, tt.kind)
+			}
+			if payload["error"] != tt.err.Error() {
+				t.Errorf("got error %v, want %s", payload["error"], tt.err)
+			}
+			if tt.field != "" && payload["field"] != tt.field {
+				t.Errorf("got field %v, want %s", payload["field"], tt.field)
+			}
+		})
+	}
+}
+
+func TestArgumentError(t *testing.T) {
+	payload := errorPayload(t, argumentError("zone_name is required"))
+	if payload["code"] != float64(handler.RPCInvalidParams) || payload["kind"] != "invalid_params" {
+		t.Errorf("got %v, want invalid params", payload)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"cf-dns-bot/external_resource/cloudflare"
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/repository"
+	"cf-dns-bot/internal/usecase"
+	"cf-dns-bot/pkg/config"
+	"cf-dns-bot/pkg/storage"
+)
+
+const usage = `Usage: zone-sync <plan|apply> -f <file> [options]
+
+Sync a Cloudflare zone with a YAML or JSON file.
+
+Commands:
+  plan    Show the changes needed to match the file
+  apply   Show the plan, ask for confirmation and apply it
+
+Options:
+`
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	file := flags.String("f", "", "zone file in YAML or JSON (required)")
+	zoneName := flags.String("zone", "", "zone name, overrides the zone in the file")
+	allowDeletes := flags.Bool("allow-deletes", false, "delete live records that are not in the file")
+	yes := flags.Bool("yes", false, "apply without asking for confirmation")
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	if command != "plan" && command != "apply" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	flags.Parse(os.Args[2:])
+	if *file == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", *file, err)
+	}
+	doc, err := usecase.ParseZoneDocument(data)
+	if err != nil {
+		log.Fatalf("Failed to parse %s: %v", *file, err)
+	}
+	if *zoneName != "" {
+		doc.Zone = *zoneName
+	}
+
+	syncUsecase := newSyncUsecase()
+	ctx := usecase.WithActor(context.Background(), domain.Actor{
+		ID:      os.Getenv("USER"),
+		Name:    os.Getenv("USER"),
+		Channel: domain.ChannelCLI,
+	})
+
+	plan, err := syncUsecase.Plan(ctx, doc, usecase.SyncOptions{AllowDeletes: *allowDeletes})
+	if err != nil {
+		log.Fatalf("Failed to plan: %v", err)
+	}
+	printPlan(plan)
+
+	if command == "plan" || !plan.HasChanges() {
+		return
+	}
+
+	if !*yes && !confirm("Apply these changes?") {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	result, err := syncUsecase.Apply(ctx, plan.ZoneName, plan.ID)
+	if err != nil {
+		log.Fatalf("Failed to apply: %v", err)
+	}
+
+	fmt.Printf("\nApplied: %d created, %d updated, %d deleted\n", len(result.Created), len(result.Updated), len(result.Deleted))
+	for _, f := range result.Failed {
+		fmt.Printf("  failed %s %s: %s\n", f.Record.Type, f.Record.Name, f.Error)
+	}
+	if len(result.Failed) > 0 {
+		os.Exit(1)
+	}
+}
+
+// newSyncUsecase wires the sync usecase from the environment configuration
+func newSyncUsecase() usecase.ZoneSyncUsecase {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.FakeCloudflare {
+		log.Fatal("FAKE_CLOUDFLARE is only supported by the bot")
+	}
+
+	configStorage := storage.NewJSONStorage(cfg.DataDir)
+
+	// Initialize a Cloudflare client per account; each paces and retries
+	// its calls so bursts do not exhaust the account's API rate limit
+	var credentials []cloudflare.Credentials
+	for _, account := range cfg.CloudflareAccounts {
+		credentials = append(credentials, cloudflare.Credentials{
+			Account:  account.Name,
+			APIToken: account.APIToken,
+			APIKey:   account.APIKey,
+			Email:    account.Email,
+		})
+	}
+	cfClient, err := cloudflare.NewAccountsClient(credentials, cloudflare.DefaultResilienceConfig())
+	if err != nil {
+		log.Fatalf("Failed to create Cloudflare client: %v", err)
+	}
+
+	zoneRepo := repository.NewZoneRepository(cfClient)
+	dnsRepo := repository.NewDNSRepository(cfClient)
+	auditStorage := storage.NewAuditStorage(cfg.DataDir)
+	dnsUsecase := usecase.NewAuditedDNSUsecase(usecase.NewDNSUsecase(zoneRepo, dnsRepo, configStorage), auditStorage)
+
+	return usecase.NewZoneSyncUsecase(dnsUsecase)
+}
+
+// printPlan prints a plan in a diff-like format
+func printPlan(plan *usecase.SyncPlan) {
+	mode := "deletes disabled"
+	if plan.AllowDeletes {
+		mode = "deletes enabled"
+	}
+	fmt.Printf("Plan for %s (%s)\n\n", plan.ZoneName, mode)
+
+	for _, r := range plan.Creates {
+		fmt.Printf("  + %s\n", describeRecord(r))
+	}
+	for _, c := range plan.Updates {
+		fmt.Printf("  ~ %s\n", describeRecord(c.Before))
+		fmt.Printf("    → %s\n", describeRecord(c.After))
+	}
+	for _, r := range plan.Deletes {
+		fmt.Printf("  - %s\n", describeRecord(r))
+	}
+	if len(plan.Unmanaged) > 0 {
+		fmt.Printf("\n  %d live records are not in the file and will be kept (use -allow-deletes to remove them)\n", len(plan.Unmanaged))
+	}
+
+	fmt.Printf("\nPlan: %d to create, %d to update, %d to delete, %d unchanged\n",
+		len(plan.Creates), len(plan.Updates), len(plan.Deletes), plan.Unchanged)
+}
+
+// describeRecord formats a record on one line
+func describeRecord(r domain.DNSRecord) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", r.Type, r.Name)
+	if r.Priority != nil {
+		fmt.Fprintf(&b, " %d", *r.Priority)
+	}
+	fmt.Fprintf(&b, " %q", r.Content)
+	if r.TTL != 0 {
+		fmt.Fprintf(&b, " ttl=%d", r.TTL)
+	}
+	if r.Proxied {
+		b.WriteString(" proxied")
+	}
+	return b.String()
+}
+
+// confirm asks a yes/no question on the terminal
+func confirm(question string) bool {
+	fmt.Printf("\n%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
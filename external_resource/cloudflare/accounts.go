@@ -0,0 +1,227 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Credentials are the API token, or API key and email, of a named
+// Cloudflare account
+type Credentials struct {
+	Account  string
+	APIToken string
+	APIKey   string
+	Email    string
+	BaseURL  string // Cloudflare's API when empty
+}
+
+// Account is a named Cloudflare account and its client
+type Account struct {
+	Name   string
+	Client Client
+}
+
+// NewAccountsClient creates a resilient client for each account and
+// combines them with NewMultiAccountClient
+func NewAccountsClient(credentials []Credentials, config ResilienceConfig) (Client, error) {
+	accounts := make([]Account, 0, len(credentials))
+	for _, cred := range credentials {
+		var options []ClientOption
+		if cred.BaseURL != "" {
+			options = append(options, WithBaseURL(cred.BaseURL))
+		}
+
+		var client Client
+		var err error
+		if cred.APIToken != "" {
+			client, err = NewClient(cred.APIToken, options...)
+		} else {
+			client, err = NewClientWithKey(cred.APIKey, cred.Email, options...)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", cred.Account, err)
+		}
+		// Each token has its own rate limit
+		accounts = append(accounts, Account{Name: cred.Account, Client: NewResilientClient(client, config)})
+	}
+	return NewMultiAccountClient(accounts), nil
+}
+
+// multiAccountClient implements Client on top of the clients of several
+// accounts. Zones are listed from every account and labelled with it;
+// calls for a zone go to the account the zone belongs to.
+type multiAccountClient struct {
+	accounts []Account
+
+	mu           sync.RWMutex
+	zoneAccounts map[string]Account // zone ID -> account
+}
+
+// NewMultiAccountClient creates a client that routes calls between the
+// accounts by zone. When a zone is visible to several accounts, the first
+// one is used.
+func NewMultiAccountClient(accounts []Account) Client {
+	return &multiAccountClient{
+		accounts:     accounts,
+		zoneAccounts: make(map[string]Account),
+	}
+}
+
+// ListZones returns the zones of every account. An account that fails is
+// logged and skipped, so one bad token does not hide the other accounts'
+// zones; the errors are only returned when no account could list zones.
+func (c *multiAccountClient) ListZones(ctx context.Context) ([]Zone, error) {
+	var result []Zone
+	var errs []error
+	seen := make(map[string]bool)
+	for _, account := range c.accounts {
+		zones, err := account.Client.ListZones(ctx)
+		if err != nil {
+			log.Printf("[CloudflareClient] ListZones failed for account %s: %v", account.Name, err)
+			errs = append(errs, fmt.Errorf("account %s: %w", account.Name, err))
+			continue
+		}
+		for _, z := range zones {
+			if seen[z.ID] {
+				continue
+			}
+			seen[z.ID] = true
+			result = append(result, c.remember(account, z))
+		}
+	}
+	if len(errs) == len(c.accounts) && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return result, nil
+}
+
+// GetZoneByName returns a zone by its name from the first account that
+// has it
+func (c *multiAccountClient) GetZoneByName(ctx context.Context, name string) (*Zone, error) {
+	var errs []error
+	for _, account := range c.accounts {
+		zone, err := account.Client.GetZoneByName(ctx, name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("account %s: %w", account.Name, err))
+			continue
+		}
+		z := c.remember(account, *zone)
+		return &z, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// GetZone returns a zone by its ID
+func (c *multiAccountClient) GetZone(ctx context.Context, zoneID string) (*Zone, error) {
+	c.mu.RLock()
+	account, ok := c.zoneAccounts[zoneID]
+	c.mu.RUnlock()
+	if ok {
+		return c.getZone(ctx, account, zoneID)
+	}
+
+	var errs []error
+	for _, account := range c.accounts {
+		zone, err := c.getZone(ctx, account, zoneID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return zone, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// getZone returns a zone by its ID from an account
+func (c *multiAccountClient) getZone(ctx context.Context, account Account, zoneID string) (*Zone, error) {
+	zone, err := account.Client.GetZone(ctx, zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("account %s: %w", account.Name, err)
+	}
+	z := c.remember(account, *zone)
+	return &z, nil
+}
+
+// ListDNSRecords returns the DNS records of a zone matching the filter
+func (c *multiAccountClient) ListDNSRecords(ctx context.Context, zoneID string, filter DNSRecordFilter) ([]DNSRecord, error) {
+	account, err := c.accountOf(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return account.Client.ListDNSRecords(ctx, zoneID, filter)
+}
+
+// GetDNSRecord returns a specific DNS record
+func (c *multiAccountClient) GetDNSRecord(ctx context.Context, zoneID, recordID string) (*DNSRecord, error) {
+	account, err := c.accountOf(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return account.Client.GetDNSRecord(ctx, zoneID, recordID)
+}
+
+// CreateDNSRecord creates a new DNS record
+func (c *multiAccountClient) CreateDNSRecord(ctx context.Context, zoneID string, input CreateDNSRecordInput) (*DNSRecord, error) {
+	account, err := c.accountOf(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return account.Client.CreateDNSRecord(ctx, zoneID, input)
+}
+
+// UpdateDNSRecord updates an existing DNS record
+func (c *multiAccountClient) UpdateDNSRecord(ctx context.Context, zoneID, recordID string, input UpdateDNSRecordInput) (*DNSRecord, error) {
+	account, err := c.accountOf(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return account.Client.UpdateDNSRecord(ctx, zoneID, recordID, input)
+}
+
+// DeleteDNSRecord deletes a DNS record
+func (c *multiAccountClient) DeleteDNSRecord(ctx context.Context, zoneID, recordID string) error {
+	account, err := c.accountOf(ctx, zoneID)
+	if err != nil {
+		return err
+	}
+	return account.Client.DeleteDNSRecord(ctx, zoneID, recordID)
+}
+
+// remember labels a zone with its account and routes later calls for it
+// there
+func (c *multiAccountClient) remember(account Account, zone Zone) Zone {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if known, ok := c.zoneAccounts[zone.ID]; ok {
+		account = known
+	} else {
+		c.zoneAccounts[zone.ID] = account
+	}
+	zone.Account = account.Name
+	return zone
+}
+
+// accountOf returns the account of a zone, asking every account for zones
+// that have not been seen yet
+func (c *multiAccountClient) accountOf(ctx context.Context, zoneID string) (Account, error) {
+	c.mu.RLock()
+	account, ok := c.zoneAccounts[zoneID]
+	c.mu.RUnlock()
+	if ok {
+		return account, nil
+	}
+	if len(c.accounts) == 1 {
+		return c.accounts[0], nil
+	}
+
+	if _, err := c.GetZone(ctx, zoneID); err != nil {
+		return Account{}, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.zoneAccounts[zoneID], nil
+}
@@ -0,0 +1,42 @@
+package cloudflare_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"cf-dns-bot/external_resource/cloudflare"
+	"cf-dns-bot/external_resource/cloudflare/cloudflaretest"
+	"cf-dns-bot/internal/domain"
+)
+
+func TestMultiAccountClientListZonesSkipsFailedAccount(t *testing.T) {
+	config := testConfig()
+	config.MaxRetries = 0
+	failing, server := newResilientClient(t, cloudflaretest.NewDemoStore(), config)
+
+	good := cloudflaretest.NewStore()
+	good.AddZone("example.net")
+
+	client := cloudflare.NewMultiAccountClient([]cloudflare.Account{
+		{Name: "broken", Client: failing},
+		{Name: "good", Client: cloudflaretest.NewClient(good)},
+	})
+
+	server.FailNext(http.StatusTooManyRequests, 1)
+	zones, err := client.ListZones(context.Background())
+	if err != nil {
+		t.Fatalf("ListZones: %v", err)
+	}
+	if len(zones) != 1 || zones[0].Name != "example.net" || zones[0].Account != "good" {
+		t.Errorf("got zones %+v, want example.net of account good", zones)
+	}
+
+	// Without any account left, the errors are returned
+	client = cloudflare.NewMultiAccountClient([]cloudflare.Account{{Name: "broken", Client: failing}})
+	server.FailNext(http.StatusTooManyRequests, 1)
+	if _, err := client.ListZones(context.Background()); !errors.Is(err, domain.ErrRateLimited) {
+		t.Errorf("got error %v, want ErrRateLimited", err)
+	}
+}
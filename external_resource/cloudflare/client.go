@@ -4,18 +4,41 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"strings"
+
+	"cf-dns-bot/internal/domain"
 
 	"github.com/cloudflare/cloudflare-go"
 )
 
+// defaultPerPage is the page size Cloudflare uses when none is given
+const defaultPerPage = 100
+
 // cloudflareClient implements the Client interface using cloudflare-go SDK
 type cloudflareClient struct {
 	api *cloudflare.API
 }
 
+// ClientOption changes how NewClient and NewClientWithKey set up a client
+type ClientOption func(*clientOptions)
+
+// clientOptions collects the settings of ClientOptions
+type clientOptions struct {
+	baseURL string
+}
+
+// WithBaseURL sends the requests to another Cloudflare v4 API, such as the
+// fake server of package cloudflaretest
+func WithBaseURL(baseURL string) ClientOption {
+	return func(o *clientOptions) {
+		o.baseURL = baseURL
+	}
+}
+
 // NewClient creates a new Cloudflare client using API token
-func NewClient(apiToken string) (Client, error) {
-	api, err := cloudflare.NewWithAPIToken(apiToken)
+func NewClient(apiToken string, options ...ClientOption) (Client, error) {
+	api, err := cloudflare.NewWithAPIToken(apiToken, sdkOptions(options)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cloudflare client: %w", err)
 	}
@@ -26,8 +49,8 @@ func NewClient(apiToken string) (Client, error) {
 }
 
 // NewClientWithKey creates a new Cloudflare client using API key and email
-func NewClientWithKey(apiKey, email string) (Client, error) {
-	api, err := cloudflare.New(apiKey, email)
+func NewClientWithKey(apiKey, email string, options ...ClientOption) (Client, error) {
+	api, err := cloudflare.New(apiKey, email, sdkOptions(options)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cloudflare client: %w", err)
 	}
@@ -37,11 +60,30 @@ func NewClientWithKey(apiKey, email string) (Client, error) {
 	}, nil
 }
 
+// sdkOptions leaves pacing and retries to NewResilientClient and lets it
+// see the status and Retry-After header of every response
+func sdkOptions(options []ClientOption) []cloudflare.Option {
+	var o clientOptions
+	for _, option := range options {
+		option(&o)
+	}
+
+	sdk := []cloudflare.Option{
+		cloudflare.HTTPClient(&http.Client{Transport: hintTransport{next: http.DefaultTransport}}),
+		cloudflare.UsingRetryPolicy(0, 0, 0),
+		cloudflare.UsingRateLimit(1000),
+	}
+	if o.baseURL != "" {
+		sdk = append(sdk, cloudflare.BaseURL(o.baseURL))
+	}
+	return sdk
+}
+
 // ListZones returns all zones accessible by the client
 func (c *cloudflareClient) ListZones(ctx context.Context) ([]Zone, error) {
 	zones, err := c.api.ListZones(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list zones: %w", err)
+		return nil, fmt.Errorf("failed to list zones: %w", domainError(err, nil))
 	}
 
 	result := make([]Zone, len(zones))
@@ -58,16 +100,20 @@ func (c *cloudflareClient) ListZones(ctx context.Context) ([]Zone, error) {
 // GetZoneByName returns a zone by its name
 func (c *cloudflareClient) GetZoneByName(ctx context.Context, name string) (*Zone, error) {
 	log.Printf("[CloudflareClient] GetZoneByName START name=%s", name)
-	zoneID, err := c.api.ZoneIDByName(name)
+	zones, err := c.api.ListZones(ctx, name)
 	if err != nil {
 		log.Printf("[CloudflareClient] GetZoneByName ERROR: %v", err)
-		return nil, fmt.Errorf("failed to get zone by name %s: %w", name, err)
+		return nil, fmt.Errorf("failed to get zone by name %s: %w", name, domainError(err, nil))
 	}
-	log.Printf("[CloudflareClient] GetZoneByName SUCCESS: zoneID=%s", zoneID)
+	if len(zones) == 0 {
+		log.Printf("[CloudflareClient] GetZoneByName ERROR: zone not found")
+		return nil, fmt.Errorf("failed to get zone by name %s: %w", name, domain.ErrZoneNotFound)
+	}
+	log.Printf("[CloudflareClient] GetZoneByName SUCCESS: zoneID=%s", zones[0].ID)
 
 	return &Zone{
-		ID:   zoneID,
-		Name: name,
+		ID:   zones[0].ID,
+		Name: zones[0].Name,
 	}, nil
 }
 
@@ -75,7 +121,7 @@ func (c *cloudflareClient) GetZoneByName(ctx context.Context, name string) (*Zon
 func (c *cloudflareClient) GetZone(ctx context.Context, zoneID string) (*Zone, error) {
 	zone, err := c.api.ZoneDetails(ctx, zoneID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get zone %s: %w", zoneID, err)
+		return nil, fmt.Errorf("failed to get zone %s: %w", zoneID, domainError(err, domain.ErrZoneNotFound))
 	}
 
 	return &Zone{
@@ -84,38 +130,88 @@ func (c *cloudflareClient) GetZone(ctx context.Context, zoneID string) (*Zone, e
 	}, nil
 }
 
-// ListDNSRecords returns all DNS records for a zone
+// ListDNSRecords returns the DNS records of a zone matching the filter
 func (c *cloudflareClient) ListDNSRecords(ctx context.Context, zoneID string, filter DNSRecordFilter) ([]DNSRecord, error) {
 	log.Printf("[CloudflareClient] ListDNSRecords START zoneID=%s", zoneID)
-	listParams := cloudflare.ListDNSRecordsParams{}
-
-	if filter.Name != "" {
-		listParams.Name = filter.Name
+	listParams := cloudflare.ListDNSRecordsParams{
+		Name:    filter.Name,
+		Type:    filter.Type,
+		Content: filter.Content,
+		Proxied: filter.Proxied,
+		Comment: filter.Comment,
+		Order:   filter.Order,
 	}
-	if filter.Type != "" {
-		listParams.Type = filter.Type
+	if filter.Tag != "" {
+		listParams.Tags = []string{filter.Tag}
+	}
+	if filter.MatchAny {
+		listParams.Match = "any"
+	}
+	if filter.Descending {
+		listParams.Direction = cloudflare.ListDirectionDesc
+	}
+
+	// Name substring filters are applied here, so the page is cut from
+	// the filtered records instead of asking Cloudflare for it
+	localFilter := filter.NameContains != "" || filter.NameStartsWith != "" || filter.NameEndsWith != ""
+	if filter.Page > 0 && !localFilter {
+		listParams.Page = filter.Page
+		listParams.PerPage = filter.PerPage
 	}
 
 	records, _, err := c.api.ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zoneID), listParams)
 	if err != nil {
 		log.Printf("[CloudflareClient] ListDNSRecords ERROR: %v", err)
-		return nil, fmt.Errorf("failed to list dns records: %w", err)
+		return nil, fmt.Errorf("failed to list dns records: %w", domainError(err, domain.ErrZoneNotFound))
 	}
 	log.Printf("[CloudflareClient] ListDNSRecords SUCCESS: found %d records", len(records))
 
-	result := make([]DNSRecord, len(records))
-	for i, r := range records {
-		result[i] = mapCloudflareRecord(r)
+	result := make([]DNSRecord, 0, len(records))
+	for _, r := range records {
+		if localFilter && !matchesName(r.Name, filter) {
+			continue
+		}
+		result = append(result, mapCloudflareRecord(r))
+	}
+
+	if filter.Page > 0 && localFilter {
+		result = pageRecords(result, filter.Page, filter.PerPage)
 	}
 
 	return result, nil
 }
 
+// matchesName checks a record name against the name substring filters,
+// ignoring case
+func matchesName(name string, filter DNSRecordFilter) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, strings.ToLower(filter.NameContains)) &&
+		strings.HasPrefix(name, strings.ToLower(filter.NameStartsWith)) &&
+		strings.HasSuffix(name, strings.ToLower(filter.NameEndsWith))
+}
+
+// pageRecords returns one page of records. Cloudflare's default page size
+// is used when perPage is 0.
+func pageRecords(records []DNSRecord, pageNumber, perPage int) []DNSRecord {
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	start := (pageNumber - 1) * perPage
+	if start >= len(records) {
+		return []DNSRecord{}
+	}
+	end := start + perPage
+	if end > len(records) {
+		end = len(records)
+	}
+	return records[start:end]
+}
+
 // GetDNSRecord returns a specific DNS record
 func (c *cloudflareClient) GetDNSRecord(ctx context.Context, zoneID, recordID string) (*DNSRecord, error) {
 	record, err := c.api.GetDNSRecord(ctx, cloudflare.ZoneIdentifier(zoneID), recordID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get dns record %s: %w", recordID, err)
+		return nil, fmt.Errorf("failed to get dns record %s: %w", recordID, domainError(err, domain.ErrRecordNotFound))
 	}
 
 	result := mapCloudflareRecord(record)
@@ -129,6 +225,8 @@ func (c *cloudflareClient) CreateDNSRecord(ctx context.Context, zoneID string, i
 		Type:    input.Type,
 		Content: input.Content,
 		TTL:     input.TTL,
+		Comment: input.Comment,
+		Tags:    input.Tags,
 	}
 	if input.Proxied {
 		createParams.Proxied = &input.Proxied
@@ -138,9 +236,15 @@ func (c *cloudflareClient) CreateDNSRecord(ctx context.Context, zoneID string, i
 		createParams.Priority = input.Priority
 	}
 
+	// Cloudflare derives the content of records sent with structured data
+	if input.Data != nil {
+		createParams.Data = input.Data
+		createParams.Content = ""
+	}
+
 	record, err := c.api.CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(zoneID), createParams)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create dns record: %w", err)
+		return nil, fmt.Errorf("failed to create dns record: %w", domainError(err, domain.ErrZoneNotFound))
 	}
 
 	result := mapCloudflareRecord(record)
@@ -150,23 +254,36 @@ func (c *cloudflareClient) CreateDNSRecord(ctx context.Context, zoneID string, i
 // UpdateDNSRecord updates an existing DNS record
 func (c *cloudflareClient) UpdateDNSRecord(ctx context.Context, zoneID, recordID string, input UpdateDNSRecordInput) (*DNSRecord, error) {
 	updateParams := cloudflare.UpdateDNSRecordParams{
-		ID:      recordID,
-		Name:    input.Name,
-		Type:    input.Type,
-		Content: input.Content,
-		TTL:     input.TTL,
+		ID:       recordID,
+		Proxied:  input.Proxied,
+		Priority: input.Priority,
+		Comment:  input.Comment,
+		Tags:     input.Tags,
 	}
-	if input.Proxied {
-		updateParams.Proxied = &input.Proxied
+	if updateParams.Tags == nil {
+		updateParams.Tags = []string{}
+	}
+	if input.Name != nil {
+		updateParams.Name = *input.Name
+	}
+	if input.Type != nil {
+		updateParams.Type = *input.Type
+	}
+	if input.Content != nil {
+		updateParams.Content = *input.Content
+	}
+	if input.TTL != nil {
+		updateParams.TTL = *input.TTL
 	}
 
-	if input.Priority != nil {
-		updateParams.Priority = input.Priority
+	if input.Data != nil {
+		updateParams.Data = input.Data
+		updateParams.Content = ""
 	}
 
 	record, err := c.api.UpdateDNSRecord(ctx, cloudflare.ZoneIdentifier(zoneID), updateParams)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update dns record %s: %w", recordID, err)
+		return nil, fmt.Errorf("failed to update dns record %s: %w", recordID, domainError(err, domain.ErrRecordNotFound))
 	}
 
 	result := mapCloudflareRecord(record)
@@ -177,7 +294,7 @@ func (c *cloudflareClient) UpdateDNSRecord(ctx context.Context, zoneID, recordID
 func (c *cloudflareClient) DeleteDNSRecord(ctx context.Context, zoneID, recordID string) error {
 	err := c.api.DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(zoneID), recordID)
 	if err != nil {
-		return fmt.Errorf("failed to delete dns record %s: %w", recordID, err)
+		return fmt.Errorf("failed to delete dns record %s: %w", recordID, domainError(err, domain.ErrRecordNotFound))
 	}
 
 	return nil
@@ -189,6 +306,7 @@ func mapCloudflareRecord(r cloudflare.DNSRecord) DNSRecord {
 	if r.Proxied != nil {
 		proxied = *r.Proxied
 	}
+	data, _ := r.Data.(map[string]interface{})
 	return DNSRecord{
 		ID:       r.ID,
 		ZoneID:   r.ZoneID,
@@ -199,5 +317,8 @@ func mapCloudflareRecord(r cloudflare.DNSRecord) DNSRecord {
 		TTL:      r.TTL,
 		Proxied:  proxied,
 		Priority: r.Priority,
+		Data:     data,
+		Comment:  r.Comment,
+		Tags:     r.Tags,
 	}
 }
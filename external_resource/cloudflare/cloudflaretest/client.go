@@ -0,0 +1,157 @@
+package cloudflaretest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cf-dns-bot/external_resource/cloudflare"
+	"cf-dns-bot/internal/domain"
+)
+
+// client implements cloudflare.Client on top of a Store
+type client struct {
+	store *Store
+}
+
+// Ensure client implements cloudflare.Client
+var _ cloudflare.Client = (*client)(nil)
+
+// NewClient creates an in-memory Cloudflare client backed by the store.
+// Its errors read like those of the real client and wrap the same domain
+// errors, so code that handles them behaves the same.
+func NewClient(store *Store) cloudflare.Client {
+	return &client{store: store}
+}
+
+// ListZones returns all zones of the store
+func (c *client) ListZones(ctx context.Context) ([]cloudflare.Zone, error) {
+	return c.store.listZones(""), nil
+}
+
+// GetZoneByName returns a zone by its name
+func (c *client) GetZoneByName(ctx context.Context, name string) (*cloudflare.Zone, error) {
+	zones := c.store.listZones(name)
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("failed to get zone by name %s: %w", name, domain.ErrZoneNotFound)
+	}
+	return &zones[0], nil
+}
+
+// GetZone returns a zone by its ID
+func (c *client) GetZone(ctx context.Context, zoneID string) (*cloudflare.Zone, error) {
+	zone, err := c.store.getZone(zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get zone %s: %w", zoneID, err)
+	}
+	return &zone, nil
+}
+
+// ListDNSRecords returns the DNS records of a zone matching the filter
+func (c *client) ListDNSRecords(ctx context.Context, zoneID string, filter cloudflare.DNSRecordFilter) ([]cloudflare.DNSRecord, error) {
+	query := recordQuery{
+		Type:       filter.Type,
+		Name:       filter.Name,
+		Content:    filter.Content,
+		Proxied:    filter.Proxied,
+		Comment:    filter.Comment,
+		MatchAny:   filter.MatchAny,
+		Order:      filter.Order,
+		Descending: filter.Descending,
+	}
+	if filter.Tag != "" {
+		query.Tags = []string{filter.Tag}
+	}
+
+	records, err := c.store.listRecords(zoneID, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dns records: %w", err)
+	}
+
+	result := make([]cloudflare.DNSRecord, 0, len(records))
+	for _, r := range records {
+		name := strings.ToLower(r.Name)
+		if strings.Contains(name, strings.ToLower(filter.NameContains)) &&
+			strings.HasPrefix(name, strings.ToLower(filter.NameStartsWith)) &&
+			strings.HasSuffix(name, strings.ToLower(filter.NameEndsWith)) {
+			result = append(result, r.DNSRecord)
+		}
+	}
+
+	if filter.Page > 0 {
+		perPage := filter.PerPage
+		if perPage <= 0 {
+			perPage = defaultRecordsPerPage
+		}
+		start := min((filter.Page-1)*perPage, len(result))
+		result = result[start:min(start+perPage, len(result))]
+	}
+	return result, nil
+}
+
+// GetDNSRecord returns a specific DNS record
+func (c *client) GetDNSRecord(ctx context.Context, zoneID, recordID string) (*cloudflare.DNSRecord, error) {
+	record, err := c.store.getRecord(zoneID, recordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dns record %s: %w", recordID, err)
+	}
+	return &record.DNSRecord, nil
+}
+
+// CreateDNSRecord creates a new DNS record
+func (c *client) CreateDNSRecord(ctx context.Context, zoneID string, input cloudflare.CreateDNSRecordInput) (*cloudflare.DNSRecord, error) {
+	record := cloudflare.DNSRecord{
+		Name:     input.Name,
+		Type:     input.Type,
+		Content:  input.Content,
+		TTL:      input.TTL,
+		Proxied:  input.Proxied,
+		Priority: input.Priority,
+		Data:     input.Data,
+		Comment:  input.Comment,
+		Tags:     input.Tags,
+	}
+	if input.Data != nil {
+		record.Content = ""
+	}
+
+	created, err := c.store.createRecord(zoneID, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dns record: %w", err)
+	}
+	return &created.DNSRecord, nil
+}
+
+// UpdateDNSRecord updates an existing DNS record. Like the real client it
+// always replaces the tags.
+func (c *client) UpdateDNSRecord(ctx context.Context, zoneID, recordID string, input cloudflare.UpdateDNSRecordInput) (*cloudflare.DNSRecord, error) {
+	tags := input.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	patch := recordPatch{
+		Type:     input.Type,
+		Name:     input.Name,
+		Content:  input.Content,
+		Data:     input.Data,
+		Priority: input.Priority,
+		TTL:      input.TTL,
+		Proxied:  input.Proxied,
+		Comment:  input.Comment,
+		Tags:     &tags,
+	}
+
+	updated, err := c.store.updateRecord(zoneID, recordID, patch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update dns record %s: %w", recordID, err)
+	}
+	return &updated.DNSRecord, nil
+}
+
+// DeleteDNSRecord deletes a DNS record
+func (c *client) DeleteDNSRecord(ctx context.Context, zoneID, recordID string) error {
+	if err := c.store.deleteRecord(zoneID, recordID); err != nil {
+		return fmt.Errorf("failed to delete dns record %s: %w", recordID, err)
+	}
+	return nil
+}
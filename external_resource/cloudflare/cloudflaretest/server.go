@@ -0,0 +1,422 @@
+package cloudflaretest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cf-dns-bot/external_resource/cloudflare"
+)
+
+// Page sizes of the fake API, matching Cloudflare's
+const (
+	defaultZonesPerPage   = 20
+	maxZonesPerPage       = 50
+	defaultRecordsPerPage = 100
+	maxRecordsPerPage     = 50000
+)
+
+// Server is a fake of the zones and dns_records endpoints of Cloudflare's
+// v4 API, backed by a Store. Serve it with httptest.NewServer and point a
+// client at it with cloudflare.WithBaseURL. Requests need an API token or
+// key but any value is accepted.
+type Server struct {
+	store *Store
+	mux   *http.ServeMux
+
+	mu       sync.Mutex
+	requests int
+	failures []int // statuses of the next requests that fail
+}
+
+// NewServer creates a fake Cloudflare API serving the store
+func NewServer(store *Store) *Server {
+	s := &Server{store: store, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /zones", s.handleListZones)
+	s.mux.HandleFunc("GET /zones/{zone}", s.handleGetZone)
+	s.mux.HandleFunc("GET /zones/{zone}/dns_records", s.handleListRecords)
+	s.mux.HandleFunc("POST /zones/{zone}/dns_records", s.handleCreateRecord)
+	s.mux.HandleFunc("GET /zones/{zone}/dns_records/{record}", s.handleGetRecord)
+	s.mux.HandleFunc("PATCH /zones/{zone}/dns_records/{record}", s.handleUpdateRecord)
+	s.mux.HandleFunc("PUT /zones/{zone}/dns_records/{record}", s.handleUpdateRecord)
+	s.mux.HandleFunc("DELETE /zones/{zone}/dns_records/{record}", s.handleDeleteRecord)
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, &apiError{http.StatusNotFound, 7000, "No route for that URI"})
+	})
+	return s
+}
+
+// FailNext makes the next count requests fail with the status code, such
+// as 429 to test rate limiting or 502 to test retries. 429 responses ask
+// to retry after one second.
+func (s *Server) FailNext(status, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < count; i++ {
+		s.failures = append(s.failures, status)
+	}
+}
+
+// Requests returns the number of requests served so far
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	failure := 0
+	if len(s.failures) > 0 {
+		failure, s.failures = s.failures[0], s.failures[1:]
+	}
+	s.mu.Unlock()
+
+	switch {
+	case failure == http.StatusTooManyRequests:
+		w.Header().Set("Retry-After", "1")
+		writeError(w, &apiError{failure, 971, "Please wait and consider throttling your request speed"})
+		return
+	case failure != 0:
+		writeError(w, &apiError{failure, 10000, http.StatusText(failure)})
+		return
+	}
+
+	if r.Header.Get("Authorization") == "" && (r.Header.Get("X-Auth-Key") == "" || r.Header.Get("X-Auth-Email") == "") {
+		writeError(w, &apiError{http.StatusBadRequest, 9106, "Missing X-Auth-Key, X-Auth-Email or Authorization headers"})
+		return
+	}
+
+	// Clients use https://api.cloudflare.com/client/v4 as their base URL
+	r.URL.Path = strings.TrimPrefix(r.URL.Path, "/client/v4")
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleListZones handles GET /zones[?name=example.com]
+func (s *Server) handleListZones(w http.ResponseWriter, r *http.Request) {
+	page, perPage, err := pagination(r, defaultZonesPerPage, maxZonesPerPage)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	zones := s.store.listZones(r.URL.Query().Get("name"))
+	result := make([]map[string]interface{}, 0, len(zones))
+	for _, z := range zones {
+		result = append(result, map[string]interface{}{"id": z.ID, "name": z.Name, "status": "active"})
+	}
+	writePage(w, result, page, perPage)
+}
+
+// handleGetZone handles GET /zones/{zone}
+func (s *Server) handleGetZone(w http.ResponseWriter, r *http.Request) {
+	zone, err := s.store.getZone(r.PathValue("zone"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResult(w, http.StatusOK, map[string]interface{}{"id": zone.ID, "name": zone.Name, "status": "active"})
+}
+
+// handleListRecords handles GET /zones/{zone}/dns_records with the type,
+// name, content, proxied, comment, tag, tag-match, match, order and
+// direction filters
+func (s *Server) handleListRecords(w http.ResponseWriter, r *http.Request) {
+	page, perPage, err := pagination(r, defaultRecordsPerPage, maxRecordsPerPage)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	params := r.URL.Query()
+	query := recordQuery{
+		Type:        params.Get("type"),
+		Name:        params.Get("name"),
+		Content:     params.Get("content"),
+		Comment:     params.Get("comment"),
+		Tags:        params["tag"],
+		TagMatchAny: params.Get("tag-match") == "any",
+		MatchAny:    params.Get("match") == "any",
+		Order:       params.Get("order"),
+		Descending:  params.Get("direction") == "desc",
+	}
+	if value := params.Get("proxied"); value != "" {
+		proxied, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, &apiError{http.StatusBadRequest, 1004, "proxied must be true or false"})
+			return
+		}
+		query.Proxied = &proxied
+	}
+	if query.Order != "" && !slices.Contains([]string{"type", "name", "content", "ttl", "proxied"}, query.Order) {
+		writeError(w, &apiError{http.StatusBadRequest, 1004, "order must be one of type, name, content, ttl or proxied"})
+		return
+	}
+
+	records, err := s.store.listRecords(r.PathValue("zone"), query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	result := make([]wireRecord, len(records))
+	for i, record := range records {
+		result[i] = toWire(record)
+	}
+	writePage(w, result, page, perPage)
+}
+
+// handleGetRecord handles GET /zones/{zone}/dns_records/{record}
+func (s *Server) handleGetRecord(w http.ResponseWriter, r *http.Request) {
+	record, err := s.store.getRecord(r.PathValue("zone"), r.PathValue("record"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResult(w, http.StatusOK, toWire(record))
+}
+
+// handleCreateRecord handles POST /zones/{zone}/dns_records
+func (s *Server) handleCreateRecord(w http.ResponseWriter, r *http.Request) {
+	var body recordBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, &apiError{http.StatusBadRequest, 9207, "Request body is invalid JSON"})
+		return
+	}
+
+	patch := body.patch()
+	record, err := s.store.createRecord(r.PathValue("zone"), patch.record())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResult(w, http.StatusOK, toWire(record))
+}
+
+// handleUpdateRecord handles PATCH and PUT /zones/{zone}/dns_records/{record}.
+// PATCH changes the fields that are sent; PUT replaces the record.
+func (s *Server) handleUpdateRecord(w http.ResponseWriter, r *http.Request) {
+	var body recordBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, &apiError{http.StatusBadRequest, 9207, "Request body is invalid JSON"})
+		return
+	}
+
+	patch := body.patch()
+	if r.Method == http.MethodPut {
+		// Fields that are not sent are reset
+		full := patch.record()
+		tags := full.Tags
+		patch = recordPatch{
+			Type:     &full.Type,
+			Name:     &full.Name,
+			Content:  &full.Content,
+			Data:     full.Data,
+			Priority: full.Priority,
+			TTL:      &full.TTL,
+			Proxied:  &full.Proxied,
+			Comment:  &full.Comment,
+			Tags:     &tags,
+		}
+		if full.TTL == 0 {
+			*patch.TTL = 1
+		}
+	}
+
+	record, err := s.store.updateRecord(r.PathValue("zone"), r.PathValue("record"), patch)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResult(w, http.StatusOK, toWire(record))
+}
+
+// handleDeleteRecord handles DELETE /zones/{zone}/dns_records/{record}
+func (s *Server) handleDeleteRecord(w http.ResponseWriter, r *http.Request) {
+	recordID := r.PathValue("record")
+	if err := s.store.deleteRecord(r.PathValue("zone"), recordID); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResult(w, http.StatusOK, map[string]string{"id": recordID})
+}
+
+// wireRecord is a DNS record as Cloudflare's API returns it
+type wireRecord struct {
+	ID         string                 `json:"id"`
+	ZoneID     string                 `json:"zone_id"`
+	ZoneName   string                 `json:"zone_name"`
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"`
+	Content    string                 `json:"content"`
+	Proxiable  bool                   `json:"proxiable"`
+	Proxied    bool                   `json:"proxied"`
+	TTL        int                    `json:"ttl"`
+	Priority   *uint16                `json:"priority,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	Comment    string                 `json:"comment,omitempty"`
+	Tags       []string               `json:"tags"`
+	CreatedOn  time.Time              `json:"created_on"`
+	ModifiedOn time.Time              `json:"modified_on"`
+}
+
+// toWire converts a stored record to its API form
+func toWire(r storedRecord) wireRecord {
+	tags := r.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return wireRecord{
+		ID:         r.ID,
+		ZoneID:     r.ZoneID,
+		ZoneName:   r.ZoneName,
+		Name:       r.Name,
+		Type:       r.Type,
+		Content:    r.Content,
+		Proxiable:  r.Type == "A" || r.Type == "AAAA" || r.Type == "CNAME",
+		Proxied:    r.Proxied,
+		TTL:        r.TTL,
+		Priority:   r.Priority,
+		Data:       r.Data,
+		Comment:    r.Comment,
+		Tags:       tags,
+		CreatedOn:  r.created,
+		ModifiedOn: r.modified,
+	}
+}
+
+// recordBody is the request body of a record create or update
+type recordBody struct {
+	Type     *string                `json:"type"`
+	Name     *string                `json:"name"`
+	Content  *string                `json:"content"`
+	Data     map[string]interface{} `json:"data"`
+	Priority *uint16                `json:"priority"`
+	TTL      *int                   `json:"ttl"`
+	Proxied  *bool                  `json:"proxied"`
+	Comment  *string                `json:"comment"`
+	Tags     *[]string              `json:"tags"`
+}
+
+// patch returns the fields of the body as a patch
+func (b recordBody) patch() recordPatch {
+	return recordPatch{
+		Type:     b.Type,
+		Name:     b.Name,
+		Content:  b.Content,
+		Data:     b.Data,
+		Priority: b.Priority,
+		TTL:      b.TTL,
+		Proxied:  b.Proxied,
+		Comment:  b.Comment,
+		Tags:     b.Tags,
+	}
+}
+
+// record returns the fields of a patch as a new record
+func (p recordPatch) record() cloudflare.DNSRecord {
+	var r cloudflare.DNSRecord
+	if p.Type != nil {
+		r.Type = *p.Type
+	}
+	if p.Name != nil {
+		r.Name = *p.Name
+	}
+	if p.Content != nil {
+		r.Content = *p.Content
+	}
+	r.Data = p.Data
+	r.Priority = p.Priority
+	if p.TTL != nil {
+		r.TTL = *p.TTL
+	}
+	if p.Proxied != nil {
+		r.Proxied = *p.Proxied
+	}
+	if p.Comment != nil {
+		r.Comment = *p.Comment
+	}
+	if p.Tags != nil {
+		r.Tags = *p.Tags
+	}
+	return r
+}
+
+// pagination reads the page and per_page parameters of a list request
+func pagination(r *http.Request, defaultPerPage, maxPerPage int) (int, int, error) {
+	page, perPage := 1, defaultPerPage
+	params := r.URL.Query()
+	if value := params.Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return 0, 0, &apiError{http.StatusBadRequest, 1001, "page must be a positive number"}
+		}
+		page = n
+	}
+	if value := params.Get("per_page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPerPage {
+			return 0, 0, &apiError{http.StatusBadRequest, 1001, "per_page must be between 1 and " + strconv.Itoa(maxPerPage)}
+		}
+		perPage = n
+	}
+	return page, perPage, nil
+}
+
+// writePage writes one page of a list with its result_info
+func writePage[T any](w http.ResponseWriter, items []T, page, perPage int) {
+	total := len(items)
+	start := min((page-1)*perPage, total)
+	end := min(start+perPage, total)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"errors":   []interface{}{},
+		"messages": []interface{}{},
+		"result":   items[start:end],
+		"result_info": map[string]int{
+			"page":        page,
+			"per_page":    perPage,
+			"count":       end - start,
+			"total_count": total,
+			"total_pages": (total + perPage - 1) / perPage,
+		},
+	})
+}
+
+// writeResult writes a successful response
+func writeResult(w http.ResponseWriter, status int, result interface{}) {
+	writeJSON(w, status, map[string]interface{}{
+		"success":  true,
+		"errors":   []interface{}{},
+		"messages": []interface{}{},
+		"result":   result,
+	})
+}
+
+// writeError writes Cloudflare's error envelope
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		apiErr = &apiError{http.StatusInternalServerError, 10000, err.Error()}
+	}
+	writeJSON(w, apiErr.status, map[string]interface{}{
+		"success":  false,
+		"errors":   []map[string]interface{}{{"code": apiErr.code, "message": apiErr.message}},
+		"messages": []interface{}{},
+		"result":   nil,
+	})
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,580 @@
+// Package cloudflaretest provides offline stand-ins for Cloudflare: an
+// in-memory cloudflare.Client and a fake of the zones and dns_records
+// endpoints of the v4 API, both backed by a Store.
+package cloudflaretest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cf-dns-bot/external_resource/cloudflare"
+	"cf-dns-bot/internal/domain"
+)
+
+// apiError is an error in the form of Cloudflare's error envelope
+type apiError struct {
+	status  int
+	code    int
+	message string
+}
+
+// Error implements error
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (%d)", e.message, e.code)
+}
+
+// Unwrap returns the domain error the real client translates the code to
+func (e *apiError) Unwrap() error {
+	switch e.code {
+	case 7003:
+		return domain.ErrZoneNotFound
+	case 81044:
+		return domain.ErrRecordNotFound
+	case 81057:
+		return domain.ErrDuplicateRecord
+	case 81053, 81054:
+		return domain.ErrRecordConflict
+	case 1004:
+		return domain.ErrInvalidRecord
+	case 971:
+		return domain.ErrRateLimited
+	case 9106:
+		return domain.ErrCloudflareAuth
+	}
+	return nil
+}
+
+func zoneNotFound(zoneID string) error {
+	return &apiError{http.StatusNotFound, 7003, fmt.Sprintf("Could not route to /zones/%s, perhaps your object identifier is invalid?", zoneID)}
+}
+
+func recordNotFound() error {
+	return &apiError{http.StatusNotFound, 81044, "Record does not exist."}
+}
+
+func invalidRecord(message string) error {
+	return &apiError{http.StatusBadRequest, 1004, "DNS Validation Error: " + message}
+}
+
+// storedRecord is a record with the timestamps Cloudflare keeps
+type storedRecord struct {
+	cloudflare.DNSRecord
+	created  time.Time
+	modified time.Time
+}
+
+// Store holds the zones and records served by Client and Server. It is
+// safe for concurrent use.
+type Store struct {
+	mu      sync.Mutex
+	zones   []cloudflare.Zone
+	records map[string][]*storedRecord // zone ID -> records
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{
+		records: make(map[string][]*storedRecord),
+	}
+}
+
+// NewDemoStore creates a store with two example zones and a few records
+func NewDemoStore() *Store {
+	s := NewStore()
+	priority := uint16(10)
+
+	com := s.AddZone("example.com")
+	for _, r := range []cloudflare.DNSRecord{
+		{Name: "example.com", Type: "A", Content: "192.0.2.10", TTL: 1, Proxied: true},
+		{Name: "www.example.com", Type: "CNAME", Content: "example.com", TTL: 1, Proxied: true},
+		{Name: "api.example.com", Type: "A", Content: "192.0.2.20", TTL: 300, Comment: "API gateway", Tags: []string{"owner:backend"}},
+		{Name: "api.example.com", Type: "AAAA", Content: "2001:db8::20", TTL: 300},
+		{Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: 3600, Priority: &priority},
+		{Name: "mail.example.com", Type: "A", Content: "192.0.2.25", TTL: 3600},
+		{Name: "example.com", Type: "TXT", Content: "v=spf1 mx -all", TTL: 3600},
+	} {
+		s.AddRecord(com.ID, r)
+	}
+
+	org := s.AddZone("example.org")
+	for _, r := range []cloudflare.DNSRecord{
+		{Name: "example.org", Type: "A", Content: "198.51.100.5", TTL: 1, Proxied: true},
+		{Name: "dev.example.org", Type: "A", Content: "198.51.100.6", TTL: 120, Tags: []string{"env:dev"}},
+	} {
+		s.AddRecord(org.ID, r)
+	}
+
+	return s
+}
+
+// AddZone adds a zone and returns it with its new ID
+func (s *Store) AddZone(name string) cloudflare.Zone {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zone := cloudflare.Zone{ID: newID(), Name: strings.ToLower(name)}
+	s.zones = append(s.zones, zone)
+	return zone
+}
+
+// AddRecord adds a record to a zone without validating it, such as to
+// prepare a test, and returns it with its new ID
+func (s *Store) AddRecord(zoneID string, record cloudflare.DNSRecord) (cloudflare.DNSRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zone, err := s.zone(zoneID)
+	if err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+	return s.insert(zone, record).DNSRecord, nil
+}
+
+// Records returns a copy of the records of a zone
+func (s *Store) Records(zoneID string) []cloudflare.DNSRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]cloudflare.DNSRecord, 0, len(s.records[zoneID]))
+	for _, r := range s.records[zoneID] {
+		result = append(result, copyRecord(r.DNSRecord))
+	}
+	return result
+}
+
+// listZones returns the zones, or the zone with the given name
+func (s *Store) listZones(name string) []cloudflare.Zone {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if name == "" {
+		return slices.Clone(s.zones)
+	}
+	var result []cloudflare.Zone
+	for _, z := range s.zones {
+		if strings.EqualFold(z.Name, strings.TrimSuffix(name, ".")) {
+			result = append(result, z)
+		}
+	}
+	return result
+}
+
+// getZone returns a zone by its ID
+func (s *Store) getZone(zoneID string) (cloudflare.Zone, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.zone(zoneID)
+}
+
+// zone returns a zone by its ID; s.mu must be held
+func (s *Store) zone(zoneID string) (cloudflare.Zone, error) {
+	for _, z := range s.zones {
+		if z.ID == zoneID {
+			return z, nil
+		}
+	}
+	return cloudflare.Zone{}, zoneNotFound(zoneID)
+}
+
+// recordQuery selects records like the parameters of Cloudflare's list
+// endpoint
+type recordQuery struct {
+	Type        string
+	Name        string
+	Content     string
+	Proxied     *bool
+	Comment     string
+	Tags        []string // tag names, or name:value
+	TagMatchAny bool
+	MatchAny    bool
+	Order       string // type, name, content, ttl or proxied
+	Descending  bool
+}
+
+// listRecords returns the records of a zone matching the query, in the
+// requested order
+func (s *Store) listRecords(zoneID string, query recordQuery) ([]storedRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.zone(zoneID); err != nil {
+		return nil, err
+	}
+
+	var result []storedRecord
+	for _, r := range s.records[zoneID] {
+		if query.matches(r.DNSRecord) {
+			record := *r
+			record.DNSRecord = copyRecord(r.DNSRecord)
+			result = append(result, record)
+		}
+	}
+
+	if query.Order != "" {
+		sort.SliceStable(result, func(i, j int) bool {
+			a, b := orderKey(result[i].DNSRecord, query.Order), orderKey(result[j].DNSRecord, query.Order)
+			if query.Descending {
+				return a > b
+			}
+			return a < b
+		})
+	}
+	return result, nil
+}
+
+// getRecord returns a record of a zone
+func (s *Store) getRecord(zoneID, recordID string) (storedRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.record(zoneID, recordID)
+	if err != nil {
+		return storedRecord{}, err
+	}
+	record := *r
+	record.DNSRecord = copyRecord(r.DNSRecord)
+	return record, nil
+}
+
+// record returns a record of a zone; s.mu must be held
+func (s *Store) record(zoneID, recordID string) (*storedRecord, error) {
+	if _, err := s.zone(zoneID); err != nil {
+		return nil, err
+	}
+	for _, r := range s.records[zoneID] {
+		if r.ID == recordID {
+			return r, nil
+		}
+	}
+	return nil, recordNotFound()
+}
+
+// createRecord validates and adds a record like Cloudflare does
+func (s *Store) createRecord(zoneID string, record cloudflare.DNSRecord) (storedRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zone, err := s.zone(zoneID)
+	if err != nil {
+		return storedRecord{}, err
+	}
+	record.Name = fullName(record.Name, zone.Name)
+	if record.TTL == 0 {
+		record.TTL = 1
+	}
+	if err := validate(record); err != nil {
+		return storedRecord{}, err
+	}
+	if err := s.checkDuplicate(zoneID, "", record); err != nil {
+		return storedRecord{}, err
+	}
+
+	r := s.insert(zone, record)
+	stored := *r
+	stored.DNSRecord = copyRecord(r.DNSRecord)
+	return stored, nil
+}
+
+// recordPatch lists the fields of a record to change; nil fields are kept
+type recordPatch struct {
+	Type     *string
+	Name     *string
+	Content  *string
+	Data     map[string]interface{}
+	Priority *uint16
+	TTL      *int
+	Proxied  *bool
+	Comment  *string
+	Tags     *[]string
+}
+
+// updateRecord changes the fields of a record that are set in the patch
+func (s *Store) updateRecord(zoneID, recordID string, patch recordPatch) (storedRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zone, err := s.zone(zoneID)
+	if err != nil {
+		return storedRecord{}, err
+	}
+	r, err := s.record(zoneID, recordID)
+	if err != nil {
+		return storedRecord{}, err
+	}
+
+	record := copyRecord(r.DNSRecord)
+	if patch.Type != nil {
+		record.Type = *patch.Type
+	}
+	if patch.Name != nil {
+		record.Name = fullName(*patch.Name, zone.Name)
+	}
+	if patch.Content != nil {
+		record.Content = *patch.Content
+	}
+	if patch.Type != nil || patch.Content != nil {
+		// New content replaces the structured data it was derived from
+		record.Data = nil
+	}
+	if patch.Data != nil {
+		record.Data = patch.Data
+		record.Content = ""
+	}
+	if patch.Priority != nil {
+		record.Priority = patch.Priority
+	}
+	if patch.TTL != nil {
+		record.TTL = *patch.TTL
+	}
+	if patch.Proxied != nil {
+		record.Proxied = *patch.Proxied
+	}
+	if patch.Comment != nil {
+		record.Comment = *patch.Comment
+	}
+	if patch.Tags != nil {
+		record.Tags = *patch.Tags
+	}
+	if err := validate(record); err != nil {
+		return storedRecord{}, err
+	}
+	if err := s.checkDuplicate(zoneID, recordID, record); err != nil {
+		return storedRecord{}, err
+	}
+
+	if record.Data != nil && record.Content == "" {
+		record.Content = contentFromData(record.Type, record.Data)
+	}
+	r.DNSRecord = record
+	r.modified = time.Now().UTC()
+
+	stored := *r
+	stored.DNSRecord = copyRecord(r.DNSRecord)
+	return stored, nil
+}
+
+// deleteRecord removes a record from a zone
+func (s *Store) deleteRecord(zoneID, recordID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.record(zoneID, recordID); err != nil {
+		return err
+	}
+	s.records[zoneID] = slices.DeleteFunc(s.records[zoneID], func(r *storedRecord) bool {
+		return r.ID == recordID
+	})
+	return nil
+}
+
+// insert adds a record with a new ID; s.mu must be held
+func (s *Store) insert(zone cloudflare.Zone, record cloudflare.DNSRecord) *storedRecord {
+	now := time.Now().UTC()
+	record = copyRecord(record)
+	record.ID = newID()
+	record.ZoneID = zone.ID
+	record.ZoneName = zone.Name
+	record.Name = fullName(record.Name, zone.Name)
+	if record.Data != nil && record.Content == "" {
+		record.Content = contentFromData(record.Type, record.Data)
+	}
+
+	r := &storedRecord{DNSRecord: record, created: now, modified: now}
+	s.records[zone.ID] = append(s.records[zone.ID], r)
+	return r
+}
+
+// checkDuplicate rejects a record identical to another record of the zone,
+// and a CNAME sharing its name with other records; s.mu must be held
+func (s *Store) checkDuplicate(zoneID, recordID string, record cloudflare.DNSRecord) error {
+	for _, r := range s.records[zoneID] {
+		if r.ID == recordID || !strings.EqualFold(r.Name, record.Name) {
+			continue
+		}
+		switch {
+		case r.Type == record.Type && r.Content == record.Content && record.Data == nil:
+			return &apiError{http.StatusBadRequest, 81057, "Record already exists."}
+		case record.Type == "CNAME":
+			return &apiError{http.StatusBadRequest, 81053, "An A, AAAA, or CNAME record with that host already exists."}
+		case r.Type == "CNAME":
+			return &apiError{http.StatusBadRequest, 81054, "A CNAME record with that host already exists."}
+		}
+	}
+	return nil
+}
+
+// matches reports whether a record matches the query
+func (q recordQuery) matches(r cloudflare.DNSRecord) bool {
+	var results []bool
+	if q.Type != "" {
+		results = append(results, strings.EqualFold(r.Type, q.Type))
+	}
+	if q.Name != "" {
+		results = append(results, strings.EqualFold(r.Name, strings.TrimSuffix(q.Name, ".")))
+	}
+	if q.Content != "" {
+		results = append(results, r.Content == q.Content)
+	}
+	if q.Proxied != nil {
+		results = append(results, r.Proxied == *q.Proxied)
+	}
+	if q.Comment != "" {
+		results = append(results, r.Comment == q.Comment)
+	}
+	if len(q.Tags) > 0 {
+		matched := 0
+		for _, tag := range q.Tags {
+			if hasTag(r.Tags, tag) {
+				matched++
+			}
+		}
+		results = append(results, matched == len(q.Tags) || (q.TagMatchAny && matched > 0))
+	}
+
+	if len(results) == 0 {
+		return true
+	}
+	if q.MatchAny {
+		return slices.Contains(results, true)
+	}
+	return !slices.Contains(results, false)
+}
+
+// hasTag reports whether the tags contain a tag name, or name:value
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+		if !strings.Contains(tag, ":") && strings.HasPrefix(t, tag+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// orderKey returns the value records are sorted by for an order field
+func orderKey(r cloudflare.DNSRecord, order string) string {
+	switch order {
+	case "type":
+		return r.Type
+	case "name":
+		return strings.ToLower(r.Name)
+	case "content":
+		return r.Content
+	case "ttl":
+		return fmt.Sprintf("%010d", r.TTL)
+	case "proxied":
+		return strconv.FormatBool(r.Proxied)
+	}
+	return ""
+}
+
+// validate checks a record the way Cloudflare rejects obviously invalid
+// records; only the addresses of A and AAAA records are checked in depth
+func validate(r cloudflare.DNSRecord) error {
+	switch {
+	case r.Type == "":
+		return invalidRecord("type is required")
+	case r.Name == "":
+		return invalidRecord("name is required")
+	case r.Content == "" && r.Data == nil:
+		return invalidRecord("content is required")
+	case r.TTL != 1 && (r.TTL < 60 || r.TTL > 86400):
+		return invalidRecord("TTL must be between 60 and 86400 seconds, or 1 for Auto")
+	case r.Proxied && r.Type != "A" && r.Type != "AAAA" && r.Type != "CNAME":
+		return invalidRecord(r.Type + " records cannot be proxied")
+	case r.Type == "MX" && r.Priority == nil:
+		return invalidRecord("MX records require a priority")
+	case r.Type == "A" && !isIPv4(r.Content):
+		return invalidRecord("content for A record must be a valid IPv4 address")
+	case r.Type == "AAAA" && !isIPv6(r.Content):
+		return invalidRecord("content for AAAA record must be a valid IPv6 address")
+	}
+	return nil
+}
+
+// isIPv4 reports whether s is an IPv4 address
+func isIPv4(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is4()
+}
+
+// isIPv6 reports whether s is an IPv6 address
+func isIPv6(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is6() && !addr.Is4In6()
+}
+
+// fullName returns a record name within the zone, like Cloudflare does for
+// "@" and names relative to the zone
+func fullName(name, zoneName string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "" || name == "@" {
+		return zoneName
+	}
+	if name == zoneName || strings.HasSuffix(name, "."+zoneName) {
+		return name
+	}
+	return name + "." + zoneName
+}
+
+// contentFromData returns an approximation of the content Cloudflare
+// derives from the structured data of a record
+func contentFromData(recordType string, data map[string]interface{}) string {
+	field := func(key string) string {
+		return fmt.Sprint(data[key])
+	}
+	switch recordType {
+	case "SRV":
+		return fmt.Sprintf("%s %s %s", field("weight"), field("port"), field("target"))
+	case "CAA":
+		return fmt.Sprintf("%s %s \"%s\"", field("flags"), field("tag"), field("value"))
+	case "URI":
+		return fmt.Sprintf("%s \"%s\"", field("weight"), field("target"))
+	}
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = field(k)
+	}
+	return strings.Join(values, " ")
+}
+
+// copyRecord returns a copy of a record that shares no slices or maps
+func copyRecord(r cloudflare.DNSRecord) cloudflare.DNSRecord {
+	r.Tags = slices.Clone(r.Tags)
+	if r.Data != nil {
+		data := make(map[string]interface{}, len(r.Data))
+		for k, v := range r.Data {
+			data[k] = v
+		}
+		r.Data = data
+	}
+	if r.Priority != nil {
+		priority := *r.Priority
+		r.Priority = &priority
+	}
+	return r
+}
+
+// newID returns a random identifier like those of Cloudflare objects
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,62 @@
+package cloudflare
+
+import (
+	"errors"
+	"fmt"
+
+	"cf-dns-bot/internal/domain"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// errorCodes maps the codes of Cloudflare's error envelope to domain errors
+var errorCodes = map[int]error{
+	6003:  domain.ErrCloudflareAuth,  // Invalid request headers
+	6111:  domain.ErrCloudflareAuth,  // Invalid format for Authorization header
+	9103:  domain.ErrCloudflareAuth,  // Unknown X-Auth-Key or X-Auth-Email
+	9106:  domain.ErrCloudflareAuth,  // Missing X-Auth-Key, X-Auth-Email or Authorization headers
+	9109:  domain.ErrCloudflareAuth,  // Invalid access token
+	10000: domain.ErrCloudflareAuth,  // Authentication error
+	7003:  domain.ErrZoneNotFound,    // Could not route to /zones/<id>
+	81044: domain.ErrRecordNotFound,  // Record does not exist
+	81057: domain.ErrDuplicateRecord, // Record already exists
+	81058: domain.ErrDuplicateRecord, // A record with the same settings already exists
+	81053: domain.ErrRecordConflict,  // An A, AAAA, or CNAME record with that host already exists
+	81054: domain.ErrRecordConflict,  // A CNAME record with that host already exists
+	1004:  domain.ErrInvalidRecord,   // DNS Validation Error
+	971:   domain.ErrRateLimited,     // Please wait and consider throttling your request speed
+}
+
+// domainError wraps an error of the Cloudflare API with the domain error
+// it stands for, keeping Cloudflare's message. Codes 9000-9999 are record
+// validation failures. notFound is used for 404 responses without a known
+// code; errors that are not from the API are returned as they are.
+func domainError(err error, notFound error) error {
+	var apiErr interface{ ErrorCodes() []int }
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	for _, code := range apiErr.ErrorCodes() {
+		if target, ok := errorCodes[code]; ok {
+			return fmt.Errorf("%w: %w", target, err)
+		}
+		if code >= 9000 && code < 10000 {
+			return fmt.Errorf("%w: %w", domain.ErrInvalidRecord, err)
+		}
+	}
+
+	var authenticationErr *cloudflare.AuthenticationError
+	var authorizationErr *cloudflare.AuthorizationError
+	var rateLimitErr *cloudflare.RatelimitError
+	var notFoundErr *cloudflare.NotFoundError
+	switch {
+	case errors.As(err, &authenticationErr), errors.As(err, &authorizationErr):
+		return fmt.Errorf("%w: %w", domain.ErrCloudflareAuth, err)
+	case errors.As(err, &rateLimitErr):
+		return fmt.Errorf("%w: %w", domain.ErrRateLimited, err)
+	case errors.As(err, &notFoundErr) && notFound != nil:
+		return fmt.Errorf("%w: %w", notFound, err)
+	}
+	return err
+}
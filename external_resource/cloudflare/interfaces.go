@@ -19,8 +19,9 @@ type Client interface {
 
 // Zone represents a Cloudflare zone (domain)
 type Zone struct {
-	ID   string
-	Name string
+	ID      string
+	Name    string
+	Account string // name of the account, set by NewMultiAccountClient
 }
 
 // DNSRecord represents a DNS record from Cloudflare
@@ -34,12 +35,29 @@ type DNSRecord struct {
 	TTL      int
 	Proxied  bool
 	Priority *uint16
+	Data     map[string]interface{} // structured data of SRV, CAA, TLSA, ... records
+	Comment  string
+	Tags     []string
 }
 
-// DNSRecordFilter represents filters for listing DNS records
+// DNSRecordFilter represents filters for listing DNS records.
+// cloudflare-go has no parameters for the name substring filters, so the
+// client applies them to the listed records.
 type DNSRecordFilter struct {
-	Name string
-	Type string
+	Name           string
+	Type           string
+	Content        string
+	NameContains   string
+	NameStartsWith string
+	NameEndsWith   string
+	Proxied        *bool
+	Comment        string
+	Tag            string // tag name, or name:value
+	MatchAny       bool
+	Order          string // type, name, content, ttl or proxied
+	Descending     bool
+	Page           int // 1-based page; 0 returns every record
+	PerPage        int
 }
 
 // CreateDNSRecordInput represents input for creating a DNS record
@@ -50,14 +68,22 @@ type CreateDNSRecordInput struct {
 	TTL      int
 	Proxied  bool
 	Priority *uint16
+	Data     map[string]interface{} // structured data of SRV, CAA, TLSA, ... records
+	Comment  string
+	Tags     []string
 }
 
-// UpdateDNSRecordInput represents input for updating a DNS record
+// UpdateDNSRecordInput represents input for updating a DNS record. Only
+// the fields that are set are changed. cloudflare-go always sends the tags,
+// so a nil Tags removes them; pass the current tags to keep them.
 type UpdateDNSRecordInput struct {
-	Name     string
-	Type     string
-	Content  string
-	TTL      int
-	Proxied  bool
+	Name     *string
+	Type     *string
+	Content  *string
+	TTL      *int
+	Proxied  *bool
 	Priority *uint16
+	Data     map[string]interface{} // structured data of SRV, CAA, TLSA, ... records
+	Comment  *string
+	Tags     []string
 }
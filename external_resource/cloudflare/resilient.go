@@ -0,0 +1,296 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"cf-dns-bot/internal/domain"
+
+	"golang.org/x/time/rate"
+)
+
+// ResilienceConfig controls how NewResilientClient paces and retries calls
+type ResilienceConfig struct {
+	RequestsPerWindow int           // requests Cloudflare allows per window
+	Window            time.Duration // length of Cloudflare's rate limit window
+	Burst             int           // requests that may be sent at once
+	MaxConcurrent     int           // requests in flight at the same time
+	MaxRetries        int           // retries after the first attempt
+	BaseDelay         time.Duration // backoff before the first retry
+	MaxDelay          time.Duration // longest backoff between retries
+	CallTimeout       time.Duration // timeout of a single attempt
+}
+
+// DefaultResilienceConfig returns settings sized to Cloudflare's limit of
+// 1200 requests per 5 minutes
+func DefaultResilienceConfig() ResilienceConfig {
+	return ResilienceConfig{
+		RequestsPerWindow: 1200,
+		Window:            5 * time.Minute,
+		Burst:             100,
+		MaxConcurrent:     4,
+		MaxRetries:        4,
+		BaseDelay:         time.Second,
+		MaxDelay:          time.Minute,
+		CallTimeout:       30 * time.Second,
+	}
+}
+
+// resilientClient wraps a Client with a rate limiter, a cap on concurrent
+// requests and retries with jittered backoff.
+// Reads are retried on rate limiting (429), server errors (5xx) and
+// network errors. Writes are only retried on 429, since Cloudflare may have
+// applied a write that failed with a server error.
+type resilientClient struct {
+	next    Client
+	config  ResilienceConfig
+	limiter *rate.Limiter
+	slots   chan struct{}
+
+	mu          sync.Mutex
+	pausedUntil time.Time // set from Retry-After, holds back every call
+}
+
+// NewResilientClient wraps a Client created by NewClient or
+// NewClientWithKey. All calls share one rate limiter, so one wrapped client
+// should be used per API token.
+func NewResilientClient(next Client, config ResilienceConfig) Client {
+	// The bucket refills so that a full burst plus the refill stays
+	// within the window's limit
+	perSecond := float64(config.RequestsPerWindow-config.Burst) / config.Window.Seconds()
+	return &resilientClient{
+		next:    next,
+		config:  config,
+		limiter: rate.NewLimiter(rate.Limit(perSecond), config.Burst),
+		slots:   make(chan struct{}, config.MaxConcurrent),
+	}
+}
+
+// ListZones returns all zones accessible by the client
+func (c *resilientClient) ListZones(ctx context.Context) ([]Zone, error) {
+	return call(ctx, c, false, func(ctx context.Context) ([]Zone, error) {
+		return c.next.ListZones(ctx)
+	})
+}
+
+// GetZoneByName returns a zone by its name
+func (c *resilientClient) GetZoneByName(ctx context.Context, name string) (*Zone, error) {
+	return call(ctx, c, false, func(ctx context.Context) (*Zone, error) {
+		return c.next.GetZoneByName(ctx, name)
+	})
+}
+
+// GetZone returns a zone by its ID
+func (c *resilientClient) GetZone(ctx context.Context, zoneID string) (*Zone, error) {
+	return call(ctx, c, false, func(ctx context.Context) (*Zone, error) {
+		return c.next.GetZone(ctx, zoneID)
+	})
+}
+
+// ListDNSRecords returns the DNS records of a zone matching the filter
+func (c *resilientClient) ListDNSRecords(ctx context.Context, zoneID string, filter DNSRecordFilter) ([]DNSRecord, error) {
+	return call(ctx, c, false, func(ctx context.Context) ([]DNSRecord, error) {
+		return c.next.ListDNSRecords(ctx, zoneID, filter)
+	})
+}
+
+// GetDNSRecord returns a specific DNS record
+func (c *resilientClient) GetDNSRecord(ctx context.Context, zoneID, recordID string) (*DNSRecord, error) {
+	return call(ctx, c, false, func(ctx context.Context) (*DNSRecord, error) {
+		return c.next.GetDNSRecord(ctx, zoneID, recordID)
+	})
+}
+
+// CreateDNSRecord creates a new DNS record
+func (c *resilientClient) CreateDNSRecord(ctx context.Context, zoneID string, input CreateDNSRecordInput) (*DNSRecord, error) {
+	return call(ctx, c, true, func(ctx context.Context) (*DNSRecord, error) {
+		return c.next.CreateDNSRecord(ctx, zoneID, input)
+	})
+}
+
+// UpdateDNSRecord updates an existing DNS record
+func (c *resilientClient) UpdateDNSRecord(ctx context.Context, zoneID, recordID string, input UpdateDNSRecordInput) (*DNSRecord, error) {
+	return call(ctx, c, true, func(ctx context.Context) (*DNSRecord, error) {
+		return c.next.UpdateDNSRecord(ctx, zoneID, recordID, input)
+	})
+}
+
+// DeleteDNSRecord deletes a DNS record
+func (c *resilientClient) DeleteDNSRecord(ctx context.Context, zoneID, recordID string) error {
+	_, err := call(ctx, c, true, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.next.DeleteDNSRecord(ctx, zoneID, recordID)
+	})
+	return err
+}
+
+// call runs fn with rate limiting, the concurrency cap and retries. Each
+// attempt gets its own timeout within the caller's context. The rate limit
+// token is taken by hintTransport for every HTTP request, so each page of
+// a paginated list is paced on its own.
+func call[T any](ctx context.Context, c *resilientClient, write bool, fn func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	for attempt := 0; ; attempt++ {
+		if err := c.acquire(ctx); err != nil {
+			return zero, err
+		}
+
+		hint := &responseHint{limiter: c.limiter}
+		attemptCtx, cancel := context.WithTimeout(context.WithValue(ctx, responseHintKey{}, hint), c.config.CallTimeout)
+		result, err := fn(attemptCtx)
+		cancel()
+		<-c.slots
+
+		if err == nil {
+			return result, nil
+		}
+		if attempt >= c.config.MaxRetries || ctx.Err() != nil || !hint.retryable(err, write) {
+			return zero, rateLimitError(err, hint.status)
+		}
+
+		delay := c.backoff(attempt)
+		if hint.status == http.StatusTooManyRequests {
+			if hint.retryAfter > delay {
+				delay = hint.retryAfter
+			}
+			c.pause(delay)
+		}
+		log.Printf("[CloudflareClient] attempt %d failed (HTTP %d), retrying in %s: %v", attempt+1, hint.status, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, rateLimitError(err, hint.status)
+		case <-timer.C:
+		}
+	}
+}
+
+// rateLimitError marks an error of a rate limited attempt as
+// domain.ErrRateLimited. cloudflare-go reports a 429 without its error
+// envelope, so only the status tells.
+func rateLimitError(err error, status int) error {
+	if status != http.StatusTooManyRequests || errors.Is(err, domain.ErrRateLimited) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", domain.ErrRateLimited, err)
+}
+
+// acquire waits for a pause to end and a free slot
+func (c *resilientClient) acquire(ctx context.Context) error {
+	c.mu.Lock()
+	wait := time.Until(c.pausedUntil)
+	c.mu.Unlock()
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pause holds back every call for the given duration after a 429
+func (c *resilientClient) pause(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if until := time.Now().Add(d); until.After(c.pausedUntil) {
+		c.pausedUntil = until
+	}
+}
+
+// backoff returns the exponential delay before a retry with full jitter
+func (c *resilientClient) backoff(attempt int) time.Duration {
+	delay := c.config.BaseDelay << attempt
+	if delay <= 0 || delay > c.config.MaxDelay {
+		delay = c.config.MaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// responseHint carries the rate limiter of the resilient client to
+// hintTransport, and the status and Retry-After of the last HTTP response
+// of an attempt back to the resilient client
+type responseHint struct {
+	limiter *rate.Limiter
+
+	mu         sync.Mutex
+	status     int
+	retryAfter time.Duration
+}
+
+// responseHintKey is the context key of a *responseHint
+type responseHintKey struct{}
+
+// record stores the status and Retry-After header of a response
+func (h *responseHint) record(resp *http.Response) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.status = resp.StatusCode
+	h.retryAfter = 0
+	value := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil {
+		h.retryAfter = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		h.retryAfter = time.Until(at)
+	}
+}
+
+// retryable reports whether a failed attempt may be retried
+func (h *responseHint) retryable(err error, write bool) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case h.status == http.StatusTooManyRequests:
+		return true
+	case write:
+		return false
+	case h.status >= 500:
+		return true
+	case h.status == 0:
+		// No response: a network error or the attempt timed out
+		var netErr interface{ Timeout() bool }
+		return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+	}
+	return false
+}
+
+// hintTransport takes a rate limit token for every request and reports
+// every response to the responseHint of the request's context
+type hintTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t hintTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	hint, ok := req.Context().Value(responseHintKey{}).(*responseHint)
+	if ok && hint.limiter != nil {
+		if err := hint.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if ok && resp != nil {
+		hint.record(resp)
+	}
+	return resp, err
+}
@@ -0,0 +1,150 @@
+package cloudflare_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cf-dns-bot/external_resource/cloudflare"
+	"cf-dns-bot/external_resource/cloudflare/cloudflaretest"
+	"cf-dns-bot/internal/domain"
+)
+
+// testConfig returns settings with short delays and no pacing to speak of
+func testConfig() cloudflare.ResilienceConfig {
+	return cloudflare.ResilienceConfig{
+		RequestsPerWindow: 1000,
+		Window:            time.Second,
+		Burst:             100,
+		MaxConcurrent:     4,
+		MaxRetries:        2,
+		BaseDelay:         10 * time.Millisecond,
+		MaxDelay:          50 * time.Millisecond,
+		CallTimeout:       5 * time.Second,
+	}
+}
+
+// newResilientClient returns a resilient client talking to a fake
+// Cloudflare API that serves the store
+func newResilientClient(t *testing.T, store *cloudflaretest.Store, config cloudflare.ResilienceConfig) (cloudflare.Client, *cloudflaretest.Server) {
+	t.Helper()
+	server := cloudflaretest.NewServer(store)
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	client, err := cloudflare.NewClient("test-token", cloudflare.WithBaseURL(ts.URL+"/client/v4"))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return cloudflare.NewResilientClient(client, config), server
+}
+
+func TestResilientClientRetriesRateLimit(t *testing.T) {
+	client, server := newResilientClient(t, cloudflaretest.NewDemoStore(), testConfig())
+	server.FailNext(http.StatusTooManyRequests, 1)
+
+	zones, err := client.ListZones(context.Background())
+	if err != nil {
+		t.Fatalf("ListZones: %v", err)
+	}
+	if len(zones) != 2 {
+		t.Errorf("got %d zones, want 2", len(zones))
+	}
+	if got := server.Requests(); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+}
+
+func TestResilientClientRateLimitedAfterRetries(t *testing.T) {
+	config := testConfig()
+	config.MaxRetries = 0
+	client, server := newResilientClient(t, cloudflaretest.NewDemoStore(), config)
+	server.FailNext(http.StatusTooManyRequests, 1)
+
+	_, err := client.ListZones(context.Background())
+	if !errors.Is(err, domain.ErrRateLimited) {
+		t.Fatalf("got error %v, want ErrRateLimited", err)
+	}
+	if got := server.Requests(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestResilientClientRateLimitedWhenCancelled(t *testing.T) {
+	client, server := newResilientClient(t, cloudflaretest.NewDemoStore(), testConfig())
+	server.FailNext(http.StatusTooManyRequests, 3)
+
+	// The fake asks to retry after a second, which outlasts the context
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err := client.ListZones(ctx)
+	if !errors.Is(err, domain.ErrRateLimited) {
+		t.Fatalf("got error %v, want ErrRateLimited", err)
+	}
+}
+
+func TestResilientClientRetriesOnlyReadsOnServerErrors(t *testing.T) {
+	store := cloudflaretest.NewStore()
+	zone := store.AddZone("example.com")
+	client, server := newResilientClient(t, store, testConfig())
+
+	server.FailNext(http.StatusBadGateway, 1)
+	if _, err := client.ListZones(context.Background()); err != nil {
+		t.Fatalf("ListZones: %v", err)
+	}
+	if got := server.Requests(); got != 2 {
+		t.Errorf("read: got %d requests, want 2", got)
+	}
+
+	// Cloudflare may have made a write that failed with a server error
+	server.FailNext(http.StatusBadGateway, 1)
+	_, err := client.CreateDNSRecord(context.Background(), zone.ID, cloudflare.CreateDNSRecordInput{
+		Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 1,
+	})
+	if err == nil {
+		t.Fatal("CreateDNSRecord succeeded, want the server error")
+	}
+	if got := server.Requests(); got != 3 {
+		t.Errorf("write: got %d requests, want 3", got)
+	}
+	if got := len(store.Records(zone.ID)); got != 0 {
+		t.Errorf("got %d records, want 0", got)
+	}
+}
+
+func TestResilientClientPacesEveryPage(t *testing.T) {
+	store := cloudflaretest.NewStore()
+	zone := store.AddZone("example.com")
+	for i := 0; i < 250; i++ {
+		store.AddRecord(zone.ID, cloudflare.DNSRecord{Name: fmt.Sprintf("host%d.example.com", i), Type: "A", Content: "192.0.2.1", TTL: 1})
+	}
+
+	// One request at once, then one every 100ms
+	config := testConfig()
+	config.Burst = 1
+	config.RequestsPerWindow = 11
+	client, server := newResilientClient(t, store, config)
+
+	start := time.Now()
+	records, err := client.ListDNSRecords(context.Background(), zone.ID, cloudflare.DNSRecordFilter{})
+	if err != nil {
+		t.Fatalf("ListDNSRecords: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if len(records) != 250 {
+		t.Errorf("got %d records, want 250", len(records))
+	}
+	pages := server.Requests()
+	if pages < 2 {
+		t.Fatalf("got %d requests, want several pages", pages)
+	}
+	if want := time.Duration(pages-1) * 80 * time.Millisecond; elapsed < want {
+		t.Errorf("%d pages took %s, want at least %s", pages, elapsed, want)
+	}
+}
@@ -6,7 +6,9 @@ require (
 	github.com/cloudflare/cloudflare-go v0.86.0
 	github.com/joho/godotenv v1.5.1
 	github.com/mark3labs/mcp-go v0.5.0
+	golang.org/x/time v0.5.0
 	gopkg.in/telebot.v3 v3.3.8
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -17,5 +19,4 @@ require (
 	github.com/hashicorp/go-retryablehttp v0.7.5 // indirect
 	golang.org/x/net v0.20.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
-	golang.org/x/time v0.5.0 // indirect
 )
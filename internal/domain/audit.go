@@ -0,0 +1,65 @@
+package domain
+
+import "time"
+
+// Audit actions
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// Channels through which DNS changes are made
+const (
+	ChannelTelegram = "telegram"
+	ChannelMCPHTTP  = "mcp-http"
+	ChannelMCPStdio = "mcp-stdio"
+	ChannelREST     = "rest"
+	ChannelCLI      = "cli"
+	ChannelDynDNS   = "dyndns"
+)
+
+// Actor identifies who made a change and through which channel
+type Actor struct {
+	ID      string // Telegram user ID, API key name, ...
+	Name    string // human readable name
+	Channel string
+}
+
+// String returns the display name of the actor
+func (a Actor) String() string {
+	if a.Name == "" {
+		return a.ID
+	}
+	if a.ID == "" || a.ID == a.Name {
+		return a.Name
+	}
+	return a.Name + " (" + a.ID + ")"
+}
+
+// AuditEntry records a single change to a DNS record.
+// Before is nil for creates and After is nil for deletes.
+type AuditEntry struct {
+	ID        string     `json:"id"`
+	Timestamp time.Time  `json:"timestamp"`
+	Actor     string     `json:"actor"`
+	ActorID   string     `json:"actor_id,omitempty"`
+	Channel   string     `json:"channel"`
+	Action    string     `json:"action"`
+	ZoneName  string     `json:"zone_name"`
+	Before    *DNSRecord `json:"before,omitempty"`
+	After     *DNSRecord `json:"after,omitempty"`
+	// ApprovedBy is the second user who approved a change held for approval
+	ApprovedBy string `json:"approved_by,omitempty"`
+}
+
+// AuditFilter represents filters for querying the audit log.
+// Zero values match everything; Actor matches the actor name or ID.
+type AuditFilter struct {
+	ZoneName string
+	Actor    string
+	Since    time.Time
+	Until    time.Time
+	Offset   int
+	Limit    int
+}
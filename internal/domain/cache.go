@@ -0,0 +1,12 @@
+package domain
+
+// CacheStats reports how often cached Cloudflare data was used instead of
+// calling the API
+type CacheStats struct {
+	ZoneHits     uint64
+	ZoneMisses   uint64
+	RecordHits   uint64
+	RecordMisses uint64
+	Zones        int // zones currently cached
+	RecordLists  int // record lists currently cached
+}
@@ -8,22 +8,76 @@ type DNSRecord struct {
 	ZoneID   string
 	ZoneName string
 	Name     string
-	Type     string // A, AAAA, CNAME, MX, TXT, NS, SRV, CAA
+	Type     string // one of RecordTypes
 	Content  string
 	TTL      int
 	Proxied  bool
-	Priority *uint16 // for MX, SRV records
+	Priority *uint16     // for MX, SRV, URI records
+	Data     *RecordData // structured data for SRV, CAA, TLSA, SSHFP, URI, LOC
+	Comment  string
+	Tags     []string // name:value pairs, or plain names
 	Created  time.Time
 	Modified time.Time
 }
 
-// RecordFilter represents filters for listing DNS records
+// RecordPatch lists the fields of a record to change. Nil fields are left
+// as they are; Data is only applied together with Type.
+type RecordPatch struct {
+	Name     *string
+	Type     *string
+	Content  *string
+	TTL      *int
+	Proxied  *bool
+	Priority *uint16
+	Data     *RecordData
+	Comment  *string
+	Tags     []string // nil keeps the tags, an empty list removes them
+}
+
+// FullPatch returns a patch that sets every field of a record, such as
+// when a record is restored to an earlier state
+func FullPatch(r DNSRecord) RecordPatch {
+	tags := r.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return RecordPatch{
+		Name:     &r.Name,
+		Type:     &r.Type,
+		Content:  &r.Content,
+		TTL:      &r.TTL,
+		Proxied:  &r.Proxied,
+		Priority: r.Priority,
+		Data:     r.Data,
+		Comment:  &r.Comment,
+		Tags:     tags,
+	}
+}
+
+// RecordFilter represents filters for listing DNS records. Empty fields
+// match every record. A record has to match all set fields, or any of
+// them with MatchAny; the name substring filters always have to match.
 type RecordFilter struct {
-	Name string
-	Type string
+	Name           string
+	Type           string
+	Content        string
+	NameContains   string
+	NameStartsWith string
+	NameEndsWith   string
+	Proxied        *bool
+	Comment        string
+	Tag            string // tag name, or name:value
+	MatchAny       bool
+	Order          string // one of RecordOrders, Cloudflare's order by default
+	Descending     bool
+	Page           int // 1-based page; 0 returns every record
+	PerPage        int // records per page, Cloudflare's default when 0
 }
 
-// RecordTypes contains all supported DNS record types for Cloudflare free tier
+// RecordOrders contains the fields records can be ordered by
+var RecordOrders = []string{"type", "name", "content", "ttl", "proxied"}
+
+// RecordTypes contains all supported DNS record types
 var RecordTypes = []string{
 	"A",
 	"AAAA",
@@ -33,6 +87,17 @@ var RecordTypes = []string{
 	"NS",
 	"SRV",
 	"CAA",
+	"TLSA",
+	"SSHFP",
+	"URI",
+	"LOC",
+	"HTTPS",
+	"SVCB",
+	"PTR",
+	"NAPTR",
+	"DS",
+	"CERT",
+	"SMIMEA",
 }
 
 // IsValidRecordType checks if the given type is a valid DNS record type
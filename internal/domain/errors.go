@@ -10,4 +10,17 @@ var (
 	ErrInvalidZone    = errors.New("invalid zone")
 	ErrDuplicateRecord = errors.New("dns record already exists")
 	ErrUnauthorized   = errors.New("unauthorized")
+	ErrAmbiguousRecord = errors.New("multiple dns records match")
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	ErrPlanNotFound   = errors.New("sync plan not found or expired")
+	ErrStalePlan      = errors.New("zone changed since the plan was made")
+	ErrChangeNotFound = errors.New("change not found in audit log")
+	ErrRollbackConflict = errors.New("record changed since, cannot undo")
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+	ErrInvalidFilter  = errors.New("invalid record filter")
+	ErrRecordConflict = errors.New("dns record conflicts with a cname record")
+	ErrRateLimited    = errors.New("cloudflare rate limit exceeded")
+	ErrCloudflareAuth = errors.New("cloudflare rejected the api credentials")
+	ErrPendingChangeNotFound = errors.New("pending change not found or expired")
+	ErrSelfApproval   = errors.New("changes must be approved by another user")
 )
@@ -0,0 +1,627 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// RecordData holds the structured fields Cloudflare requires for compound
+// record types. Only the fields of the record's type are used; the JSON
+// names match Cloudflare's data object.
+type RecordData struct {
+	// SRV: the service and protocol form the record name
+	// (_service._proto.name); weight and target are also used by URI,
+	// target by HTTPS and SVCB, and service by NAPTR
+	Service string `json:"service,omitempty"`
+	Proto   string `json:"proto,omitempty"`
+	Weight  uint16 `json:"weight,omitempty"`
+	Port    uint16 `json:"port,omitempty"`
+	Target  string `json:"target,omitempty"`
+
+	// CAA; flags are also used by NAPTR and value by HTTPS and SVCB
+	Flags Flags  `json:"flags,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+	Value string `json:"value,omitempty"`
+
+	// HTTPS and SVCB: the SvcPriority, 0 for alias mode. Unlike MX, SRV
+	// and URI it is part of the data, not the record priority.
+	Priority uint16 `json:"priority,omitempty"`
+
+	// TLSA and SMIMEA; the certificate is also used by CERT
+	Usage        uint8  `json:"usage,omitempty"`
+	Selector     uint8  `json:"selector,omitempty"`
+	MatchingType uint8  `json:"matching_type,omitempty"`
+	Certificate  string `json:"certificate,omitempty"`
+
+	// SSHFP; the algorithm is also used by DS and CERT, and the type
+	// (fingerprint type for SSHFP) by CERT as the certificate type
+	Algorithm   uint8  `json:"algorithm,omitempty"`
+	Type        uint16 `json:"type,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// DS; the key tag is also used by CERT
+	KeyTag     uint16 `json:"key_tag,omitempty"`
+	DigestType uint8  `json:"digest_type,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+
+	// NAPTR
+	Order       uint16 `json:"order,omitempty"`
+	Preference  uint16 `json:"preference,omitempty"`
+	Regex       string `json:"regex,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+
+	// LOC
+	LatDegrees    int     `json:"lat_degrees,omitempty"`
+	LatMinutes    int     `json:"lat_minutes,omitempty"`
+	LatSeconds    float64 `json:"lat_seconds,omitempty"`
+	LatDirection  string  `json:"lat_direction,omitempty"`
+	LongDegrees   int     `json:"long_degrees,omitempty"`
+	LongMinutes   int     `json:"long_minutes,omitempty"`
+	LongSeconds   float64 `json:"long_seconds,omitempty"`
+	LongDirection string  `json:"long_direction,omitempty"`
+	Altitude      float64 `json:"altitude,omitempty"`
+	Size          float64 `json:"size,omitempty"`
+	PrecisionHorz float64 `json:"precision_horz,omitempty"`
+	PrecisionVert float64 `json:"precision_vert,omitempty"`
+}
+
+// Flags holds CAA flags, a number, or NAPTR flags, a string such as "S".
+// Both JSON forms are accepted; numeric flags are written as a number.
+type Flags string
+
+// MarshalJSON implements json.Marshaler
+func (f Flags) MarshalJSON() ([]byte, error) {
+	if _, err := strconv.ParseUint(string(f), 10, 8); err == nil {
+		return []byte(f), nil
+	}
+	return json.Marshal(string(f))
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (f *Flags) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*f = Flags(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*f = Flags(n)
+	return nil
+}
+
+// structuredTypes are the record types Cloudflare creates from a data
+// object instead of content
+var structuredTypes = map[string]bool{
+	"SRV":    true,
+	"CAA":    true,
+	"TLSA":   true,
+	"SSHFP":  true,
+	"URI":    true,
+	"LOC":    true,
+	"HTTPS":  true,
+	"SVCB":   true,
+	"NAPTR":  true,
+	"DS":     true,
+	"CERT":   true,
+	"SMIMEA": true,
+}
+
+// HasStructuredData returns true if records of the type carry RecordData
+func HasStructuredData(recordType string) bool {
+	return structuredTypes[recordType]
+}
+
+// CompleteRecordData makes a record's content and data agree. Data parsed
+// from the content is added when missing; when data is given, the content
+// is rendered from it. SRV service and protocol are prefixed to the name.
+func CompleteRecordData(r *DNSRecord) error {
+	if !HasStructuredData(r.Type) {
+		r.Data = nil
+		return nil
+	}
+
+	if r.Data == nil {
+		data, priority, err := ParseRecordData(r.Type, r.Content)
+		if err != nil {
+			return err
+		}
+		r.Data = data
+		if priority != nil {
+			r.Priority = priority
+		}
+	}
+
+	if r.Type == "SRV" && r.Data.Service != "" && r.Data.Proto != "" && !strings.HasPrefix(r.Name, "_") {
+		prefix := underscored(r.Data.Service) + "." + underscored(r.Data.Proto)
+		if r.Name == "" || r.Name == "@" {
+			r.Name = prefix
+		} else {
+			r.Name = prefix + "." + r.Name
+		}
+	}
+
+	r.Content = r.Data.Format(r.Type)
+	return nil
+}
+
+// ParseRecordData parses the content of a compound record. SRV and URI
+// content may start with the priority, which is returned separately
+// because Cloudflare keeps it outside the data object.
+func ParseRecordData(recordType, content string) (*RecordData, *uint16, error) {
+	fields := strings.Fields(content)
+
+	switch recordType {
+	case "SRV":
+		var priority *uint16
+		if len(fields) == 4 {
+			p, err := parseUint(fields[0], 16, "SRV priority")
+			if err != nil {
+				return nil, nil, err
+			}
+			priority = uint16Ptr(p)
+			fields = fields[1:]
+		}
+		if len(fields) != 3 {
+			return nil, nil, invalid("content", "SRV content must be `[priority] weight port target`, e.g. `10 5 5060 sip.example.com`")
+		}
+		weight, err := parseUint(fields[0], 16, "SRV weight")
+		if err != nil {
+			return nil, nil, err
+		}
+		port, err := parseUint(fields[1], 16, "SRV port")
+		if err != nil {
+			return nil, nil, err
+		}
+		target := fields[2]
+		if target != "." && !IsValidHostname(target) {
+			return nil, nil, invalid("content", "SRV target %q is not a valid hostname", target)
+		}
+		return &RecordData{Weight: uint16(weight), Port: uint16(port), Target: target}, priority, nil
+
+	case "CAA":
+		return parseCAA(content)
+
+	case "TLSA", "SMIMEA":
+		if len(fields) < 4 {
+			return nil, nil, invalid("content", "%s content must be `usage selector matching_type certificate`, e.g. `3 1 1 0123…`", recordType)
+		}
+		usage, err := parseRange(fields[0], 3, recordType+" usage")
+		if err != nil {
+			return nil, nil, err
+		}
+		selector, err := parseRange(fields[1], 1, recordType+" selector")
+		if err != nil {
+			return nil, nil, err
+		}
+		matchingType, err := parseRange(fields[2], 2, recordType+" matching type")
+		if err != nil {
+			return nil, nil, err
+		}
+		certificate, err := parseHex(strings.Join(fields[3:], ""), recordType+" certificate")
+		if err != nil {
+			return nil, nil, err
+		}
+		return &RecordData{Usage: usage, Selector: selector, MatchingType: matchingType, Certificate: certificate}, nil, nil
+
+	case "SSHFP":
+		if len(fields) < 3 {
+			return nil, nil, invalid("content", "SSHFP content must be `algorithm type fingerprint`, e.g. `4 2 0123…`")
+		}
+		algorithm, err := parseRange(fields[0], 255, "SSHFP algorithm")
+		if err != nil {
+			return nil, nil, err
+		}
+		fingerprintType, err := parseRange(fields[1], 255, "SSHFP type")
+		if err != nil {
+			return nil, nil, err
+		}
+		fingerprint, err := parseHex(strings.Join(fields[2:], ""), "SSHFP fingerprint")
+		if err != nil {
+			return nil, nil, err
+		}
+		return &RecordData{Algorithm: algorithm, Type: uint16(fingerprintType), Fingerprint: fingerprint}, nil, nil
+
+	case "URI":
+		var priority *uint16
+		if len(fields) == 3 {
+			p, err := parseUint(fields[0], 16, "URI priority")
+			if err != nil {
+				return nil, nil, err
+			}
+			priority = uint16Ptr(p)
+			fields = fields[1:]
+		}
+		if len(fields) != 2 {
+			return nil, nil, invalid("content", "URI content must be `[priority] weight \"target\"`, e.g. `10 1 \"https://example.com/\"`")
+		}
+		weight, err := parseUint(fields[0], 16, "URI weight")
+		if err != nil {
+			return nil, nil, err
+		}
+		target := strings.Trim(fields[1], "\"")
+		if target == "" {
+			return nil, nil, invalid("content", "URI target is required")
+		}
+		return &RecordData{Weight: uint16(weight), Target: target}, priority, nil
+
+	case "LOC":
+		data, err := parseLOC(fields)
+		return data, nil, err
+
+	case "HTTPS", "SVCB":
+		data, err := parseSVCB(recordType, fields)
+		return data, nil, err
+
+	case "NAPTR":
+		data, err := parseNAPTR(content)
+		return data, nil, err
+
+	case "DS":
+		if len(fields) < 4 {
+			return nil, nil, invalid("content", "DS content must be `key_tag algorithm digest_type digest`, e.g. `2371 13 2 0123…`")
+		}
+		keyTag, err := parseUint(fields[0], 16, "DS key tag")
+		if err != nil {
+			return nil, nil, err
+		}
+		algorithm, err := parseRange(fields[1], 255, "DS algorithm")
+		if err != nil {
+			return nil, nil, err
+		}
+		digestType, err := parseRange(fields[2], 255, "DS digest type")
+		if err != nil {
+			return nil, nil, err
+		}
+		digest, err := parseHex(strings.Join(fields[3:], ""), "DS digest")
+		if err != nil {
+			return nil, nil, err
+		}
+		return &RecordData{KeyTag: uint16(keyTag), Algorithm: algorithm, DigestType: digestType, Digest: digest}, nil, nil
+
+	case "CERT":
+		if len(fields) < 4 {
+			return nil, nil, invalid("content", "CERT content must be `type key_tag algorithm certificate`, e.g. `1 0 0 MIIB…`")
+		}
+		certType, err := parseUint(fields[0], 16, "CERT type")
+		if err != nil {
+			return nil, nil, err
+		}
+		keyTag, err := parseUint(fields[1], 16, "CERT key tag")
+		if err != nil {
+			return nil, nil, err
+		}
+		algorithm, err := parseRange(fields[2], 255, "CERT algorithm")
+		if err != nil {
+			return nil, nil, err
+		}
+		certificate := strings.Join(fields[3:], "")
+		if _, err := base64.StdEncoding.DecodeString(certificate); err != nil {
+			return nil, nil, invalid("content", "CERT certificate must be base64")
+		}
+		return &RecordData{Type: uint16(certType), KeyTag: uint16(keyTag), Algorithm: algorithm, Certificate: certificate}, nil, nil
+	}
+
+	return nil, nil, invalid("type", "%s records have no structured data", recordType)
+}
+
+// Format renders the data as record content in the form Cloudflare uses
+func (d *RecordData) Format(recordType string) string {
+	switch recordType {
+	case "SRV":
+		return fmt.Sprintf("%d %d %s", d.Weight, d.Port, d.Target)
+	case "CAA":
+		return fmt.Sprintf("%s %s \"%s\"", d.Flags, d.Tag, d.Value)
+	case "TLSA", "SMIMEA":
+		return fmt.Sprintf("%d %d %d %s", d.Usage, d.Selector, d.MatchingType, d.Certificate)
+	case "SSHFP":
+		return fmt.Sprintf("%d %d %s", d.Algorithm, d.Type, d.Fingerprint)
+	case "HTTPS", "SVCB":
+		return strings.TrimSpace(fmt.Sprintf("%d %s %s", d.Priority, d.Target, d.Value))
+	case "NAPTR":
+		return fmt.Sprintf("%d %d %q %q %q %s", d.Order, d.Preference, string(d.Flags), d.Service, d.Regex, d.Replacement)
+	case "DS":
+		return fmt.Sprintf("%d %d %d %s", d.KeyTag, d.Algorithm, d.DigestType, d.Digest)
+	case "CERT":
+		return fmt.Sprintf("%d %d %d %s", d.Type, d.KeyTag, d.Algorithm, d.Certificate)
+	case "URI":
+		return fmt.Sprintf("%d \"%s\"", d.Weight, d.Target)
+	case "LOC":
+		return fmt.Sprintf("%d %d %s %s %d %d %s %s %sm %sm %sm %sm",
+			d.LatDegrees, d.LatMinutes, formatFloat(d.LatSeconds), d.LatDirection,
+			d.LongDegrees, d.LongMinutes, formatFloat(d.LongSeconds), d.LongDirection,
+			formatFloat(d.Altitude), formatFloat(d.Size), formatFloat(d.PrecisionHorz), formatFloat(d.PrecisionVert))
+	}
+	return ""
+}
+
+// parseCAA parses CAA content in the form `flags tag "value"`
+func parseCAA(content string) (*RecordData, *uint16, error) {
+	fields := strings.SplitN(strings.TrimSpace(content), " ", 3)
+	if len(fields) != 3 {
+		return nil, nil, invalid("content", "CAA content must be `flags tag value`, e.g. `0 issue \"letsencrypt.org\"`")
+	}
+
+	flags, err := parseRange(fields[0], 255, "CAA flags")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tag := fields[1]
+	if !caaTags[tag] {
+		return nil, nil, invalid("content", "CAA tag must be issue, issuewild or iodef, got %q", tag)
+	}
+
+	value := strings.TrimSpace(fields[2])
+	if strings.HasPrefix(value, "\"") {
+		if len(value) < 2 || !strings.HasSuffix(value, "\"") {
+			return nil, nil, invalid("content", "CAA value has unbalanced quotes")
+		}
+		value = value[1 : len(value)-1]
+	}
+	if tag == "iodef" && !strings.HasPrefix(value, "mailto:") && !strings.HasPrefix(value, "https://") && !strings.HasPrefix(value, "http://") {
+		return nil, nil, invalid("content", "CAA iodef value must be a mailto: or http(s) URL")
+	}
+
+	return &RecordData{Flags: Flags(strconv.Itoa(int(flags))), Tag: tag, Value: value}, nil, nil
+}
+
+// parseSVCB parses HTTPS and SVCB content: `priority target [key=value ...]`.
+// A priority of 0 is alias mode, which takes no parameters.
+func parseSVCB(recordType string, fields []string) (*RecordData, error) {
+	if len(fields) < 2 {
+		return nil, invalid("content", "%s content must be `priority target [params]`, e.g. `1 . alpn=\"h3,h2\"`", recordType)
+	}
+	priority, err := parseUint(fields[0], 16, recordType+" priority")
+	if err != nil {
+		return nil, err
+	}
+	target := fields[1]
+	if target != "." && !IsValidHostname(target) {
+		return nil, invalid("content", "%s target %q is not a valid hostname", recordType, target)
+	}
+
+	params := fields[2:]
+	if priority == 0 && len(params) > 0 {
+		return nil, invalid("content", "%s records with priority 0 (alias mode) take no parameters", recordType)
+	}
+	for _, param := range params {
+		if err := validateSvcParam(recordType, param); err != nil {
+			return nil, err
+		}
+	}
+
+	return &RecordData{Priority: uint16(priority), Target: target, Value: strings.Join(params, " ")}, nil
+}
+
+// validateSvcParam checks one `key=value` parameter of an HTTPS or SVCB record
+func validateSvcParam(recordType, param string) error {
+	key, value, _ := strings.Cut(param, "=")
+	value = strings.Trim(value, "\"")
+
+	switch key {
+	case "no-default-alpn":
+		if value != "" {
+			return invalid("content", "%s parameter no-default-alpn takes no value", recordType)
+		}
+		return nil
+	case "mandatory", "alpn", "ech":
+	case "port":
+		_, err := parseUint(value, 16, recordType+" port")
+		return err
+	case "ipv4hint", "ipv6hint":
+		for _, hint := range strings.Split(value, ",") {
+			addr, err := netip.ParseAddr(hint)
+			if err != nil || addr.Is4() != (key == "ipv4hint") {
+				return invalid("content", "%s %s %q is not a valid address", recordType, key, hint)
+			}
+		}
+		return nil
+	default:
+		if !strings.HasPrefix(key, "key") {
+			return invalid("content", "unknown %s parameter %q", recordType, key)
+		}
+		if _, err := strconv.ParseUint(key[len("key"):], 10, 16); err != nil {
+			return invalid("content", "unknown %s parameter %q", recordType, key)
+		}
+	}
+
+	if value == "" {
+		return invalid("content", "%s parameter %s needs a value", recordType, key)
+	}
+	return nil
+}
+
+// parseNAPTR parses NAPTR content:
+// `order preference "flags" "service" "regex" replacement`
+func parseNAPTR(content string) (*RecordData, error) {
+	usage := invalid("content", "NAPTR content must be `order preference \"flags\" \"service\" \"regex\" replacement`, e.g. `100 10 \"S\" \"SIP+D2U\" \"\" _sip._udp.example.com`")
+
+	fields, ok := splitFields(content)
+	if !ok || len(fields) != 6 {
+		return nil, usage
+	}
+	order, err := parseUint(fields[0], 16, "NAPTR order")
+	if err != nil {
+		return nil, err
+	}
+	preference, err := parseUint(fields[1], 16, "NAPTR preference")
+	if err != nil {
+		return nil, err
+	}
+	replacement := fields[5]
+	if replacement != "." && !IsValidHostname(replacement) {
+		return nil, invalid("content", "NAPTR replacement %q is not a valid hostname", replacement)
+	}
+	if fields[4] != "" && replacement != "." {
+		return nil, invalid("content", "NAPTR records take a regex or a replacement, not both; use `.` as the replacement")
+	}
+
+	return &RecordData{
+		Order:       uint16(order),
+		Preference:  uint16(preference),
+		Flags:       Flags(fields[2]),
+		Service:     fields[3],
+		Regex:       fields[4],
+		Replacement: replacement,
+	}, nil
+}
+
+// splitFields splits content into fields separated by spaces, where a
+// field may be a quoted string. Quotes are removed and escapes resolved.
+func splitFields(s string) ([]string, bool) {
+	var fields []string
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return fields, true
+		}
+		if s[0] != '"' {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			fields = append(fields, s[:end])
+			s = s[end:]
+			continue
+		}
+
+		chunks, ok := splitQuoted(s[:quotedEnd(s)])
+		if !ok || len(chunks) != 1 {
+			return nil, false
+		}
+		fields = append(fields, chunks[0])
+		s = s[quotedEnd(s):]
+	}
+}
+
+// quotedEnd returns the index after the closing quote of the quoted
+// string at the start of s, or len(s) if it is not closed
+func quotedEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// parseLOC parses LOC content as in RFC 1876:
+// d [m [s]] N|S d [m [s]] E|W alt[m] [size[m] [hp[m] [vp[m]]]]
+func parseLOC(fields []string) (*RecordData, error) {
+	usage := invalid("content", "LOC content must be `d m s N|S d m s E|W altitude [size hp vp]`, e.g. `51 30 12.748 N 0 7 39.611 W 0m`")
+
+	// RFC 1876 defaults for the optional size and precision
+	data := &RecordData{Size: 1, PrecisionHorz: 10000, PrecisionVert: 10}
+
+	rest, ok := parseCoordinate(fields, "N", "S", 90, &data.LatDegrees, &data.LatMinutes, &data.LatSeconds, &data.LatDirection)
+	if !ok {
+		return nil, usage
+	}
+	rest, ok = parseCoordinate(rest, "E", "W", 180, &data.LongDegrees, &data.LongMinutes, &data.LongSeconds, &data.LongDirection)
+	if !ok || len(rest) == 0 || len(rest) > 4 {
+		return nil, usage
+	}
+
+	values := []*float64{&data.Altitude, &data.Size, &data.PrecisionHorz, &data.PrecisionVert}
+	for i, field := range rest {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(field, "m"), 64)
+		if err != nil {
+			return nil, usage
+		}
+		*values[i] = v
+	}
+	return data, nil
+}
+
+// parseCoordinate reads degrees, optional minutes and seconds and the
+// direction from fields and returns the fields that follow
+func parseCoordinate(fields []string, positive, negative string, maxDegrees int, degrees, minutes *int, seconds *float64, direction *string) ([]string, bool) {
+	var numbers []string
+	for i, field := range fields {
+		if field == positive || field == negative {
+			if len(numbers) == 0 || len(numbers) > 3 {
+				return nil, false
+			}
+			*direction = field
+
+			d, err := strconv.Atoi(numbers[0])
+			if err != nil || d < 0 || d > maxDegrees {
+				return nil, false
+			}
+			*degrees = d
+			if len(numbers) > 1 {
+				m, err := strconv.Atoi(numbers[1])
+				if err != nil || m < 0 || m > 59 {
+					return nil, false
+				}
+				*minutes = m
+			}
+			if len(numbers) > 2 {
+				s, err := strconv.ParseFloat(numbers[2], 64)
+				if err != nil || s < 0 || s >= 60 {
+					return nil, false
+				}
+				*seconds = s
+			}
+			return fields[i+1:], true
+		}
+		numbers = append(numbers, field)
+	}
+	return nil, false
+}
+
+// parseUint parses an unsigned number of the given bit size
+func parseUint(s string, bits int, what string) (uint64, error) {
+	v, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return 0, invalid("content", "%s must be a number from 0 to %d, got %q", what, uint64(1)<<bits-1, s)
+	}
+	return v, nil
+}
+
+// parseRange parses a small number between 0 and max
+func parseRange(s string, max int, what string) (uint8, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 0 || v > max {
+		return 0, invalid("content", "%s must be a number from 0 to %d, got %q", what, max, s)
+	}
+	return uint8(v), nil
+}
+
+// parseHex checks a hexadecimal string and returns it in lower case
+func parseHex(s, what string) (string, error) {
+	if _, err := hex.DecodeString(s); err != nil || s == "" {
+		return "", invalid("content", "%s must be hexadecimal", what)
+	}
+	return strings.ToLower(s), nil
+}
+
+// underscored returns a service or protocol label with its leading underscore
+func underscored(label string) string {
+	if strings.HasPrefix(label, "_") {
+		return label
+	}
+	return "_" + label
+}
+
+// formatFloat formats a number without trailing zeros
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// uint16Ptr returns a pointer to v as uint16
+func uint16Ptr(v uint64) *uint16 {
+	p := uint16(v)
+	return &p
+}
@@ -0,0 +1,134 @@
+package domain_test
+
+import (
+	"reflect"
+	"testing"
+
+	"cf-dns-bot/internal/domain"
+)
+
+func TestCompleteRecordDataRoundTrip(t *testing.T) {
+	tests := []struct {
+		recordType string
+		content    string
+		want       string // content rendered from the data
+		data       domain.RecordData
+		priority   uint16
+	}{
+		{
+			recordType: "SRV",
+			content:    "10 5 5060 sip.example.com",
+			want:       "5 5060 sip.example.com",
+			data:       domain.RecordData{Weight: 5, Port: 5060, Target: "sip.example.com"},
+			priority:   10,
+		},
+		{
+			recordType: "CAA",
+			content:    `0 issue "letsencrypt.org"`,
+			want:       `0 issue "letsencrypt.org"`,
+			data:       domain.RecordData{Flags: "0", Tag: "issue", Value: "letsencrypt.org"},
+		},
+		{
+			recordType: "TLSA",
+			content:    "3 1 1 0A1B2C3D",
+			want:       "3 1 1 0a1b2c3d",
+			data:       domain.RecordData{Usage: 3, Selector: 1, MatchingType: 1, Certificate: "0a1b2c3d"},
+		},
+		{
+			recordType: "SSHFP",
+			content:    "4 2 0A1B 2C3D",
+			want:       "4 2 0a1b2c3d",
+			data:       domain.RecordData{Algorithm: 4, Type: 2, Fingerprint: "0a1b2c3d"},
+		},
+		{
+			recordType: "URI",
+			content:    `10 1 "https://example.com/"`,
+			want:       `1 "https://example.com/"`,
+			data:       domain.RecordData{Weight: 1, Target: "https://example.com/"},
+			priority:   10,
+		},
+		{
+			recordType: "LOC",
+			content:    "51 30 12.748 N 0 7 39.611 W 0m",
+			want:       "51 30 12.748 N 0 7 39.611 W 0m 1m 10000m 10m",
+			data: domain.RecordData{
+				LatDegrees: 51, LatMinutes: 30, LatSeconds: 12.748, LatDirection: "N",
+				LongDegrees: 0, LongMinutes: 7, LongSeconds: 39.611, LongDirection: "W",
+				Size: 1, PrecisionHorz: 10000, PrecisionVert: 10,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.recordType, func(t *testing.T) {
+			// Content to data
+			record := domain.DNSRecord{Name: "host.example.com", Type: tt.recordType, Content: tt.content}
+			if err := domain.CompleteRecordData(&record); err != nil {
+				t.Fatalf("CompleteRecordData from content: %v", err)
+			}
+			if record.Data == nil || !reflect.DeepEqual(*record.Data, tt.data) {
+				t.Fatalf("got data %+v, want %+v", record.Data, tt.data)
+			}
+			if tt.priority != 0 && (record.Priority == nil || *record.Priority != tt.priority) {
+				t.Errorf("got priority %v, want %d", record.Priority, tt.priority)
+			}
+			if record.Content != tt.want {
+				t.Errorf("got content %q, want %q", record.Content, tt.want)
+			}
+
+			// Data back to the same content
+			data := *record.Data
+			fromData := domain.DNSRecord{Name: "host.example.com", Type: tt.recordType, Data: &data}
+			if err := domain.CompleteRecordData(&fromData); err != nil {
+				t.Fatalf("CompleteRecordData from data: %v", err)
+			}
+			if fromData.Content != tt.want {
+				t.Errorf("from data: got content %q, want %q", fromData.Content, tt.want)
+			}
+
+			// The rendered content parses to the same data
+			parsed, _, err := domain.ParseRecordData(tt.recordType, fromData.Content)
+			if err != nil {
+				t.Fatalf("ParseRecordData: %v", err)
+			}
+			if !reflect.DeepEqual(*parsed, tt.data) {
+				t.Errorf("reparsed data %+v, want %+v", *parsed, tt.data)
+			}
+		})
+	}
+}
+
+func TestCompleteRecordDataSRVName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"example.com", "_sip._tcp.example.com"},
+		{"@", "_sip._tcp"},
+		{"_sip._tcp.example.com", "_sip._tcp.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := domain.DNSRecord{Name: tt.name, Type: "SRV", Data: &domain.RecordData{
+				Service: "sip", Proto: "_tcp", Weight: 5, Port: 5060, Target: "sip.example.com",
+			}}
+			if err := domain.CompleteRecordData(&record); err != nil {
+				t.Fatalf("CompleteRecordData: %v", err)
+			}
+			if record.Name != tt.want {
+				t.Errorf("got name %s, want %s", record.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteRecordDataPlainType(t *testing.T) {
+	record := domain.DNSRecord{Type: "A", Content: "192.0.2.1", Data: &domain.RecordData{Port: 80}}
+	if err := domain.CompleteRecordData(&record); err != nil {
+		t.Fatalf("CompleteRecordData: %v", err)
+	}
+	if record.Data != nil || record.Content != "192.0.2.1" {
+		t.Errorf("got %+v, want the content kept and no data", record)
+	}
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// Snapshot is the full record set of a zone at a point in time
+type Snapshot struct {
+	ID          string      `json:"id"`
+	ZoneName    string      `json:"zone_name"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Fingerprint string      `json:"fingerprint"`
+	RecordCount int         `json:"record_count"`
+	Records     []DNSRecord `json:"records,omitempty"`
+}
@@ -0,0 +1,229 @@
+package domain
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// TTL limits accepted by Cloudflare. A TTL of 1 means "automatic" and 0
+// means the configured default is used.
+const (
+	AutoTTL = 1
+	MinTTL  = 60
+	MaxTTL  = 86400
+)
+
+const (
+	// maxTXTLength is the longest TXT content Cloudflare accepts
+	maxTXTLength = 2048
+	// maxTXTChunk is the longest character-string inside TXT data
+	maxTXTChunk = 255
+	// maxHostnameLength is the longest hostname in presentation format
+	maxHostnameLength = 253
+)
+
+// caaTags are the CAA property tags supported by Cloudflare
+var caaTags = map[string]bool{"issue": true, "issuewild": true, "iodef": true}
+
+// ValidationError explains why a record was rejected. It wraps
+// ErrInvalidRecord so callers can keep using errors.Is.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v: %s: %s", ErrInvalidRecord, e.Field, e.Message)
+}
+
+// Unwrap returns ErrInvalidRecord
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidRecord
+}
+
+// invalid returns a ValidationError for a field
+func invalid(field, format string, args ...interface{}) error {
+	return &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidateRecord checks a record against the rules of its type before it
+// is sent to Cloudflare
+func ValidateRecord(r DNSRecord) error {
+	if !IsValidRecordType(r.Type) {
+		return invalid("type", "unsupported record type %q", r.Type)
+	}
+	if err := ValidateContent(r.Type, r.Content); err != nil {
+		return err
+	}
+	if err := ValidateTTL(r.TTL); err != nil {
+		return err
+	}
+	if (r.Type == "MX" || r.Type == "SRV" || r.Type == "URI") && r.Priority == nil {
+		return invalid("priority", "%s records need a priority", r.Type)
+	}
+	if r.Proxied && !CanProxy(r.Type) {
+		return invalid("proxied", "%s records cannot be proxied, only A, AAAA and CNAME", r.Type)
+	}
+	return ValidateTags(r.Tags)
+}
+
+// ValidateContent checks the content of a record of the given type
+func ValidateContent(recordType, content string) error {
+	if content == "" {
+		return invalid("content", "content is required")
+	}
+
+	switch recordType {
+	case "A":
+		addr, err := netip.ParseAddr(content)
+		if err != nil || !addr.Is4() {
+			return invalid("content", "%q is not an IPv4 address", content)
+		}
+	case "AAAA":
+		addr, err := netip.ParseAddr(content)
+		if err != nil || !addr.Is6() {
+			return invalid("content", "%q is not an IPv6 address", content)
+		}
+	case "CNAME", "NS", "MX", "PTR":
+		if !IsValidHostname(content) {
+			return invalid("content", "%q is not a valid hostname", content)
+		}
+	case "TXT":
+		return validateTXT(content)
+	}
+
+	if HasStructuredData(recordType) {
+		_, _, err := ParseRecordData(recordType, content)
+		return err
+	}
+	return nil
+}
+
+// ValidateTTL checks a TTL; 0 (default) and 1 (automatic) are accepted
+func ValidateTTL(ttl int) error {
+	if ttl == 0 || ttl == AutoTTL {
+		return nil
+	}
+	if ttl < MinTTL || ttl > MaxTTL {
+		return invalid("ttl", "TTL must be 1 (auto) or between %d and %d seconds, got %d", MinTTL, MaxTTL, ttl)
+	}
+	return nil
+}
+
+// ValidateTags checks record tags, which are a name or name:value
+// without spaces
+func ValidateTags(tags []string) error {
+	for _, tag := range tags {
+		name, _, _ := strings.Cut(tag, ":")
+		if name == "" || strings.ContainsAny(tag, " \t\n") {
+			return invalid("tags", "tag %q must be a name or name:value without spaces", tag)
+		}
+	}
+	return nil
+}
+
+// ParseTags splits a comma separated list of tags
+func ParseTags(s string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ValidateFilter checks the ordering and paging of a record filter
+func ValidateFilter(f RecordFilter) error {
+	if f.Order != "" && !slices.Contains(RecordOrders, f.Order) {
+		return fmt.Errorf("%w: order must be one of %s, got %q", ErrInvalidFilter, strings.Join(RecordOrders, ", "), f.Order)
+	}
+	if f.Page < 0 || f.PerPage < 0 {
+		return fmt.Errorf("%w: page and per_page cannot be negative", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// CanProxy returns true if Cloudflare can proxy records of the given type
+func CanProxy(recordType string) bool {
+	return recordType == "A" || recordType == "AAAA" || recordType == "CNAME"
+}
+
+// IsValidHostname checks a hostname in presentation format. A trailing
+// dot is allowed; underscores are accepted for service labels.
+func IsValidHostname(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" || len(name) > maxHostnameLength {
+		return false
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+			if !isAlnum && c != '-' && c != '_' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validateTXT checks TXT content. Plain text is split into
+// character-strings by Cloudflare; content already in quoted form must
+// have balanced quotes and no string longer than 255 characters.
+func validateTXT(content string) error {
+	if len(content) > maxTXTLength {
+		return invalid("content", "TXT content is %d characters, the limit is %d", len(content), maxTXTLength)
+	}
+	if !strings.HasPrefix(content, "\"") {
+		return nil
+	}
+
+	chunks, ok := splitQuoted(content)
+	if !ok {
+		return invalid("content", "TXT content has unbalanced quotes")
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > maxTXTChunk {
+			return invalid("content", "TXT string %d is %d characters, the limit is %d", i+1, len(chunk), maxTXTChunk)
+		}
+	}
+	return nil
+}
+
+// splitQuoted splits `"a" "b"` into its quoted strings
+func splitQuoted(s string) ([]string, bool) {
+	var chunks []string
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return chunks, true
+		}
+		if s[0] != '"' {
+			return nil, false
+		}
+
+		var b strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			}
+			b.WriteByte(s[i])
+		}
+		if i == len(s) {
+			return nil, false
+		}
+		chunks = append(chunks, b.String())
+		s = s[i+1:]
+	}
+}
@@ -0,0 +1,130 @@
+package domain_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"cf-dns-bot/internal/domain"
+)
+
+// checkValidation compares an error with the field a ValidationError
+// should name, or no error when field is empty
+func checkValidation(t *testing.T, err error, field string) {
+	t.Helper()
+	if field == "" {
+		if err != nil {
+			t.Errorf("got error %v, want none", err)
+		}
+		return
+	}
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("got error %v, want a ValidationError for %s", err, field)
+	}
+	if validationErr.Field != field {
+		t.Errorf("got field %s, want %s", validationErr.Field, field)
+	}
+	if !errors.Is(err, domain.ErrInvalidRecord) {
+		t.Errorf("got error %v, want it to wrap ErrInvalidRecord", err)
+	}
+}
+
+func TestValidateRecord(t *testing.T) {
+	priority := uint16(10)
+
+	tests := []struct {
+		name   string
+		record domain.DNSRecord
+		field  string
+	}{
+		{"A", domain.DNSRecord{Type: "A", Content: "192.0.2.1"}, ""},
+		{"A with IPv6", domain.DNSRecord{Type: "A", Content: "2001:db8::1"}, "content"},
+		{"AAAA", domain.DNSRecord{Type: "AAAA", Content: "2001:db8::1"}, ""},
+		{"AAAA with IPv4", domain.DNSRecord{Type: "AAAA", Content: "192.0.2.1"}, "content"},
+		{"CNAME", domain.DNSRecord{Type: "CNAME", Content: "www.example.com."}, ""},
+		{"CNAME not a hostname", domain.DNSRecord{Type: "CNAME", Content: "-bad.example.com"}, "content"},
+		{"NS not a hostname", domain.DNSRecord{Type: "NS", Content: "ns 1.example.com"}, "content"},
+		{"unknown type", domain.DNSRecord{Type: "BOGUS", Content: "x"}, "type"},
+		{"no content", domain.DNSRecord{Type: "TXT"}, "content"},
+
+		{"TTL auto", domain.DNSRecord{Type: "A", Content: "192.0.2.1", TTL: 1}, ""},
+		{"TTL default", domain.DNSRecord{Type: "A", Content: "192.0.2.1", TTL: 0}, ""},
+		{"TTL too short", domain.DNSRecord{Type: "A", Content: "192.0.2.1", TTL: 59}, "ttl"},
+		{"TTL minimum", domain.DNSRecord{Type: "A", Content: "192.0.2.1", TTL: 60}, ""},
+		{"TTL maximum", domain.DNSRecord{Type: "A", Content: "192.0.2.1", TTL: 86400}, ""},
+		{"TTL too long", domain.DNSRecord{Type: "A", Content: "192.0.2.1", TTL: 86401}, "ttl"},
+
+		{"MX without priority", domain.DNSRecord{Type: "MX", Content: "mail.example.com"}, "priority"},
+		{"MX", domain.DNSRecord{Type: "MX", Content: "mail.example.com", Priority: &priority}, ""},
+
+		{"proxied A", domain.DNSRecord{Type: "A", Content: "192.0.2.1", Proxied: true}, ""},
+		{"proxied AAAA", domain.DNSRecord{Type: "AAAA", Content: "2001:db8::1", Proxied: true}, ""},
+		{"proxied CNAME", domain.DNSRecord{Type: "CNAME", Content: "origin.example.com", Proxied: true}, ""},
+		{"proxied TXT", domain.DNSRecord{Type: "TXT", Content: "hello", Proxied: true}, "proxied"},
+		{"proxied MX", domain.DNSRecord{Type: "MX", Content: "mail.example.com", Priority: &priority, Proxied: true}, "proxied"},
+
+		{"tags", domain.DNSRecord{Type: "A", Content: "192.0.2.1", Tags: []string{"env:prod", "web"}}, ""},
+		{"tag with a space", domain.DNSRecord{Type: "A", Content: "192.0.2.1", Tags: []string{"env prod"}}, "tags"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidation(t, domain.ValidateRecord(tt.record), tt.field)
+		})
+	}
+}
+
+func TestValidateTXT(t *testing.T) {
+	quoted := func(n int) string { return `"` + strings.Repeat("a", n) + `"` }
+
+	tests := []struct {
+		name    string
+		content string
+		field   string
+	}{
+		{"plain text", "v=spf1 -all", ""},
+		// Cloudflare splits plain text into strings itself
+		{"long plain text", strings.Repeat("a", 1000), ""},
+		{"too long", strings.Repeat("a", 2049), "content"},
+		{"quoted string at the limit", quoted(255), ""},
+		{"quoted string over the limit", quoted(256), "content"},
+		{"second string over the limit", quoted(10) + " " + quoted(256), "content"},
+		{"several strings", quoted(255) + " " + quoted(255), ""},
+		{"escaped quote", `"say \"hi\""`, ""},
+		{"unbalanced quotes", `"open`, "content"},
+		{"text after a string", `"a" b`, "content"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidation(t, domain.ValidateContent("TXT", tt.content), tt.field)
+		})
+	}
+}
+
+func TestValidateCAA(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		field   string
+	}{
+		{"issue", `0 issue "letsencrypt.org"`, ""},
+		{"issuewild unquoted", "0 issuewild letsencrypt.org", ""},
+		{"critical flag", `128 issue "letsencrypt.org"`, ""},
+		{"iodef mail", `0 iodef "mailto:security@example.com"`, ""},
+		{"iodef URL", `0 iodef "https://example.com/caa"`, ""},
+		{"iodef not a URL", `0 iodef "security@example.com"`, "content"},
+		{"flags too large", `256 issue "letsencrypt.org"`, "content"},
+		{"flags not a number", `x issue "letsencrypt.org"`, "content"},
+		{"unknown tag", `0 issuer "letsencrypt.org"`, "content"},
+		{"no value", "0 issue", "content"},
+		{"unbalanced quotes", `0 issue "letsencrypt.org`, "content"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidation(t, domain.ValidateContent("CAA", tt.content), tt.field)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package domain
 
 // Zone represents a DNS zone (domain)
 type Zone struct {
-	ID   string
-	Name string
+	ID      string
+	Name    string
+	Account string // name of the Cloudflare account the zone belongs to
 }
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"cf-dns-bot/internal/domain"
+)
+
+// JSON-RPC error codes of the MCP servers. Codes from -32000 to -32099 are
+// left to servers by the JSON-RPC spec.
+const (
+	RPCInvalidParams = -32602
+	RPCInternalError = -32603
+	RPCUnauthorized  = -32001
+	RPCUpstreamAuth  = -32002
+	RPCNotFound      = -32004
+	RPCConflict      = -32009
+	RPCRateLimited   = -32029
+)
+
+// errorMappings lists the domain errors with their REST status and JSON-RPC
+// code, checked in order
+var errorMappings = []struct {
+	err    error
+	status int
+	code   int
+}{
+	{domain.ErrInvalidRecord, http.StatusBadRequest, RPCInvalidParams},
+	{domain.ErrInvalidZone, http.StatusBadRequest, RPCInvalidParams},
+	{domain.ErrInvalidFilter, http.StatusBadRequest, RPCInvalidParams},
+	{domain.ErrUnsupportedFormat, http.StatusBadRequest, RPCInvalidParams},
+	{domain.ErrUnauthorized, http.StatusForbidden, RPCUnauthorized},
+	{domain.ErrSelfApproval, http.StatusForbidden, RPCUnauthorized},
+	{domain.ErrZoneNotFound, http.StatusNotFound, RPCNotFound},
+	{domain.ErrRecordNotFound, http.StatusNotFound, RPCNotFound},
+	{domain.ErrPlanNotFound, http.StatusNotFound, RPCNotFound},
+	{domain.ErrChangeNotFound, http.StatusNotFound, RPCNotFound},
+	{domain.ErrSnapshotNotFound, http.StatusNotFound, RPCNotFound},
+	{domain.ErrPendingChangeNotFound, http.StatusNotFound, RPCNotFound},
+	{domain.ErrDuplicateRecord, http.StatusConflict, RPCConflict},
+	{domain.ErrRecordConflict, http.StatusConflict, RPCConflict},
+	{domain.ErrAmbiguousRecord, http.StatusConflict, RPCConflict},
+	{domain.ErrStalePlan, http.StatusConflict, RPCConflict},
+	{domain.ErrRollbackConflict, http.StatusConflict, RPCConflict},
+	{domain.ErrRateLimited, http.StatusTooManyRequests, RPCRateLimited},
+	// The server's Cloudflare credentials failed, not the caller's
+	{domain.ErrCloudflareAuth, http.StatusBadGateway, RPCUpstreamAuth},
+}
+
+// HTTPStatus returns the REST status code of an error
+func HTTPStatus(err error) int {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return m.status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// RPCCode returns the JSON-RPC error code of an error
+func RPCCode(err error) int {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return m.code
+		}
+	}
+	return RPCInternalError
+}
+
+// rpcKinds names the JSON-RPC error codes for clients that would rather
+// match a string than a number
+var rpcKinds = map[int]string{
+	RPCInvalidParams: "invalid_params",
+	RPCInternalError: "internal_error",
+	RPCUnauthorized:  "unauthorized",
+	RPCUpstreamAuth:  "upstream_auth",
+	RPCNotFound:      "not_found",
+	RPCConflict:      "conflict",
+	RPCRateLimited:   "rate_limited",
+}
+
+// RPCKind returns the name of a JSON-RPC error code
+func RPCKind(code int) string {
+	return rpcKinds[code]
+}
+
+// ErrorKind returns the name of the JSON-RPC error code of an error, such
+// as "not_found" or "conflict"
+func ErrorKind(err error) string {
+	return RPCKind(RPCCode(err))
+}
@@ -0,0 +1,44 @@
+package handler_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/handler"
+)
+
+func TestErrorMapping(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   int
+		kind   string
+	}{
+		{"validation", &domain.ValidationError{Field: "ttl", Message: "too short"}, http.StatusBadRequest, handler.RPCInvalidParams, "invalid_params"},
+		{"scope", fmt.Errorf("%w: read-only key", domain.ErrUnauthorized), http.StatusForbidden, handler.RPCUnauthorized, "unauthorized"},
+		{"zone not found", fmt.Errorf("zone example.net: %w", domain.ErrZoneNotFound), http.StatusNotFound, handler.RPCNotFound, "not_found"},
+		{"duplicate", domain.ErrDuplicateRecord, http.StatusConflict, handler.RPCConflict, "conflict"},
+		{"ambiguous", fmt.Errorf("%w: 2 records", domain.ErrAmbiguousRecord), http.StatusConflict, handler.RPCConflict, "conflict"},
+		{"rate limited", domain.ErrRateLimited, http.StatusTooManyRequests, handler.RPCRateLimited, "rate_limited"},
+		{"Cloudflare credentials", domain.ErrCloudflareAuth, http.StatusBadGateway, handler.RPCUpstreamAuth, "upstream_auth"},
+		{"other", errors.New("boom"), http.StatusInternalServerError, handler.RPCInternalError, "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := handler.HTTPStatus(tt.err); got != tt.status {
+				t.Errorf("HTTPStatus = %d, want %d", got, tt.status)
+			}
+			if got := handler.RPCCode(tt.err); got != tt.code {
+				t.Errorf("RPCCode = %d, want %d", got, tt.code)
+			}
+			if got := handler.ErrorKind(tt.err); got != tt.kind {
+				t.Errorf("ErrorKind = %s, want %s", got, tt.kind)
+			}
+		})
+	}
+}
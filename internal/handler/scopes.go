@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/usecase"
+	"cf-dns-bot/pkg/storage"
+)
+
+// Tools lists the MCP tools. The REST operations of the standalone server
+// use the same names, so one API key scope covers both.
+var Tools = []string{
+	"list_zones",
+	"list_records",
+	"search_records",
+	"get_record",
+	"export_zone",
+	"plan_zone",
+	"create_record",
+	"update_record",
+	"delete_record",
+	"upsert_record",
+	"apply_plan",
+	"import_zone",
+	"bulk_create",
+	"bulk_delete",
+	"bulk_update",
+	"undo_change",
+}
+
+// readOnlyTools are the tools that do not change records
+var readOnlyTools = map[string]bool{
+	"list_zones":     true,
+	"list_records":   true,
+	"search_records": true,
+	"get_record":     true,
+	"export_zone":    true,
+	"plan_zone":      true,
+}
+
+// IsReadOnlyTool reports whether a tool leaves records unchanged
+func IsReadOnlyTool(tool string) bool {
+	return readOnlyTools[tool]
+}
+
+// ValidateKeyScope checks that a scope only names known tools and valid
+// patterns
+func ValidateKeyScope(scope storage.APIKeyScope) error {
+	for _, tool := range scope.Tools {
+		if !slices.Contains(Tools, tool) {
+			return fmt.Errorf("unknown tool %q", tool)
+		}
+	}
+	for _, pattern := range slices.Concat(scope.Zones, scope.Records) {
+		if !usecase.ValidPattern(pattern) {
+			return fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// CheckKeyScope returns ErrUnauthorized if an API key with the scope may
+// not use the tool or REST operation
+func CheckKeyScope(scope storage.APIKeyScope, tool string) error {
+	if scope.ReadOnly && !IsReadOnlyTool(tool) {
+		return fmt.Errorf("%w: API key is read-only, %s changes records", domain.ErrUnauthorized, tool)
+	}
+	if len(scope.Tools) > 0 && !slices.Contains(scope.Tools, tool) {
+		return fmt.Errorf("%w: API key may not use %s", domain.ErrUnauthorized, tool)
+	}
+	return nil
+}
+
+// keyScopeKey is the context key for the scope of the calling API key
+type keyScopeKey struct{}
+
+// WithKeyScope returns a context restricted to the zones and records of an
+// API key scope, which KeyScopeFromContext returns for the tool checks
+func WithKeyScope(ctx context.Context, scope storage.APIKeyScope) context.Context {
+	if scope.IsEmpty() {
+		return ctx
+	}
+	ctx = usecase.WithRecords(usecase.WithZones(ctx, scope.Zones), scope.Records)
+	return context.WithValue(ctx, keyScopeKey{}, scope)
+}
+
+// KeyScopeFromContext returns the API key scope of ctx; the zero scope
+// allows everything
+func KeyScopeFromContext(ctx context.Context) storage.APIKeyScope {
+	scope, _ := ctx.Value(keyScopeKey{}).(storage.APIKeyScope)
+	return scope
+}
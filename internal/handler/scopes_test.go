@@ -0,0 +1,91 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/handler"
+	"cf-dns-bot/pkg/storage"
+)
+
+func TestCheckKeyScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		scope   storage.APIKeyScope
+		tool    string
+		allowed bool
+	}{
+		{"no scope", storage.APIKeyScope{}, "delete_record", true},
+		{"read-only read", storage.APIKeyScope{ReadOnly: true}, "list_records", true},
+		{"read-only write", storage.APIKeyScope{ReadOnly: true}, "create_record", false},
+		{"listed tool", storage.APIKeyScope{Tools: []string{"upsert_record"}}, "upsert_record", true},
+		{"unlisted tool", storage.APIKeyScope{Tools: []string{"upsert_record"}}, "delete_record", false},
+		{"zones only", storage.APIKeyScope{Zones: []string{"example.com"}}, "delete_record", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := handler.CheckKeyScope(tt.scope, tt.tool)
+			if tt.allowed && err != nil {
+				t.Errorf("got error %v, want allowed", err)
+			}
+			if !tt.allowed && !errors.Is(err, domain.ErrUnauthorized) {
+				t.Errorf("got error %v, want ErrUnauthorized", err)
+			}
+		})
+	}
+}
+
+func TestValidateKeyScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope storage.APIKeyScope
+		valid bool
+	}{
+		{"empty", storage.APIKeyScope{}, true},
+		{"patterns and tools", storage.APIKeyScope{Zones: []string{"*.example.com"}, Records: []string{"_acme-challenge.*"}, Tools: []string{"upsert_record"}}, true},
+		{"unknown tool", storage.APIKeyScope{Tools: []string{"drop_zone"}}, false},
+		{"bad pattern", storage.APIKeyScope{Zones: []string{"[example.com"}}, false},
+		{"empty pattern", storage.APIKeyScope{Records: []string{""}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := handler.ValidateKeyScope(tt.scope); (err == nil) != tt.valid {
+				t.Errorf("got error %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestIsReadOnlyTool(t *testing.T) {
+	tests := map[string]bool{
+		"list_zones":   true,
+		"plan_zone":    true,
+		"export_zone":  true,
+		"apply_plan":   false,
+		"undo_change":  false,
+		"bulk_update":  false,
+		"unknown_tool": false,
+	}
+	for tool, want := range tests {
+		if got := handler.IsReadOnlyTool(tool); got != want {
+			t.Errorf("IsReadOnlyTool(%s) = %v, want %v", tool, got, want)
+		}
+	}
+}
+
+func TestWithKeyScope(t *testing.T) {
+	ctx := context.Background()
+	if got := handler.WithKeyScope(ctx, storage.APIKeyScope{}); got != ctx {
+		t.Error("empty scope changed the context")
+	}
+
+	scope := storage.APIKeyScope{ReadOnly: true, Zones: []string{"example.com"}}
+	got := handler.KeyScopeFromContext(handler.WithKeyScope(ctx, scope))
+	if !got.ReadOnly || len(got.Zones) != 1 {
+		t.Errorf("got scope %+v, want %+v", got, scope)
+	}
+}
@@ -0,0 +1,232 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"cf-dns-bot/internal/domain"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// allAccounts is the callback value that lifts an account restriction
+const allAccounts = "*"
+
+// accountNames returns the Cloudflare accounts that have zones, in the
+// order they are configured
+func (b *Bot) accountNames() ([]string, error) {
+	zones, err := b.dnsUsecase.ListZones(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, z := range zones {
+		if z.Account != "" && !seen[z.Account] {
+			seen[z.Account] = true
+			names = append(names, z.Account)
+		}
+	}
+	return names, nil
+}
+
+// spansAccounts reports whether the zones belong to more than one account,
+// in which case zone lists show the account of each zone
+func spansAccounts(zones []domain.Zone) bool {
+	for _, z := range zones {
+		if z.Account != zones[0].Account {
+			return true
+		}
+	}
+	return false
+}
+
+// zoneLabel returns the button text of a zone, with its account when the
+// listed zones span several accounts
+func zoneLabel(zone domain.Zone, withAccount bool) string {
+	if !withAccount || zone.Account == "" {
+		return zone.Name
+	}
+	return fmt.Sprintf("%s · %s", zone.Name, zone.Account)
+}
+
+// accountLabel returns the display text of an account restriction
+func accountLabel(account string) string {
+	if account == "" {
+		return "all accounts"
+	}
+	return account
+}
+
+// canManageAccounts reports whether a user may restrict users and API keys
+// to accounts. Users restricted to an account or to zones themselves may
+// not.
+func (b *Bot) canManageAccounts(userID int64) bool {
+	if !b.isAdmin(userID) {
+		return false
+	}
+	return b.allowedUserStorage == nil || (b.allowedUserStorage.GetUserAccount(userID) == "" && len(b.userZones(userID)) == 0)
+}
+
+// inheritAccount restricts a user added by a restricted user to the same
+// account
+func (b *Bot) inheritAccount(adminID, userID int64) {
+	if b.allowedUserStorage == nil {
+		return
+	}
+	account := b.allowedUserStorage.GetUserAccount(adminID)
+	if account == "" {
+		return
+	}
+	if err := b.allowedUserStorage.SetUserAccount(userID, account); err != nil {
+		log.Printf("[inheritAccount] Warning: failed to restrict user %d to %s: %v", userID, account, err)
+	}
+}
+
+// accountMenu returns buttons to pick an account, or every account, with
+// the given callback and arguments followed by the account
+func accountMenu(accounts []string, current, unique string, args []string, back string) *tele.ReplyMarkup {
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	var rows []tele.Row
+
+	options := append([]string{allAccounts}, accounts...)
+	for _, option := range options {
+		value := option
+		if option == allAccounts {
+			value = ""
+		}
+		label := "🏢 " + accountLabel(value)
+		if value == current {
+			label = "✅ " + accountLabel(value)
+		}
+		rows = append(rows, menu.Row(menu.Data(label, unique, append(args, option)...)))
+	}
+	rows = append(rows, menu.Row(menu.Data("◀️ Back", back)))
+	menu.Inline(rows...)
+	return menu
+}
+
+// showUserAccount asks which account a user is restricted to
+func (b *Bot) showUserAccount(c tele.Context, adminID int64, userIDStr string) error {
+	if !b.canManageAccounts(adminID) || b.allowedUserStorage == nil {
+		return b.sendWithThread(c, "⛔ You are not authorized to change user accounts.", tele.ModeMarkdown)
+	}
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		return b.sendWithThread(c, "❌ Invalid user ID.", tele.ModeMarkdown)
+	}
+
+	accounts, err := b.accountNames()
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("loading accounts", err), tele.ModeMarkdown)
+	}
+
+	current := b.allowedUserStorage.GetUserAccount(userID)
+	menu := accountMenu(accounts, current, "user_acct_set", []string{userIDStr}, "users")
+	return b.editWithThread(c, fmt.Sprintf(
+		"*🏢 Account of User* `%d`\n\nCurrently: *%s*\n\nThe user only sees and changes zones of the selected account:",
+		userID, accountLabel(current),
+	), menu, tele.ModeMarkdown)
+}
+
+// handleUserAccount restricts a user to an account
+func (b *Bot) handleUserAccount(c tele.Context, adminID int64, userIDStr, account string) error {
+	if !b.canManageAccounts(adminID) || b.allowedUserStorage == nil {
+		return b.sendWithThread(c, "⛔ You are not authorized to change user accounts.", tele.ModeMarkdown)
+	}
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		return b.sendWithThread(c, "❌ Invalid user ID.", tele.ModeMarkdown)
+	}
+	if account == allAccounts {
+		account = ""
+	}
+
+	if err := b.allowedUserStorage.SetUserAccount(userID, account); err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ Error saving account: %v", err), tele.ModeMarkdown)
+	}
+	return b.showAllowedUsers(c)
+}
+
+// showAPIKeyAccountMenu asks which API key to restrict to an account
+func (b *Bot) showAPIKeyAccountMenu(c tele.Context, adminID int64) error {
+	if !b.canManageAccounts(adminID) || b.apiKeyStorage == nil {
+		return b.sendWithThread(c, "⛔ You are not authorized to change API key accounts.", tele.ModeMarkdown)
+	}
+
+	keys, err := b.apiKeyStorage.GetAPIKeys()
+	if err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ Error getting keys: %v", err), tele.ModeMarkdown)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	var rows []tele.Row
+	for i, key := range keys {
+		label := fmt.Sprintf("🔑 %s (%s)", b.maskAPIKey(key), accountLabel(b.apiKeyStorage.GetAPIKeyAccount(key)))
+		rows = append(rows, menu.Row(menu.Data(label, "apikey_acct_key", strconv.Itoa(i))))
+	}
+	rows = append(rows, menu.Row(menu.Data("◀️ Back", "apikeys")))
+	menu.Inline(rows...)
+
+	return b.editWithThread(c, "*🏢 API Key Accounts*\n\nSelect a key to restrict:", menu, tele.ModeMarkdown)
+}
+
+// showAPIKeyAccount asks which account an API key is restricted to
+func (b *Bot) showAPIKeyAccount(c tele.Context, adminID int64, keyIdxStr string) error {
+	key, err := b.apiKeyAt(adminID, keyIdxStr)
+	if err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ %v", err), tele.ModeMarkdown)
+	}
+
+	accounts, err := b.accountNames()
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("loading accounts", err), tele.ModeMarkdown)
+	}
+
+	current := b.apiKeyStorage.GetAPIKeyAccount(key)
+	menu := accountMenu(accounts, current, "apikey_acct_set", []string{keyIdxStr}, "apikey_acct")
+	return b.editWithThread(c, fmt.Sprintf(
+		"*🏢 Account of Key* `%s`\n\nCurrently: *%s*\n\nThe key only sees and changes zones of the selected account:",
+		b.maskAPIKey(key), accountLabel(current),
+	), menu, tele.ModeMarkdown)
+}
+
+// handleAPIKeyAccount restricts an API key to an account
+func (b *Bot) handleAPIKeyAccount(c tele.Context, adminID int64, keyIdxStr, account string) error {
+	key, err := b.apiKeyAt(adminID, keyIdxStr)
+	if err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ %v", err), tele.ModeMarkdown)
+	}
+	if account == allAccounts {
+		account = ""
+	}
+
+	if err := b.apiKeyStorage.SetAPIKeyAccount(key, account); err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ Error saving account: %v", err), tele.ModeMarkdown)
+	}
+	return b.showAPIKeyAccountMenu(c, adminID)
+}
+
+// apiKeyAt returns the API key at an index of the key list, checking that
+// the user may change its account
+func (b *Bot) apiKeyAt(adminID int64, keyIdxStr string) (string, error) {
+	if !b.canManageAccounts(adminID) || b.apiKeyStorage == nil {
+		return "", fmt.Errorf("you are not authorized to change API key accounts")
+	}
+
+	keyIdx, err := strconv.Atoi(keyIdxStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid key index")
+	}
+	keys, err := b.apiKeyStorage.GetAPIKeys()
+	if err != nil {
+		return "", fmt.Errorf("error getting keys: %w", err)
+	}
+	if keyIdx < 0 || keyIdx >= len(keys) {
+		return "", fmt.Errorf("invalid key index")
+	}
+	return keys[keyIdx], nil
+}
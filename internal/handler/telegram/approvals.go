@@ -0,0 +1,327 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/usecase"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// approvalChat is the chat a change was requested in
+type approvalChat struct {
+	chatID   int64
+	threadID int
+}
+
+// approvalChats remembers where pending changes were requested, so the
+// requester hears about the decision
+type approvalChats struct {
+	chats map[string]approvalChat
+	mu    sync.Mutex
+}
+
+// newApprovalChats creates an empty approvalChats
+func newApprovalChats() *approvalChats {
+	return &approvalChats{chats: make(map[string]approvalChat)}
+}
+
+// add remembers the chat of a change
+func (a *approvalChats) add(changeID string, chat approvalChat) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.chats[changeID] = chat
+}
+
+// take returns and forgets the chat of a change
+func (a *approvalChats) take(changeID string) (approvalChat, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	chat, ok := a.chats[changeID]
+	delete(a.chats, changeID)
+	return chat, ok
+}
+
+// requestApproval parks a change that the approval policy holds for a
+// second user, notifies the admins and tells the requester. It returns
+// false when the change can be made right away.
+func (b *Bot) requestApproval(c tele.Context, change usecase.PendingChange) (bool, error) {
+	if b.approvalUsecase == nil || !b.approvalUsecase.RequiresApproval(change) {
+		return false, nil
+	}
+	reply := b.replyFunc(c)
+
+	pending, err := b.approvalUsecase.Submit(b.actorContext(c), change)
+	if err != nil {
+		return true, reply(c, recordErrorText("submitting change", err), tele.ModeMarkdown)
+	}
+
+	chat := approvalChat{chatID: c.Chat().ID, threadID: b.getThreadIDFromContext(c)}
+	b.approvalChats.add(pending.ID, chat)
+	b.notifyApprovers(*pending, c.Sender().ID, chat.chatID)
+
+	// In a group, a teammate can approve right below the request
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	if chat.chatID < 0 {
+		menu.Inline(approvalRow(menu, pending.ID))
+	} else {
+		menu.Inline(menu.Row(menu.Data("↩️ Withdraw", "reject_change", pending.ID)))
+	}
+
+	return true, reply(c, fmt.Sprintf(
+		"⏳ *Waiting for Approval*\n\n%s\n\nThis change needs the approval of a second user. Admins have been notified; `/approvals` lists pending changes.",
+		describeChange(*pending),
+	), menu, tele.ModeMarkdown)
+}
+
+// notifyApprovers sends a pending change with approve and reject buttons
+// to every admin except the requester and the chat it was requested in
+func (b *Bot) notifyApprovers(change usecase.PendingChange, requesterID, requestChatID int64) {
+	menu := &tele.ReplyMarkup{}
+	menu.Inline(approvalRow(menu, change.ID))
+	message := "🔔 *Change Needs Approval*\n\n" + describeChange(change)
+
+	for _, adminID := range b.adminIDs() {
+		if adminID == requesterID || adminID == requestChatID {
+			continue
+		}
+		if err := b.sendMessageWithMarkup(adminID, message, menu); err != nil {
+			log.Printf("[notifyApprovers] Failed to notify admin %d: %v", adminID, err)
+		}
+	}
+}
+
+// showPendingChanges lists the changes waiting for approval
+func (b *Bot) showPendingChanges(c tele.Context) error {
+	if b.approvalUsecase == nil {
+		return b.sendWithThread(c, "❌ Approvals not configured.", tele.ModeMarkdown)
+	}
+
+	changes, err := b.approvalUsecase.ListPending(b.actorContext(c))
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("loading pending changes", err), tele.ModeMarkdown)
+	}
+	if len(changes) == 0 {
+		return b.sendWithThread(c, "📭 No changes are waiting for approval.", tele.ModeMarkdown)
+	}
+
+	for _, change := range changes {
+		menu := &tele.ReplyMarkup{}
+		menu.Inline(approvalRow(menu, change.ID))
+		b.sendWithThread(c, "⏳ *Pending Change*\n\n"+describeChange(change), menu, tele.ModeMarkdown)
+	}
+	return nil
+}
+
+// handleApproveChange approves and makes a pending change
+func (b *Bot) handleApproveChange(c tele.Context, changeID string) error {
+	if b.approvalUsecase == nil {
+		return b.sendWithThread(c, "❌ Approvals not configured.", tele.ModeMarkdown)
+	}
+
+	ctx, ref := usecase.WithChangeRef(b.actorContext(c))
+	change, result, err := b.approvalUsecase.Approve(ctx, changeID)
+	switch {
+	case errors.Is(err, domain.ErrSelfApproval):
+		return b.sendWithThread(c, "⛔ You cannot approve your own change. Another user has to approve it.", tele.ModeMarkdown)
+	case errors.Is(err, domain.ErrPendingChangeNotFound):
+		return b.editWithThread(c, "⌛ This change was already decided or has expired.", tele.ModeMarkdown)
+	case change == nil:
+		return b.sendWithThread(c, recordErrorText("approving change", err), tele.ModeMarkdown)
+	}
+
+	approver := usecase.ActorFromContext(ctx)
+	if err != nil {
+		text := fmt.Sprintf("❌ *Approved Change Failed*\n\n%s\n\n%s", describeChange(*change), recordErrorText("making change", err))
+		b.notifyRequester(c, change.ID, text)
+		return b.editWithThread(c, text, tele.ModeMarkdown)
+	}
+
+	text := fmt.Sprintf(
+		"✅ *Change Approved* by `%s`\n\n%s\n\n%s",
+		approver, describeChange(*change), approvalResultText(*change, result),
+	)
+	b.notifyRequester(c, change.ID, text)
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	rows := undoRows(menu, ref)
+	rows = append(rows, menu.Row(menu.Data("🏠 Main Menu", "menu")))
+	menu.Inline(rows...)
+	return b.editWithThread(c, text, menu, tele.ModeMarkdown)
+}
+
+// handleRejectChange rejects a pending change, or withdraws it when the
+// requester clicks it
+func (b *Bot) handleRejectChange(c tele.Context, changeID string) error {
+	if b.approvalUsecase == nil {
+		return b.sendWithThread(c, "❌ Approvals not configured.", tele.ModeMarkdown)
+	}
+
+	ctx := b.actorContext(c)
+	change, err := b.approvalUsecase.Reject(ctx, changeID)
+	if errors.Is(err, domain.ErrPendingChangeNotFound) {
+		return b.editWithThread(c, "⌛ This change was already decided or has expired.", tele.ModeMarkdown)
+	}
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("rejecting change", err), tele.ModeMarkdown)
+	}
+
+	if change.RequestedBy.ID == strconv.FormatInt(c.Sender().ID, 10) {
+		b.approvalChats.take(change.ID)
+		return b.editWithThread(c, "↩️ *Change Withdrawn*\n\n"+describeChange(*change), tele.ModeMarkdown)
+	}
+
+	text := fmt.Sprintf("❌ *Change Rejected* by `%s`\n\n%s", usecase.ActorFromContext(ctx), describeChange(*change))
+	b.notifyRequester(c, change.ID, text)
+	return b.editWithThread(c, text, tele.ModeMarkdown)
+}
+
+// notifyRequester reports the decision on a change in the chat it was
+// requested in, unless the decision was made in that chat
+func (b *Bot) notifyRequester(c tele.Context, changeID, text string) {
+	chat, ok := b.approvalChats.take(changeID)
+	if !ok || chat.chatID == c.Chat().ID {
+		return
+	}
+	b.sendMessageToThread(chat.chatID, chat.threadID, text)
+}
+
+// approvalRow returns the approve and reject buttons of a pending change
+func approvalRow(menu *tele.ReplyMarkup, changeID string) tele.Row {
+	return menu.Row(menu.Data("✅ Approve", "approve_change", changeID), menu.Data("❌ Reject", "reject_change", changeID))
+}
+
+// describeChange formats a pending change for the requester and approvers
+func describeChange(change usecase.PendingChange) string {
+	var text strings.Builder
+	switch change.Kind {
+	case usecase.ChangeCreate:
+		in := change.Create
+		text.WriteString(fmt.Sprintf("➕ *Create* in `%s`\n", change.ZoneName))
+		text.WriteString(recordSummary(domain.DNSRecord{
+			Name:     in.Name,
+			Type:     in.Type,
+			Content:  in.Content,
+			TTL:      in.TTL,
+			Proxied:  in.Proxied,
+			Priority: in.Priority,
+		}))
+	case usecase.ChangeUpdate:
+		text.WriteString(fmt.Sprintf("✏️ *Edit* in `%s`\n", change.ZoneName))
+		text.WriteString(recordSummary(*change.Record))
+		text.WriteString("\n\nChanges:\n")
+		text.WriteString(describeUpdate(*change.Update))
+	case usecase.ChangeDelete:
+		text.WriteString(fmt.Sprintf("🗑️ *Delete* from `%s`\n", change.ZoneName))
+		text.WriteString(recordSummary(*change.Record))
+	case usecase.ChangeBulkDelete:
+		text.WriteString(fmt.Sprintf("🧰 *Bulk Delete* in `%s`\nFilter: %s", change.ZoneName, describeBulkFilter(*change.BulkFilter)))
+	case usecase.ChangeBulkUpdate:
+		text.WriteString(fmt.Sprintf("🧰 *Bulk Update* in `%s`\nFilter: %s\nSet: %s", change.ZoneName, describeBulkFilter(*change.BulkFilter), describeBulkChanges(*change.BulkChanges)))
+	case usecase.ChangeRollback:
+		entry := change.Rollback
+		record := entry.After
+		if record == nil {
+			record = entry.Before
+		}
+		text.WriteString(fmt.Sprintf("↩️ *Undo* of a %s in `%s`\n", entry.Action, change.ZoneName))
+		if record != nil {
+			text.WriteString(fmt.Sprintf("`%s` %s `%s`", record.Name, record.Type, record.Content))
+		}
+	case usecase.ChangeRestore:
+		restore := change.Restore
+		scope := "whole zone"
+		if len(restore.RecordIDs) > 0 {
+			scope = fmt.Sprintf("%d record(s)", len(restore.RecordIDs))
+		}
+		text.WriteString(fmt.Sprintf("♻️ *Snapshot Restore* of `%s` (%s)\nSnapshot: `%s`\n", change.ZoneName, scope, restore.SnapshotID))
+		text.WriteString(fmt.Sprintf("Recreate: %d\nRevert: %d\nDelete: %d", restore.Creates, restore.Updates, restore.Deletes))
+	case usecase.ChangeImport:
+		text.WriteString(fmt.Sprintf("📥 *Zone Import* into `%s`\nCreate: %d record(s)", change.ZoneName, len(change.Import.ToCreate)))
+		for i, r := range change.Import.ToCreate {
+			if i == maxImportPreviewLines {
+				text.WriteString(fmt.Sprintf("\n…and %d more", len(change.Import.ToCreate)-i))
+				break
+			}
+			text.WriteString(fmt.Sprintf("\n• `%s %s` `%s`", r.Type, r.Name, r.Content))
+		}
+	}
+
+	text.WriteString(fmt.Sprintf("\n\nRequested by `%s`\nExpires: %s", change.RequestedBy, change.ExpiresAt.Format("2006-01-02 15:04 MST")))
+	return text.String()
+}
+
+// describeUpdate lists the fields an update changes
+func describeUpdate(update usecase.UpdateRecordInput) string {
+	var text strings.Builder
+	if update.Content != nil {
+		text.WriteString(fmt.Sprintf("• Content: `%s`\n", *update.Content))
+	}
+	if update.Priority != nil {
+		text.WriteString(fmt.Sprintf("• Priority: `%d`\n", *update.Priority))
+	}
+	if update.TTL != nil {
+		text.WriteString(fmt.Sprintf("• TTL: `%d`\n", *update.TTL))
+	}
+	if update.Proxied != nil {
+		text.WriteString(fmt.Sprintf("• Proxied: `%v`\n", *update.Proxied))
+	}
+	if update.Comment != nil {
+		text.WriteString(fmt.Sprintf("• Comment: `%s`\n", *update.Comment))
+	}
+	if update.Tags != nil {
+		text.WriteString(fmt.Sprintf("• Tags: `%s`\n", strings.Join(update.Tags, ", ")))
+	}
+	return strings.TrimSuffix(text.String(), "\n")
+}
+
+// describeBulkChanges formats the settings of a bulk update
+func describeBulkChanges(changes usecase.BulkChanges) string {
+	var parts []string
+	if changes.TTL != nil {
+		parts = append(parts, fmt.Sprintf("TTL `%d`", *changes.TTL))
+	}
+	if changes.Proxied != nil {
+		parts = append(parts, fmt.Sprintf("proxied `%v`", *changes.Proxied))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// approvalResultText formats the outcome of an approved change
+func approvalResultText(change usecase.PendingChange, result *usecase.ApprovalResult) string {
+	switch {
+	case result.Restore != nil:
+		text := fmt.Sprintf("Recreated: %d\nReverted: %d\nDeleted: %d", len(result.Restore.Created), len(result.Restore.Updated), len(result.Restore.Deleted))
+		if len(result.Restore.Failed) > 0 {
+			text += fmt.Sprintf("\n❌ %d failed", len(result.Restore.Failed))
+		}
+		return text
+	case result.Import != nil:
+		text := fmt.Sprintf("✅ Created: %d", len(result.Import.Created))
+		if len(result.Import.Existing) > 0 {
+			text += fmt.Sprintf("\n⏭️ Already existed: %d", len(result.Import.Existing))
+		}
+		if len(result.Import.Failed) > 0 {
+			text += fmt.Sprintf("\n❌ %d failed", len(result.Import.Failed))
+		}
+		return text
+	case result.Bulk != nil:
+		text := fmt.Sprintf("✅ %d succeeded", len(result.Bulk.Succeeded))
+		if len(result.Bulk.Failed) > 0 {
+			text += fmt.Sprintf("\n❌ %d failed", len(result.Bulk.Failed))
+		}
+		return text
+	case result.Record != nil:
+		return "Result:\n" + recordSummary(*result.Record)
+	case change.Kind == usecase.ChangeDelete:
+		return "The record was deleted."
+	default:
+		return "The record was removed."
+	}
+}
@@ -0,0 +1,173 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/usecase"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// auditEntriesPerPage is the number of audit entries shown per page
+const auditEntriesPerPage = 10
+
+// actorContext returns a context that attributes DNS changes to the sender
+// and restricts them to the sender's Cloudflare account and zones
+func (b *Bot) actorContext(c tele.Context) context.Context {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return ctx
+	}
+	if b.allowedUserStorage != nil {
+		ctx = usecase.WithAccount(ctx, b.allowedUserStorage.GetUserAccount(sender.ID))
+		ctx = usecase.WithZones(ctx, b.userZones(sender.ID))
+	}
+
+	name := sender.FirstName
+	if sender.Username != "" {
+		name = "@" + sender.Username
+	}
+	return usecase.WithActor(ctx, domain.Actor{
+		ID:      strconv.FormatInt(sender.ID, 10),
+		Name:    name,
+		Channel: domain.ChannelTelegram,
+	})
+}
+
+// showAuditLog shows a page of the audit log, newest first (admin only).
+// The log covers every account, so users restricted to one cannot see it.
+func (b *Bot) showAuditLog(c tele.Context, userID int64, page int) error {
+	if !b.canManageAccounts(userID) {
+		return b.sendWithThread(c, "⛔ You are not authorized to view the audit log.", tele.ModeMarkdown)
+	}
+	if b.auditStorage == nil {
+		return b.editWithThread(c, "❌ Audit log not configured.", tele.ModeMarkdown)
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	entries, total, err := b.auditStorage.QueryAuditEntries(domain.AuditFilter{
+		Offset: page * auditEntriesPerPage,
+		Limit:  auditEntriesPerPage,
+	})
+	if err != nil {
+		return b.editWithThread(c, fmt.Sprintf("❌ Error reading audit log: %v", err), tele.ModeMarkdown)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	if total == 0 {
+		menu.Inline(menu.Row(menu.Data("🏠 Main Menu", "menu")))
+		return b.editWithThread(c, "📭 The audit log is empty.", menu, tele.ModeMarkdown)
+	}
+
+	totalPages := (total + auditEntriesPerPage - 1) / auditEntriesPerPage
+
+	var text strings.Builder
+	text.WriteString("*📜 Audit Log*\n")
+	text.WriteString(fmt.Sprintf("Page %d/%d (%d changes)\n\n", page+1, totalPages, total))
+	for _, entry := range entries {
+		text.WriteString(formatAuditEntry(entry))
+		text.WriteString("\n\n")
+	}
+
+	var paginationRow tele.Row
+	if page > 0 {
+		paginationRow = append(paginationRow, menu.Data("⬅️ Newer", "audit", strconv.Itoa(page-1)))
+	}
+	paginationRow = append(paginationRow, menu.Data(fmt.Sprintf("📄 %d/%d", page+1, totalPages), "noop"))
+	if page < totalPages-1 {
+		paginationRow = append(paginationRow, menu.Data("Older ➡️", "audit", strconv.Itoa(page+1)))
+	}
+	menu.Inline(paginationRow, menu.Row(menu.Data("🏠 Main Menu", "menu")))
+
+	return b.editWithThread(c, text.String(), menu, tele.ModeMarkdown)
+}
+
+// formatAuditEntry formats an audit entry for the audit log screen
+func formatAuditEntry(entry domain.AuditEntry) string {
+	icon := map[string]string{
+		domain.AuditActionCreate: "➕",
+		domain.AuditActionUpdate: "✏️",
+		domain.AuditActionDelete: "🗑️",
+	}[entry.Action]
+
+	record := entry.After
+	if record == nil {
+		record = entry.Before
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("%s *%s* `%s`\n", icon, entry.Action, entry.Timestamp.Format("2006-01-02 15:04:05")))
+	if record != nil {
+		text.WriteString(fmt.Sprintf("`%s` %s in `%s`\n", record.Name, record.Type, entry.ZoneName))
+	}
+	if entry.Before != nil && entry.After != nil && entry.Before.Content != entry.After.Content {
+		text.WriteString(fmt.Sprintf("`%s` → `%s`\n", entry.Before.Content, entry.After.Content))
+	} else if record != nil {
+		text.WriteString(fmt.Sprintf("`%s`\n", record.Content))
+	}
+	text.WriteString(fmt.Sprintf("by %s via %s", entry.Actor, entry.Channel))
+	if entry.ApprovedBy != "" {
+		text.WriteString(fmt.Sprintf(", approved by %s", entry.ApprovedBy))
+	}
+	return text.String()
+}
+
+// undoRows returns a row with an Undo button if the change was logged
+func undoRows(menu *tele.ReplyMarkup, change *usecase.ChangeRef) []tele.Row {
+	if change.ID == "" {
+		return nil
+	}
+	return []tele.Row{menu.Row(menu.Data("↩️ Undo", "undo", change.ID))}
+}
+
+// handleUndoChange rolls back a change from the audit log. Users can undo
+// their own changes; admins can undo any change.
+func (b *Bot) handleUndoChange(c tele.Context, userID int64, changeID string) error {
+	if b.auditStorage == nil {
+		return b.sendWithThread(c, "❌ Audit log not configured.", tele.ModeMarkdown)
+	}
+
+	entry, err := b.auditStorage.GetAuditEntry(changeID)
+	if err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ Error: %v", err), tele.ModeMarkdown)
+	}
+
+	ownChange := entry.Channel == domain.ChannelTelegram && entry.ActorID == strconv.FormatInt(userID, 10)
+	if !ownChange && !b.isAdmin(userID) {
+		return b.sendWithThread(c, "⛔ You can only undo your own changes.", tele.ModeMarkdown)
+	}
+
+	if parked, err := b.requestApproval(c, usecase.PendingChange{Kind: usecase.ChangeRollback, ZoneName: entry.ZoneName, Rollback: entry}); parked {
+		return err
+	}
+
+	restored, err := b.dnsUsecase.RollbackChange(b.actorContext(c), *entry)
+	if err != nil {
+		if errors.Is(err, domain.ErrRollbackConflict) {
+			return b.sendWithThread(c, fmt.Sprintf("⚠️ Cannot undo: %v", err), tele.ModeMarkdown)
+		}
+		return b.sendWithThread(c, recordErrorText("undoing change", err), tele.ModeMarkdown)
+	}
+
+	var text string
+	switch {
+	case restored == nil:
+		text = fmt.Sprintf("↩️ *Change Undone*\n\nRemoved `%s` %s `%s`", entry.After.Name, entry.After.Type, entry.After.Content)
+	case entry.After == nil:
+		text = fmt.Sprintf("↩️ *Change Undone*\n\nRestored `%s` %s `%s`", restored.Name, restored.Type, restored.Content)
+	default:
+		text = fmt.Sprintf("↩️ *Change Undone*\n\nReverted `%s` %s to `%s`", restored.Name, restored.Type, restored.Content)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(menu.Row(menu.Data("🏠 Main Menu", "menu")))
+	return b.editWithThread(c, text, menu, tele.ModeMarkdown)
+}
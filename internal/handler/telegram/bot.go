@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -23,6 +24,10 @@ type APIKeyStorage interface {
 	AddAPIKey(key string) error
 	RemoveAPIKey(key string) error
 	IsValidAPIKey(key string) bool
+	GetAPIKeyAccount(key string) string
+	SetAPIKeyAccount(key, account string) error
+	GetAPIKeyScope(key string) storage.APIKeyScope
+	SetAPIKeyScope(key string, scope storage.APIKeyScope) error
 }
 
 // ConfigStorage defines the interface for configuration storage
@@ -59,11 +64,31 @@ type AllowedUserStorage interface {
 	AddAllowedUser(userID int64, scope storage.AccessScope) error
 	RemoveAllowedUser(userID int64) error
 	IsUserAllowed(userID int64, chatID int64, threadID int) bool
+	GetUserAccount(userID int64) string
+	SetUserAccount(userID int64, account string) error
+	GetUserRole(userID int64) string
+	GetUserZones(userID int64) []string
+	SetUserRole(userID int64, role string, zones []string) error
+}
+
+// AuditStorage defines the interface for reading the audit log
+type AuditStorage interface {
+	GetAuditEntry(id string) (*domain.AuditEntry, error)
+	QueryAuditEntries(filter domain.AuditFilter) ([]domain.AuditEntry, int, error)
+}
+
+// RecordCache gives admins control over the cache of Cloudflare data
+type RecordCache interface {
+	Refresh(ctx context.Context, zoneName string) error
+	Stats() domain.CacheStats
 }
 
 // Bot implements handler.BotHandler for Telegram with button-based UI
 type Bot struct {
 	dnsUsecase        usecase.DNSUsecase
+	zoneImportUsecase usecase.ZoneImportUsecase
+	snapshotUsecase   usecase.SnapshotUsecase
+	approvalUsecase   usecase.ApprovalUsecase
 	bot               *tele.Bot
 	token             string
 	allowedIDs        map[int64]bool
@@ -73,10 +98,13 @@ type Bot struct {
 	mcpHTTPController MCPHTTPServerController
 	pendingReqStorage PendingRequestStorage
 	allowedUserStorage AllowedUserStorage
+	auditStorage      AuditStorage
+	cache             RecordCache
+	approvalChats     *approvalChats
 }
 
 // NewBot creates a new Telegram bot handler
-func NewBot(dnsUsecase usecase.DNSUsecase, token string, allowedUsers []int64, apiKeyStorage APIKeyStorage, configStorage ConfigStorage, mcpHTTPController MCPHTTPServerController, pendingReqStorage PendingRequestStorage, allowedUserStorage AllowedUserStorage) *Bot {
+func NewBot(dnsUsecase usecase.DNSUsecase, zoneImportUsecase usecase.ZoneImportUsecase, snapshotUsecase usecase.SnapshotUsecase, approvalUsecase usecase.ApprovalUsecase, token string, allowedUsers []int64, apiKeyStorage APIKeyStorage, configStorage ConfigStorage, mcpHTTPController MCPHTTPServerController, pendingReqStorage PendingRequestStorage, allowedUserStorage AllowedUserStorage, auditStorage AuditStorage, cache RecordCache) *Bot {
 	allowedIDs := make(map[int64]bool)
 	for _, id := range allowedUsers {
 		allowedIDs[id] = true
@@ -84,6 +112,9 @@ func NewBot(dnsUsecase usecase.DNSUsecase, token string, allowedUsers []int64, a
 
 	return &Bot{
 		dnsUsecase:        dnsUsecase,
+		zoneImportUsecase: zoneImportUsecase,
+		snapshotUsecase:   snapshotUsecase,
+		approvalUsecase:   approvalUsecase,
 		token:             token,
 		allowedIDs:        allowedIDs,
 		stateManager:      NewStateManager(),
@@ -92,6 +123,9 @@ func NewBot(dnsUsecase usecase.DNSUsecase, token string, allowedUsers []int64, a
 		mcpHTTPController: mcpHTTPController,
 		pendingReqStorage: pendingReqStorage,
 		allowedUserStorage: allowedUserStorage,
+		auditStorage:      auditStorage,
+		cache:             cache,
+		approvalChats:     newApprovalChats(),
 	}
 }
 
@@ -131,14 +165,6 @@ func (b *Bot) Stop() error {
 	return nil
 }
 
-// isAuthorized checks if a user is authorized (backward compatibility)
-func (b *Bot) isAuthorized(userID int64) bool {
-	if len(b.allowedIDs) == 0 {
-		return true
-	}
-	return b.allowedIDs[userID]
-}
-
 // isAuthorizedWithScope checks if a user is authorized for a specific chat/thread scope
 func (b *Bot) isAuthorizedWithScope(userID int64, chatID int64, threadID int) bool {
 	// Check legacy allowed IDs first (backward compatibility)
@@ -187,6 +213,11 @@ func (b *Bot) setupHandlers() {
 				b.handleUnauthorizedUser(c)
 				return nil
 			}
+
+			// Check that the user's role allows the callback or command
+			if !b.checkRole(c) {
+				return nil
+			}
 			return next(c)
 		}
 	})
@@ -220,27 +251,44 @@ func (b *Bot) setupHandlers() {
 		return b.showMainMenu(c)
 	})
 
+	b.bot.Handle("/find", func(c tele.Context) error {
+		return b.handleFindCommand(c)
+	})
+
+	// Text commands for experienced users
+	b.bot.Handle("/help", func(c tele.Context) error {
+		return b.handleHelpCommand(c)
+	})
+
+	b.bot.Handle("/add", func(c tele.Context) error {
+		return b.handleAddCommand(c)
+	})
+
+	b.bot.Handle("/set", func(c tele.Context) error {
+		return b.handleSetCommand(c)
+	})
+
+	b.bot.Handle("/del", func(c tele.Context) error {
+		return b.handleDeleteCommand(c)
+	})
+
+	b.bot.Handle("/ls", func(c tele.Context) error {
+		return b.handleListCommand(c)
+	})
+
+	b.bot.Handle("/approvals", func(c tele.Context) error {
+		return b.showPendingChanges(c)
+	})
+
 	b.bot.Handle("/requests", func(c tele.Context) error {
-		// Only admins can use this command
-		if !b.isAuthorized(c.Sender().ID) {
-			return c.Send("⛔ You are not authorized to use this command.", tele.ModeMarkdown)
-		}
 		return b.showPendingRequests(c)
 	})
 
 	b.bot.Handle("/adduser", func(c tele.Context) error {
-		// Only admins can use this command
-		if !b.isAuthorized(c.Sender().ID) {
-			return c.Send("⛔ You are not authorized to use this command.", tele.ModeMarkdown)
-		}
 		return b.handleAddUserCommand(c)
 	})
 
 	b.bot.Handle("/users", func(c tele.Context) error {
-		// Only admins can use this command
-		if !b.isAuthorized(c.Sender().ID) {
-			return c.Send("⛔ You are not authorized to use this command.", tele.ModeMarkdown)
-		}
 		return b.showAllowedUsers(c)
 	})
 
@@ -274,6 +322,11 @@ func (b *Bot) setupHandlers() {
 		return b.handleTextMessage(c)
 	})
 
+	// Handle zone file uploads
+	b.bot.Handle(tele.OnDocument, func(c tele.Context) error {
+		return b.handleDocument(c)
+	})
+
 	// Handle callback data with pattern matching
 	b.bot.Handle(tele.OnCallback, func(c tele.Context) error {
 		data := c.Data()
@@ -290,7 +343,7 @@ func (b *Bot) notifyAdminOnStartup() {
 
 	message := "🤖 *Bot Started*\n\nCF DNS Bot is now online and ready to use."
 
-	for userID := range b.allowedIDs {
+	for _, userID := range b.adminIDs() {
 		b.sendMessage(userID, message)
 	}
 }
@@ -354,7 +407,23 @@ func (b *Bot) handleTextMessage(c tele.Context) error {
 		}
 	case StepInputMCPHTTPPort:
 		return b.handleMCPHTTPPortChange(c, chatID, userID, c.Text())
+	case StepInputBulkPattern:
+		return b.handleBulkPattern(c, userID, c.Text())
+	case StepEditRecordComment:
+		return b.handleInputRecordComment(c, userID, c.Text())
+	case StepEditRecordTags:
+		return b.handleInputRecordTags(c, userID, c.Text())
+	case StepInputRecordSearch:
+		return b.handleRecordSearch(c, userID, c.Text())
+	case StepInputKeyZones:
+		return b.handleKeyZones(c, userID, c.Text())
+	case StepInputKeyRecords:
+		return b.handleKeyRecords(c, userID, c.Text())
 	default:
+		// Unknown commands get the list of text commands
+		if strings.HasPrefix(c.Text(), "/") {
+			return b.handleHelpCommand(c)
+		}
 		return b.showMainMenu(c)
 	}
 
@@ -424,6 +493,72 @@ func (b *Bot) handleCallback(c tele.Context) error {
 		return b.showAPIKeysMenu(c)
 	case "users":
 		return b.showAllowedUsers(c)
+	case "snapshots":
+		if len(parts) >= 2 {
+			return b.showSnapshots(c, userID, parts[1])
+		}
+	case "snap_take":
+		if len(parts) >= 2 {
+			return b.handleTakeSnapshot(c, userID, parts[1])
+		}
+	case "snap_view":
+		if len(parts) >= 3 {
+			return b.showSnapshotDiff(c, userID, parts[1], parts[2])
+		}
+	case "snap_rec":
+		if len(parts) >= 2 {
+			idx, _ := strconv.Atoi(parts[1])
+			return b.handleRestoreSnapshotRecord(c, userID, idx)
+		}
+	case "snap_all":
+		return b.handleRestoreSnapshotZone(c, userID)
+	case "snap_all_ok":
+		return b.handleRestoreSnapshotZoneConfirm(c, userID)
+	case "bulk":
+		if len(parts) >= 2 {
+			return b.showBulkMenu(c, userID, parts[1])
+		}
+	case "bulk_type":
+		if len(parts) >= 2 {
+			return b.handleBulkType(c, userID, parts[1])
+		}
+	case "bulk_act":
+		if len(parts) >= 2 {
+			return b.handleBulkAction(c, userID, parts[1])
+		}
+	case "bulk_ok":
+		if len(parts) >= 2 {
+			return b.handleBulkConfirm(c, userID, parts[1])
+		}
+	case "cmd_ok":
+		if len(parts) >= 2 {
+			return b.handleCommandConfirm(c, userID, parts[1])
+		}
+	case "cmd_cancel":
+		if len(parts) >= 2 {
+			return b.handleCommandCancel(c, userID, parts[1])
+		}
+	case "approve_change":
+		if len(parts) >= 2 {
+			return b.handleApproveChange(c, parts[1])
+		}
+	case "reject_change":
+		if len(parts) >= 2 {
+			return b.handleRejectChange(c, parts[1])
+		}
+	case "undo":
+		if len(parts) >= 2 {
+			return b.handleUndoChange(c, userID, parts[1])
+		}
+	case "audit":
+		if len(parts) >= 2 {
+			page, _ := strconv.Atoi(parts[1])
+			return b.showAuditLog(c, userID, page)
+		}
+	case "search":
+		if len(parts) >= 2 {
+			return b.showSearchPrompt(c, userID, parts[1])
+		}
 	case "view_rec":
 		if len(parts) >= 4 {
 			return b.handleViewRecord(c, chatID, userID, messageID, parts[1], parts[2], parts[3])
@@ -434,8 +569,18 @@ func (b *Bot) handleCallback(c tele.Context) error {
 		}
 	case "refresh":
 		if len(parts) >= 3 && parts[1] == "zone" {
+			// Refresh bypasses the cache so changes made elsewhere show up
+			if b.cache != nil {
+				if err := b.cache.Refresh(b.actorContext(c), parts[2]); err != nil {
+					log.Printf("[Callback] cache refresh failed: %v", err)
+				}
+			}
 			return b.refreshZoneRecords(c, chatID, userID, messageID, parts[2], 0)
 		}
+	case "cache":
+		return b.showCacheStats(c, userID)
+	case "cache_clear":
+		return b.handleCacheClear(c, userID)
 	case "create_in_zone":
 		if len(parts) >= 2 {
 			zoneName := parts[1]
@@ -450,7 +595,25 @@ func (b *Bot) handleCallback(c tele.Context) error {
 	case "mcphttp_status":
 		return b.handleMCPHTTPStatus(c)
 	case "apikey_generate":
-		return b.handleAPIKeyGenerate(c)
+		return b.startKeyScope(c, userID)
+	case "key_scope":
+		return b.showKeyScope(c, userID)
+	case "key_zones":
+		return b.handleKeyZonesInput(c, userID)
+	case "key_records":
+		return b.handleKeyRecordsInput(c, userID)
+	case "key_ro":
+		return b.handleKeyReadOnly(c, userID)
+	case "key_tools":
+		return b.showKeyTools(c, userID)
+	case "key_tool":
+		if len(parts) >= 2 {
+			return b.handleKeyTool(c, userID, parts[1])
+		}
+	case "key_tool_all":
+		return b.handleKeyToolAll(c, userID)
+	case "key_create":
+		return b.handleAPIKeyGenerate(c, userID)
 	case "apikey_list":
 		return b.handleAPIKeyList(c)
 	case "apikey_delete":
@@ -461,10 +624,22 @@ func (b *Bot) handleCallback(c tele.Context) error {
 		}
 	case "request_access":
 		return b.handleRequestAccess(c, userID)
-	case "approve_request":
+	case "approve_request", "user_role":
+		if len(parts) >= 2 {
+			return b.showUserRole(c, userID, parts[1])
+		}
+	case "user_role_set":
+		if len(parts) >= 3 {
+			return b.handleUserRole(c, userID, parts[1], parts[2])
+		}
+	case "user_zone":
 		if len(parts) >= 2 {
-			return b.handleApproveRequest(c, parts[1])
+			return b.handleUserZone(c, userID, parts[1])
 		}
+	case "user_zone_all":
+		return b.handleUserZoneAll(c, userID)
+	case "user_zone_ok":
+		return b.handleUserZoneSave(c, userID)
 	case "reject_request":
 		if len(parts) >= 2 {
 			return b.handleRejectRequest(c, parts[1])
@@ -473,6 +648,24 @@ func (b *Bot) handleCallback(c tele.Context) error {
 		if len(parts) >= 2 {
 			return b.handleRemoveUser(c, parts[1])
 		}
+	case "user_acct":
+		if len(parts) >= 2 {
+			return b.showUserAccount(c, userID, parts[1])
+		}
+	case "user_acct_set":
+		if len(parts) >= 3 {
+			return b.handleUserAccount(c, userID, parts[1], parts[2])
+		}
+	case "apikey_acct":
+		return b.showAPIKeyAccountMenu(c, userID)
+	case "apikey_acct_key":
+		if len(parts) >= 2 {
+			return b.showAPIKeyAccount(c, userID, parts[1])
+		}
+	case "apikey_acct_set":
+		if len(parts) >= 3 {
+			return b.handleAPIKeyAccount(c, userID, parts[1], parts[2])
+		}
 	case "noop":
 		// Do nothing for pagination display button
 		return nil
@@ -484,6 +677,14 @@ func (b *Bot) handleCallback(c tele.Context) error {
 		if len(parts) >= 4 {
 			return b.handleDeleteRecord(c, chatID, userID, messageID, parts[1], parts[2], parts[3])
 		}
+	case "edit_comment":
+		if len(parts) >= 4 {
+			return b.handleEditComment(c, userID, parts[1], parts[2], parts[3])
+		}
+	case "edit_tags":
+		if len(parts) >= 4 {
+			return b.handleEditTags(c, userID, parts[1], parts[2], parts[3])
+		}
 	case "back":
 		if len(parts) > 1 {
 			return b.handleBackNavigation(c, chatID, userID, messageID, parts[1])
@@ -499,6 +700,23 @@ func (b *Bot) handleCallback(c tele.Context) error {
 		if len(parts) > 1 {
 			return b.handleEditProxiedSelected(c, chatID, userID, messageID, parts[1] == "true")
 		}
+	case "import_zone":
+		if len(parts) > 1 {
+			return b.handleImportZone(c, userID, parts[1])
+		}
+	case "export_zone":
+		if len(parts) > 1 {
+			return b.handleExportZone(c, parts[1])
+		}
+	case "export_fmt":
+		if len(parts) > 2 {
+			return b.handleExportFormatSelected(c, parts[1], parts[2])
+		}
+	case "import_confirm":
+		return b.handleImportConfirm(c, userID)
+	case "import_cancel":
+		b.stateManager.ClearState(userID)
+		return b.editWithThread(c, "❌ Import cancelled.", tele.ModeMarkdown)
 	}
 
 	return nil
@@ -582,34 +800,40 @@ func (b *Bot) showMainMenu(c tele.Context) error {
 	chatID := c.Chat().ID
 	isPrivateChat := chatID > 0
 
+	rows := []tele.Row{menu.Row(btnManage), menu.Row(btnMCPHTTP)}
 	if isPrivateChat {
 		// In private chat, show Users button for admin
 		btnUsers := menu.Data("👥 Users", "users")
-		menu.Inline(menu.Row(btnManage), menu.Row(btnMCPHTTP), menu.Row(btnUsers))
-	} else {
-		// In group/thread, only show basic buttons
-		menu.Inline(menu.Row(btnManage), menu.Row(btnMCPHTTP))
+		btnAudit := menu.Data("📜 Audit Log", "audit", "0")
+		btnCache := menu.Data("🗄️ Cache", "cache")
+		rows = append(rows, menu.Row(btnUsers), menu.Row(btnAudit, btnCache))
 	}
+	menu.Inline(b.permittedRows(c.Sender().ID, rows...)...)
 
-	return b.sendWithThread(c, "*🏠 Main Menu*\n\nWhat would you like to do?", menu, tele.ModeMarkdown)
+	return b.sendWithThread(c, "*🏠 Main Menu*\n\nWhat would you like to do?\n\n_Tip: /help lists text commands such as /add and /ls._", menu, tele.ModeMarkdown)
 }
 
 // showZones shows all zones
 func (b *Bot) showZones(c tele.Context) error {
-	ctx := context.Background()
+	ctx := b.actorContext(c)
 	zones, err := b.dnsUsecase.ListZones(ctx)
 	if err != nil {
-		return b.sendWithThread(c, fmt.Sprintf("❌ Error: %v", err), tele.ModeMarkdown)
+		return b.sendWithThread(c, recordErrorText("loading zones", err), tele.ModeMarkdown)
 	}
 
 	if len(zones) == 0 {
 		return b.sendWithThread(c, "📭 No zones found.", tele.ModeMarkdown)
 	}
 
+	withAccount := spansAccounts(zones)
 	var text strings.Builder
 	text.WriteString("*📋 Your Zones:*\n\n")
 	for i, zone := range zones {
-		text.WriteString(fmt.Sprintf("%d. `%s`\n", i+1, zone.Name))
+		text.WriteString(fmt.Sprintf("%d. `%s`", i+1, zone.Name))
+		if withAccount {
+			text.WriteString(fmt.Sprintf(" · %s", zone.Account))
+		}
+		text.WriteString("\n")
 	}
 
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
@@ -621,10 +845,10 @@ func (b *Bot) showZones(c tele.Context) error {
 
 // startCreateRecord starts the create record flow
 func (b *Bot) startCreateRecord(c tele.Context) error {
-	ctx := context.Background()
+	ctx := b.actorContext(c)
 	zones, err := b.dnsUsecase.ListZones(ctx)
 	if err != nil {
-		return b.sendWithThread(c, fmt.Sprintf("❌ Error: %v", err), tele.ModeMarkdown)
+		return b.sendWithThread(c, recordErrorText("loading zones", err), tele.ModeMarkdown)
 	}
 
 	if len(zones) == 0 {
@@ -634,14 +858,15 @@ func (b *Bot) startCreateRecord(c tele.Context) error {
 	userID := c.Sender().ID
 	b.stateManager.SetStep(userID, StepSelectZoneForCreate)
 
+	withAccount := spansAccounts(zones)
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
 	var rows []tele.Row
 	for i := 0; i < len(zones); i += 2 {
 		var row tele.Row
-		btn1 := menu.Data(zones[i].Name, "select_zone_create", zones[i].Name)
+		btn1 := menu.Data(zoneLabel(zones[i], withAccount), "select_zone_create", zones[i].Name)
 		row = append(row, btn1)
 		if i+1 < len(zones) {
-			btn2 := menu.Data(zones[i+1].Name, "select_zone_create", zones[i+1].Name)
+			btn2 := menu.Data(zoneLabel(zones[i+1], withAccount), "select_zone_create", zones[i+1].Name)
 			row = append(row, btn2)
 		}
 		rows = append(rows, row)
@@ -657,16 +882,8 @@ func (b *Bot) handleZoneSelectedForCreate(c tele.Context, chatID int64, userID i
 	b.stateManager.SetData(userID, "zone", zoneName)
 	b.stateManager.SetStep(userID, StepSelectRecordType)
 
-	types := []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA"}
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
-	var rows []tele.Row
-	for i := 0; i < len(types); i += 4 {
-		var row tele.Row
-		for j := i; j < i+4 && j < len(types); j++ {
-			row = append(row, menu.Data(types[j], "select_type", types[j]))
-		}
-		rows = append(rows, row)
-	}
+	rows := recordTypeRows(menu, "select_type")
 	rows = append(rows, menu.Row(menu.Data("◀️ Back", "back", "create")))
 	rows = append(rows, menu.Row(menu.Data("❌ Cancel", "cancel_create")))
 	menu.Inline(rows...)
@@ -707,13 +924,30 @@ func (b *Bot) handleInputRecordName(c tele.Context, chatID int64, userID int64,
 	recordType, _ := b.stateManager.GetData(userID, "type")
 
 	return b.editWithThread(c, fmt.Sprintf(
-		"*➕ Create DNS Record*\n\nZone: `%s`\nType: `%s`\nName: `%s`\n\nStep 4/6: Enter the content (IP for A/AAAA, domain for CNAME, etc.):",
-		zone, recordType, name,
+		"*➕ Create DNS Record*\n\nZone: `%s`\nType: `%s`\nName: `%s`\n\nStep 4/6: Enter %s:",
+		zone, recordType, name, contentHint(b.getStateData(userID, "type")),
 	), menu, tele.ModeMarkdown)
 }
 
 // handleInputRecordContent handles record content input
 func (b *Bot) handleInputRecordContent(c tele.Context, chatID int64, userID int64, messageID int, content string) error {
+	recordType := b.getStateData(userID, "type")
+	content = strings.TrimSpace(content)
+
+	// MX content is entered as "priority host"
+	if recordType == "MX" {
+		host, priority, ok := splitMXContent(content)
+		if !ok {
+			return b.sendWithThread(c, "❌ Enter the priority and the mail server, e.g. `10 mail.example.com`.", tele.ModeMarkdown)
+		}
+		b.stateManager.SetData(userID, "priority", priority)
+		content = host
+	}
+
+	if err := domain.ValidateContent(recordType, content); err != nil {
+		return b.sendWithThread(c, recordErrorText("", err)+"\n\nPlease enter the content again.", tele.ModeMarkdown)
+	}
+
 	b.stateManager.SetData(userID, "content", content)
 	b.stateManager.SetStep(userID, StepInputRecordTTL)
 
@@ -733,7 +967,6 @@ func (b *Bot) handleInputRecordContent(c tele.Context, chatID int64, userID int6
 	)
 
 	zone, _ := b.stateManager.GetData(userID, "zone")
-	recordType, _ := b.stateManager.GetData(userID, "type")
 	name, _ := b.stateManager.GetData(userID, "name")
 
 	return b.editWithThread(c, fmt.Sprintf(
@@ -750,10 +983,7 @@ func (b *Bot) handleTTLSelected(c tele.Context, chatID int64, userID int64, mess
 
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
 	menu.Inline(
-		menu.Row(
-			menu.Data("✅ Yes (Proxied)", "proxied", "true"),
-			menu.Data("❌ No (DNS Only)", "proxied", "false"),
-		),
+		proxiedRow(menu, b.getStateData(userID, "type"), "proxied"),
 		menu.Row(menu.Data("◀️ Back", "back", "ttl"), menu.Data("❌ Cancel", "cancel_create")),
 	)
 
@@ -798,7 +1028,7 @@ func (b *Bot) handleProxiedSelected(c tele.Context, chatID int64, userID int64,
 
 // handleConfirmCreate confirms and creates the record
 func (b *Bot) handleConfirmCreate(c tele.Context, chatID int64, userID int64, messageID int) error {
-	ctx := context.Background()
+	ctx, change := usecase.WithChangeRef(b.actorContext(c))
 
 	zone, _ := b.stateManager.GetData(userID, "zone")
 	recordType, _ := b.stateManager.GetData(userID, "type")
@@ -815,19 +1045,27 @@ func (b *Bot) handleConfirmCreate(c tele.Context, chatID int64, userID int64, me
 		TTL:      ttl.(int),
 		Proxied:  proxied.(bool),
 	}
+	if priority, ok := b.stateManager.GetData(userID, "priority"); ok {
+		input.Priority = priority.(*uint16)
+	}
+
+	if parked, err := b.requestApproval(c, usecase.PendingChange{Kind: usecase.ChangeCreate, ZoneName: input.ZoneName, Create: &input}); parked {
+		b.stateManager.ClearState(userID)
+		return err
+	}
 
 	record, err := b.dnsUsecase.CreateRecord(ctx, input)
 	if err != nil {
-		if err == domain.ErrDuplicateRecord {
+		if errors.Is(err, domain.ErrDuplicateRecord) {
 			return b.editWithThread(c, fmt.Sprintf("❌ Record `%s` already exists. Use *Manage Records* to update it.", name.(string)), tele.ModeMarkdown)
 		}
-		return b.editWithThread(c, fmt.Sprintf("❌ Error creating record: %v", err), tele.ModeMarkdown)
+		return b.editWithThread(c, recordErrorText("creating record", err), tele.ModeMarkdown)
 	}
 
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
-	menu.Inline(
-		menu.Row(menu.Data("➕ Create Another", "create"), menu.Data("🏠 Main Menu", "menu")),
-	)
+	rows := undoRows(menu, change)
+	rows = append(rows, menu.Row(menu.Data("➕ Create Another", "create"), menu.Data("🏠 Main Menu", "menu")))
+	menu.Inline(rows...)
 
 	b.stateManager.ClearState(userID)
 
@@ -839,10 +1077,10 @@ func (b *Bot) handleConfirmCreate(c tele.Context, chatID int64, userID int64, me
 
 // startManageRecords starts the manage records flow
 func (b *Bot) startManageRecords(c tele.Context) error {
-	ctx := context.Background()
+	ctx := b.actorContext(c)
 	zones, err := b.dnsUsecase.ListZones(ctx)
 	if err != nil {
-		return b.sendWithThread(c, fmt.Sprintf("❌ Error: %v", err), tele.ModeMarkdown)
+		return b.sendWithThread(c, recordErrorText("loading zones", err), tele.ModeMarkdown)
 	}
 
 	if len(zones) == 0 {
@@ -852,14 +1090,15 @@ func (b *Bot) startManageRecords(c tele.Context) error {
 	userID := c.Sender().ID
 	b.stateManager.SetStep(userID, StepSelectZoneForManage)
 
+	withAccount := spansAccounts(zones)
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
 	var rows []tele.Row
 	for i := 0; i < len(zones); i += 2 {
 		var row tele.Row
-		btn1 := menu.Data(zones[i].Name, "select_zone_manage", zones[i].Name)
+		btn1 := menu.Data(zoneLabel(zones[i], withAccount), "select_zone_manage", zones[i].Name)
 		row = append(row, btn1)
 		if i+1 < len(zones) {
-			btn2 := menu.Data(zones[i+1].Name, "select_zone_manage", zones[i+1].Name)
+			btn2 := menu.Data(zoneLabel(zones[i+1], withAccount), "select_zone_manage", zones[i+1].Name)
 			row = append(row, btn2)
 		}
 		rows = append(rows, row)
@@ -877,17 +1116,18 @@ func (b *Bot) handleZoneSelectedForManage(c tele.Context, chatID int64, userID i
 
 // refreshZoneRecords refreshes the zone records display with pagination
 func (b *Bot) refreshZoneRecords(c tele.Context, chatID int64, userID int64, messageID int, zoneName string, page int) error {
-	ctx := context.Background()
-	records, err := b.dnsUsecase.ListRecords(ctx, zoneName)
+	ctx := b.actorContext(c)
+	records, err := b.dnsUsecase.ListRecords(ctx, zoneName, domain.RecordFilter{})
 	if err != nil {
-		return b.editWithThread(c, fmt.Sprintf("❌ Error loading records: %v", err), tele.ModeMarkdown)
+		return b.editWithThread(c, recordErrorText("loading records", err), tele.ModeMarkdown)
 	}
 
 	if len(records) == 0 {
 		menu := &tele.ReplyMarkup{ResizeKeyboard: true}
-		menu.Inline(
+		menu.Inline(b.permittedRows(userID,
 			menu.Row(menu.Data("➕ Create Record", "create_in_zone", zoneName), menu.Data("◀️ Back", "manage")),
-		)
+			menu.Row(menu.Data("📥 Import Zone File", "import_zone", zoneName)),
+		)...)
 		return b.editWithThread(c, fmt.Sprintf("📭 No records found in `%s`.", zoneName), menu, tele.ModeMarkdown)
 	}
 
@@ -941,10 +1181,12 @@ func (b *Bot) refreshZoneRecords(c tele.Context, chatID int64, userID int64, mes
 	}
 	rows = append(rows, paginationRow)
 
-	rows = append(rows, menu.Row(menu.Data("🔄 Refresh", "refresh", "zone", zoneName), menu.Data("➕ Create", "create_in_zone", zoneName)))
+	rows = append(rows, menu.Row(menu.Data("🔄 Refresh", "refresh", "zone", zoneName), menu.Data("➕ Create", "create_in_zone", zoneName), menu.Data("🧰 Bulk Actions", "bulk", zoneName)))
+	rows = append(rows, menu.Row(menu.Data("📥 Import", "import_zone", zoneName), menu.Data("📤 Export", "export_zone", zoneName), menu.Data("🕓 Snapshots", "snapshots", zoneName)))
+	rows = append(rows, menu.Row(menu.Data("🔎 Search in zone", "search", zoneName)))
 	rows = append(rows, menu.Row(menu.Data("◀️ Back", "manage"), menu.Data("🏠 Menu", "menu")))
 
-	menu.Inline(rows...)
+	menu.Inline(b.permittedRows(userID, rows...)...)
 	return b.editWithThread(c, text.String(), menu, tele.ModeMarkdown)
 }
 
@@ -954,16 +1196,16 @@ func (b *Bot) handleInputRecordTTL(c tele.Context, chatID int64, userID int64, t
 	if err != nil {
 		return b.sendWithThread(c, "❌ Invalid TTL. Please enter a number.", tele.ModeMarkdown)
 	}
+	if err := domain.ValidateTTL(ttl); err != nil {
+		return b.sendWithThread(c, recordErrorText("", err), tele.ModeMarkdown)
+	}
 
 	b.stateManager.SetData(userID, "ttl", ttl)
 	b.stateManager.SetStep(userID, StepInputRecordProxied)
 
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
 	menu.Inline(
-		menu.Row(
-			menu.Data("✅ Yes (Proxied)", "proxied", "true"),
-			menu.Data("❌ No (DNS Only)", "proxied", "false"),
-		),
+		proxiedRow(menu, b.getStateData(userID, "type"), "proxied"),
 		menu.Row(menu.Data("❌ Cancel", "cancel_create")),
 	)
 
@@ -1016,12 +1258,16 @@ func (b *Bot) showMCPHTTPMenu(c tele.Context) error {
 // showAPIKeysMenu shows the API key management menu
 func (b *Bot) showAPIKeysMenu(c tele.Context) error {
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
-	menu.Inline(
+	rows := []tele.Row{
 		menu.Row(menu.Data("➕ Generate New Key", "apikey_generate")),
 		menu.Row(menu.Data("📋 List Keys", "apikey_list")),
 		menu.Row(menu.Data("🗑️ Delete Key", "apikey_delete")),
-		menu.Row(menu.Data("◀️ Back to MCP HTTP Server", "mcphttp")),
-	)
+	}
+	if accounts, err := b.accountNames(); err == nil && len(accounts) > 1 && b.canManageAccounts(c.Sender().ID) {
+		rows = append(rows, menu.Row(menu.Data("🏢 Key Accounts", "apikey_acct")))
+	}
+	rows = append(rows, menu.Row(menu.Data("◀️ Back to MCP HTTP Server", "mcphttp")))
+	menu.Inline(rows...)
 
 	return b.sendWithThread(c, "*🔑 MCP API Key Management*\n\nManage API keys for MCP server access:", menu, tele.ModeMarkdown)
 }
@@ -1097,10 +1343,10 @@ func (b *Bot) handleViewRecord(c tele.Context, chatID int64, userID int64, messa
 	page, _ := strconv.Atoi(pageStr)
 	idx, _ := strconv.Atoi(idxStr)
 
-	ctx := context.Background()
-	records, err := b.dnsUsecase.ListRecords(ctx, zoneName)
+	ctx := b.actorContext(c)
+	records, err := b.dnsUsecase.ListRecords(ctx, zoneName, domain.RecordFilter{})
 	if err != nil {
-		return b.editWithThread(c, fmt.Sprintf("❌ Error loading records: %v", err), tele.ModeMarkdown)
+		return b.editWithThread(c, recordErrorText("loading records", err), tele.ModeMarkdown)
 	}
 
 	recordsPerPage := 10
@@ -1113,11 +1359,12 @@ func (b *Bot) handleViewRecord(c tele.Context, chatID int64, userID int64, messa
 	r := records[startIdx+idx]
 
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
-	menu.Inline(
+	menu.Inline(b.permittedRows(userID,
 		menu.Row(menu.Data("✏️ Edit", "edit_rec", zoneName, pageStr, idxStr), menu.Data("🗑️ Delete", "delete_rec", zoneName, pageStr, idxStr)),
+		menu.Row(menu.Data("💬 Comment", "edit_comment", zoneName, pageStr, idxStr), menu.Data("🏷️ Tags", "edit_tags", zoneName, pageStr, idxStr)),
 		menu.Row(menu.Data("◀️ Back to List", "page", zoneName, pageStr)),
 		menu.Row(menu.Data("🏠 Main Menu", "menu")),
-	)
+	)...)
 
 	proxiedStr := "❌ No"
 	if r.Proxied {
@@ -1125,8 +1372,8 @@ func (b *Bot) handleViewRecord(c tele.Context, chatID int64, userID int64, messa
 	}
 
 	return b.editWithThread(c, fmt.Sprintf(
-		"*📄 Record Details*\n\nZone: `%s`\nName: `%s`\nType: `%s`\nContent: `%s`\nTTL: `%d`\nProxied: `%s`\nRecord ID: `%s`",
-		zoneName, r.Name, r.Type, r.Content, r.TTL, proxiedStr, r.ID,
+		"*📄 Record Details*\n\nZone: `%s`\nName: `%s`\nType: `%s`\nContent: `%s`\nTTL: `%d`\nProxied: `%s`\n%sRecord ID: `%s`",
+		zoneName, r.Name, r.Type, r.Content, r.TTL, proxiedStr, recordMetaText(r), r.ID,
 	), menu, tele.ModeMarkdown)
 }
 
@@ -1141,16 +1388,8 @@ func (b *Bot) handleCreateInZone(c tele.Context, chatID int64, userID int64, zon
 	b.stateManager.SetData(userID, "zone", zoneName)
 	b.stateManager.SetStep(userID, StepSelectRecordType)
 
-	types := []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA"}
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
-	var rows []tele.Row
-	for i := 0; i < len(types); i += 4 {
-		var row tele.Row
-		for j := i; j < i+4 && j < len(types); j++ {
-			row = append(row, menu.Data(types[j], "select_type", types[j]))
-		}
-		rows = append(rows, row)
-	}
+	rows := recordTypeRows(menu, "select_type")
 	rows = append(rows, menu.Row(menu.Data("◀️ Back", "back", "create")))
 	rows = append(rows, menu.Row(menu.Data("❌ Cancel", "cancel_create")))
 	menu.Inline(rows...)
@@ -1215,11 +1454,16 @@ func (b *Bot) handleMCPHTTPStatus(c tele.Context) error {
 	), menu, tele.ModeMarkdown)
 }
 
-// handleAPIKeyGenerate generates a new API key
-func (b *Bot) handleAPIKeyGenerate(c tele.Context) error {
+// handleAPIKeyGenerate generates a new API key with the picked scope
+func (b *Bot) handleAPIKeyGenerate(c tele.Context, adminID int64) error {
 	if b.apiKeyStorage == nil {
 		return b.sendWithThread(c, "❌ API key storage not configured.", tele.ModeMarkdown)
 	}
+	scope, ok := b.keyScope(adminID)
+	if !ok {
+		return b.editWithThread(c, "❌ Key setup expired. Please generate the key again.", tele.ModeMarkdown)
+	}
+	b.stateManager.DeleteData(adminID, "key_scope")
 
 	key, err := b.generateRandomKey()
 	if err != nil {
@@ -1230,6 +1474,21 @@ func (b *Bot) handleAPIKeyGenerate(c tele.Context) error {
 		return b.sendWithThread(c, fmt.Sprintf("❌ Error saving key: %v", err), tele.ModeMarkdown)
 	}
 
+	if err := b.apiKeyStorage.SetAPIKeyScope(key, scope); err != nil {
+		b.apiKeyStorage.RemoveAPIKey(key)
+		return b.sendWithThread(c, fmt.Sprintf("❌ Error saving key: %v", err), tele.ModeMarkdown)
+	}
+
+	// Keys made by a user restricted to an account get the same restriction
+	if b.allowedUserStorage != nil {
+		if account := b.allowedUserStorage.GetUserAccount(c.Sender().ID); account != "" {
+			if err := b.apiKeyStorage.SetAPIKeyAccount(key, account); err != nil {
+				b.apiKeyStorage.RemoveAPIKey(key)
+				return b.sendWithThread(c, fmt.Sprintf("❌ Error saving key: %v", err), tele.ModeMarkdown)
+			}
+		}
+	}
+
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
 	menu.Inline(
 		menu.Row(menu.Data("➕ Generate Another", "apikey_generate")),
@@ -1238,8 +1497,8 @@ func (b *Bot) handleAPIKeyGenerate(c tele.Context) error {
 	)
 
 	return b.sendWithThread(c, fmt.Sprintf(
-		"✅ *API Key Generated!*\n\nKey: `%s`\n\n⚠️ *Important:* Copy this key now. It will not be shown again.",
-		key,
+		"✅ *API Key Generated!*\n\nKey: `%s`\n\n%s\n\n⚠️ *Important:* Copy this key now. It will not be shown again.",
+		key, describeKeyScope(scope),
 	), menu, tele.ModeMarkdown)
 }
 
@@ -1266,7 +1525,14 @@ func (b *Bot) handleAPIKeyList(c tele.Context) error {
 	var text strings.Builder
 	text.WriteString("*🔑 API Keys:*\n\n")
 	for i, key := range keys {
-		text.WriteString(fmt.Sprintf("%d. `%s`\n", i+1, b.maskAPIKey(key)))
+		text.WriteString(fmt.Sprintf("%d. `%s`", i+1, b.maskAPIKey(key)))
+		if account := b.apiKeyStorage.GetAPIKeyAccount(key); account != "" {
+			text.WriteString(fmt.Sprintf(" · %s", account))
+		}
+		text.WriteString("\n")
+		if scope := b.apiKeyStorage.GetAPIKeyScope(key); !scope.IsEmpty() {
+			text.WriteString(describeKeyScope(scope) + "\n\n")
+		}
 	}
 
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
@@ -1453,9 +1719,10 @@ func (b *Bot) resendPendingRequestNotifications() {
 // notifyAdminsOfRequest notifies all admins of a new access request with approve/reject buttons
 func (b *Bot) notifyAdminsOfRequest(req storage.PendingRequest) {
 	log.Printf("[notifyAdminsOfRequest] Notifying admins of request from user %d", req.UserID)
-	log.Printf("[notifyAdminsOfRequest] Number of admins: %d", len(b.allowedIDs))
+	admins := b.adminIDs()
+	log.Printf("[notifyAdminsOfRequest] Number of admins: %d", len(admins))
 
-	if len(b.allowedIDs) == 0 {
+	if len(admins) == 0 {
 		log.Printf("[notifyAdminsOfRequest] WARNING: No admins configured to receive notifications")
 		return
 	}
@@ -1490,7 +1757,7 @@ func (b *Bot) notifyAdminsOfRequest(req storage.PendingRequest) {
 	btnReject := menu.Data("❌ Reject", "reject_request", strconv.FormatInt(req.UserID, 10))
 	menu.Inline(menu.Row(btnApprove, btnReject))
 
-	for adminID := range b.allowedIDs {
+	for _, adminID := range admins {
 		log.Printf("[notifyAdminsOfRequest] Sending notification to admin %d", adminID)
 		// Send directly to admin's private chat (explicitly no thread)
 		_, err := b.bot.Send(&tele.Chat{ID: adminID}, message, menu)
@@ -1502,8 +1769,9 @@ func (b *Bot) notifyAdminsOfRequest(req storage.PendingRequest) {
 	}
 }
 
-// handleApproveRequest approves an access request
-func (b *Bot) handleApproveRequest(c tele.Context, userIDStr string) error {
+// handleApproveRequest approves an access request with the role and zones
+// picked by the admin
+func (b *Bot) handleApproveRequest(c tele.Context, userIDStr, role string, zones []string) error {
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
 	if err != nil {
 		return b.sendWithThread(c, "❌ Invalid user ID.", tele.ModeMarkdown)
@@ -1528,9 +1796,6 @@ func (b *Bot) handleApproveRequest(c tele.Context, userIDStr string) error {
 		return b.sendWithThread(c, fmt.Sprintf("❌ Error removing request: %v", err), tele.ModeMarkdown)
 	}
 
-	// Add to allowed IDs (legacy)
-	b.allowedIDs[userID] = true
-
 	// Add to scope-based storage if available
 	if b.allowedUserStorage != nil && targetReq != nil {
 		scope := storage.AccessScope{
@@ -1541,6 +1806,7 @@ func (b *Bot) handleApproveRequest(c tele.Context, userIDStr string) error {
 			log.Printf("[handleApproveRequest] Warning: failed to add user scope: %v", err)
 		}
 	}
+	role, zones = b.grantRole(c.Sender().ID, userID, role, zones)
 
 	// Notify user in the chat where they requested access
 	if targetReq != nil {
@@ -1555,7 +1821,7 @@ func (b *Bot) handleApproveRequest(c tele.Context, userIDStr string) error {
 			scopeDesc = "this group"
 		}
 
-		message := fmt.Sprintf("✅ *Access Approved*\n\nYour access request has been approved. You can now use the bot in %s.", scopeDesc)
+		message := fmt.Sprintf("✅ *Access Approved*\n\nYour access request has been approved. You can now use the bot in %s as *%s* for %s.", scopeDesc, role, zonesLabel(zones))
 
 		if threadID != 0 {
 			b.sendMessageToThread(chatID, threadID, message)
@@ -1564,17 +1830,26 @@ func (b *Bot) handleApproveRequest(c tele.Context, userIDStr string) error {
 		}
 	} else {
 		// Fallback to private message
-		b.sendMessage(userID, "✅ *Access Approved*\n\nYour access request has been approved. You can now use the bot.")
+		b.sendMessage(userID, fmt.Sprintf("✅ *Access Approved*\n\nYour access request has been approved. You can now use the bot as *%s* for %s.", role, zonesLabel(zones)))
 	}
 
-	return b.sendWithThread(c, fmt.Sprintf("✅ User `%d` has been approved.", userID), tele.ModeMarkdown)
+	return b.editWithThread(c, fmt.Sprintf("✅ User `%d` has been approved as *%s* for %s.", userID, role, zonesLabel(zones)), tele.ModeMarkdown)
 }
 
-// handleAddUserCommand handles the /adduser command for admin to add users directly
+// handleAddUserCommand handles the /adduser command for admin to add users
+// directly, as editors unless another role is given
 func (b *Bot) handleAddUserCommand(c tele.Context) error {
 	args := c.Args()
 	if len(args) == 0 {
-		return b.sendWithThread(c, "ℹ️ Usage: `/adduser <user_id>`\n\nExample: `/adduser 123456789`", tele.ModeMarkdown)
+		return b.sendWithThread(c, "ℹ️ Usage: `/adduser <user_id> [viewer|editor|admin]`\n\nExample: `/adduser 123456789 viewer`", tele.ModeMarkdown)
+	}
+
+	role := ""
+	if len(args) > 1 {
+		role = strings.ToLower(args[1])
+		if roleRanks[role] == 0 {
+			return b.sendWithThread(c, "❌ Invalid role. Use `viewer`, `editor` or `admin`.", tele.ModeMarkdown)
+		}
 	}
 
 	userIDStr := args[0]
@@ -1595,12 +1870,22 @@ func (b *Bot) handleAddUserCommand(c tele.Context) error {
 		if b.allowedUserStorage.IsUserAllowed(userID, chatID, threadID) {
 			return b.sendWithThread(c, fmt.Sprintf("ℹ️ User `%d` is already authorized for this scope.", userID), tele.ModeMarkdown)
 		}
-	} else if b.isAuthorized(userID) {
+	} else if b.allowedIDs[userID] {
 		return b.sendWithThread(c, fmt.Sprintf("ℹ️ User `%d` is already authorized.", userID), tele.ModeMarkdown)
 	}
 
-	// Add to allowed IDs (legacy)
-	b.allowedIDs[userID] = true
+	if role != "" && b.isConfiguredAdmin(userID) {
+		return b.sendWithThread(c, configuredAdminText(userID), tele.ModeMarkdown)
+	}
+
+	// Users who already have access keep their role unless one is given
+	grant := role != "" || !b.isAllowedUser(userID)
+	if role == "" {
+		role = storage.RoleEditor
+		if !grant {
+			role = b.userRole(userID)
+		}
+	}
 
 	// Add to scope-based storage
 	if b.allowedUserStorage != nil {
@@ -1612,6 +1897,9 @@ func (b *Bot) handleAddUserCommand(c tele.Context) error {
 			log.Printf("[handleAddUserCommand] Warning: failed to add user scope: %v", err)
 		}
 	}
+	if grant {
+		role, _ = b.grantRole(c.Sender().ID, userID, role, b.userZones(c.Sender().ID))
+	}
 
 	// Also remove from pending if exists
 	if b.pendingReqStorage != nil {
@@ -1627,14 +1915,14 @@ func (b *Bot) handleAddUserCommand(c tele.Context) error {
 	}
 
 	// Notify the user in the current chat/thread
-	message := fmt.Sprintf("✅ *Access Approved*\n\nYou have been granted access to use the bot in %s. Type /start to begin.", scopeDesc)
+	message := fmt.Sprintf("✅ *Access Approved*\n\nYou have been granted access to use the bot in %s as *%s*. Type /start to begin.", scopeDesc, role)
 	if threadID != 0 {
 		b.sendMessageToThread(chatID, threadID, message)
 	} else {
 		b.sendMessage(chatID, message)
 	}
 
-	return b.sendWithThread(c, fmt.Sprintf("✅ User `%d` has been added and notified for %s.", userID, scopeDesc), tele.ModeMarkdown)
+	return b.sendWithThread(c, fmt.Sprintf("✅ User `%d` has been added as *%s* and notified for %s.", userID, role, scopeDesc), tele.ModeMarkdown)
 }
 
 // showPendingRequests shows all pending access requests to admin
@@ -1726,6 +2014,10 @@ func (b *Bot) handleRejectRequest(c tele.Context, userIDStr string) error {
 
 // handleEditRecordContent handles editing record content
 func (b *Bot) handleEditRecordContent(c tele.Context, chatID int64, userID int64, messageID int, content string) error {
+	if err := domain.ValidateContent(b.getStateData(userID, "edit_type"), content); err != nil {
+		return b.sendWithThread(c, recordErrorText("", err)+"\n\nPlease enter the content again.", tele.ModeMarkdown)
+	}
+
 	b.stateManager.SetData(userID, "edit_content", content)
 	b.stateManager.SetStep(userID, StepEditRecordTTL)
 
@@ -1760,16 +2052,16 @@ func (b *Bot) handleEditRecordTTL(c tele.Context, chatID int64, userID int64, me
 	if err != nil {
 		return b.sendWithThread(c, "❌ Invalid TTL. Please enter a number.", tele.ModeMarkdown)
 	}
+	if err := domain.ValidateTTL(ttl); err != nil {
+		return b.sendWithThread(c, recordErrorText("", err), tele.ModeMarkdown)
+	}
 
 	b.stateManager.SetData(userID, "edit_ttl", ttl)
 	b.stateManager.SetStep(userID, StepEditRecordProxied)
 
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
 	menu.Inline(
-		menu.Row(
-			menu.Data("✅ Yes (Proxied)", "edit_proxied", "true"),
-			menu.Data("❌ No (DNS Only)", "edit_proxied", "false"),
-		),
+		proxiedRow(menu, b.getStateData(userID, "edit_type"), "edit_proxied"),
 		menu.Row(menu.Data("❌ Cancel", "cancel_edit")),
 	)
 
@@ -1789,10 +2081,10 @@ func (b *Bot) handleEditRecord(c tele.Context, chatID int64, userID int64, messa
 	page, _ := strconv.Atoi(pageStr)
 	idx, _ := strconv.Atoi(idxStr)
 
-	ctx := context.Background()
-	records, err := b.dnsUsecase.ListRecords(ctx, zoneName)
+	ctx := b.actorContext(c)
+	records, err := b.dnsUsecase.ListRecords(ctx, zoneName, domain.RecordFilter{})
 	if err != nil {
-		return b.editWithThread(c, fmt.Sprintf("❌ Error loading records: %v", err), tele.ModeMarkdown)
+		return b.editWithThread(c, recordErrorText("loading records", err), tele.ModeMarkdown)
 	}
 
 	recordsPerPage := 10
@@ -1830,10 +2122,10 @@ func (b *Bot) handleDeleteRecord(c tele.Context, chatID int64, userID int64, mes
 	page, _ := strconv.Atoi(pageStr)
 	idx, _ := strconv.Atoi(idxStr)
 
-	ctx := context.Background()
-	records, err := b.dnsUsecase.ListRecords(ctx, zoneName)
+	ctx, change := usecase.WithChangeRef(b.actorContext(c))
+	records, err := b.dnsUsecase.ListRecords(ctx, zoneName, domain.RecordFilter{})
 	if err != nil {
-		return b.editWithThread(c, fmt.Sprintf("❌ Error loading records: %v", err), tele.ModeMarkdown)
+		return b.editWithThread(c, recordErrorText("loading records", err), tele.ModeMarkdown)
 	}
 
 	recordsPerPage := 10
@@ -1844,18 +2136,25 @@ func (b *Bot) handleDeleteRecord(c tele.Context, chatID int64, userID int64, mes
 	}
 
 	r := records[startIdx+idx]
+	selector := usecase.RecordSelector{RecordID: r.ID}
+
+	if parked, err := b.requestApproval(c, usecase.PendingChange{Kind: usecase.ChangeDelete, ZoneName: zoneName, Delete: &selector}); parked {
+		return err
+	}
 
 	// Delete the record
-	err = b.dnsUsecase.DeleteRecord(ctx, zoneName, r.ID)
+	err = b.dnsUsecase.DeleteRecord(ctx, zoneName, selector)
 	if err != nil {
-		return b.editWithThread(c, fmt.Sprintf("❌ Error deleting record: %v", err), tele.ModeMarkdown)
+		return b.editWithThread(c, recordErrorText("deleting record", err), tele.ModeMarkdown)
 	}
 
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
-	menu.Inline(
+	rows := undoRows(menu, change)
+	rows = append(rows,
 		menu.Row(menu.Data("◀️ Back to List", "page", zoneName, pageStr)),
 		menu.Row(menu.Data("🏠 Main Menu", "menu")),
 	)
+	menu.Inline(rows...)
 
 	return b.editWithThread(c, fmt.Sprintf(
 		"✅ *Record Deleted*\n\nName: `%s`\nType: `%s`\nContent: `%s`",
@@ -1894,6 +2193,112 @@ func (b *Bot) handleBackNavigation(c tele.Context, chatID int64, userID int64, m
 	return b.showMainMenu(c)
 }
 
+// proxiedRow returns the proxy choice buttons. Records that cannot be
+// proxied only get the DNS only option.
+func proxiedRow(menu *tele.ReplyMarkup, recordType, unique string) tele.Row {
+	dnsOnly := menu.Data("❌ No (DNS Only)", unique, "false")
+	if !domain.CanProxy(recordType) {
+		return menu.Row(dnsOnly)
+	}
+	return menu.Row(menu.Data("✅ Yes (Proxied)", unique, "true"), dnsOnly)
+}
+
+// recordTypeRows returns buttons for all supported record types, four
+// per row
+func recordTypeRows(menu *tele.ReplyMarkup, unique string) []tele.Row {
+	var rows []tele.Row
+	for i := 0; i < len(domain.RecordTypes); i += 4 {
+		var row tele.Row
+		for j := i; j < i+4 && j < len(domain.RecordTypes); j++ {
+			row = append(row, menu.Data(domain.RecordTypes[j], unique, domain.RecordTypes[j]))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// splitMXContent splits MX content entered as "priority host"
+func splitMXContent(content string) (string, *uint16, bool) {
+	fields := strings.Fields(content)
+	if len(fields) != 2 {
+		return "", nil, false
+	}
+	priority, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return "", nil, false
+	}
+	p := uint16(priority)
+	return fields[1], &p, true
+}
+
+// contentHint describes the content expected for a record type
+func contentHint(recordType string) string {
+	switch recordType {
+	case "A":
+		return "the IPv4 address (e.g., `192.0.2.1`)"
+	case "AAAA":
+		return "the IPv6 address (e.g., `2001:db8::1`)"
+	case "CNAME":
+		return "the target hostname (e.g., `example.com`)"
+	case "MX":
+		return "the priority and mail server (e.g., `10 mail.example.com`)"
+	case "NS":
+		return "the name server hostname"
+	case "SRV":
+		return "the priority, weight, port and target (e.g., `10 5 5060 sip.example.com`); use a name like `_sip._tcp`"
+	case "CAA":
+		return "the flags, tag and value (e.g., `0 issue \"letsencrypt.org\"`)"
+	case "TLSA":
+		return "the usage, selector, matching type and certificate data (e.g., `3 1 1 0123abcd…`)"
+	case "SSHFP":
+		return "the algorithm, fingerprint type and fingerprint (e.g., `4 2 0123abcd…`)"
+	case "URI":
+		return "the priority, weight and target (e.g., `10 1 \"https://example.com/\"`)"
+	case "LOC":
+		return "the location (e.g., `51 30 12.748 N 0 7 39.611 W 0m`)"
+	case "PTR":
+		return "the hostname the address points to (e.g., `host.example.com`)"
+	case "HTTPS", "SVCB":
+		return "the priority, target and parameters (e.g., `1 . alpn=\"h3,h2\" ipv4hint=192.0.2.1`)"
+	case "NAPTR":
+		return "the order, preference, flags, service, regex and replacement (e.g., `100 10 \"S\" \"SIP+D2U\" \"\" _sip._udp.example.com`)"
+	case "DS":
+		return "the key tag, algorithm, digest type and digest (e.g., `2371 13 2 0123abcd…`)"
+	case "CERT":
+		return "the type, key tag, algorithm and base64 certificate (e.g., `1 0 0 MIIB…`)"
+	case "SMIMEA":
+		return "the usage, selector, matching type and certificate data (e.g., `3 0 1 0123abcd…`)"
+	default:
+		return "the content"
+	}
+}
+
+// recordErrorText formats a record error for the user. Validation errors
+// name the offending field instead of the wrapped error chain, and errors
+// from Cloudflare the user can act on are explained.
+func recordErrorText(action string, err error) string {
+	var validationErr *domain.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return fmt.Sprintf("❌ Invalid %s: %s", validationErr.Field, validationErr.Message)
+	case errors.Is(err, domain.ErrRateLimited):
+		return "⏳ Cloudflare is limiting requests right now. Please try again in a minute."
+	case errors.Is(err, domain.ErrCloudflareAuth):
+		return "🔐 Cloudflare rejected the bot's API credentials. Ask an admin to check the token and its DNS permissions."
+	case errors.Is(err, domain.ErrZoneNotFound):
+		return "❌ Zone not found, or not accessible to you."
+	case errors.Is(err, domain.ErrRecordNotFound):
+		return "❌ Record not found. It may have been changed or deleted in the meantime."
+	case errors.Is(err, domain.ErrDuplicateRecord):
+		return "❌ An identical record already exists."
+	case errors.Is(err, domain.ErrAmbiguousRecord):
+		return "❌ Several records match. Add the record type, or open the record from Manage Records."
+	case errors.Is(err, domain.ErrRecordConflict):
+		return "❌ A CNAME record cannot share its name with other records. Remove the conflicting record first."
+	}
+	return fmt.Sprintf("❌ Error %s: %v", action, err)
+}
+
 // getStateData safely gets state data
 func (b *Bot) getStateData(userID int64, key string) string {
 	val, exists := b.stateManager.GetData(userID, key)
@@ -1923,25 +2328,32 @@ func (b *Bot) handleEditProxiedSelected(c tele.Context, chatID int64, userID int
 
 	ttl, _ := strconv.Atoi(ttlStr)
 
-	ctx := context.Background()
+	ctx, change := usecase.WithChangeRef(b.actorContext(c))
 	input := usecase.UpdateRecordInput{
 		ZoneName: zone,
-		RecordID: recordID,
-		Content:  content,
-		TTL:      ttl,
-		Proxied:  proxied,
+		Selector: usecase.RecordSelector{RecordID: recordID},
+		Content:  &content,
+		TTL:      &ttl,
+		Proxied:  &proxied,
+	}
+
+	if parked, err := b.requestApproval(c, usecase.PendingChange{Kind: usecase.ChangeUpdate, ZoneName: zone, Update: &input}); parked {
+		b.stateManager.ClearState(userID)
+		return err
 	}
 
 	_, err := b.dnsUsecase.UpdateRecord(ctx, input)
 	if err != nil {
-		return b.sendWithThread(c, fmt.Sprintf("❌ Error updating record: %v", err), tele.ModeMarkdown)
+		return b.sendWithThread(c, recordErrorText("updating record", err), tele.ModeMarkdown)
 	}
 
 	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
-	menu.Inline(
+	rows := undoRows(menu, change)
+	rows = append(rows,
 		menu.Row(menu.Data("◀️ Back to List", "page", zone, b.getStateData(userID, "edit_page"))),
 		menu.Row(menu.Data("🏠 Main Menu", "menu")),
 	)
+	menu.Inline(rows...)
 
 	b.stateManager.ClearState(userID)
 
@@ -1970,12 +2382,26 @@ func (b *Bot) showAllowedUsers(c tele.Context) error {
 	chatID := c.Chat().ID
 	isPrivateChat := chatID > 0
 
+	// Users can be restricted to an account when there are several
+	accounts, err := b.accountNames()
+	if err != nil {
+		log.Printf("[showAllowedUsers] Warning: failed to load accounts: %v", err)
+	}
+	multiAccount := len(accounts) > 1
+
 	// Build user list message
 	var text strings.Builder
 	text.WriteString(fmt.Sprintf("*👥 Allowed Users (%d)*\n\n", len(users)))
 
 	for i, user := range users {
 		userDesc := fmt.Sprintf("*%d. User ID:* `%d`", i+1, user.UserID)
+		userDesc += fmt.Sprintf("\n   *Role:* %s", b.userRole(user.UserID))
+		if len(user.Zones) > 0 {
+			userDesc += fmt.Sprintf("\n   *Zones:* %s", zonesLabel(user.Zones))
+		}
+		if account := b.allowedUserStorage.GetUserAccount(user.UserID); multiAccount || account != "" {
+			userDesc += fmt.Sprintf("\n   *Account:* %s", accountLabel(account))
+		}
 
 		if len(user.Scopes) > 0 {
 			userDesc += "\n   *Scopes:*"
@@ -2003,10 +2429,20 @@ func (b *Bot) showAllowedUsers(c tele.Context) error {
 		menu := &tele.ReplyMarkup{ResizeKeyboard: true}
 		var rows []tele.Row
 
-		// Add a button for each user to remove them
+		// Add a button for each user to remove them and to pick their
+		// role, and to pick their account when there are several
+		canManageAccounts := multiAccount && b.canManageAccounts(c.Sender().ID)
 		for _, user := range users {
+			userIDStr := strconv.FormatInt(user.UserID, 10)
 			btnText := fmt.Sprintf("🗑️ Remove %d", user.UserID)
-			rows = append(rows, menu.Row(menu.Data(btnText, "remove_user", strconv.FormatInt(user.UserID, 10))))
+			row := menu.Row(menu.Data(btnText, "remove_user", userIDStr))
+			if user.UserID != c.Sender().ID {
+				row = append(row, menu.Data(fmt.Sprintf("🎭 Role %d", user.UserID), "user_role", userIDStr))
+			}
+			if canManageAccounts {
+				row = append(row, menu.Data(fmt.Sprintf("🏢 Account %d", user.UserID), "user_acct", userIDStr))
+			}
+			rows = append(rows, row)
 		}
 
 		rows = append(rows, menu.Row(menu.Data("🏠 Main Menu", "menu")))
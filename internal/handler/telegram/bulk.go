@@ -0,0 +1,256 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	"cf-dns-bot/internal/usecase"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// maxBulkPreviewItems is the number of matching records listed before
+// a bulk action is confirmed
+const maxBulkPreviewItems = 15
+
+// bulkActions are the actions offered for the matching records
+var bulkActions = []struct {
+	key   string
+	label string
+}{
+	{"delete", "🗑️ Delete"},
+	{"ttl_auto", "⏱️ TTL Auto"},
+	{"ttl_3600", "⏱️ TTL 1h"},
+	{"proxy_on", "☁️ Proxy On"},
+	{"proxy_off", "☁️ Proxy Off"},
+}
+
+// showBulkMenu asks for the filter of a bulk action (admin only)
+func (b *Bot) showBulkMenu(c tele.Context, userID int64, zoneName string) error {
+	if !b.isAdmin(userID) {
+		return b.sendWithThread(c, "⛔ Only admins can run bulk actions.", tele.ModeMarkdown)
+	}
+
+	b.stateManager.SetData(userID, "bulk_zone", zoneName)
+	b.stateManager.SetStep(userID, StepInputBulkPattern)
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	rows := recordTypeRows(menu, "bulk_type")
+	rows = append(rows, menu.Row(menu.Data("◀️ Back to List", "select_zone_manage", zoneName)))
+	menu.Inline(rows...)
+
+	return b.editWithThread(c, fmt.Sprintf(
+		"*🧰 Bulk Actions*\n\nZone: `%s`\n\nSelect a record type, or send a name pattern such as `*.dev` or `test-*`.",
+		zoneName,
+	), menu, tele.ModeMarkdown)
+}
+
+// handleBulkType selects all records of a type
+func (b *Bot) handleBulkType(c tele.Context, userID int64, recordType string) error {
+	b.stateManager.SetData(userID, "bulk_filter", usecase.BulkFilter{Type: recordType})
+	return b.showBulkPreview(c, userID)
+}
+
+// handleBulkPattern selects the records matching a name pattern
+func (b *Bot) handleBulkPattern(c tele.Context, userID int64, pattern string) error {
+	b.stateManager.SetData(userID, "bulk_filter", usecase.BulkFilter{NamePattern: strings.TrimSpace(pattern)})
+	return b.showBulkPreview(c, userID)
+}
+
+// showBulkPreview lists the records matching the selected filter and
+// offers the bulk actions
+func (b *Bot) showBulkPreview(c tele.Context, userID int64) error {
+	reply := b.replyFunc(c)
+
+	zoneName, filter, ok := b.bulkSelection(userID)
+	if !ok {
+		return reply(c, "❌ Bulk session expired. Please open Bulk Actions again.", tele.ModeMarkdown)
+	}
+	if !b.isAdmin(userID) {
+		return reply(c, "⛔ Only admins can run bulk actions.", tele.ModeMarkdown)
+	}
+	b.stateManager.SetStep(userID, StepNone)
+
+	records, err := b.dnsUsecase.MatchRecords(b.actorContext(c), zoneName, filter)
+	if err != nil {
+		return reply(c, recordErrorText("matching records", err), tele.ModeMarkdown)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	backRow := menu.Row(menu.Data("◀️ Back", "bulk", zoneName))
+
+	var text strings.Builder
+	text.WriteString("*🧰 Bulk Actions*\n\n")
+	text.WriteString(fmt.Sprintf("Zone: `%s`\nFilter: %s\n\n", zoneName, describeBulkFilter(filter)))
+
+	if len(records) == 0 {
+		text.WriteString("No records match this filter.")
+		menu.Inline(backRow)
+		return reply(c, text.String(), menu, tele.ModeMarkdown)
+	}
+
+	text.WriteString(fmt.Sprintf("*%d record(s) match:*\n", len(records)))
+	for i, r := range records {
+		if i == maxBulkPreviewItems {
+			text.WriteString(fmt.Sprintf("… and %d more\n", len(records)-maxBulkPreviewItems))
+			break
+		}
+		text.WriteString(fmt.Sprintf("• `%s` %s `%s`\n", r.Name, r.Type, r.Content))
+	}
+	text.WriteString("\nChoose an action:")
+
+	var row tele.Row
+	var rows []tele.Row
+	for _, action := range bulkActions {
+		row = append(row, menu.Data(action.label, "bulk_act", action.key))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, backRow)
+	menu.Inline(rows...)
+
+	return reply(c, text.String(), menu, tele.ModeMarkdown)
+}
+
+// handleBulkAction asks to confirm a bulk action with the current
+// number of matching records
+func (b *Bot) handleBulkAction(c tele.Context, userID int64, action string) error {
+	zoneName, filter, ok := b.bulkSelection(userID)
+	if !ok {
+		return b.sendWithThread(c, "❌ Bulk session expired. Please open Bulk Actions again.", tele.ModeMarkdown)
+	}
+	if !b.isAdmin(userID) {
+		return b.sendWithThread(c, "⛔ Only admins can run bulk actions.", tele.ModeMarkdown)
+	}
+
+	records, err := b.dnsUsecase.MatchRecords(b.actorContext(c), zoneName, filter)
+	if err != nil {
+		return b.editWithThread(c, recordErrorText("matching records", err), tele.ModeMarkdown)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(
+		menu.Row(menu.Data("✅ Confirm", "bulk_ok", action), menu.Data("❌ Cancel", "bulk", zoneName)),
+	)
+
+	return b.editWithThread(c, fmt.Sprintf(
+		"*⚠️ Confirm Bulk Action*\n\nZone: `%s`\nFilter: %s\nAction: *%s*\n\nThis affects *%d record(s)*. Continue?",
+		zoneName, describeBulkFilter(filter), bulkActionLabel(action), len(records),
+	), menu, tele.ModeMarkdown)
+}
+
+// handleBulkConfirm runs a confirmed bulk action and reports the result
+func (b *Bot) handleBulkConfirm(c tele.Context, userID int64, action string) error {
+	zoneName, filter, ok := b.bulkSelection(userID)
+	if !ok {
+		return b.sendWithThread(c, "❌ Bulk session expired. Please open Bulk Actions again.", tele.ModeMarkdown)
+	}
+	if !b.isAdmin(userID) {
+		return b.sendWithThread(c, "⛔ Only admins can run bulk actions.", tele.ModeMarkdown)
+	}
+
+	change := usecase.PendingChange{Kind: usecase.ChangeBulkUpdate, ZoneName: zoneName, BulkFilter: &filter}
+	switch action {
+	case "delete":
+		change.Kind = usecase.ChangeBulkDelete
+	case "ttl_auto", "ttl_3600":
+		ttl := 1
+		if action == "ttl_3600" {
+			ttl = 3600
+		}
+		change.BulkChanges = &usecase.BulkChanges{TTL: &ttl}
+	case "proxy_on", "proxy_off":
+		proxied := action == "proxy_on"
+		change.BulkChanges = &usecase.BulkChanges{Proxied: &proxied}
+	default:
+		return b.sendWithThread(c, "❌ Unknown bulk action.", tele.ModeMarkdown)
+	}
+
+	if parked, err := b.requestApproval(c, change); parked {
+		return err
+	}
+
+	ctx := b.actorContext(c)
+	var result *usecase.BulkResult
+	var err error
+	if change.Kind == usecase.ChangeBulkDelete {
+		result, err = b.dnsUsecase.BulkDelete(ctx, zoneName, filter)
+	} else {
+		result, err = b.dnsUsecase.BulkUpdate(ctx, zoneName, filter, *change.BulkChanges)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(menu.Row(menu.Data("◀️ Back to List", "select_zone_manage", zoneName)))
+
+	if err != nil {
+		return b.editWithThread(c, fmt.Sprintf("❌ Bulk action failed: %v", err), menu, tele.ModeMarkdown)
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("*🧰 %s done*\n\n", bulkActionLabel(action)))
+	text.WriteString(fmt.Sprintf("Zone: `%s`\n✅ %d succeeded\n", zoneName, len(result.Succeeded)))
+	if len(result.Failed) > 0 {
+		text.WriteString(fmt.Sprintf("❌ %d failed:\n", len(result.Failed)))
+		for i, f := range result.Failed {
+			if i == maxBulkPreviewItems {
+				text.WriteString(fmt.Sprintf("… and %d more\n", len(result.Failed)-maxBulkPreviewItems))
+				break
+			}
+			text.WriteString(fmt.Sprintf("• `%s` %s: %s\n", f.Record.Name, f.Record.Type, f.Error))
+		}
+	}
+
+	return b.editWithThread(c, text.String(), menu, tele.ModeMarkdown)
+}
+
+// bulkSelection returns the zone and filter of the current bulk action
+func (b *Bot) bulkSelection(userID int64) (string, usecase.BulkFilter, bool) {
+	zoneName, ok := b.stateManager.GetData(userID, "bulk_zone")
+	if !ok {
+		return "", usecase.BulkFilter{}, false
+	}
+	filter, ok := b.stateManager.GetData(userID, "bulk_filter")
+	if !ok {
+		return "", usecase.BulkFilter{}, false
+	}
+	return zoneName.(string), filter.(usecase.BulkFilter), true
+}
+
+// replyFunc edits the message of a button, or answers a text message
+// such as a name pattern with a new message
+func (b *Bot) replyFunc(c tele.Context) func(tele.Context, string, ...interface{}) error {
+	if c.Callback() == nil {
+		return b.sendWithThread
+	}
+	return b.editWithThread
+}
+
+// describeBulkFilter formats a bulk filter for display
+func describeBulkFilter(filter usecase.BulkFilter) string {
+	var parts []string
+	if filter.Type != "" {
+		parts = append(parts, fmt.Sprintf("type `%s`", filter.Type))
+	}
+	if filter.NamePattern != "" {
+		parts = append(parts, fmt.Sprintf("name `%s`", filter.NamePattern))
+	}
+	if filter.Content != "" {
+		parts = append(parts, fmt.Sprintf("content `%s`", filter.Content))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// bulkActionLabel returns the button label of a bulk action
+func bulkActionLabel(action string) string {
+	for _, a := range bulkActions {
+		if a.key == action {
+			return a.label
+		}
+	}
+	return action
+}
@@ -0,0 +1,58 @@
+package telegram
+
+import (
+	"fmt"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// showCacheStats shows the hit and miss counters of the Cloudflare cache
+// (admin only)
+func (b *Bot) showCacheStats(c tele.Context, userID int64) error {
+	if !b.isAdmin(userID) {
+		return b.sendWithThread(c, "⛔ Only admins can view the cache.", tele.ModeMarkdown)
+	}
+	if b.cache == nil {
+		return b.editWithThread(c, "❌ Cache not configured.", tele.ModeMarkdown)
+	}
+
+	stats := b.cache.Stats()
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(
+		menu.Row(menu.Data("🔄 Refresh", "cache"), menu.Data("🧹 Clear Cache", "cache_clear")),
+		menu.Row(menu.Data("🏠 Main Menu", "menu")),
+	)
+
+	return b.editWithThread(c, fmt.Sprintf(
+		"*🗄️ Cloudflare Cache*\n\n"+
+			"*Zones:* %d hits, %d misses (%s)\n"+
+			"*Records:* %d hits, %d misses (%s)\n\n"+
+			"Cached now: %d zone(s), %d record list(s)",
+		stats.ZoneHits, stats.ZoneMisses, hitRate(stats.ZoneHits, stats.ZoneMisses),
+		stats.RecordHits, stats.RecordMisses, hitRate(stats.RecordHits, stats.RecordMisses),
+		stats.Zones, stats.RecordLists,
+	), menu, tele.ModeMarkdown)
+}
+
+// handleCacheClear drops everything from the cache (admin only)
+func (b *Bot) handleCacheClear(c tele.Context, userID int64) error {
+	if !b.isAdmin(userID) {
+		return b.sendWithThread(c, "⛔ Only admins can clear the cache.", tele.ModeMarkdown)
+	}
+	if b.cache == nil {
+		return b.editWithThread(c, "❌ Cache not configured.", tele.ModeMarkdown)
+	}
+
+	if err := b.cache.Refresh(b.actorContext(c), ""); err != nil {
+		return b.editWithThread(c, fmt.Sprintf("❌ Error clearing cache: %v", err), tele.ModeMarkdown)
+	}
+	return b.showCacheStats(c, userID)
+}
+
+// hitRate formats the share of cache hits
+func hitRate(hits, misses uint64) string {
+	if hits+misses == 0 {
+		return "no requests yet"
+	}
+	return fmt.Sprintf("%.0f%% hit rate", float64(hits)*100/float64(hits+misses))
+}
@@ -0,0 +1,432 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/usecase"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// commandHelp describes the text commands for experienced users
+const commandHelp = "*⌨️ Text Commands*\n\n" +
+	"• `/add <zone> <name> <type> <content> [ttl] [proxied]`\n" +
+	"• `/set <zone> <name> [type] <field>=<value>`\n" +
+	"• `/del <zone> <name> [type]`\n" +
+	"• `/ls <zone> [type]`\n" +
+	"• `/find <name, content or IP>`\n" +
+	"• `/approvals` lists changes waiting for a second approval\n\n" +
+	"`/set` changes `content`, `ttl`, `proxied`, `comment` or `tags`; send `-` to remove a comment or tags. " +
+	"Names are relative to the zone, `@` is the zone itself. Changes are shown for confirmation first.\n\n" +
+	"Examples:\n" +
+	"`/add example.com _acme-challenge TXT abc123`\n" +
+	"`/add example.com www A 192.0.2.1 300 yes`\n" +
+	"`/set example.com www A ttl=3600`\n" +
+	"`/del example.com old CNAME`"
+
+// pendingCommand is a record change sent as a text command that waits for
+// confirmation. Only the latest command of a user can be confirmed.
+type pendingCommand struct {
+	id     string
+	action string // add, set or del
+	zone   string
+	create usecase.CreateRecordInput
+	update usecase.UpdateRecordInput
+	record domain.DNSRecord // the record changed by set and del
+}
+
+// handleHelpCommand lists the text commands
+func (b *Bot) handleHelpCommand(c tele.Context) error {
+	return b.sendWithThread(c, commandHelp, tele.ModeMarkdown)
+}
+
+// handleAddCommand previews a record given as
+// /add <zone> <name> <type> <content> [ttl] [proxied]
+func (b *Bot) handleAddCommand(c tele.Context) error {
+	args := strings.Fields(c.Message().Payload)
+	if len(args) < 4 {
+		return b.sendWithThread(c, "ℹ️ Usage: `/add <zone> <name> <type> <content> [ttl] [proxied]`\n\nExample: `/add example.com _acme-challenge TXT abc123`", tele.ModeMarkdown)
+	}
+
+	zoneName, name, recordType := strings.ToLower(args[0]), args[1], strings.ToUpper(args[2])
+	if !domain.IsValidRecordType(recordType) {
+		return b.sendWithThread(c, fmt.Sprintf("❌ Unknown record type `%s`.", args[2]), tele.ModeMarkdown)
+	}
+
+	content, ttl, proxied := splitCommandContent(recordType, args[3:])
+	input := usecase.CreateRecordInput{
+		ZoneName: zoneName,
+		Name:     name,
+		Type:     recordType,
+		Content:  content,
+		TTL:      ttl,
+		Proxied:  proxied,
+	}
+	if recordType == "MX" {
+		host, priority, ok := splitMXContent(content)
+		if !ok {
+			return b.sendWithThread(c, "❌ Enter the priority and the mail server, e.g. `/add example.com @ MX 10 mail.example.com`.", tele.ModeMarkdown)
+		}
+		input.Content, input.Priority = host, priority
+	}
+
+	// Check the record the way the usecase will, before asking to confirm
+	record := domain.DNSRecord{
+		Name:     input.Name,
+		Type:     input.Type,
+		Content:  input.Content,
+		TTL:      input.TTL,
+		Proxied:  input.Proxied,
+		Priority: input.Priority,
+	}
+	if err := domain.CompleteRecordData(&record); err != nil {
+		return b.sendWithThread(c, recordErrorText("", err), tele.ModeMarkdown)
+	}
+	if err := domain.ValidateRecord(record); err != nil {
+		return b.sendWithThread(c, recordErrorText("", err), tele.ModeMarkdown)
+	}
+
+	ctx := b.actorContext(c)
+	zones, err := b.dnsUsecase.ListZones(ctx)
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("loading zones", err), tele.ModeMarkdown)
+	}
+	if !hasZone(zones, zoneName) {
+		return b.sendWithThread(c, recordErrorText("", domain.ErrZoneNotFound), tele.ModeMarkdown)
+	}
+
+	pending := pendingCommand{action: "add", zone: zoneName, create: input}
+	return b.confirmCommand(c, pending, fmt.Sprintf(
+		"*➕ Create DNS Record - Confirm*\n\nZone: `%s`\n%s\n\nConfirm creation?",
+		zoneName, recordSummary(record),
+	))
+}
+
+// handleSetCommand previews a change given as
+// /set <zone> <name> [type] <field>=<value>
+func (b *Bot) handleSetCommand(c tele.Context) error {
+	usage := "ℹ️ Usage: `/set <zone> <name> [type] <field>=<value>`\n\nFields: `content`, `ttl`, `proxied`, `comment`, `tags`\nExample: `/set example.com www A ttl=3600`"
+	args := strings.Fields(c.Message().Payload)
+	if len(args) < 3 {
+		return b.sendWithThread(c, usage, tele.ModeMarkdown)
+	}
+
+	selector := usecase.RecordSelector{Name: args[1]}
+	rest := args[2:]
+	if !strings.Contains(rest[0], "=") {
+		selector.Type = strings.ToUpper(rest[0])
+		rest = rest[1:]
+	}
+	field, value, ok := strings.Cut(strings.Join(rest, " "), "=")
+	if !ok {
+		return b.sendWithThread(c, usage, tele.ModeMarkdown)
+	}
+	field, value = strings.ToLower(strings.TrimSpace(field)), strings.TrimSpace(value)
+
+	zoneName := strings.ToLower(args[0])
+	ctx := b.actorContext(c)
+	existing, err := b.dnsUsecase.GetRecord(ctx, zoneName, selector)
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("loading record", err), tele.ModeMarkdown)
+	}
+
+	input := usecase.UpdateRecordInput{
+		ZoneName: zoneName,
+		Selector: usecase.RecordSelector{RecordID: existing.ID},
+	}
+	updated := *existing
+	var before, after string
+	switch field {
+	case "content":
+		if existing.Type == "MX" {
+			if host, priority, ok := splitMXContent(value); ok {
+				value, input.Priority, updated.Priority = host, priority, priority
+			}
+		}
+		input.Content = &value
+		updated.Content, updated.Data = value, nil
+		before, after = existing.Content, value
+	case "ttl":
+		ttl, ok := parseTTL(value)
+		if !ok {
+			return b.sendWithThread(c, "❌ Invalid TTL. Send a number of seconds or `auto`.", tele.ModeMarkdown)
+		}
+		input.TTL = &ttl
+		updated.TTL = ttl
+		before, after = strconv.Itoa(existing.TTL), strconv.Itoa(ttl)
+	case "proxied":
+		proxied, ok := parseYesNo(value)
+		if !ok {
+			return b.sendWithThread(c, "❌ `proxied` must be `yes` or `no`.", tele.ModeMarkdown)
+		}
+		input.Proxied = &proxied
+		updated.Proxied = proxied
+		before, after = strconv.FormatBool(existing.Proxied), strconv.FormatBool(proxied)
+	case "comment":
+		if value == "-" {
+			value = ""
+		}
+		input.Comment = &value
+		updated.Comment = value
+		before, after = existing.Comment, value
+	case "tags":
+		tags := []string{}
+		if value != "-" {
+			tags = domain.ParseTags(value)
+		}
+		input.Tags = tags
+		updated.Tags = tags
+		before, after = strings.Join(existing.Tags, ", "), strings.Join(tags, ", ")
+	default:
+		return b.sendWithThread(c, fmt.Sprintf("❌ Unknown field `%s`. Use `content`, `ttl`, `proxied`, `comment` or `tags`.", field), tele.ModeMarkdown)
+	}
+
+	if err := domain.CompleteRecordData(&updated); err != nil {
+		return b.sendWithThread(c, recordErrorText("", err), tele.ModeMarkdown)
+	}
+	if err := domain.ValidateRecord(updated); err != nil {
+		return b.sendWithThread(c, recordErrorText("", err), tele.ModeMarkdown)
+	}
+
+	pending := pendingCommand{action: "set", zone: zoneName, update: input, record: *existing}
+	return b.confirmCommand(c, pending, fmt.Sprintf(
+		"*✏️ Edit DNS Record - Confirm*\n\nZone: `%s`\n%s\n\nChange %s: `%s` → `%s`\n\nConfirm change?",
+		zoneName, recordSummary(*existing), field, before, after,
+	))
+}
+
+// handleDeleteCommand previews deleting the record given as
+// /del <zone> <name> [type]
+func (b *Bot) handleDeleteCommand(c tele.Context) error {
+	args := strings.Fields(c.Message().Payload)
+	if len(args) < 2 || len(args) > 3 {
+		return b.sendWithThread(c, "ℹ️ Usage: `/del <zone> <name> [type]`\n\nExample: `/del example.com old CNAME`", tele.ModeMarkdown)
+	}
+
+	zoneName := strings.ToLower(args[0])
+	selector := usecase.RecordSelector{Name: args[1]}
+	if len(args) == 3 {
+		selector.Type = strings.ToUpper(args[2])
+	}
+
+	ctx := b.actorContext(c)
+	record, err := b.dnsUsecase.GetRecord(ctx, zoneName, selector)
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("loading record", err), tele.ModeMarkdown)
+	}
+
+	pending := pendingCommand{action: "del", zone: zoneName, record: *record}
+	return b.confirmCommand(c, pending, fmt.Sprintf(
+		"*🗑️ Delete DNS Record - Confirm*\n\nZone: `%s`\n%s\n\nDelete this record?",
+		zoneName, recordSummary(*record),
+	))
+}
+
+// handleListCommand lists the records of a zone given as /ls <zone> [type]
+func (b *Bot) handleListCommand(c tele.Context) error {
+	args := strings.Fields(c.Message().Payload)
+	if len(args) < 1 || len(args) > 2 {
+		return b.sendWithThread(c, "ℹ️ Usage: `/ls <zone> [type]`\n\nExample: `/ls example.com TXT`", tele.ModeMarkdown)
+	}
+
+	zoneName := strings.ToLower(args[0])
+	filter := domain.RecordFilter{Order: "name"}
+	if len(args) == 2 {
+		filter.Type = strings.ToUpper(args[1])
+		if !domain.IsValidRecordType(filter.Type) {
+			return b.sendWithThread(c, fmt.Sprintf("❌ Unknown record type `%s`.", args[1]), tele.ModeMarkdown)
+		}
+	}
+
+	ctx := b.actorContext(c)
+	records, err := b.dnsUsecase.ListRecords(ctx, zoneName, filter)
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("loading records", err), tele.ModeMarkdown)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	var rows []tele.Row
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("*📋 %s*", zoneName))
+	if filter.Type != "" {
+		text.WriteString(fmt.Sprintf(" (%s)", filter.Type))
+	}
+	text.WriteString(fmt.Sprintf("\n\n*%d record(s)*\n", len(records)))
+	for i, r := range records {
+		if i == maxFindLines {
+			text.WriteString(fmt.Sprintf("… and %d more\n", len(records)-maxFindLines))
+			break
+		}
+		text.WriteString(fmt.Sprintf("• `%s` %s `%s`\n", r.Name, r.Type, r.Content))
+	}
+
+	recordRows, err := b.viewRecordRows(ctx, menu, zoneName, records[:min(len(records), maxSearchResults)])
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("loading records", err), tele.ModeMarkdown)
+	}
+	rows = append(rows, recordRows...)
+	rows = append(rows, menu.Row(menu.Data("◀️ Open Zone", "page", zoneName, "0"), menu.Data("🏠 Main Menu", "menu")))
+	menu.Inline(rows...)
+
+	return b.sendWithThread(c, text.String(), menu, tele.ModeMarkdown)
+}
+
+// confirmCommand keeps a command as the user's pending command and asks to
+// confirm it
+func (b *Bot) confirmCommand(c tele.Context, pending pendingCommand, text string) error {
+	pending.id = strconv.FormatInt(time.Now().UnixNano(), 36)
+	b.stateManager.SetData(c.Sender().ID, "command", pending)
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(
+		menu.Row(menu.Data("✅ Confirm", "cmd_ok", pending.id), menu.Data("❌ Cancel", "cmd_cancel", pending.id)),
+	)
+	return b.sendWithThread(c, text, menu, tele.ModeMarkdown)
+}
+
+// handleCommandConfirm runs a confirmed text command
+func (b *Bot) handleCommandConfirm(c tele.Context, userID int64, id string) error {
+	pending, ok := b.pendingCommand(userID, id)
+	if !ok {
+		return b.editWithThread(c, "❌ This command has expired. Please send it again.", tele.ModeMarkdown)
+	}
+	b.stateManager.DeleteData(userID, "command")
+
+	if parked, err := b.requestApproval(c, pending.pendingChange()); parked {
+		return err
+	}
+
+	ctx, change := usecase.WithChangeRef(b.actorContext(c))
+	var text string
+	switch pending.action {
+	case "add":
+		record, err := b.dnsUsecase.CreateRecord(ctx, pending.create)
+		if err != nil {
+			return b.editWithThread(c, recordErrorText("creating record", err), tele.ModeMarkdown)
+		}
+		text = "✅ *Record Created Successfully!*\n\n" + recordSummary(*record)
+	case "set":
+		record, err := b.dnsUsecase.UpdateRecord(ctx, pending.update)
+		if err != nil {
+			return b.editWithThread(c, recordErrorText("updating record", err), tele.ModeMarkdown)
+		}
+		text = "✅ *Record Updated Successfully!*\n\n" + recordSummary(*record)
+	case "del":
+		err := b.dnsUsecase.DeleteRecord(ctx, pending.zone, usecase.RecordSelector{RecordID: pending.record.ID})
+		if err != nil {
+			return b.editWithThread(c, recordErrorText("deleting record", err), tele.ModeMarkdown)
+		}
+		text = "✅ *Record Deleted*\n\n" + recordSummary(pending.record)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	rows := undoRows(menu, change)
+	rows = append(rows, menu.Row(menu.Data("◀️ Open Zone", "page", pending.zone, "0"), menu.Data("🏠 Main Menu", "menu")))
+	menu.Inline(rows...)
+
+	return b.editWithThread(c, text, menu, tele.ModeMarkdown)
+}
+
+// pendingChange returns the change a text command makes, for the
+// approval policy
+func (p pendingCommand) pendingChange() usecase.PendingChange {
+	change := usecase.PendingChange{ZoneName: p.zone}
+	switch p.action {
+	case "add":
+		change.Kind, change.Create = usecase.ChangeCreate, &p.create
+	case "set":
+		change.Kind, change.Update = usecase.ChangeUpdate, &p.update
+	case "del":
+		change.Kind, change.Delete = usecase.ChangeDelete, &usecase.RecordSelector{RecordID: p.record.ID}
+	}
+	return change
+}
+
+// handleCommandCancel drops a pending text command
+func (b *Bot) handleCommandCancel(c tele.Context, userID int64, id string) error {
+	if _, ok := b.pendingCommand(userID, id); ok {
+		b.stateManager.DeleteData(userID, "command")
+	}
+	return b.editWithThread(c, "❌ Cancelled.", tele.ModeMarkdown)
+}
+
+// pendingCommand returns the user's pending command if it has the given ID
+func (b *Bot) pendingCommand(userID int64, id string) (pendingCommand, bool) {
+	val, ok := b.stateManager.GetData(userID, "command")
+	if !ok {
+		return pendingCommand{}, false
+	}
+	pending, ok := val.(pendingCommand)
+	return pending, ok && pending.id == id
+}
+
+// splitCommandContent takes the optional TTL and proxy status from the end
+// of the arguments of /add and returns the rest as the content. Trailing
+// words are only taken as options if the content before them is valid on
+// its own, so the last field of content such as "3 1 1 0123" stays.
+func splitCommandContent(recordType string, args []string) (string, int, bool) {
+	n := len(args)
+	if n > 2 && validCommandContent(recordType, args[:n-2]) {
+		ttl, ttlOK := parseTTL(args[n-2])
+		proxied, proxiedOK := parseYesNo(args[n-1])
+		if ttlOK && proxiedOK {
+			return strings.Join(args[:n-2], " "), ttl, proxied
+		}
+	}
+	if n > 1 && validCommandContent(recordType, args[:n-1]) {
+		if proxied, ok := parseYesNo(args[n-1]); ok {
+			return strings.Join(args[:n-1], " "), domain.AutoTTL, proxied
+		}
+		if ttl, ok := parseTTL(args[n-1]); ok {
+			return strings.Join(args[:n-1], " "), ttl, false
+		}
+	}
+	return strings.Join(args, " "), domain.AutoTTL, false
+}
+
+// validCommandContent reports whether the arguments form valid content
+// for the record type
+func validCommandContent(recordType string, args []string) bool {
+	content := strings.Join(args, " ")
+	if recordType == "MX" {
+		host, _, ok := splitMXContent(content)
+		if !ok {
+			return false
+		}
+		content = host
+	}
+	return domain.ValidateContent(recordType, content) == nil
+}
+
+// parseTTL parses a TTL in seconds or "auto"
+func parseTTL(value string) (int, bool) {
+	if strings.EqualFold(value, "auto") {
+		return domain.AutoTTL, true
+	}
+	ttl, err := strconv.Atoi(value)
+	return ttl, err == nil
+}
+
+// hasZone reports whether a zone of the given name is in the list
+func hasZone(zones []domain.Zone, name string) bool {
+	for _, z := range zones {
+		if z.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// recordSummary formats the fields of a record for a message
+func recordSummary(r domain.DNSRecord) string {
+	summary := fmt.Sprintf("Name: `%s`\nType: `%s`\nContent: `%s`\nTTL: `%d`\nProxied: `%v`", r.Name, r.Type, r.Content, r.TTL, r.Proxied)
+	if r.Priority != nil {
+		summary += fmt.Sprintf("\nPriority: `%d`", *r.Priority)
+	}
+	return summary
+}
@@ -0,0 +1,164 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/usecase"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// clearValue is the text that removes a comment or all tags
+const clearValue = "-"
+
+// recordMetaText formats the comment and tags of a record for the record
+// details, or returns an empty string if it has neither
+func recordMetaText(r domain.DNSRecord) string {
+	var text strings.Builder
+	if r.Comment != "" {
+		text.WriteString(fmt.Sprintf("Comment: `%s`\n", strings.ReplaceAll(r.Comment, "`", "'")))
+	}
+	if len(r.Tags) > 0 {
+		text.WriteString(fmt.Sprintf("Tags: `%s`\n", strings.Join(r.Tags, ", ")))
+	}
+	return text.String()
+}
+
+// handleEditComment asks for a new comment of the selected record
+func (b *Bot) handleEditComment(c tele.Context, userID int64, zoneName, pageStr, idxStr string) error {
+	r, err := b.startEditMeta(c, userID, zoneName, pageStr, idxStr, StepEditRecordComment)
+	if err != nil {
+		return b.editWithThread(c, recordErrorText("loading record", err), tele.ModeMarkdown)
+	}
+
+	current := "none"
+	if r.Comment != "" {
+		current = "`" + strings.ReplaceAll(r.Comment, "`", "'") + "`"
+	}
+
+	return b.editWithThread(c, fmt.Sprintf(
+		"*💬 Edit Comment*\n\nName: `%s`\nType: `%s`\nCurrent comment: %s\n\nSend the new comment, or `%s` to remove it:",
+		r.Name, r.Type, current, clearValue,
+	), b.editMetaMenu(), tele.ModeMarkdown)
+}
+
+// handleEditTags asks for the new tags of the selected record
+func (b *Bot) handleEditTags(c tele.Context, userID int64, zoneName, pageStr, idxStr string) error {
+	r, err := b.startEditMeta(c, userID, zoneName, pageStr, idxStr, StepEditRecordTags)
+	if err != nil {
+		return b.editWithThread(c, recordErrorText("loading record", err), tele.ModeMarkdown)
+	}
+
+	current := "none"
+	if len(r.Tags) > 0 {
+		current = "`" + strings.Join(r.Tags, ", ") + "`"
+	}
+
+	return b.editWithThread(c, fmt.Sprintf(
+		"*🏷️ Edit Tags*\n\nName: `%s`\nType: `%s`\nCurrent tags: %s\n\nSend the new tags separated by commas (e.g., `owner:dns-team, ticket:OPS-123`), or `%s` to remove all tags:",
+		r.Name, r.Type, current, clearValue,
+	), b.editMetaMenu(), tele.ModeMarkdown)
+}
+
+// handleInputRecordComment saves the comment sent by the user
+func (b *Bot) handleInputRecordComment(c tele.Context, userID int64, text string) error {
+	comment := strings.TrimSpace(text)
+	if comment == clearValue {
+		comment = ""
+	}
+	return b.saveRecordMeta(c, userID, usecase.UpdateRecordInput{Comment: &comment})
+}
+
+// handleInputRecordTags saves the tags sent by the user
+func (b *Bot) handleInputRecordTags(c tele.Context, userID int64, text string) error {
+	tags := []string{}
+	if strings.TrimSpace(text) != clearValue {
+		tags = domain.ParseTags(text)
+	}
+	if err := domain.ValidateTags(tags); err != nil {
+		return b.sendWithThread(c, recordErrorText("", err)+"\n\nPlease enter the tags again.", tele.ModeMarkdown)
+	}
+	return b.saveRecordMeta(c, userID, usecase.UpdateRecordInput{Tags: tags})
+}
+
+// startEditMeta loads the selected record and remembers it for a comment
+// or tags edit
+func (b *Bot) startEditMeta(c tele.Context, userID int64, zoneName, pageStr, idxStr string, step Step) (*domain.DNSRecord, error) {
+	page, _ := strconv.Atoi(pageStr)
+	idx, _ := strconv.Atoi(idxStr)
+
+	records, err := b.dnsUsecase.ListRecords(b.actorContext(c), zoneName, domain.RecordFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	recordsPerPage := 10
+	startIdx := page * recordsPerPage
+	if startIdx+idx >= len(records) {
+		return nil, domain.ErrRecordNotFound
+	}
+	r := records[startIdx+idx]
+
+	b.stateManager.SetData(userID, "edit_zone", zoneName)
+	b.stateManager.SetData(userID, "edit_record_id", r.ID)
+	b.stateManager.SetData(userID, "edit_page", page)
+	b.stateManager.SetData(userID, "edit_idx", idx)
+	b.stateManager.SetStep(userID, step)
+
+	return &r, nil
+}
+
+// saveRecordMeta updates the comment or tags of the record being edited
+func (b *Bot) saveRecordMeta(c tele.Context, userID int64, input usecase.UpdateRecordInput) error {
+	zone := b.getStateData(userID, "edit_zone")
+	recordID := b.getStateData(userID, "edit_record_id")
+	if zone == "" || recordID == "" {
+		return b.sendWithThread(c, "❌ Edit session expired. Please open the record again.", tele.ModeMarkdown)
+	}
+
+	ctx, change := usecase.WithChangeRef(b.actorContext(c))
+	input.ZoneName = zone
+	input.Selector = usecase.RecordSelector{RecordID: recordID}
+
+	if parked, err := b.requestApproval(c, usecase.PendingChange{Kind: usecase.ChangeUpdate, ZoneName: zone, Update: &input}); parked {
+		b.stateManager.ClearState(userID)
+		return err
+	}
+
+	updated, err := b.dnsUsecase.UpdateRecord(ctx, input)
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("updating record", err), tele.ModeMarkdown)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	rows := undoRows(menu, change)
+	rows = append(rows,
+		menu.Row(menu.Data("◀️ Back to List", "page", zone, b.getStateData(userID, "edit_page"))),
+		menu.Row(menu.Data("🏠 Main Menu", "menu")),
+	)
+	menu.Inline(rows...)
+
+	b.stateManager.ClearState(userID)
+
+	meta := recordMetaText(*updated)
+	if meta == "" {
+		meta = "No comment or tags.\n"
+	}
+	return b.sendWithThread(c, fmt.Sprintf(
+		"✅ *Record Updated Successfully!*\n\nName: `%s`\nType: `%s`\n%s",
+		updated.Name, updated.Type, meta,
+	), menu, tele.ModeMarkdown)
+}
+
+// editMetaMenu returns the buttons shown while a comment or tags are
+// being entered
+func (b *Bot) editMetaMenu() *tele.ReplyMarkup {
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(
+		menu.Row(menu.Data("◀️ Back", "back", "edit_content"), menu.Data("❌ Cancel", "cancel_edit")),
+	)
+	return menu
+}
@@ -0,0 +1,263 @@
+package telegram
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"cf-dns-bot/internal/handler"
+	"cf-dns-bot/internal/usecase"
+	"cf-dns-bot/pkg/storage"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// startKeyScope begins picking the scope of a new API key. Keys made by
+// an admin restricted to zones start with the same zones.
+func (b *Bot) startKeyScope(c tele.Context, adminID int64) error {
+	if b.apiKeyStorage == nil {
+		return b.sendWithThread(c, "❌ API key storage not configured.", tele.ModeMarkdown)
+	}
+	b.stateManager.SetData(adminID, "key_scope", storage.APIKeyScope{Zones: b.userZones(adminID)})
+	return b.showKeyScope(c, adminID)
+}
+
+// showKeyScope shows the scope picked so far with the buttons to change it
+func (b *Bot) showKeyScope(c tele.Context, adminID int64) error {
+	reply := b.replyFunc(c)
+	scope, ok := b.keyScope(adminID)
+	if !ok {
+		return reply(c, "❌ Key setup expired. Please generate the key again.", tele.ModeMarkdown)
+	}
+	b.stateManager.SetStep(adminID, StepNone)
+
+	readOnlyLabel := "👁️ Read-only: off"
+	if scope.ReadOnly {
+		readOnlyLabel = "👁️ Read-only: on"
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(
+		menu.Row(menu.Data("🌐 Zones", "key_zones"), menu.Data("🏷️ Records", "key_records")),
+		menu.Row(menu.Data(readOnlyLabel, "key_ro"), menu.Data("🧰 Tools", "key_tools")),
+		menu.Row(menu.Data("✅ Generate Key", "key_create")),
+		menu.Row(menu.Data("◀️ Back", "apikeys")),
+	)
+
+	return reply(c, fmt.Sprintf(
+		"*🔑 New API Key*\n\n%s\n\nNarrow down what the key may do, then generate it. An unrestricted key may do everything.",
+		describeKeyScope(scope),
+	), menu, tele.ModeMarkdown)
+}
+
+// handleKeyZonesInput asks for the zones of the new key
+func (b *Bot) handleKeyZonesInput(c tele.Context, adminID int64) error {
+	if _, ok := b.keyScope(adminID); !ok {
+		return b.editWithThread(c, "❌ Key setup expired. Please generate the key again.", tele.ModeMarkdown)
+	}
+	b.stateManager.SetStep(adminID, StepInputKeyZones)
+
+	text := fmt.Sprintf(
+		"*🌐 Key Zones*\n\nSend the zones separated by commas. Wildcards are allowed: `*.example.com` matches the zones below `example.com` but not `example.com` itself.\n\nSend `%s` to allow every zone.",
+		clearValue,
+	)
+	if zones := b.userZones(adminID); len(zones) > 0 {
+		text += fmt.Sprintf("\n\nYou can only give your own zones: %s", zonesLabel(zones))
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(menu.Row(menu.Data("◀️ Back", "key_scope")))
+	return b.editWithThread(c, text, menu, tele.ModeMarkdown)
+}
+
+// handleKeyZones saves the zones sent by the admin
+func (b *Bot) handleKeyZones(c tele.Context, adminID int64, text string) error {
+	scope, ok := b.keyScope(adminID)
+	if !ok {
+		return b.sendWithThread(c, "❌ Key setup expired. Please generate the key again.", tele.ModeMarkdown)
+	}
+
+	zones, err := parsePatterns(text)
+	if err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ Invalid zones: %v\n\nPlease enter the zones again.", err), tele.ModeMarkdown)
+	}
+
+	// Admins restricted to zones can only give their own zones
+	if own := b.userZones(adminID); len(own) > 0 {
+		if len(zones) == 0 {
+			zones = own
+		}
+		for _, zone := range zones {
+			if !slices.ContainsFunc(own, func(z string) bool { return strings.EqualFold(z, zone) }) {
+				return b.sendWithThread(c, fmt.Sprintf("⛔ `%s` is not one of your zones.\n\nPlease enter the zones again.", zone), tele.ModeMarkdown)
+			}
+		}
+	}
+
+	scope.Zones = zones
+	b.stateManager.SetData(adminID, "key_scope", scope)
+	return b.showKeyScope(c, adminID)
+}
+
+// handleKeyRecordsInput asks for the record names of the new key
+func (b *Bot) handleKeyRecordsInput(c tele.Context, adminID int64) error {
+	if _, ok := b.keyScope(adminID); !ok {
+		return b.editWithThread(c, "❌ Key setup expired. Please generate the key again.", tele.ModeMarkdown)
+	}
+	b.stateManager.SetStep(adminID, StepInputKeyRecords)
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(menu.Row(menu.Data("◀️ Back", "key_scope")))
+	return b.editWithThread(c, fmt.Sprintf(
+		"*🏷️ Key Records*\n\nSend the full names of the records the key may see and change, separated by commas. Wildcards are allowed, e.g. `_acme-challenge.*` for ACME DNS challenges.\n\nSend `%s` to allow every record.",
+		clearValue,
+	), menu, tele.ModeMarkdown)
+}
+
+// handleKeyRecords saves the record names sent by the admin
+func (b *Bot) handleKeyRecords(c tele.Context, adminID int64, text string) error {
+	scope, ok := b.keyScope(adminID)
+	if !ok {
+		return b.sendWithThread(c, "❌ Key setup expired. Please generate the key again.", tele.ModeMarkdown)
+	}
+
+	records, err := parsePatterns(text)
+	if err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ Invalid records: %v\n\nPlease enter the records again.", err), tele.ModeMarkdown)
+	}
+
+	scope.Records = records
+	b.stateManager.SetData(adminID, "key_scope", scope)
+	return b.showKeyScope(c, adminID)
+}
+
+// handleKeyReadOnly switches the new key between read-only and read-write
+func (b *Bot) handleKeyReadOnly(c tele.Context, adminID int64) error {
+	scope, ok := b.keyScope(adminID)
+	if !ok {
+		return b.editWithThread(c, "❌ Key setup expired. Please generate the key again.", tele.ModeMarkdown)
+	}
+	scope.ReadOnly = !scope.ReadOnly
+	b.stateManager.SetData(adminID, "key_scope", scope)
+	return b.showKeyScope(c, adminID)
+}
+
+// showKeyTools lists the tools the new key may be limited to, marking the
+// picked ones
+func (b *Bot) showKeyTools(c tele.Context, adminID int64) error {
+	scope, ok := b.keyScope(adminID)
+	if !ok {
+		return b.editWithThread(c, "❌ Key setup expired. Please generate the key again.", tele.ModeMarkdown)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	var rows []tele.Row
+	allLabel := "🧰 All tools"
+	if len(scope.Tools) == 0 {
+		allLabel = "✅ All tools"
+	}
+	rows = append(rows, menu.Row(menu.Data(allLabel, "key_tool_all")))
+	for i := 0; i < len(handler.Tools); i += 2 {
+		var row tele.Row
+		for j := i; j < i+2 && j < len(handler.Tools); j++ {
+			label := handler.Tools[j]
+			if slices.Contains(scope.Tools, handler.Tools[j]) {
+				label = "✅ " + label
+			}
+			row = append(row, menu.Data(label, "key_tool", strconv.Itoa(j)))
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows, menu.Row(menu.Data("◀️ Done", "key_scope")))
+	menu.Inline(rows...)
+
+	text := "*🧰 Key Tools*\n\nTap the MCP tools the key may use. The REST endpoints of the standalone server are named the same way."
+	if scope.ReadOnly {
+		text += "\n\nThe key is read-only, so tools that change records stay blocked."
+	}
+	return b.editWithThread(c, text, menu, tele.ModeMarkdown)
+}
+
+// handleKeyTool adds a tool to the picked tools, or removes it
+func (b *Bot) handleKeyTool(c tele.Context, adminID int64, idxStr string) error {
+	scope, ok := b.keyScope(adminID)
+	if !ok {
+		return b.editWithThread(c, "❌ Key setup expired. Please generate the key again.", tele.ModeMarkdown)
+	}
+
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil || idx < 0 || idx >= len(handler.Tools) {
+		return b.showKeyTools(c, adminID)
+	}
+
+	tool := handler.Tools[idx]
+	if i := slices.Index(scope.Tools, tool); i >= 0 {
+		scope.Tools = slices.Delete(slices.Clone(scope.Tools), i, i+1)
+	} else {
+		scope.Tools = append(slices.Clone(scope.Tools), tool)
+	}
+	b.stateManager.SetData(adminID, "key_scope", scope)
+	return b.showKeyTools(c, adminID)
+}
+
+// handleKeyToolAll clears the picked tools, allowing every tool
+func (b *Bot) handleKeyToolAll(c tele.Context, adminID int64) error {
+	scope, ok := b.keyScope(adminID)
+	if !ok {
+		return b.editWithThread(c, "❌ Key setup expired. Please generate the key again.", tele.ModeMarkdown)
+	}
+	scope.Tools = nil
+	b.stateManager.SetData(adminID, "key_scope", scope)
+	return b.showKeyTools(c, adminID)
+}
+
+// keyScope returns the scope an admin is picking for a new key
+func (b *Bot) keyScope(adminID int64) (storage.APIKeyScope, bool) {
+	val, ok := b.stateManager.GetData(adminID, "key_scope")
+	if !ok {
+		return storage.APIKeyScope{}, false
+	}
+	scope, ok := val.(storage.APIKeyScope)
+	return scope, ok
+}
+
+// parsePatterns splits a list of zone or record name patterns separated by
+// commas or spaces. clearValue returns no patterns.
+func parsePatterns(text string) ([]string, error) {
+	if strings.TrimSpace(text) == clearValue {
+		return nil, nil
+	}
+
+	var patterns []string
+	for _, pattern := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' }) {
+		pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+		if !usecase.ValidPattern(pattern) {
+			return nil, fmt.Errorf("bad pattern `%s`", pattern)
+		}
+		if !slices.Contains(patterns, pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no patterns given")
+	}
+	return patterns, nil
+}
+
+// describeKeyScope formats the scope of an API key
+func describeKeyScope(scope storage.APIKeyScope) string {
+	records := "all records"
+	if len(scope.Records) > 0 {
+		records = "`" + strings.Join(scope.Records, "`, `") + "`"
+	}
+	access := "read-write"
+	if scope.ReadOnly {
+		access = "read-only"
+	}
+	tools := "all tools"
+	if len(scope.Tools) > 0 {
+		tools = "`" + strings.Join(scope.Tools, "`, `") + "`"
+	}
+	return fmt.Sprintf("Zones: %s\nRecords: %s\nAccess: %s\nTools: %s", zonesLabel(scope.Zones), records, access, tools)
+}
@@ -0,0 +1,501 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"cf-dns-bot/pkg/storage"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// roleRanks orders the roles; a user may do what the lower roles may
+var roleRanks = map[string]int{
+	storage.RoleViewer: 1,
+	storage.RoleEditor: 2,
+	storage.RoleAdmin:  3,
+}
+
+// requiredRoles maps callbacks and commands to the least role that may use
+// them. Anything not listed only needs access to the bot, which viewers
+// have.
+var requiredRoles = map[string]string{
+	// Record changes
+	"create":             storage.RoleEditor,
+	"create_in_zone":     storage.RoleEditor,
+	"select_zone_create": storage.RoleEditor,
+	"select_type":        storage.RoleEditor,
+	"select_ttl":         storage.RoleEditor,
+	"proxied":            storage.RoleEditor,
+	"confirm_create":     storage.RoleEditor,
+	"back":               storage.RoleEditor,
+	"edit_rec":           storage.RoleEditor,
+	"edit_ttl":           storage.RoleEditor,
+	"edit_proxied":       storage.RoleEditor,
+	"edit_comment":       storage.RoleEditor,
+	"edit_tags":          storage.RoleEditor,
+	"delete_rec":         storage.RoleEditor,
+	"cmd_ok":             storage.RoleEditor,
+	"undo":               storage.RoleEditor,
+	"approve_change":     storage.RoleEditor,
+	"reject_change":      storage.RoleEditor,
+	"/add":               storage.RoleEditor,
+	"/set":               storage.RoleEditor,
+	"/del":               storage.RoleEditor,
+	"/approvals":         storage.RoleEditor,
+
+	// Zone-wide changes
+	"bulk":           storage.RoleAdmin,
+	"bulk_type":      storage.RoleAdmin,
+	"bulk_act":       storage.RoleAdmin,
+	"bulk_ok":        storage.RoleAdmin,
+	"import_zone":    storage.RoleAdmin,
+	"import_confirm": storage.RoleAdmin,
+	"snapshots":      storage.RoleAdmin,
+	"snap_take":      storage.RoleAdmin,
+	"snap_view":      storage.RoleAdmin,
+	"snap_rec":       storage.RoleAdmin,
+	"snap_all":       storage.RoleAdmin,
+	"snap_all_ok":    storage.RoleAdmin,
+
+	// Administration
+	"users":           storage.RoleAdmin,
+	"remove_user":     storage.RoleAdmin,
+	"user_acct":       storage.RoleAdmin,
+	"user_acct_set":   storage.RoleAdmin,
+	"user_role":       storage.RoleAdmin,
+	"user_role_set":   storage.RoleAdmin,
+	"user_zone":       storage.RoleAdmin,
+	"user_zone_all":   storage.RoleAdmin,
+	"user_zone_ok":    storage.RoleAdmin,
+	"approve_request": storage.RoleAdmin,
+	"reject_request":  storage.RoleAdmin,
+	"audit":           storage.RoleAdmin,
+	"cache":           storage.RoleAdmin,
+	"cache_clear":     storage.RoleAdmin,
+	"mcphttp":         storage.RoleAdmin,
+	"mcphttp_start":   storage.RoleAdmin,
+	"mcphttp_stop":    storage.RoleAdmin,
+	"mcphttp_port":    storage.RoleAdmin,
+	"mcphttp_status":  storage.RoleAdmin,
+	"apikeys":         storage.RoleAdmin,
+	"apikey_generate": storage.RoleAdmin,
+	"apikey_list":     storage.RoleAdmin,
+	"apikey_delete":   storage.RoleAdmin,
+	"delete_key":      storage.RoleAdmin,
+	"key_scope":       storage.RoleAdmin,
+	"key_zones":       storage.RoleAdmin,
+	"key_records":     storage.RoleAdmin,
+	"key_ro":          storage.RoleAdmin,
+	"key_tools":       storage.RoleAdmin,
+	"key_tool":        storage.RoleAdmin,
+	"key_tool_all":    storage.RoleAdmin,
+	"key_create":      storage.RoleAdmin,
+	"apikey_acct":     storage.RoleAdmin,
+	"apikey_acct_key": storage.RoleAdmin,
+	"apikey_acct_set": storage.RoleAdmin,
+	"/requests":       storage.RoleAdmin,
+	"/adduser":        storage.RoleAdmin,
+	"/users":          storage.RoleAdmin,
+}
+
+// roleLabels are the display names of the roles
+var roleLabels = map[string]string{
+	storage.RoleViewer: "👁️ Viewer",
+	storage.RoleEditor: "✏️ Editor",
+	storage.RoleAdmin:  "👑 Admin",
+}
+
+// userRole returns the role of a user. The configured admins are always
+// admins; other users have their stored role, and users added before roles
+// existed are editors. Without configured admins the bot is open and
+// everyone is an admin.
+func (b *Bot) userRole(userID int64) string {
+	if len(b.allowedIDs) == 0 || b.isConfiguredAdmin(userID) {
+		return storage.RoleAdmin
+	}
+	if b.allowedUserStorage != nil {
+		if role := b.allowedUserStorage.GetUserRole(userID); role != "" {
+			return role
+		}
+	}
+	return storage.RoleEditor
+}
+
+// isConfiguredAdmin reports whether a user is one of the admins set in
+// TELEGRAM_ALLOWED_USERS, whose role and zones cannot be changed in the bot
+func (b *Bot) isConfiguredAdmin(userID int64) bool {
+	return b.allowedIDs[userID]
+}
+
+// hasRole reports whether a user has at least the given role
+func (b *Bot) hasRole(userID int64, role string) bool {
+	return roleRanks[b.userRole(userID)] >= roleRanks[role]
+}
+
+// isAdmin reports whether a user has the admin role
+func (b *Bot) isAdmin(userID int64) bool {
+	return b.hasRole(userID, storage.RoleAdmin)
+}
+
+// userZones returns the zones a user is restricted to, or nil for every zone
+func (b *Bot) userZones(userID int64) []string {
+	if b.allowedUserStorage == nil || b.isConfiguredAdmin(userID) {
+		return nil
+	}
+	return b.allowedUserStorage.GetUserZones(userID)
+}
+
+// adminIDs returns the users with the admin role, who get access requests
+// and changes waiting for approval
+func (b *Bot) adminIDs() []int64 {
+	candidates := make(map[int64]bool, len(b.allowedIDs))
+	for id := range b.allowedIDs {
+		candidates[id] = true
+	}
+	if b.allowedUserStorage != nil {
+		users, err := b.allowedUserStorage.GetAllowedUsers()
+		if err != nil {
+			log.Printf("[adminIDs] Warning: failed to load allowed users: %v", err)
+		}
+		for _, u := range users {
+			candidates[u.UserID] = true
+		}
+	}
+
+	var ids []int64
+	for id := range candidates {
+		if b.isAdmin(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// requiredRole returns the role needed for a callback or command
+func requiredRole(c tele.Context) string {
+	if cb := c.Callback(); cb != nil {
+		action := cb.Unique
+		if action == "" {
+			action, _, _ = strings.Cut(strings.TrimPrefix(cb.Data, "\f"), "|")
+		}
+		return requiredRoles[action]
+	}
+
+	if msg := c.Message(); msg != nil && strings.HasPrefix(msg.Text, "/") {
+		command, _, _ := strings.Cut(strings.Fields(msg.Text)[0], "@")
+		return requiredRoles[command]
+	}
+	return ""
+}
+
+// checkRole tells the user when their role does not allow a callback or
+// command and reports whether it may go ahead
+func (b *Bot) checkRole(c tele.Context) bool {
+	role := requiredRole(c)
+	if role == "" || b.hasRole(c.Sender().ID, role) {
+		return true
+	}
+
+	if c.Callback() != nil {
+		c.Respond(&tele.CallbackResponse{Text: "⛔ Your role does not allow this.", ShowAlert: true})
+		return false
+	}
+	b.sendWithThread(c, fmt.Sprintf(
+		"⛔ Your role (*%s*) does not allow this. Ask an admin for more access.",
+		b.userRole(c.Sender().ID),
+	), tele.ModeMarkdown)
+	return false
+}
+
+// permittedRows drops the buttons a user's role does not allow, and rows
+// left empty
+func (b *Bot) permittedRows(userID int64, rows ...tele.Row) []tele.Row {
+	var permitted []tele.Row
+	for _, row := range rows {
+		var kept tele.Row
+		for _, btn := range row {
+			if role := requiredRoles[btn.Unique]; role == "" || b.hasRole(userID, role) {
+				kept = append(kept, btn)
+			}
+		}
+		if len(kept) > 0 {
+			permitted = append(permitted, kept)
+		}
+	}
+	return permitted
+}
+
+// isAllowedUser reports whether a user already has access in some chat
+func (b *Bot) isAllowedUser(userID int64) bool {
+	if b.allowedIDs[userID] || b.allowedUserStorage == nil {
+		return b.allowedIDs[userID]
+	}
+	users, err := b.allowedUserStorage.GetAllowedUsers()
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(users, func(u storage.AllowedUser) bool {
+		return u.UserID == userID
+	})
+}
+
+// grantRole stores the role and zones of a newly allowed user and
+// restricts them to the account of the admin who added them. Without user
+// storage only the configured admins can use the bot, so the user is
+// added to them. It returns the role and zones the user ends up with.
+func (b *Bot) grantRole(adminID, userID int64, role string, zones []string) (string, []string) {
+	if b.allowedUserStorage == nil {
+		b.allowedIDs[userID] = true
+		return storage.RoleAdmin, nil
+	}
+	if err := b.allowedUserStorage.SetUserRole(userID, role, zones); err != nil {
+		log.Printf("[grantRole] Warning: failed to set role of user %d: %v", userID, err)
+	}
+	b.inheritAccount(adminID, userID)
+	return role, zones
+}
+
+// configuredAdminText explains why the role of a configured admin cannot
+// be changed
+func configuredAdminText(userID int64) string {
+	return fmt.Sprintf("⛔ User `%d` is an admin set in `TELEGRAM_ALLOWED_USERS`. Their role and zones cannot be changed in the bot.", userID)
+}
+
+// roleGrant is the role and zones an admin is picking for a user
+type roleGrant struct {
+	userID int64
+	role   string
+	zones  []string
+}
+
+// showUserRole asks which role to give a user, either when approving an
+// access request or for an allowed user
+func (b *Bot) showUserRole(c tele.Context, adminID int64, userIDStr string) error {
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		return b.sendWithThread(c, "❌ Invalid user ID.", tele.ModeMarkdown)
+	}
+	if userID == adminID {
+		return b.sendWithThread(c, "⛔ You cannot change your own role.", tele.ModeMarkdown)
+	}
+	if b.isConfiguredAdmin(userID) {
+		return b.sendWithThread(c, configuredAdminText(userID), tele.ModeMarkdown)
+	}
+
+	pending := b.isPendingRequest(userID)
+	if b.allowedUserStorage == nil && !pending {
+		return b.sendWithThread(c, "❌ User storage not configured.", tele.ModeMarkdown)
+	}
+
+	text := fmt.Sprintf("*🎭 Role of User* `%d`\n\nCurrently: *%s*", userID, b.userRole(userID))
+	back := "users"
+	if pending {
+		text = fmt.Sprintf("*✅ Approve User* `%d`", userID)
+		back = "menu"
+	}
+
+	// Without user storage, roles and zones cannot be kept and every
+	// approved user becomes an admin
+	roles := []string{storage.RoleViewer, storage.RoleEditor, storage.RoleAdmin}
+	if b.allowedUserStorage == nil {
+		roles = []string{storage.RoleAdmin}
+		text += "\n\n⚠️ User storage is not configured, so roles and zones cannot be kept. The user will be an *admin* for all zones."
+	} else {
+		text += "\n\n• *Viewer* browses zones and records\n• *Editor* also creates, edits and deletes records\n• *Admin* also manages users, API keys, bulk actions, snapshots and the MCP server\n\nPick a role:"
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	var row tele.Row
+	for _, role := range roles {
+		row = append(row, menu.Data(roleLabels[role], "user_role_set", userIDStr, role))
+	}
+	menu.Inline(row, menu.Row(menu.Data("◀️ Back", back)))
+	return b.editWithThread(c, text, menu, tele.ModeMarkdown)
+}
+
+// handleUserRole picks a role and asks which zones the user may use
+func (b *Bot) handleUserRole(c tele.Context, adminID int64, userIDStr, role string) error {
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil || roleRanks[role] == 0 {
+		return b.sendWithThread(c, "❌ Invalid user or role.", tele.ModeMarkdown)
+	}
+	if userID == adminID {
+		return b.sendWithThread(c, "⛔ You cannot change your own role.", tele.ModeMarkdown)
+	}
+	if b.isConfiguredAdmin(userID) {
+		return b.sendWithThread(c, configuredAdminText(userID), tele.ModeMarkdown)
+	}
+
+	// Without user storage there are no zones to pick
+	if b.allowedUserStorage == nil {
+		if !b.isPendingRequest(userID) {
+			return b.sendWithThread(c, "❌ User storage not configured.", tele.ModeMarkdown)
+		}
+		return b.handleApproveRequest(c, userIDStr, storage.RoleAdmin, nil)
+	}
+
+	grant := roleGrant{userID: userID, role: role}
+	if !b.isPendingRequest(userID) {
+		grant.zones = b.userZones(userID)
+	}
+	b.stateManager.SetData(adminID, "role_grant", grant)
+	return b.showUserZones(c, adminID)
+}
+
+// handleUserZone adds a zone to the picked zones, or removes it
+func (b *Bot) handleUserZone(c tele.Context, adminID int64, idxStr string) error {
+	grant, ok := b.roleGrant(adminID)
+	if !ok {
+		return b.editWithThread(c, "❌ Role selection expired. Please start again.", tele.ModeMarkdown)
+	}
+
+	zones, err := b.grantableZones(c)
+	if err != nil {
+		return b.editWithThread(c, recordErrorText("loading zones", err), tele.ModeMarkdown)
+	}
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil || idx < 0 || idx >= len(zones) {
+		return b.showUserZones(c, adminID)
+	}
+
+	zone := zones[idx]
+	if i := slices.Index(grant.zones, zone); i >= 0 {
+		grant.zones = slices.Delete(slices.Clone(grant.zones), i, i+1)
+	} else {
+		grant.zones = append(slices.Clone(grant.zones), zone)
+	}
+	b.stateManager.SetData(adminID, "role_grant", grant)
+	return b.showUserZones(c, adminID)
+}
+
+// handleUserZoneAll clears the picked zones, allowing every zone
+func (b *Bot) handleUserZoneAll(c tele.Context, adminID int64) error {
+	grant, ok := b.roleGrant(adminID)
+	if !ok {
+		return b.editWithThread(c, "❌ Role selection expired. Please start again.", tele.ModeMarkdown)
+	}
+	grant.zones = nil
+	b.stateManager.SetData(adminID, "role_grant", grant)
+	return b.showUserZones(c, adminID)
+}
+
+// showUserZones lists the zones an admin can give a user, marking the
+// picked ones
+func (b *Bot) showUserZones(c tele.Context, adminID int64) error {
+	grant, ok := b.roleGrant(adminID)
+	if !ok {
+		return b.editWithThread(c, "❌ Role selection expired. Please start again.", tele.ModeMarkdown)
+	}
+
+	zones, err := b.grantableZones(c)
+	if err != nil {
+		return b.editWithThread(c, recordErrorText("loading zones", err), tele.ModeMarkdown)
+	}
+
+	userIDStr := strconv.FormatInt(grant.userID, 10)
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	var rows []tele.Row
+	allLabel := "🌐 All zones"
+	if len(grant.zones) == 0 {
+		allLabel = "✅ All zones"
+	}
+	rows = append(rows, menu.Row(menu.Data(allLabel, "user_zone_all")))
+	for i := 0; i < len(zones); i += 2 {
+		var row tele.Row
+		for j := i; j < i+2 && j < len(zones); j++ {
+			label := zones[j]
+			if slices.Contains(grant.zones, zones[j]) {
+				label = "✅ " + label
+			}
+			row = append(row, menu.Data(label, "user_zone", strconv.Itoa(j)))
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows,
+		menu.Row(menu.Data("💾 Save", "user_zone_ok")),
+		menu.Row(menu.Data("◀️ Back", "user_role", userIDStr)),
+	)
+	menu.Inline(rows...)
+
+	return b.editWithThread(c, fmt.Sprintf(
+		"*🌐 Zones of User* `%d`\n\nRole: *%s*\nZones: %s\n\nTap zones to restrict the user to them, then save:",
+		grant.userID, grant.role, zonesLabel(grant.zones),
+	), menu, tele.ModeMarkdown)
+}
+
+// handleUserZoneSave approves the access request of the user with the
+// picked role and zones, or changes the role of an allowed user
+func (b *Bot) handleUserZoneSave(c tele.Context, adminID int64) error {
+	grant, ok := b.roleGrant(adminID)
+	if !ok {
+		return b.editWithThread(c, "❌ Role selection expired. Please start again.", tele.ModeMarkdown)
+	}
+	b.stateManager.DeleteData(adminID, "role_grant")
+	if b.isConfiguredAdmin(grant.userID) {
+		return b.sendWithThread(c, configuredAdminText(grant.userID), tele.ModeMarkdown)
+	}
+
+	// Admins restricted to zones can only give their own zones
+	if len(grant.zones) == 0 {
+		grant.zones = b.userZones(adminID)
+	}
+
+	if b.isPendingRequest(grant.userID) {
+		return b.handleApproveRequest(c, strconv.FormatInt(grant.userID, 10), grant.role, grant.zones)
+	}
+
+	if b.allowedUserStorage == nil {
+		return b.sendWithThread(c, "❌ User storage not configured.", tele.ModeMarkdown)
+	}
+	if err := b.allowedUserStorage.SetUserRole(grant.userID, grant.role, grant.zones); err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ Error saving role: %v", err), tele.ModeMarkdown)
+	}
+	b.sendMessage(grant.userID, fmt.Sprintf(
+		"🎭 *Role Changed*\n\nYou are now *%s* for %s.", grant.role, zonesLabel(grant.zones),
+	))
+	return b.showAllowedUsers(c)
+}
+
+// roleGrant returns the role and zones an admin is picking
+func (b *Bot) roleGrant(adminID int64) (roleGrant, bool) {
+	val, ok := b.stateManager.GetData(adminID, "role_grant")
+	if !ok {
+		return roleGrant{}, false
+	}
+	grant, ok := val.(roleGrant)
+	return grant, ok
+}
+
+// grantableZones returns the names of the zones the admin can give others
+func (b *Bot) grantableZones(c tele.Context) ([]string, error) {
+	zones, err := b.dnsUsecase.ListZones(b.actorContext(c))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(zones))
+	for _, z := range zones {
+		names = append(names, z.Name)
+	}
+	return names, nil
+}
+
+// isPendingRequest reports whether a user is waiting for access
+func (b *Bot) isPendingRequest(userID int64) bool {
+	if b.pendingReqStorage == nil {
+		return false
+	}
+	pending, _ := b.pendingReqStorage.IsPendingRequest(userID)
+	return pending
+}
+
+// zonesLabel returns the display text of a zone restriction
+func zonesLabel(zones []string) string {
+	if len(zones) == 0 {
+		return "all zones"
+	}
+	return "`" + strings.Join(zones, "`, `") + "`"
+}
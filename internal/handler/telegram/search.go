@@ -0,0 +1,246 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"cf-dns-bot/internal/domain"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// maxSearchResults is the number of matching records shown as buttons
+const maxSearchResults = 20
+
+// maxFindLines is the number of records /find lists in its message
+const maxFindLines = 50
+
+// showSearchPrompt asks for the search terms of a zone
+func (b *Bot) showSearchPrompt(c tele.Context, userID int64, zoneName string) error {
+	b.stateManager.SetData(userID, "search_zone", zoneName)
+	b.stateManager.SetStep(userID, StepInputRecordSearch)
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(menu.Row(menu.Data("◀️ Back to List", "page", zoneName, "0")))
+
+	return b.editWithThread(c, fmt.Sprintf(
+		"*🔎 Search in %s*\n\n"+
+			"Send part of a record name, optionally with filters:\n"+
+			"• `api` - name contains `api`\n"+
+			"• `mail*` / `*.dev.%s` - name starts / ends with\n"+
+			"• `type:A` `content:192.0.2.1` `proxied:yes`\n"+
+			"• `comment:text` `tag:owner:dns-team`\n\n"+
+			"Example: `api type:A proxied:no`",
+		zoneName, zoneName,
+	), menu, tele.ModeMarkdown)
+}
+
+// handleRecordSearch lists the records of the zone matching the search
+// terms sent by the user
+func (b *Bot) handleRecordSearch(c tele.Context, userID int64, query string) error {
+	zoneName := b.getStateData(userID, "search_zone")
+	if zoneName == "" {
+		return b.sendWithThread(c, "❌ Search session expired. Please open the zone again.", tele.ModeMarkdown)
+	}
+
+	filter, err := parseSearchQuery(query)
+	if err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ Invalid search: %v\n\nPlease enter the search again.", err), tele.ModeMarkdown)
+	}
+	b.stateManager.SetStep(userID, StepNone)
+
+	ctx := b.actorContext(c)
+	matches, err := b.dnsUsecase.ListRecords(ctx, zoneName, filter)
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("searching records", err), tele.ModeMarkdown)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	var rows []tele.Row
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("*🔎 Search in %s*\n", zoneName))
+	text.WriteString(fmt.Sprintf("Query: `%s`\n\n", strings.TrimSpace(query)))
+
+	if len(matches) == 0 {
+		text.WriteString("No records match.")
+	} else {
+		text.WriteString(fmt.Sprintf("*%d record(s) match.* Click a record to view details:\n", len(matches)))
+		if len(matches) > maxSearchResults {
+			text.WriteString(fmt.Sprintf("… and %d more, narrow down the search to see them\n", len(matches)-maxSearchResults))
+			matches = matches[:maxSearchResults]
+		}
+		recordRows, err := b.viewRecordRows(ctx, menu, zoneName, matches)
+		if err != nil {
+			return b.sendWithThread(c, recordErrorText("loading records", err), tele.ModeMarkdown)
+		}
+		rows = append(rows, recordRows...)
+	}
+
+	rows = append(rows,
+		menu.Row(menu.Data("🔎 New Search", "search", zoneName), menu.Data("◀️ Back to List", "page", zoneName, "0")),
+		menu.Row(menu.Data("🏠 Main Menu", "menu")),
+	)
+	menu.Inline(rows...)
+
+	return b.sendWithThread(c, text.String(), menu, tele.ModeMarkdown)
+}
+
+// viewRecordRows returns buttons opening the records at their place in the
+// zone's full record list, which is how view_rec addresses a record
+func (b *Bot) viewRecordRows(ctx context.Context, menu *tele.ReplyMarkup, zoneName string, records []domain.DNSRecord) ([]tele.Row, error) {
+	all, err := b.dnsUsecase.ListRecords(ctx, zoneName, domain.RecordFilter{})
+	if err != nil {
+		return nil, err
+	}
+	position := make(map[string]int, len(all))
+	for i, r := range all {
+		position[r.ID] = i
+	}
+
+	recordsPerPage := 10
+	var rows []tele.Row
+	for _, r := range records {
+		pos, ok := position[r.ID]
+		if !ok {
+			continue
+		}
+		page, idx := pos/recordsPerPage, pos%recordsPerPage
+		rows = append(rows, menu.Row(menu.Data(fmt.Sprintf("📄 %s (%s)", r.Name, r.Type), "view_rec", zoneName, strconv.Itoa(page), strconv.Itoa(idx))))
+	}
+	return rows, nil
+}
+
+// handleFindCommand searches every zone for records whose name or content
+// matches the query of /find, such as the IP of a server to retire
+func (b *Bot) handleFindCommand(c tele.Context) error {
+	query := strings.TrimSpace(c.Message().Payload)
+	if query == "" {
+		return b.sendWithThread(c, "ℹ️ Usage: `/find <name, content or IP>`\n\nExample: `/find 192.0.2.10`", tele.ModeMarkdown)
+	}
+
+	ctx := b.actorContext(c)
+	results, err := b.dnsUsecase.SearchRecords(ctx, query)
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("searching records", err), tele.ModeMarkdown)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	var rows []tele.Row
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("*🔎 Find* `%s`\n\n", query))
+
+	total, listed, buttons := 0, 0, 0
+	for _, result := range results {
+		total += len(result.Records)
+	}
+	if total == 0 {
+		text.WriteString("No records match in any zone.\n")
+	} else {
+		text.WriteString(fmt.Sprintf("*%d record(s) in %d zone(s).* Click a record to view details:\n", total, len(results)))
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			text.WriteString(fmt.Sprintf("\n⚠️ *%s* could not be searched: %s\n", result.Zone.Name, result.Error))
+			continue
+		}
+
+		text.WriteString(fmt.Sprintf("\n*%s* (%d)\n", result.Zone.Name, len(result.Records)))
+		for _, r := range result.Records {
+			if listed == maxFindLines {
+				break
+			}
+			text.WriteString(fmt.Sprintf("• `%s` %s `%s`\n", r.Name, r.Type, r.Content))
+			listed++
+		}
+
+		if buttons < maxSearchResults {
+			records := result.Records[:min(len(result.Records), maxSearchResults-buttons)]
+			recordRows, err := b.viewRecordRows(ctx, menu, result.Zone.Name, records)
+			if err != nil {
+				log.Printf("[handleFindCommand] Warning: failed to load records of %s: %v", result.Zone.Name, err)
+				continue
+			}
+			rows = append(rows, recordRows...)
+			buttons += len(records)
+		}
+	}
+	if listed < total {
+		text.WriteString(fmt.Sprintf("\n… and %d more, narrow down the search to see them\n", total-listed))
+	}
+
+	rows = append(rows, menu.Row(menu.Data("🏠 Main Menu", "menu")))
+	menu.Inline(rows...)
+
+	return b.sendWithThread(c, text.String(), menu, tele.ModeMarkdown)
+}
+
+// parseSearchQuery turns search terms such as `api type:A proxied:no` into
+// a record filter. A plain word matches part of the name; with a leading
+// or trailing * it matches the end or the start of the name.
+func parseSearchQuery(query string) (domain.RecordFilter, error) {
+	filter := domain.RecordFilter{Order: "name"}
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return filter, errors.New("search terms are empty")
+	}
+
+	for _, term := range terms {
+		key, value, hasKey := strings.Cut(term, ":")
+		if !hasKey {
+			key, value = "", term
+		}
+		if hasKey && value == "" {
+			return filter, fmt.Errorf("`%s` needs a value", term)
+		}
+
+		switch strings.ToLower(key) {
+		case "":
+			switch {
+			case strings.HasSuffix(value, "*"):
+				filter.NameStartsWith = strings.TrimSuffix(value, "*")
+			case strings.HasPrefix(value, "*"):
+				filter.NameEndsWith = strings.TrimPrefix(value, "*")
+			default:
+				filter.NameContains = value
+			}
+		case "type":
+			filter.Type = strings.ToUpper(value)
+			if !domain.IsValidRecordType(filter.Type) {
+				return filter, fmt.Errorf("unknown record type `%s`", value)
+			}
+		case "content":
+			filter.Content = value
+		case "comment":
+			filter.Comment = value
+		case "tag":
+			filter.Tag = value
+		case "proxied":
+			proxied, ok := parseYesNo(value)
+			if !ok {
+				return filter, fmt.Errorf("`proxied` must be `yes` or `no`, got `%s`", value)
+			}
+			filter.Proxied = &proxied
+		default:
+			return filter, fmt.Errorf("unknown filter `%s`, use type, content, comment, tag or proxied", key)
+		}
+	}
+	return filter, nil
+}
+
+// parseYesNo parses a yes/no answer such as the proxy status
+func parseYesNo(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "yes", "true", "on":
+		return true, true
+	case "no", "false", "off":
+		return false, true
+	}
+	return false, false
+}
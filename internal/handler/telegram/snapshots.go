@@ -0,0 +1,234 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	"cf-dns-bot/internal/usecase"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const (
+	// snapshotsPerPage is the number of snapshots listed for a zone
+	snapshotsPerPage = 10
+	// maxSnapshotDiffItems is the number of differences shown with a
+	// restore button; the whole zone can still be restored
+	maxSnapshotDiffItems = 20
+)
+
+// showSnapshots lists the latest snapshots of a zone (admin only)
+func (b *Bot) showSnapshots(c tele.Context, userID int64, zoneName string) error {
+	if !b.isAdmin(userID) {
+		return b.sendWithThread(c, "⛔ Only admins can manage snapshots.", tele.ModeMarkdown)
+	}
+
+	snapshots, err := b.snapshotUsecase.ListSnapshots(b.actorContext(c), zoneName)
+	if err != nil {
+		return b.editWithThread(c, recordErrorText("loading snapshots", err), tele.ModeMarkdown)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	var rows []tele.Row
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("*🕓 Snapshots of %s*\n\n", zoneName))
+	if len(snapshots) == 0 {
+		text.WriteString("No snapshots yet.")
+	} else {
+		text.WriteString(fmt.Sprintf("%d snapshot(s), newest first. Select one to compare it with the live zone:", len(snapshots)))
+	}
+
+	for i, snapshot := range snapshots {
+		if i == snapshotsPerPage {
+			break
+		}
+		label := fmt.Sprintf("📸 %s (%d records)", snapshot.CreatedAt.Format("2006-01-02 15:04"), snapshot.RecordCount)
+		rows = append(rows, menu.Row(menu.Data(label, "snap_view", zoneName, snapshot.ID)))
+	}
+
+	rows = append(rows,
+		menu.Row(menu.Data("📸 Take Snapshot Now", "snap_take", zoneName)),
+		menu.Row(menu.Data("◀️ Back to List", "select_zone_manage", zoneName)),
+	)
+	menu.Inline(rows...)
+
+	return b.editWithThread(c, text.String(), menu, tele.ModeMarkdown)
+}
+
+// handleTakeSnapshot snapshots a zone on demand
+func (b *Bot) handleTakeSnapshot(c tele.Context, userID int64, zoneName string) error {
+	if !b.isAdmin(userID) {
+		return b.sendWithThread(c, "⛔ Only admins can manage snapshots.", tele.ModeMarkdown)
+	}
+
+	if _, _, err := b.snapshotUsecase.TakeSnapshot(b.actorContext(c), zoneName); err != nil {
+		return b.editWithThread(c, recordErrorText("taking snapshot", err), tele.ModeMarkdown)
+	}
+	return b.showSnapshots(c, userID, zoneName)
+}
+
+// showSnapshotDiff compares a snapshot with the live zone and offers to
+// restore single records or the whole zone
+func (b *Bot) showSnapshotDiff(c tele.Context, userID int64, zoneName, snapshotID string) error {
+	if !b.isAdmin(userID) {
+		return b.sendWithThread(c, "⛔ Only admins can manage snapshots.", tele.ModeMarkdown)
+	}
+
+	diff, err := b.snapshotUsecase.DiffSnapshot(b.actorContext(c), zoneName, snapshotID)
+	if err != nil {
+		return b.editWithThread(c, recordErrorText("comparing snapshot", err), tele.ModeMarkdown)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	backRow := menu.Row(menu.Data("◀️ Back to Snapshots", "snapshots", zoneName))
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("*🕓 Snapshot %s*\n", diff.CreatedAt.Format("2006-01-02 15:04 MST")))
+	text.WriteString(fmt.Sprintf("Zone: `%s`\n\n", zoneName))
+
+	if !diff.HasChanges() {
+		text.WriteString(fmt.Sprintf("✅ The live zone matches this snapshot (%d records).", diff.Unchanged))
+		menu.Inline(backRow)
+		return b.editWithThread(c, text.String(), menu, tele.ModeMarkdown)
+	}
+
+	text.WriteString("Restoring would:\n")
+
+	// Remember the record IDs so the buttons only carry an index
+	var recordIDs []string
+	var lines []string
+	for _, r := range diff.Creates {
+		recordIDs = append(recordIDs, r.ID)
+		lines = append(lines, fmt.Sprintf("➕ recreate `%s` %s `%s`", r.Name, r.Type, r.Content))
+	}
+	for _, change := range diff.Updates {
+		recordIDs = append(recordIDs, change.Before.ID)
+		lines = append(lines, fmt.Sprintf("✏️ revert `%s` %s `%s` → `%s`", change.Before.Name, change.Before.Type, change.Before.Content, change.After.Content))
+	}
+	for _, r := range diff.Deletes {
+		recordIDs = append(recordIDs, r.ID)
+		lines = append(lines, fmt.Sprintf("🗑️ delete `%s` %s `%s`", r.Name, r.Type, r.Content))
+	}
+
+	var rows []tele.Row
+	var row tele.Row
+	for i, line := range lines {
+		if i == maxSnapshotDiffItems {
+			text.WriteString(fmt.Sprintf("… and %d more\n", len(lines)-maxSnapshotDiffItems))
+			break
+		}
+		text.WriteString(fmt.Sprintf("%d. %s\n", i+1, line))
+		row = append(row, menu.Data(fmt.Sprintf("↩️ %d", i+1), "snap_rec", fmt.Sprint(i)))
+		if len(row) == 5 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	text.WriteString(fmt.Sprintf("\n%d record(s) unchanged. Tap a number to restore one record.", diff.Unchanged))
+
+	b.stateManager.SetData(userID, "snap_zone", zoneName)
+	b.stateManager.SetData(userID, "snap_id", snapshotID)
+	b.stateManager.SetData(userID, "snap_records", recordIDs)
+
+	rows = append(rows, menu.Row(menu.Data("♻️ Restore Whole Zone", "snap_all")), backRow)
+	menu.Inline(rows...)
+
+	return b.editWithThread(c, text.String(), menu, tele.ModeMarkdown)
+}
+
+// handleRestoreSnapshotRecord restores a single record from the snapshot
+// shown in the last diff
+func (b *Bot) handleRestoreSnapshotRecord(c tele.Context, userID int64, idx int) error {
+	zoneName, snapshotID, recordIDs, ok := b.snapshotSelection(userID)
+	if !ok || idx < 0 || idx >= len(recordIDs) {
+		return b.sendWithThread(c, "❌ Snapshot session expired. Please open the snapshot again.", tele.ModeMarkdown)
+	}
+
+	return b.restoreSnapshot(c, userID, zoneName, snapshotID, []string{recordIDs[idx]})
+}
+
+// handleRestoreSnapshotZone asks for confirmation before restoring a whole zone
+func (b *Bot) handleRestoreSnapshotZone(c tele.Context, userID int64) error {
+	zoneName, snapshotID, recordIDs, ok := b.snapshotSelection(userID)
+	if !ok {
+		return b.sendWithThread(c, "❌ Snapshot session expired. Please open the snapshot again.", tele.ModeMarkdown)
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(menu.Row(
+		menu.Data("✅ Restore Zone", "snap_all_ok"),
+		menu.Data("❌ Cancel", "snap_view", zoneName, snapshotID),
+	))
+
+	return b.editWithThread(c, fmt.Sprintf(
+		"*♻️ Restore Whole Zone*\n\nZone: `%s`\nSnapshot: `%s`\n\nThis will make %d change(s), including deleting records added since the snapshot. Continue?",
+		zoneName, snapshotID, len(recordIDs),
+	), menu, tele.ModeMarkdown)
+}
+
+// handleRestoreSnapshotZoneConfirm restores a whole zone
+func (b *Bot) handleRestoreSnapshotZoneConfirm(c tele.Context, userID int64) error {
+	zoneName, snapshotID, _, ok := b.snapshotSelection(userID)
+	if !ok {
+		return b.sendWithThread(c, "❌ Snapshot session expired. Please open the snapshot again.", tele.ModeMarkdown)
+	}
+
+	return b.restoreSnapshot(c, userID, zoneName, snapshotID, nil)
+}
+
+// restoreSnapshot restores records and reports the result
+func (b *Bot) restoreSnapshot(c tele.Context, userID int64, zoneName, snapshotID string, recordIDs []string) error {
+	if !b.isAdmin(userID) {
+		return b.sendWithThread(c, "⛔ Only admins can manage snapshots.", tele.ModeMarkdown)
+	}
+
+	ctx := b.actorContext(c)
+	if b.approvalUsecase != nil {
+		diff, err := b.snapshotUsecase.DiffSnapshot(ctx, zoneName, snapshotID)
+		if err != nil {
+			return b.editWithThread(c, recordErrorText("restoring snapshot", err), tele.ModeMarkdown)
+		}
+		restore := usecase.NewSnapshotRestore(diff, recordIDs)
+		parked, err := b.requestApproval(c, usecase.PendingChange{Kind: usecase.ChangeRestore, ZoneName: zoneName, Restore: &restore})
+		if parked {
+			return err
+		}
+	}
+
+	result, err := b.snapshotUsecase.RestoreSnapshot(ctx, zoneName, snapshotID, recordIDs)
+	if err != nil {
+		return b.editWithThread(c, recordErrorText("restoring snapshot", err), tele.ModeMarkdown)
+	}
+
+	var text strings.Builder
+	text.WriteString("*♻️ Snapshot Restored*\n\n")
+	text.WriteString(fmt.Sprintf("Zone: `%s`\n", zoneName))
+	text.WriteString(fmt.Sprintf("Recreated: %d\nReverted: %d\nDeleted: %d\n", len(result.Created), len(result.Updated), len(result.Deleted)))
+	if len(result.Failed) > 0 {
+		text.WriteString(fmt.Sprintf("\n⚠️ *Failed (%d):*\n", len(result.Failed)))
+		for _, f := range result.Failed {
+			text.WriteString(fmt.Sprintf("• `%s` %s: %s\n", f.Record.Name, f.Record.Type, f.Error))
+		}
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(
+		menu.Row(menu.Data("🔍 Compare Again", "snap_view", zoneName, snapshotID)),
+		menu.Row(menu.Data("◀️ Back to List", "select_zone_manage", zoneName), menu.Data("🏠 Menu", "menu")),
+	)
+	return b.editWithThread(c, text.String(), menu, tele.ModeMarkdown)
+}
+
+// snapshotSelection returns the snapshot and record IDs of the last diff
+// shown to the user
+func (b *Bot) snapshotSelection(userID int64) (string, string, []string, bool) {
+	zoneName := b.getStateData(userID, "snap_zone")
+	snapshotID := b.getStateData(userID, "snap_id")
+	value, _ := b.stateManager.GetData(userID, "snap_records")
+	recordIDs, _ := value.([]string)
+	return zoneName, snapshotID, recordIDs, zoneName != "" && snapshotID != ""
+}
@@ -34,6 +34,13 @@ const (
 	StepEditRecordProxied
 	StepConfirmDelete
 	StepInputMCPHTTPPort
+	StepAwaitZoneFile
+	StepInputBulkPattern
+	StepEditRecordComment
+	StepEditRecordTags
+	StepInputRecordSearch
+	StepInputKeyZones
+	StepInputKeyRecords
 )
 
 // StateManager manages user states
@@ -91,6 +98,12 @@ func (sm *StateManager) GetData(userID int64, key string) (interface{}, bool) {
 	return val, exists
 }
 
+// DeleteData removes data of a user
+func (sm *StateManager) DeleteData(userID int64, key string) {
+	state := sm.GetState(userID)
+	delete(state.Data, key)
+}
+
 // ClearState clears a user's state
 func (sm *StateManager) ClearState(userID int64) {
 	sm.mu.Lock()
@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"bytes"
+	"fmt"
+
+	"cf-dns-bot/internal/usecase"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// handleExportZone asks for the export format of a zone
+func (b *Bot) handleExportZone(c tele.Context, zoneName string) error {
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(
+		menu.Row(
+			menu.Data("BIND", "export_fmt", zoneName, string(usecase.ExportFormatBIND)),
+			menu.Data("JSON", "export_fmt", zoneName, string(usecase.ExportFormatJSON)),
+			menu.Data("CSV", "export_fmt", zoneName, string(usecase.ExportFormatCSV)),
+		),
+		menu.Row(menu.Data("◀️ Back to List", "select_zone_manage", zoneName)),
+	)
+
+	return b.editWithThread(c, fmt.Sprintf("*📤 Export Zone*\n\nZone: `%s`\n\nSelect a format:", zoneName), menu, tele.ModeMarkdown)
+}
+
+// handleExportFormatSelected sends the zone export as a document
+func (b *Bot) handleExportFormatSelected(c tele.Context, zoneName, formatName string) error {
+	format, err := usecase.ParseExportFormat(formatName)
+	if err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ %v", err), tele.ModeMarkdown)
+	}
+
+	ctx := b.actorContext(c)
+	data, err := b.dnsUsecase.ExportZone(ctx, zoneName, format)
+	if err != nil {
+		return b.sendWithThread(c, recordErrorText("exporting zone", err), tele.ModeMarkdown)
+	}
+
+	doc := &tele.Document{
+		File:     tele.FromReader(bytes.NewReader(data)),
+		FileName: fmt.Sprintf("%s.%s", zoneName, format.FileExtension()),
+		MIME:     format.ContentType(),
+		Caption:  fmt.Sprintf("📤 Export of `%s`", zoneName),
+	}
+
+	opts := []interface{}{tele.ModeMarkdown}
+	if threadID := b.getThreadIDFromContext(c); threadID != 0 {
+		opts = append([]interface{}{&tele.SendOptions{ThreadID: threadID}}, opts...)
+	}
+	return c.Send(doc, opts...)
+}
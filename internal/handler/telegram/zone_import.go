@@ -0,0 +1,205 @@
+package telegram
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/usecase"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// maxZoneFileSize limits the size of uploaded zone files
+const maxZoneFileSize = 1 << 20
+
+// maxImportPreviewLines limits how many records are listed in the preview
+const maxImportPreviewLines = 20
+
+// handleImportZone asks the user to upload a zone file for a zone
+func (b *Bot) handleImportZone(c tele.Context, userID int64, zoneName string) error {
+	if !b.isAdmin(userID) {
+		return b.sendWithThread(c, "⛔ Only admins can import zone files.", tele.ModeMarkdown)
+	}
+
+	b.stateManager.SetData(userID, "import_zone", zoneName)
+	b.stateManager.SetStep(userID, StepAwaitZoneFile)
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(menu.Row(menu.Data("❌ Cancel", "import_cancel")))
+
+	return b.editWithThread(c, fmt.Sprintf(
+		"*📥 Import Zone File*\n\nZone: `%s`\n\nSend the BIND zone file (`.zone`) as a document. You will see a preview before anything is changed.",
+		zoneName,
+	), menu, tele.ModeMarkdown)
+}
+
+// handleDocument handles uploaded zone files and shows an import preview.
+// The zone is taken from the import flow, the caption or the file name.
+func (b *Bot) handleDocument(c tele.Context) error {
+	userID := c.Sender().ID
+	doc := c.Message().Document
+	if doc == nil {
+		return nil
+	}
+
+	if b.zoneImportUsecase == nil {
+		return b.sendWithThread(c, "❌ Zone import is not configured.", tele.ModeMarkdown)
+	}
+	if !b.isAdmin(userID) {
+		return b.sendWithThread(c, "⛔ Only admins can import zone files.", tele.ModeMarkdown)
+	}
+
+	zoneName := ""
+	if b.stateManager.GetCurrentStep(userID) == StepAwaitZoneFile {
+		zoneName = b.getStateData(userID, "import_zone")
+	}
+	if zoneName == "" {
+		zoneName = strings.TrimSpace(c.Message().Caption)
+	}
+	if zoneName == "" {
+		zoneName = zoneNameFromFileName(doc.FileName)
+	}
+	if zoneName == "" {
+		return b.sendWithThread(c, "❌ Could not tell which zone this file is for. Send it again with the zone name as the caption.", tele.ModeMarkdown)
+	}
+
+	if doc.FileSize > maxZoneFileSize {
+		return b.sendWithThread(c, fmt.Sprintf("❌ Zone file is too large (max %d KB).", maxZoneFileSize/1024), tele.ModeMarkdown)
+	}
+
+	reader, err := b.bot.File(&doc.File)
+	if err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ Error downloading file: %v", err), tele.ModeMarkdown)
+	}
+	defer reader.Close()
+
+	ctx := b.actorContext(c)
+	plan, err := b.zoneImportUsecase.PreviewImport(ctx, zoneName, io.LimitReader(reader, maxZoneFileSize))
+	if err != nil {
+		return b.sendWithThread(c, fmt.Sprintf("❌ Error reading zone file: %v", err), tele.ModeMarkdown)
+	}
+
+	b.stateManager.SetStep(userID, StepNone)
+	b.stateManager.SetData(userID, "import_plan", plan)
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	if len(plan.ToCreate) > 0 {
+		menu.Inline(
+			menu.Row(menu.Data(fmt.Sprintf("✅ Create %d Records", len(plan.ToCreate)), "import_confirm")),
+			menu.Row(menu.Data("❌ Cancel", "import_cancel")),
+		)
+	} else {
+		menu.Inline(menu.Row(menu.Data("🏠 Main Menu", "menu")))
+	}
+
+	return b.sendWithThread(c, formatImportPlan(plan), menu, tele.ModeMarkdown)
+}
+
+// handleImportConfirm applies the previewed import plan
+func (b *Bot) handleImportConfirm(c tele.Context, userID int64) error {
+	val, exists := b.stateManager.GetData(userID, "import_plan")
+	plan, ok := val.(*usecase.ImportPlan)
+	if !exists || !ok {
+		return b.editWithThread(c, "❌ Import preview expired. Please upload the zone file again.", tele.ModeMarkdown)
+	}
+	b.stateManager.ClearState(userID)
+
+	parked, err := b.requestApproval(c, usecase.PendingChange{Kind: usecase.ChangeImport, ZoneName: plan.ZoneName, Import: plan})
+	if parked {
+		return err
+	}
+
+	ctx := b.actorContext(c)
+	result, err := b.zoneImportUsecase.ApplyImport(ctx, plan)
+	if err != nil {
+		return b.editWithThread(c, recordErrorText("importing zone file", err), tele.ModeMarkdown)
+	}
+
+	var text strings.Builder
+	text.WriteString("*📥 Import Finished*\n\n")
+	text.WriteString(fmt.Sprintf("Zone: `%s`\n", result.ZoneName))
+	text.WriteString(fmt.Sprintf("✅ Created: %d\n", len(result.Created)))
+	if len(result.Existing) > 0 {
+		text.WriteString(fmt.Sprintf("⏭️ Already existed: %d\n", len(result.Existing)))
+	}
+	if len(result.Failed) > 0 {
+		text.WriteString(fmt.Sprintf("❌ Failed: %d\n\n", len(result.Failed)))
+		for i, f := range result.Failed {
+			if i == maxImportPreviewLines {
+				text.WriteString(fmt.Sprintf("…and %d more\n", len(result.Failed)-i))
+				break
+			}
+			text.WriteString(fmt.Sprintf("• `%s %s`: %s\n", f.Record.Type, f.Record.Name, f.Error))
+		}
+	}
+
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	menu.Inline(menu.Row(menu.Data("🔍 View Records", "select_zone_manage", result.ZoneName), menu.Data("🏠 Main Menu", "menu")))
+
+	return b.editWithThread(c, text.String(), menu, tele.ModeMarkdown)
+}
+
+// formatImportPlan renders an import preview
+func formatImportPlan(plan *usecase.ImportPlan) string {
+	var text strings.Builder
+	text.WriteString("*📥 Import Preview*\n\n")
+	text.WriteString(fmt.Sprintf("Zone: `%s`\n", plan.ZoneName))
+	text.WriteString(fmt.Sprintf("➕ To create: %d\n", len(plan.ToCreate)))
+	text.WriteString(fmt.Sprintf("✔️ Already exist: %d\n", len(plan.Existing)))
+	text.WriteString(fmt.Sprintf("⏭️ Skipped: %d\n", len(plan.Skipped)))
+
+	if len(plan.ToCreate) > 0 {
+		text.WriteString("\n*New records:*\n")
+		for i, r := range plan.ToCreate {
+			if i == maxImportPreviewLines {
+				text.WriteString(fmt.Sprintf("…and %d more\n", len(plan.ToCreate)-i))
+				break
+			}
+			text.WriteString(fmt.Sprintf("• `%s %s %s`\n", r.Type, r.Name, formatRecordContent(r)))
+		}
+	}
+
+	if len(plan.Skipped) > 0 {
+		text.WriteString("\n*Skipped:*\n")
+		for i, s := range plan.Skipped {
+			if i == maxImportPreviewLines {
+				text.WriteString(fmt.Sprintf("…and %d more\n", len(plan.Skipped)-i))
+				break
+			}
+			text.WriteString(fmt.Sprintf("• `%s %s`: %s\n", s.Record.Type, s.Record.Name, s.Reason))
+		}
+	}
+
+	if len(plan.ToCreate) == 0 {
+		text.WriteString("\nNothing to import.")
+	}
+
+	return text.String()
+}
+
+// formatRecordContent returns the record content including its priority
+func formatRecordContent(r domain.DNSRecord) string {
+	content := r.Content
+	if runes := []rune(content); len(runes) > 40 {
+		content = string(runes[:40]) + "…"
+	}
+	if r.Priority != nil {
+		return fmt.Sprintf("%d %s", *r.Priority, content)
+	}
+	return content
+}
+
+// zoneNameFromFileName derives a zone name from names like example.com.zone
+func zoneNameFromFileName(fileName string) string {
+	name := strings.ToLower(fileName)
+	for _, suffix := range []string{".zone", ".db", ".txt"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	name = strings.TrimPrefix(name, "db.")
+	if !strings.Contains(name, ".") {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cf-dns-bot/internal/domain"
+)
+
+// CacheConfig sets how long cached data is used. A TTL of 0 disables the
+// cache of that kind.
+type CacheConfig struct {
+	ZoneTTL   time.Duration
+	RecordTTL time.Duration
+}
+
+// cacheEntry is a cached value and the time it expires
+type cacheEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+// CachedRepository implements ZoneRepository and DNSRepository on top of
+// other repositories, keeping zones and record lists in memory. Writes
+// through it drop the cached records of their zone. Single record lookups
+// (GetRecord and FindOne) are not cached, since they are used to check the
+// current state before a change.
+type CachedRepository struct {
+	zoneRepo ZoneRepository
+	dnsRepo  DNSRepository
+	config   CacheConfig
+
+	mu          sync.Mutex
+	zoneList    *cacheEntry[[]domain.Zone]
+	zonesByName map[string]cacheEntry[domain.Zone]
+	recordLists map[string]map[string]cacheEntry[[]domain.DNSRecord] // zone ID -> filter -> records
+	generation  uint64                                               // bumped whenever records are dropped
+	stats       domain.CacheStats
+}
+
+// Ensure CachedRepository implements both repositories
+var _ ZoneRepository = (*CachedRepository)(nil)
+var _ DNSRepository = (*CachedRepository)(nil)
+
+// NewCachedRepository creates a caching decorator for the given repositories
+func NewCachedRepository(zoneRepo ZoneRepository, dnsRepo DNSRepository, config CacheConfig) *CachedRepository {
+	return &CachedRepository{
+		zoneRepo:    zoneRepo,
+		dnsRepo:     dnsRepo,
+		config:      config,
+		zonesByName: make(map[string]cacheEntry[domain.Zone]),
+		recordLists: make(map[string]map[string]cacheEntry[[]domain.DNSRecord]),
+	}
+}
+
+// ListZones returns all accessible zones
+func (r *CachedRepository) ListZones(ctx context.Context) ([]domain.Zone, error) {
+	r.mu.Lock()
+	if r.zoneList != nil && time.Now().Before(r.zoneList.expires) {
+		zones := slices.Clone(r.zoneList.value)
+		r.stats.ZoneHits++
+		r.mu.Unlock()
+		return zones, nil
+	}
+	r.stats.ZoneMisses++
+	r.mu.Unlock()
+
+	zones, err := r.zoneRepo.ListZones(ctx)
+	if err != nil || r.config.ZoneTTL <= 0 {
+		return zones, err
+	}
+
+	r.mu.Lock()
+	expires := time.Now().Add(r.config.ZoneTTL)
+	r.zoneList = &cacheEntry[[]domain.Zone]{value: slices.Clone(zones), expires: expires}
+	for _, z := range zones {
+		r.zonesByName[strings.ToLower(z.Name)] = cacheEntry[domain.Zone]{value: z, expires: expires}
+	}
+	r.mu.Unlock()
+
+	return zones, nil
+}
+
+// GetZoneByName returns a zone by its name
+func (r *CachedRepository) GetZoneByName(ctx context.Context, name string) (*domain.Zone, error) {
+	key := strings.ToLower(name)
+
+	r.mu.Lock()
+	if entry, ok := r.zonesByName[key]; ok && time.Now().Before(entry.expires) {
+		zone := entry.value
+		r.stats.ZoneHits++
+		r.mu.Unlock()
+		return &zone, nil
+	}
+	r.stats.ZoneMisses++
+	r.mu.Unlock()
+
+	zone, err := r.zoneRepo.GetZoneByName(ctx, name)
+	if err != nil || r.config.ZoneTTL <= 0 {
+		return zone, err
+	}
+
+	r.mu.Lock()
+	r.zonesByName[key] = cacheEntry[domain.Zone]{value: *zone, expires: time.Now().Add(r.config.ZoneTTL)}
+	r.mu.Unlock()
+
+	return zone, nil
+}
+
+// GetZone returns a zone by its ID
+func (r *CachedRepository) GetZone(ctx context.Context, zoneID string) (*domain.Zone, error) {
+	r.mu.Lock()
+	for _, entry := range r.zonesByName {
+		if entry.value.ID == zoneID && time.Now().Before(entry.expires) {
+			zone := entry.value
+			r.stats.ZoneHits++
+			r.mu.Unlock()
+			return &zone, nil
+		}
+	}
+	r.stats.ZoneMisses++
+	r.mu.Unlock()
+
+	zone, err := r.zoneRepo.GetZone(ctx, zoneID)
+	if err != nil || r.config.ZoneTTL <= 0 {
+		return zone, err
+	}
+
+	r.mu.Lock()
+	r.zonesByName[strings.ToLower(zone.Name)] = cacheEntry[domain.Zone]{value: *zone, expires: time.Now().Add(r.config.ZoneTTL)}
+	r.mu.Unlock()
+
+	return zone, nil
+}
+
+// ListRecords returns the DNS records of a zone matching the filter
+func (r *CachedRepository) ListRecords(ctx context.Context, zoneID string, filter domain.RecordFilter) ([]domain.DNSRecord, error) {
+	key := filterKey(filter)
+
+	r.mu.Lock()
+	if entry, ok := r.recordLists[zoneID][key]; ok && time.Now().Before(entry.expires) {
+		records := slices.Clone(entry.value)
+		r.stats.RecordHits++
+		r.mu.Unlock()
+		return records, nil
+	}
+	r.stats.RecordMisses++
+	generation := r.generation
+	r.mu.Unlock()
+
+	records, err := r.dnsRepo.ListRecords(ctx, zoneID, filter)
+	if err != nil || r.config.RecordTTL <= 0 {
+		return records, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.generation != generation {
+		// Records changed while listing, the list may be outdated
+		return records, nil
+	}
+	if r.recordLists[zoneID] == nil {
+		r.recordLists[zoneID] = make(map[string]cacheEntry[[]domain.DNSRecord])
+	}
+	r.recordLists[zoneID][key] = cacheEntry[[]domain.DNSRecord]{value: slices.Clone(records), expires: time.Now().Add(r.config.RecordTTL)}
+
+	return records, nil
+}
+
+// GetRecord returns a specific DNS record, always from Cloudflare
+func (r *CachedRepository) GetRecord(ctx context.Context, zoneID, recordID string) (*domain.DNSRecord, error) {
+	return r.dnsRepo.GetRecord(ctx, zoneID, recordID)
+}
+
+// FindOne finds the single DNS record matching the filter, always from
+// Cloudflare
+func (r *CachedRepository) FindOne(ctx context.Context, zoneID string, filter domain.RecordFilter) (*domain.DNSRecord, error) {
+	return r.dnsRepo.FindOne(ctx, zoneID, filter)
+}
+
+// CreateRecord creates a new DNS record and drops the cached records of
+// its zone
+func (r *CachedRepository) CreateRecord(ctx context.Context, zoneID string, record *domain.DNSRecord) (*domain.DNSRecord, error) {
+	defer r.invalidateRecords(zoneID)
+	return r.dnsRepo.CreateRecord(ctx, zoneID, record)
+}
+
+// UpdateRecord updates a DNS record and drops the cached records of its
+// zone
+func (r *CachedRepository) UpdateRecord(ctx context.Context, zoneID, recordID string, patch domain.RecordPatch) (*domain.DNSRecord, error) {
+	defer r.invalidateRecords(zoneID)
+	return r.dnsRepo.UpdateRecord(ctx, zoneID, recordID, patch)
+}
+
+// DeleteRecord deletes a DNS record and drops the cached records of its
+// zone
+func (r *CachedRepository) DeleteRecord(ctx context.Context, zoneID, recordID string) error {
+	defer r.invalidateRecords(zoneID)
+	return r.dnsRepo.DeleteRecord(ctx, zoneID, recordID)
+}
+
+// Refresh drops the cached zone and records of a zone, so the next request
+// goes to Cloudflare. An empty zone name drops everything.
+func (r *CachedRepository) Refresh(ctx context.Context, zoneName string) error {
+	if zoneName == "" {
+		r.mu.Lock()
+		r.zoneList = nil
+		r.zonesByName = make(map[string]cacheEntry[domain.Zone])
+		r.recordLists = make(map[string]map[string]cacheEntry[[]domain.DNSRecord])
+		r.generation++
+		r.mu.Unlock()
+		return nil
+	}
+
+	key := strings.ToLower(zoneName)
+	r.mu.Lock()
+	entry, cached := r.zonesByName[key]
+	delete(r.zonesByName, key)
+	r.mu.Unlock()
+
+	zoneID := entry.value.ID
+	if !cached {
+		// The records may be cached longer than the zone
+		zone, err := r.zoneRepo.GetZoneByName(ctx, zoneName)
+		if err != nil {
+			return err
+		}
+		zoneID = zone.ID
+	}
+	r.invalidateRecords(zoneID)
+	return nil
+}
+
+// Stats returns the hit and miss counters and the size of the cache
+func (r *CachedRepository) Stats() domain.CacheStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats
+	stats.Zones = len(r.zonesByName)
+	for _, lists := range r.recordLists {
+		stats.RecordLists += len(lists)
+	}
+	return stats
+}
+
+// invalidateRecords drops the cached record lists of a zone
+func (r *CachedRepository) invalidateRecords(zoneID string) {
+	r.mu.Lock()
+	delete(r.recordLists, zoneID)
+	r.generation++
+	r.mu.Unlock()
+}
+
+// filterKey identifies the record list of a filter in the cache
+func filterKey(filter domain.RecordFilter) string {
+	key, _ := json.Marshal(filter)
+	return string(key)
+}
@@ -0,0 +1,93 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cf-dns-bot/external_resource/cloudflare"
+	"cf-dns-bot/external_resource/cloudflare/cloudflaretest"
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/repository"
+)
+
+// newCachedRepository returns a cache in front of an in-memory Cloudflare
+// with one empty zone
+func newCachedRepository(t *testing.T) (*repository.CachedRepository, *cloudflaretest.Store, string) {
+	t.Helper()
+	store := cloudflaretest.NewStore()
+	zone := store.AddZone("example.com")
+	client := cloudflaretest.NewClient(store)
+	cache := repository.NewCachedRepository(repository.NewZoneRepository(client), repository.NewDNSRepository(client), repository.CacheConfig{
+		ZoneTTL:   time.Hour,
+		RecordTTL: time.Hour,
+	})
+	return cache, store, zone.ID
+}
+
+// countRecords lists the records of a zone through the cache
+func countRecords(t *testing.T, cache *repository.CachedRepository, zoneID string) int {
+	t.Helper()
+	records, err := cache.ListRecords(context.Background(), zoneID, domain.RecordFilter{})
+	if err != nil {
+		t.Fatalf("ListRecords: %v", err)
+	}
+	return len(records)
+}
+
+func TestCachedRepositoryInvalidatesAfterWrites(t *testing.T) {
+	ctx := context.Background()
+	cache, store, zoneID := newCachedRepository(t)
+
+	if got := countRecords(t, cache, zoneID); got != 0 {
+		t.Fatalf("got %d records, want 0", got)
+	}
+
+	// A change made behind the cache's back is not seen until a write
+	// through the cache drops the cached list
+	store.AddRecord(zoneID, cloudflare.DNSRecord{Name: "old.example.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+	if got := countRecords(t, cache, zoneID); got != 0 {
+		t.Fatalf("got %d records from the cache, want the cached 0", got)
+	}
+
+	created, err := cache.CreateRecord(ctx, zoneID, &domain.DNSRecord{Name: "new.example.com", Type: "A", Content: "192.0.2.2", TTL: 1})
+	if err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	if got := countRecords(t, cache, zoneID); got != 2 {
+		t.Fatalf("after create: got %d records, want 2", got)
+	}
+
+	ttl := 300
+	if _, err := cache.UpdateRecord(ctx, zoneID, created.ID, domain.RecordPatch{TTL: &ttl, Tags: []string{}}); err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+	records, err := cache.ListRecords(ctx, zoneID, domain.RecordFilter{Name: "new.example.com"})
+	if err != nil {
+		t.Fatalf("ListRecords: %v", err)
+	}
+	if len(records) != 1 || records[0].TTL != 300 {
+		t.Fatalf("after update: got %+v, want new.example.com with TTL 300", records)
+	}
+
+	if err := cache.DeleteRecord(ctx, zoneID, created.ID); err != nil {
+		t.Fatalf("DeleteRecord: %v", err)
+	}
+	if got := countRecords(t, cache, zoneID); got != 1 {
+		t.Fatalf("after delete: got %d records, want 1", got)
+	}
+}
+
+func TestCachedRepositoryRefresh(t *testing.T) {
+	cache, store, zoneID := newCachedRepository(t)
+
+	countRecords(t, cache, zoneID)
+	store.AddRecord(zoneID, cloudflare.DNSRecord{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+	if err := cache.Refresh(context.Background(), "example.com"); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := countRecords(t, cache, zoneID); got != 1 {
+		t.Errorf("got %d records, want 1", got)
+	}
+}
@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"time"
 
 	"cf-dns-bot/external_resource/cloudflare"
@@ -23,8 +26,20 @@ func NewDNSRepository(client cloudflare.Client) DNSRepository {
 // ListRecords returns all DNS records for a zone
 func (r *dnsRepository) ListRecords(ctx context.Context, zoneID string, filter domain.RecordFilter) ([]domain.DNSRecord, error) {
 	cfFilter := cloudflare.DNSRecordFilter{
-		Name: filter.Name,
-		Type: filter.Type,
+		Name:           filter.Name,
+		Type:           filter.Type,
+		Content:        filter.Content,
+		NameContains:   filter.NameContains,
+		NameStartsWith: filter.NameStartsWith,
+		NameEndsWith:   filter.NameEndsWith,
+		Proxied:        filter.Proxied,
+		Comment:        filter.Comment,
+		Tag:            filter.Tag,
+		MatchAny:       filter.MatchAny,
+		Order:          filter.Order,
+		Descending:     filter.Descending,
+		Page:           filter.Page,
+		PerPage:        filter.PerPage,
 	}
 
 	records, err := r.client.ListDNSRecords(ctx, zoneID, cfFilter)
@@ -60,6 +75,9 @@ func (r *dnsRepository) CreateRecord(ctx context.Context, zoneID string, record
 		TTL:      record.TTL,
 		Proxied:  record.Proxied,
 		Priority: record.Priority,
+		Data:     mapToCloudflareData(record),
+		Comment:  record.Comment,
+		Tags:     record.Tags,
 	}
 
 	created, err := r.client.CreateDNSRecord(ctx, zoneID, input)
@@ -71,15 +89,21 @@ func (r *dnsRepository) CreateRecord(ctx context.Context, zoneID string, record
 	return &result, nil
 }
 
-// UpdateRecord updates an existing DNS record
-func (r *dnsRepository) UpdateRecord(ctx context.Context, zoneID, recordID string, record *domain.DNSRecord) (*domain.DNSRecord, error) {
+// UpdateRecord changes the fields of an existing DNS record that are set
+// in the patch
+func (r *dnsRepository) UpdateRecord(ctx context.Context, zoneID, recordID string, patch domain.RecordPatch) (*domain.DNSRecord, error) {
 	input := cloudflare.UpdateDNSRecordInput{
-		Name:     record.Name,
-		Type:     record.Type,
-		Content:  record.Content,
-		TTL:      record.TTL,
-		Proxied:  record.Proxied,
-		Priority: record.Priority,
+		Name:     patch.Name,
+		Type:     patch.Type,
+		Content:  patch.Content,
+		TTL:      patch.TTL,
+		Proxied:  patch.Proxied,
+		Priority: patch.Priority,
+		Comment:  patch.Comment,
+		Tags:     patch.Tags,
+	}
+	if patch.Type != nil {
+		input.Data = mapToCloudflareData(&domain.DNSRecord{Type: *patch.Type, Priority: patch.Priority, Data: patch.Data})
 	}
 
 	updated, err := r.client.UpdateDNSRecord(ctx, zoneID, recordID, input)
@@ -96,13 +120,11 @@ func (r *dnsRepository) DeleteRecord(ctx context.Context, zoneID, recordID strin
 	return r.client.DeleteDNSRecord(ctx, zoneID, recordID)
 }
 
-// FindByName finds a DNS record by name within a zone
-func (r *dnsRepository) FindByName(ctx context.Context, zoneID, name string) (*domain.DNSRecord, error) {
-	filter := cloudflare.DNSRecordFilter{
-		Name: name,
-	}
-
-	records, err := r.client.ListDNSRecords(ctx, zoneID, filter)
+// FindOne finds the single DNS record within a zone matching the filter.
+// It returns ErrRecordNotFound when nothing matches and ErrAmbiguousRecord
+// when more than one record matches.
+func (r *dnsRepository) FindOne(ctx context.Context, zoneID string, filter domain.RecordFilter) (*domain.DNSRecord, error) {
+	records, err := r.ListRecords(ctx, zoneID, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -110,9 +132,11 @@ func (r *dnsRepository) FindByName(ctx context.Context, zoneID, name string) (*d
 	if len(records) == 0 {
 		return nil, domain.ErrRecordNotFound
 	}
+	if len(records) > 1 {
+		return nil, fmt.Errorf("%w: %d records named %s, specify the record ID, type or content", domain.ErrAmbiguousRecord, len(records), filter.Name)
+	}
 
-	result := mapToDomainRecord(records[0])
-	return &result, nil
+	return &records[0], nil
 }
 
 // mapToDomainRecord maps external resource record to domain record
@@ -127,7 +151,73 @@ func mapToDomainRecord(r cloudflare.DNSRecord) domain.DNSRecord {
 		TTL:      r.TTL,
 		Proxied:  r.Proxied,
 		Priority: r.Priority,
+		Data:     mapToDomainData(r.Type, r.Data),
+		Comment:  r.Comment,
+		Tags:     r.Tags,
 		Created:  time.Now(), // Cloudflare API doesn't return created time
 		Modified: time.Now(), // Cloudflare API doesn't return modified time
 	}
 }
+
+// mapToCloudflareData builds Cloudflare's data object for records with
+// structured data, or nil for plain records
+func mapToCloudflareData(record *domain.DNSRecord) map[string]interface{} {
+	d := record.Data
+	if d == nil || !domain.HasStructuredData(record.Type) {
+		return nil
+	}
+
+	switch record.Type {
+	case "SRV":
+		priority := uint16(0)
+		if record.Priority != nil {
+			priority = *record.Priority
+		}
+		return map[string]interface{}{"priority": priority, "weight": d.Weight, "port": d.Port, "target": d.Target}
+	case "CAA":
+		flags, _ := strconv.Atoi(string(d.Flags))
+		return map[string]interface{}{"flags": flags, "tag": d.Tag, "value": d.Value}
+	case "TLSA", "SMIMEA":
+		return map[string]interface{}{"usage": d.Usage, "selector": d.Selector, "matching_type": d.MatchingType, "certificate": d.Certificate}
+	case "SSHFP":
+		return map[string]interface{}{"algorithm": d.Algorithm, "type": d.Type, "fingerprint": d.Fingerprint}
+	case "HTTPS", "SVCB":
+		return map[string]interface{}{"priority": d.Priority, "target": d.Target, "value": d.Value}
+	case "NAPTR":
+		return map[string]interface{}{
+			"order": d.Order, "preference": d.Preference, "flags": string(d.Flags),
+			"service": d.Service, "regex": d.Regex, "replacement": d.Replacement,
+		}
+	case "DS":
+		return map[string]interface{}{"key_tag": d.KeyTag, "algorithm": d.Algorithm, "digest_type": d.DigestType, "digest": d.Digest}
+	case "CERT":
+		return map[string]interface{}{"type": d.Type, "key_tag": d.KeyTag, "algorithm": d.Algorithm, "certificate": d.Certificate}
+	case "URI":
+		return map[string]interface{}{"weight": d.Weight, "target": d.Target}
+	case "LOC":
+		return map[string]interface{}{
+			"lat_degrees": d.LatDegrees, "lat_minutes": d.LatMinutes, "lat_seconds": d.LatSeconds, "lat_direction": d.LatDirection,
+			"long_degrees": d.LongDegrees, "long_minutes": d.LongMinutes, "long_seconds": d.LongSeconds, "long_direction": d.LongDirection,
+			"altitude": d.Altitude, "size": d.Size, "precision_horz": d.PrecisionHorz, "precision_vert": d.PrecisionVert,
+		}
+	}
+	return nil
+}
+
+// mapToDomainData converts Cloudflare's data object to RecordData. The JSON
+// names of RecordData match Cloudflare's, so the object is decoded as is.
+func mapToDomainData(recordType string, data map[string]interface{}) *domain.RecordData {
+	if data == nil || !domain.HasStructuredData(recordType) {
+		return nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+	var result domain.RecordData
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil
+	}
+	return &result
+}
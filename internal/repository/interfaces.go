@@ -17,14 +17,16 @@ type DNSRepository interface {
 	// CreateRecord creates a new DNS record
 	CreateRecord(ctx context.Context, zoneID string, record *domain.DNSRecord) (*domain.DNSRecord, error)
 
-	// UpdateRecord updates an existing DNS record
-	UpdateRecord(ctx context.Context, zoneID, recordID string, record *domain.DNSRecord) (*domain.DNSRecord, error)
+	// UpdateRecord changes the fields of an existing DNS record that are
+	// set in the patch
+	UpdateRecord(ctx context.Context, zoneID, recordID string, patch domain.RecordPatch) (*domain.DNSRecord, error)
 
 	// DeleteRecord deletes a DNS record
 	DeleteRecord(ctx context.Context, zoneID, recordID string) error
 
-	// FindByName finds a DNS record by name within a zone
-	FindByName(ctx context.Context, zoneID, name string) (*domain.DNSRecord, error)
+	// FindOne finds the single DNS record matching the filter within a zone.
+	// It returns domain.ErrAmbiguousRecord when several records match.
+	FindOne(ctx context.Context, zoneID string, filter domain.RecordFilter) (*domain.DNSRecord, error)
 }
 
 // ZoneRepository defines the interface for zone operations
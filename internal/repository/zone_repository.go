@@ -30,8 +30,9 @@ func (r *zoneRepository) ListZones(ctx context.Context) ([]domain.Zone, error) {
 	result := make([]domain.Zone, len(zones))
 	for i, z := range zones {
 		result[i] = domain.Zone{
-			ID:   z.ID,
-			Name: z.Name,
+			ID:      z.ID,
+			Name:    z.Name,
+			Account: z.Account,
 		}
 	}
 
@@ -49,8 +50,9 @@ func (r *zoneRepository) GetZoneByName(ctx context.Context, name string) (*domai
 	log.Printf("[GetZoneByName] SUCCESS: ID=%s, Name=%s", zone.ID, zone.Name)
 
 	return &domain.Zone{
-		ID:   zone.ID,
-		Name: zone.Name,
+		ID:      zone.ID,
+		Name:    zone.Name,
+		Account: zone.Account,
 	}, nil
 }
 
@@ -62,7 +64,8 @@ func (r *zoneRepository) GetZone(ctx context.Context, zoneID string) (*domain.Zo
 	}
 
 	return &domain.Zone{
-		ID:   zone.ID,
-		Name: zone.Name,
+		ID:      zone.ID,
+		Name:    zone.Name,
+		Account: zone.Account,
 	}, nil
 }
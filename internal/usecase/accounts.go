@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"cf-dns-bot/internal/domain"
+)
+
+// accountKey is the context key for the account a caller is restricted to
+type accountKey struct{}
+
+// WithAccount returns a context that only sees the zones of the named
+// Cloudflare account. An empty name allows every account.
+func WithAccount(ctx context.Context, account string) context.Context {
+	if account == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, accountKey{}, account)
+}
+
+// AccountFromContext returns the account ctx is restricted to, or an empty
+// string when every account is allowed
+func AccountFromContext(ctx context.Context) string {
+	account, _ := ctx.Value(accountKey{}).(string)
+	return account
+}
+
+// zonesKey is the context key for the zones a caller is restricted to
+type zonesKey struct{}
+
+// WithZones returns a context that only sees the named zones, which may be
+// patterns such as *.example.com. No zones allow every zone.
+func WithZones(ctx context.Context, zones []string) context.Context {
+	if len(zones) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, zonesKey{}, zones)
+}
+
+// ZonesFromContext returns the zones ctx is restricted to, or nil when
+// every zone is allowed
+func ZonesFromContext(ctx context.Context) []string {
+	zones, _ := ctx.Value(zonesKey{}).([]string)
+	return zones
+}
+
+// recordsKey is the context key for the record names a caller is
+// restricted to
+type recordsKey struct{}
+
+// WithRecords returns a context that only sees and changes the records
+// whose full name matches one of the patterns, such as _acme-challenge.*.
+// No patterns allow every record.
+func WithRecords(ctx context.Context, patterns []string) context.Context {
+	if len(patterns) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, recordsKey{}, patterns)
+}
+
+// RecordsFromContext returns the record name patterns ctx is restricted
+// to, or nil when every record is allowed
+func RecordsFromContext(ctx context.Context) []string {
+	patterns, _ := ctx.Value(recordsKey{}).([]string)
+	return patterns
+}
+
+// allowedZone reports whether the account and zones of ctx allow the zone
+func allowedZone(ctx context.Context, zone domain.Zone) bool {
+	account := AccountFromContext(ctx)
+	if account != "" && !strings.EqualFold(zone.Account, account) {
+		return false
+	}
+	zones := ZonesFromContext(ctx)
+	return len(zones) == 0 || matchesAny(zones, zone.Name)
+}
+
+// allowedRecord reports whether the record names of ctx allow the record
+func allowedRecord(ctx context.Context, recordName string) bool {
+	patterns := RecordsFromContext(ctx)
+	return len(patterns) == 0 || matchesAny(patterns, recordName)
+}
+
+// checkRecord returns ErrUnauthorized if the caller may not change a
+// record with the name
+func checkRecord(ctx context.Context, recordName string) error {
+	if !allowedRecord(ctx, recordName) {
+		return fmt.Errorf("%w: record %s is outside your scope", domain.ErrUnauthorized, recordName)
+	}
+	return nil
+}
+
+// ValidPattern reports whether a zone or record name pattern is well formed
+func ValidPattern(pattern string) bool {
+	_, err := path.Match(pattern, "")
+	return pattern != "" && err == nil
+}
+
+// matchesAny reports whether a zone or record name matches one of the
+// patterns, ignoring case and a trailing dot
+func matchesAny(patterns []string, name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		matched, _ := path.Match(strings.ToLower(strings.TrimSuffix(pattern, ".")), name)
+		return matched
+	})
+}
+
+// getZone returns a zone by its name, or domain.ErrZoneNotFound when the
+// caller is not allowed to use it
+func (u *dnsUsecase) getZone(ctx context.Context, zoneName string) (*domain.Zone, error) {
+	zone, err := u.zoneRepo.GetZoneByName(ctx, zoneName)
+	if err != nil {
+		return nil, err
+	}
+	if !allowedZone(ctx, *zone) {
+		return nil, domain.ErrZoneNotFound
+	}
+	return zone, nil
+}
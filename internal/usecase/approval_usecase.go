@@ -0,0 +1,338 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cf-dns-bot/internal/domain"
+)
+
+// defaultApprovalTimeout is how long a change waits for approval when the
+// policy does not set a timeout
+const defaultApprovalTimeout = time.Hour
+
+// storedChange is a pending change with the account and zones its
+// requester is restricted to
+type storedChange struct {
+	change  PendingChange
+	account string
+	zones   []string
+}
+
+// approvalUsecase implements ApprovalUsecase on top of DNSUsecase, with
+// SnapshotUsecase and ZoneImportUsecase for restores and imports.
+// Pending changes are kept in memory until they are decided or expire.
+type approvalUsecase struct {
+	dnsUsecase        DNSUsecase
+	snapshotUsecase   SnapshotUsecase
+	zoneImportUsecase ZoneImportUsecase
+	policy            ApprovalPolicy
+	pending           map[string]*storedChange
+	mu                sync.Mutex
+}
+
+// NewApprovalUsecase creates a new approval usecase with the given policy
+func NewApprovalUsecase(dnsUsecase DNSUsecase, snapshotUsecase SnapshotUsecase, zoneImportUsecase ZoneImportUsecase, policy ApprovalPolicy) ApprovalUsecase {
+	if policy.Timeout <= 0 {
+		policy.Timeout = defaultApprovalTimeout
+	}
+	return &approvalUsecase{
+		dnsUsecase:        dnsUsecase,
+		snapshotUsecase:   snapshotUsecase,
+		zoneImportUsecase: zoneImportUsecase,
+		policy:            policy,
+		pending:           make(map[string]*storedChange),
+	}
+}
+
+// Requires reports whether the policy holds a change for approval
+func (p ApprovalPolicy) Requires(change PendingChange) bool {
+	protected := slices.ContainsFunc(p.ProtectedZones, func(zone string) bool {
+		return strings.EqualFold(strings.TrimSuffix(zone, "."), change.ZoneName)
+	})
+	if protected {
+		return true
+	}
+
+	switch change.Kind {
+	case ChangeDelete:
+		return p.Deletes
+	case ChangeBulkDelete, ChangeBulkUpdate:
+		return p.BulkChanges
+	case ChangeRollback:
+		// Undoing a create deletes the record
+		return p.Deletes && change.Rollback != nil && change.Rollback.Action == domain.AuditActionCreate
+	case ChangeRestore:
+		// A restore deletes the records added since the snapshot
+		if change.Restore == nil {
+			return false
+		}
+		restore := change.Restore
+		return (p.Deletes && restore.Deletes > 0) || (p.BulkChanges && restore.Creates+restore.Updates+restore.Deletes > 1)
+	case ChangeImport:
+		return p.BulkChanges && change.Import != nil && len(change.Import.ToCreate) > 1
+	}
+	return false
+}
+
+// RequiresApproval reports whether the policy holds the change for approval
+func (u *approvalUsecase) RequiresApproval(change PendingChange) bool {
+	return u.policy.Requires(change)
+}
+
+// Submit parks a change of the actor in ctx. The record of an update or
+// delete is looked up now and pinned by its ID, so the approved change
+// cannot hit another record that has the same name by then.
+func (u *approvalUsecase) Submit(ctx context.Context, change PendingChange) (*PendingChange, error) {
+	if err := u.resolve(ctx, &change); err != nil {
+		return nil, err
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	change.ID = id
+	change.RequestedBy = ActorFromContext(ctx)
+	change.RequestedAt = now
+	change.ExpiresAt = now.Add(u.policy.Timeout)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.removeExpired(now)
+	u.pending[id] = &storedChange{change: change, account: AccountFromContext(ctx), zones: ZonesFromContext(ctx)}
+
+	return &change, nil
+}
+
+// Approve makes a pending change on behalf of its requester and records
+// the actor in ctx as its approver in the audit log
+func (u *approvalUsecase) Approve(ctx context.Context, id string) (*PendingChange, *ApprovalResult, error) {
+	stored, err := u.lookup(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	approver := ActorFromContext(ctx)
+	if sameActor(stored.change.RequestedBy, approver) {
+		return nil, nil, domain.ErrSelfApproval
+	}
+	if err := u.checkZoneAccess(ctx, stored.change.ZoneName); err != nil {
+		return nil, nil, err
+	}
+	// Another user may have decided on the change in the meantime
+	if !u.take(id) {
+		return nil, nil, domain.ErrPendingChangeNotFound
+	}
+
+	change := stored.change
+	ctx = WithAccount(WithActor(ctx, change.RequestedBy), stored.account)
+	ctx = WithZones(ctx, stored.zones)
+	ctx = WithApprover(ctx, approver)
+
+	result, err := u.apply(ctx, change)
+	return &change, result, err
+}
+
+// Reject drops a pending change. Users other than the requester need
+// access to the zone of the change.
+func (u *approvalUsecase) Reject(ctx context.Context, id string) (*PendingChange, error) {
+	stored, err := u.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sameActor(stored.change.RequestedBy, ActorFromContext(ctx)) {
+		if err := u.checkZoneAccess(ctx, stored.change.ZoneName); err != nil {
+			return nil, err
+		}
+	}
+	if !u.take(id) {
+		return nil, domain.ErrPendingChangeNotFound
+	}
+
+	change := stored.change
+	return &change, nil
+}
+
+// ListPending returns the pending changes in zones the caller may use,
+// oldest first
+func (u *approvalUsecase) ListPending(ctx context.Context) ([]PendingChange, error) {
+	zones, err := u.dnsUsecase.ListZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible := make(map[string]bool, len(zones))
+	for _, z := range zones {
+		visible[strings.ToLower(z.Name)] = true
+	}
+
+	u.mu.Lock()
+	u.removeExpired(time.Now())
+	var changes []PendingChange
+	for _, stored := range u.pending {
+		if visible[strings.ToLower(stored.change.ZoneName)] {
+			changes = append(changes, stored.change)
+		}
+	}
+	u.mu.Unlock()
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].RequestedAt.Before(changes[j].RequestedAt)
+	})
+	return changes, nil
+}
+
+// resolve checks that the change carries the input of its kind and pins
+// the record of an update or delete
+func (u *approvalUsecase) resolve(ctx context.Context, change *PendingChange) error {
+	missing := fmt.Errorf("%w: %s change without its input", domain.ErrInvalidRecord, change.Kind)
+	if change.ZoneName == "" {
+		return fmt.Errorf("%w: zone is required", domain.ErrInvalidZone)
+	}
+
+	switch change.Kind {
+	case ChangeCreate:
+		if change.Create == nil {
+			return missing
+		}
+		_, err := newRecord(*change.Create)
+		return err
+	case ChangeUpdate:
+		if change.Update == nil {
+			return missing
+		}
+		selector := change.Update.Selector
+		if selector.IsEmpty() {
+			selector = legacySelector(*change.Update)
+		}
+		record, err := u.dnsUsecase.GetRecord(ctx, change.ZoneName, selector)
+		if err != nil {
+			return err
+		}
+		update := *change.Update
+		update.Selector = RecordSelector{RecordID: record.ID}
+		change.Update, change.Record = &update, record
+	case ChangeDelete:
+		if change.Delete == nil {
+			return missing
+		}
+		record, err := u.dnsUsecase.GetRecord(ctx, change.ZoneName, *change.Delete)
+		if err != nil {
+			return err
+		}
+		change.Delete, change.Record = &RecordSelector{RecordID: record.ID}, record
+	case ChangeBulkDelete:
+		if change.BulkFilter == nil {
+			return missing
+		}
+	case ChangeBulkUpdate:
+		if change.BulkFilter == nil || change.BulkChanges == nil {
+			return missing
+		}
+	case ChangeRollback:
+		if change.Rollback == nil {
+			return missing
+		}
+	case ChangeRestore:
+		if change.Restore == nil || change.Restore.SnapshotID == "" {
+			return missing
+		}
+	case ChangeImport:
+		if change.Import == nil {
+			return missing
+		}
+		if !strings.EqualFold(change.Import.ZoneName, change.ZoneName) {
+			return fmt.Errorf("%w: import plan is for zone %s", domain.ErrInvalidZone, change.Import.ZoneName)
+		}
+	default:
+		return fmt.Errorf("%w: unknown change kind %q", domain.ErrInvalidRecord, change.Kind)
+	}
+	return nil
+}
+
+// apply makes an approved change through DNSUsecase
+func (u *approvalUsecase) apply(ctx context.Context, change PendingChange) (*ApprovalResult, error) {
+	result := &ApprovalResult{}
+	var err error
+	switch change.Kind {
+	case ChangeCreate:
+		result.Record, err = u.dnsUsecase.CreateRecord(ctx, *change.Create)
+	case ChangeUpdate:
+		result.Record, err = u.dnsUsecase.UpdateRecord(ctx, *change.Update)
+	case ChangeDelete:
+		err = u.dnsUsecase.DeleteRecord(ctx, change.ZoneName, *change.Delete)
+	case ChangeBulkDelete:
+		result.Bulk, err = u.dnsUsecase.BulkDelete(ctx, change.ZoneName, *change.BulkFilter)
+	case ChangeBulkUpdate:
+		result.Bulk, err = u.dnsUsecase.BulkUpdate(ctx, change.ZoneName, *change.BulkFilter, *change.BulkChanges)
+	case ChangeRollback:
+		result.Record, err = u.dnsUsecase.RollbackChange(ctx, *change.Rollback)
+	case ChangeRestore:
+		result.Restore, err = u.snapshotUsecase.RestoreSnapshot(ctx, change.ZoneName, change.Restore.SnapshotID, change.Restore.RecordIDs)
+	case ChangeImport:
+		result.Import, err = u.zoneImportUsecase.ApplyImport(ctx, change.Import)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkZoneAccess returns ErrUnauthorized if the caller may not use the zone
+func (u *approvalUsecase) checkZoneAccess(ctx context.Context, zoneName string) error {
+	zones, err := u.dnsUsecase.ListZones(ctx)
+	if err != nil {
+		return err
+	}
+	for _, z := range zones {
+		if strings.EqualFold(z.Name, zoneName) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: zone %s is not accessible to you", domain.ErrUnauthorized, zoneName)
+}
+
+// lookup returns a pending change that has not expired
+func (u *approvalUsecase) lookup(id string) (*storedChange, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	stored, exists := u.pending[id]
+	if !exists || time.Now().After(stored.change.ExpiresAt) {
+		delete(u.pending, id)
+		return nil, domain.ErrPendingChangeNotFound
+	}
+	return stored, nil
+}
+
+// take removes a pending change and reports whether it was still there
+func (u *approvalUsecase) take(id string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	_, exists := u.pending[id]
+	delete(u.pending, id)
+	return exists
+}
+
+// removeExpired drops the changes whose approval timed out; u.mu must be held
+func (u *approvalUsecase) removeExpired(now time.Time) {
+	for id, stored := range u.pending {
+		if now.After(stored.change.ExpiresAt) {
+			delete(u.pending, id)
+		}
+	}
+}
+
+// sameActor reports whether two actors are the same user of the same channel
+func sameActor(a, b domain.Actor) bool {
+	return a.ID != "" && a.ID == b.ID && a.Channel == b.Channel
+}
@@ -0,0 +1,39 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/usecase"
+)
+
+func TestApprovalPolicyRequires(t *testing.T) {
+	policy := usecase.ApprovalPolicy{Deletes: true, BulkChanges: true, ProtectedZones: []string{"example.org"}}
+	deletesOnly := usecase.ApprovalPolicy{Deletes: true}
+
+	tests := []struct {
+		name   string
+		policy usecase.ApprovalPolicy
+		change usecase.PendingChange
+		want   bool
+	}{
+		{"create", policy, usecase.PendingChange{Kind: usecase.ChangeCreate, ZoneName: "example.com"}, false},
+		{"create in protected zone", policy, usecase.PendingChange{Kind: usecase.ChangeCreate, ZoneName: "example.org"}, true},
+		{"delete", policy, usecase.PendingChange{Kind: usecase.ChangeDelete, ZoneName: "example.com"}, true},
+		{"bulk delete without bulk approval", deletesOnly, usecase.PendingChange{Kind: usecase.ChangeBulkDelete, ZoneName: "example.com"}, false},
+		{"undo of a create", policy, usecase.PendingChange{Kind: usecase.ChangeRollback, ZoneName: "example.com", Rollback: &domain.AuditEntry{Action: domain.AuditActionCreate}}, true},
+		{"restore of one record", policy, usecase.PendingChange{Kind: usecase.ChangeRestore, ZoneName: "example.com", Restore: &usecase.SnapshotRestore{SnapshotID: "s1", Creates: 1}}, false},
+		{"restore of a zone", policy, usecase.PendingChange{Kind: usecase.ChangeRestore, ZoneName: "example.com", Restore: &usecase.SnapshotRestore{SnapshotID: "s1", Creates: 1, Updates: 2}}, true},
+		{"restore that deletes", deletesOnly, usecase.PendingChange{Kind: usecase.ChangeRestore, ZoneName: "example.com", Restore: &usecase.SnapshotRestore{SnapshotID: "s1", Deletes: 1}}, true},
+		{"import", policy, usecase.PendingChange{Kind: usecase.ChangeImport, ZoneName: "example.com", Import: &usecase.ImportPlan{ToCreate: make([]domain.DNSRecord, 3)}}, true},
+		{"import without bulk approval", deletesOnly, usecase.PendingChange{Kind: usecase.ChangeImport, ZoneName: "example.com", Import: &usecase.ImportPlan{ToCreate: make([]domain.DNSRecord, 3)}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Requires(tt.change); got != tt.want {
+				t.Errorf("Requires = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
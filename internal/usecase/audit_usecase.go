@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/pkg/storage"
+)
+
+// actorKey is the context key for the acting user
+type actorKey struct{}
+
+// WithActor returns a context that attributes DNS changes to actor
+func WithActor(ctx context.Context, actor domain.Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx, or an unknown actor
+func ActorFromContext(ctx context.Context) domain.Actor {
+	if actor, ok := ctx.Value(actorKey{}).(domain.Actor); ok {
+		return actor
+	}
+	return domain.Actor{Name: "unknown", Channel: "unknown"}
+}
+
+// approverKey is the context key for the user approving a change
+type approverKey struct{}
+
+// WithApprover returns a context that records approver as the second
+// user who approved the DNS changes made with it
+func WithApprover(ctx context.Context, approver domain.Actor) context.Context {
+	return context.WithValue(ctx, approverKey{}, approver)
+}
+
+// changeRefKey is the context key for a ChangeRef
+type changeRefKey struct{}
+
+// ChangeRef receives the audit log ID of a change made with its context,
+// so callers can offer to undo it. ID stays empty if nothing was logged.
+type ChangeRef struct {
+	ID string
+}
+
+// WithChangeRef returns a context that reports the ID of the change made
+// with it in the returned ChangeRef
+func WithChangeRef(ctx context.Context) (context.Context, *ChangeRef) {
+	ref := &ChangeRef{}
+	return context.WithValue(ctx, changeRefKey{}, ref), ref
+}
+
+// auditedDNSUsecase records every successful write of the wrapped
+// DNSUsecase in the audit log. Reads pass through unchanged.
+type auditedDNSUsecase struct {
+	DNSUsecase
+	auditStorage storage.AuditStorage
+}
+
+// NewAuditedDNSUsecase wraps a DNS usecase with audit logging
+func NewAuditedDNSUsecase(inner DNSUsecase, auditStorage storage.AuditStorage) DNSUsecase {
+	return &auditedDNSUsecase{
+		DNSUsecase:   inner,
+		auditStorage: auditStorage,
+	}
+}
+
+// CreateRecord creates a record and logs it
+func (u *auditedDNSUsecase) CreateRecord(ctx context.Context, input CreateRecordInput) (*domain.DNSRecord, error) {
+	created, err := u.DNSUsecase.CreateRecord(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	u.record(ctx, domain.AuditActionCreate, input.ZoneName, nil, created)
+	return created, nil
+}
+
+// UpdateRecord updates a record and logs its previous and new state
+func (u *auditedDNSUsecase) UpdateRecord(ctx context.Context, input UpdateRecordInput) (*domain.DNSRecord, error) {
+	selector := input.Selector
+	if selector.IsEmpty() {
+		selector = legacySelector(input)
+	}
+	before, _ := u.DNSUsecase.GetRecord(ctx, input.ZoneName, selector)
+
+	updated, err := u.DNSUsecase.UpdateRecord(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	u.record(ctx, domain.AuditActionUpdate, input.ZoneName, before, updated)
+	return updated, nil
+}
+
+// DeleteRecord deletes a record and logs its last state
+func (u *auditedDNSUsecase) DeleteRecord(ctx context.Context, zoneName string, selector RecordSelector) error {
+	before, err := u.DNSUsecase.GetRecord(ctx, zoneName, selector)
+	if err != nil {
+		return err
+	}
+
+	if err := u.DNSUsecase.DeleteRecord(ctx, zoneName, RecordSelector{RecordID: before.ID}); err != nil {
+		return err
+	}
+
+	u.record(ctx, domain.AuditActionDelete, zoneName, before, nil)
+	return nil
+}
+
+// UpsertRecord creates or updates a record and logs which one happened
+func (u *auditedDNSUsecase) UpsertRecord(ctx context.Context, input CreateRecordInput) (*domain.DNSRecord, error) {
+	before, _ := u.DNSUsecase.GetRecord(ctx, input.ZoneName, RecordSelector{Name: input.Name, Type: input.Type})
+
+	record, err := u.DNSUsecase.UpsertRecord(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	action := domain.AuditActionCreate
+	if before != nil {
+		action = domain.AuditActionUpdate
+	}
+	u.record(ctx, action, input.ZoneName, before, record)
+	return record, nil
+}
+
+// BulkCreate creates many records, logging each one
+func (u *auditedDNSUsecase) BulkCreate(ctx context.Context, zoneName string, inputs []CreateRecordInput) (*BulkResult, error) {
+	return bulkCreate(ctx, u, zoneName, inputs)
+}
+
+// BulkDelete deletes matching records, logging each one
+func (u *auditedDNSUsecase) BulkDelete(ctx context.Context, zoneName string, filter BulkFilter) (*BulkResult, error) {
+	return bulkDelete(ctx, u, zoneName, filter)
+}
+
+// BulkUpdate updates matching records, logging each one
+func (u *auditedDNSUsecase) BulkUpdate(ctx context.Context, zoneName string, filter BulkFilter, changes BulkChanges) (*BulkResult, error) {
+	return bulkUpdate(ctx, u, zoneName, filter, changes)
+}
+
+// RollbackChange undoes a change and logs the rollback as a change of its own
+func (u *auditedDNSUsecase) RollbackChange(ctx context.Context, change domain.AuditEntry) (*domain.DNSRecord, error) {
+	restored, err := u.DNSUsecase.RollbackChange(ctx, change)
+	if err != nil {
+		return nil, err
+	}
+
+	action := domain.AuditActionUpdate
+	switch change.Action {
+	case domain.AuditActionCreate:
+		action = domain.AuditActionDelete
+	case domain.AuditActionDelete:
+		action = domain.AuditActionCreate
+	}
+	u.record(ctx, action, change.ZoneName, change.After, restored)
+	return restored, nil
+}
+
+// record appends an audit entry. Failures are logged but do not fail the
+// change, which has already been made.
+func (u *auditedDNSUsecase) record(ctx context.Context, action, zoneName string, before, after *domain.DNSRecord) {
+	id, err := newRandomID()
+	if err != nil {
+		log.Printf("[Audit] ERROR: %v", err)
+		return
+	}
+
+	actor := ActorFromContext(ctx)
+	entry := domain.AuditEntry{
+		ID:        id,
+		Timestamp: time.Now().UTC(),
+		Actor:     actor.String(),
+		ActorID:   actor.ID,
+		Channel:   actor.Channel,
+		Action:    action,
+		ZoneName:  zoneName,
+		Before:    before,
+		After:     after,
+	}
+	if approver, ok := ctx.Value(approverKey{}).(domain.Actor); ok {
+		entry.ApprovedBy = approver.String()
+	}
+
+	if err := u.auditStorage.AppendAuditEntry(entry); err != nil {
+		log.Printf("[Audit] ERROR writing entry for %s %s: %v", action, zoneName, err)
+		return
+	}
+
+	if ref, ok := ctx.Value(changeRefKey{}).(*ChangeRef); ok {
+		ref.ID = id
+	}
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"cf-dns-bot/internal/domain"
+)
+
+// MatchRecords returns the records of a zone matching the filter
+func (u *dnsUsecase) MatchRecords(ctx context.Context, zoneName string, filter BulkFilter) ([]domain.DNSRecord, error) {
+	return matchRecords(ctx, u, zoneName, filter)
+}
+
+// BulkCreate creates many records
+func (u *dnsUsecase) BulkCreate(ctx context.Context, zoneName string, inputs []CreateRecordInput) (*BulkResult, error) {
+	return bulkCreate(ctx, u, zoneName, inputs)
+}
+
+// BulkDelete deletes all records matching the filter
+func (u *dnsUsecase) BulkDelete(ctx context.Context, zoneName string, filter BulkFilter) (*BulkResult, error) {
+	return bulkDelete(ctx, u, zoneName, filter)
+}
+
+// BulkUpdate sets the TTL and/or proxy status of all matching records
+func (u *dnsUsecase) BulkUpdate(ctx context.Context, zoneName string, filter BulkFilter, changes BulkChanges) (*BulkResult, error) {
+	return bulkUpdate(ctx, u, zoneName, filter, changes)
+}
+
+// The bulk operations are written against DNSUsecase so that wrappers
+// such as the audit log see every single record change.
+
+// matchRecords lists the records of a zone and applies the filter
+func matchRecords(ctx context.Context, dnsUsecase DNSUsecase, zoneName string, filter BulkFilter) ([]domain.DNSRecord, error) {
+	if filter.NamePattern != "" {
+		if _, err := path.Match(filter.NamePattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: invalid name pattern %q", domain.ErrInvalidRecord, filter.NamePattern)
+		}
+	}
+
+	records, err := dnsUsecase.ListRecords(ctx, zoneName, domain.RecordFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []domain.DNSRecord
+	for _, r := range records {
+		if matchesBulkFilter(r, zoneName, filter) {
+			matches = append(matches, r)
+		}
+	}
+	return matches, nil
+}
+
+// bulkCreate creates each record in turn
+func bulkCreate(ctx context.Context, dnsUsecase DNSUsecase, zoneName string, inputs []CreateRecordInput) (*BulkResult, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: no records to create", domain.ErrInvalidRecord)
+	}
+
+	result := &BulkResult{ZoneName: zoneName}
+	for _, input := range inputs {
+		input.ZoneName = zoneName
+		created, err := dnsUsecase.CreateRecord(ctx, input)
+		if err != nil {
+			record := domain.DNSRecord{Name: input.Name, Type: input.Type, Content: input.Content}
+			result.Failed = append(result.Failed, FailedRecord{Record: record, Error: err.Error()})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, *created)
+	}
+	return result, nil
+}
+
+// bulkDelete deletes the matching records. An empty filter is rejected so
+// a missing parameter cannot wipe a zone.
+func bulkDelete(ctx context.Context, dnsUsecase DNSUsecase, zoneName string, filter BulkFilter) (*BulkResult, error) {
+	if filter.IsEmpty() {
+		return nil, fmt.Errorf("%w: bulk delete needs a type, name pattern or content filter", domain.ErrInvalidRecord)
+	}
+
+	records, err := matchRecords(ctx, dnsUsecase, zoneName, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkResult{ZoneName: zoneName}
+	for _, r := range records {
+		if err := dnsUsecase.DeleteRecord(ctx, zoneName, RecordSelector{RecordID: r.ID}); err != nil {
+			result.Failed = append(result.Failed, FailedRecord{Record: r, Error: err.Error()})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, r)
+	}
+	return result, nil
+}
+
+// bulkUpdate applies the changes to the matching records. Records that
+// already have the requested settings are left alone.
+func bulkUpdate(ctx context.Context, dnsUsecase DNSUsecase, zoneName string, filter BulkFilter, changes BulkChanges) (*BulkResult, error) {
+	if changes.TTL == nil && changes.Proxied == nil {
+		return nil, fmt.Errorf("%w: bulk update needs a TTL or proxied setting", domain.ErrInvalidRecord)
+	}
+	if changes.TTL != nil {
+		if err := domain.ValidateTTL(*changes.TTL); err != nil {
+			return nil, err
+		}
+	}
+
+	records, err := matchRecords(ctx, dnsUsecase, zoneName, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkResult{ZoneName: zoneName}
+	for _, r := range records {
+		ttl, proxied := r.TTL, r.Proxied
+		if changes.TTL != nil {
+			ttl = *changes.TTL
+		}
+		if changes.Proxied != nil {
+			if *changes.Proxied && !domain.CanProxy(r.Type) {
+				result.Failed = append(result.Failed, FailedRecord{Record: r, Error: fmt.Sprintf("%s records cannot be proxied", r.Type)})
+				continue
+			}
+			proxied = *changes.Proxied
+		}
+		if ttl == r.TTL && proxied == r.Proxied {
+			result.Succeeded = append(result.Succeeded, r)
+			continue
+		}
+
+		updated, err := dnsUsecase.UpdateRecord(ctx, UpdateRecordInput{
+			ZoneName: zoneName,
+			Selector: RecordSelector{RecordID: r.ID},
+			TTL:      changes.TTL,
+			Proxied:  changes.Proxied,
+		})
+		if err != nil {
+			result.Failed = append(result.Failed, FailedRecord{Record: r, Error: err.Error()})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, *updated)
+	}
+	return result, nil
+}
+
+// matchesBulkFilter returns true if the record matches the filter
+func matchesBulkFilter(r domain.DNSRecord, zoneName string, filter BulkFilter) bool {
+	if filter.Type != "" && !strings.EqualFold(r.Type, filter.Type) {
+		return false
+	}
+	if filter.Content != "" && r.Content != filter.Content {
+		return false
+	}
+	if filter.NamePattern == "" {
+		return true
+	}
+
+	pattern := strings.ToLower(strings.TrimSuffix(filter.NamePattern, "."))
+	name := strings.ToLower(r.Name)
+	relative := "@"
+	if suffix := "." + strings.ToLower(zoneName); strings.HasSuffix(name, suffix) {
+		relative = strings.TrimSuffix(name, suffix)
+	}
+
+	matchFull, _ := path.Match(pattern, name)
+	matchRelative, _ := path.Match(pattern, relative)
+	return matchFull || matchRelative
+}
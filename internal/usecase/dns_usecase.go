@@ -2,8 +2,10 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 
 	"cf-dns-bot/internal/domain"
@@ -31,59 +33,69 @@ func NewDNSUsecase(
 	}
 }
 
-// ListZones returns all accessible zones
+// ListZones returns all zones of the accounts the caller may use
 func (u *dnsUsecase) ListZones(ctx context.Context) ([]domain.Zone, error) {
-	return u.zoneRepo.ListZones(ctx)
+	zones, err := u.zoneRepo.ListZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(zones, func(z domain.Zone) bool {
+		return !allowedZone(ctx, z)
+	}), nil
 }
 
-// ListRecords returns all DNS records for a zone
-func (u *dnsUsecase) ListRecords(ctx context.Context, zoneName string) ([]domain.DNSRecord, error) {
+// ListRecords returns the DNS records of a zone matching the filter; an
+// empty filter returns all records
+func (u *dnsUsecase) ListRecords(ctx context.Context, zoneName string, filter domain.RecordFilter) ([]domain.DNSRecord, error) {
 	log.Printf("[ListRecords] START zoneName=%s", zoneName)
-	zone, err := u.zoneRepo.GetZoneByName(ctx, zoneName)
+	if err := domain.ValidateFilter(filter); err != nil {
+		return nil, err
+	}
+
+	zone, err := u.getZone(ctx, zoneName)
 	if err != nil {
 		log.Printf("[ListRecords] ERROR GetZoneByName: %v", err)
 		return nil, fmt.Errorf("failed to get zone %s: %w", zoneName, err)
 	}
 	log.Printf("[ListRecords] Got zone: ID=%s, Name=%s", zone.ID, zone.Name)
 
+	if filter.Name != "" {
+		filter.Name = ensureFullRecordName(filter.Name, zone.Name)
+	}
+
 	log.Printf("[ListRecords] Calling ListRecords for zoneID=%s", zone.ID)
-	records, err := u.dnsRepo.ListRecords(ctx, zone.ID, domain.RecordFilter{})
+	records, err := u.dnsRepo.ListRecords(ctx, zone.ID, filter)
 	if err != nil {
 		log.Printf("[ListRecords] ERROR ListRecords: %v", err)
 		return nil, fmt.Errorf("failed to list records: %w", err)
 	}
 	log.Printf("[ListRecords] SUCCESS: Found %d records", len(records))
 
-	return records, nil
+	return slices.DeleteFunc(records, func(r domain.DNSRecord) bool {
+		return !allowedRecord(ctx, r.Name)
+	}), nil
 }
 
-// GetRecord returns a specific DNS record by name
-func (u *dnsUsecase) GetRecord(ctx context.Context, zoneName, recordName string) (*domain.DNSRecord, error) {
-	zone, err := u.zoneRepo.GetZoneByName(ctx, zoneName)
+// GetRecord returns the DNS record identified by the selector
+func (u *dnsUsecase) GetRecord(ctx context.Context, zoneName string, selector RecordSelector) (*domain.DNSRecord, error) {
+	zone, err := u.getZone(ctx, zoneName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get zone %s: %w", zoneName, err)
 	}
 
-	// Ensure record name is fully qualified
-	fullRecordName := u.ensureFullRecordName(recordName, zone.Name)
-
-	record, err := u.dnsRepo.FindByName(ctx, zone.ID, fullRecordName)
-	if err != nil {
-		return nil, err
-	}
-
-	return record, nil
+	return u.resolveRecord(ctx, zone, selector)
 }
 
 // CreateRecord creates a new DNS record
 func (u *dnsUsecase) CreateRecord(ctx context.Context, input CreateRecordInput) (*domain.DNSRecord, error) {
-	// Validate record type
-	if !domain.IsValidRecordType(input.Type) {
-		return nil, fmt.Errorf("%w: invalid record type %s", domain.ErrInvalidRecord, input.Type)
+	// Validate record before any Cloudflare call
+	record, err := newRecord(input)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get zone
-	zone, err := u.zoneRepo.GetZoneByName(ctx, input.ZoneName)
+	zone, err := u.getZone(ctx, input.ZoneName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get zone %s: %w", input.ZoneName, err)
 	}
@@ -91,29 +103,28 @@ func (u *dnsUsecase) CreateRecord(ctx context.Context, input CreateRecordInput)
 	// Apply defaults from config if needed
 	config, err := u.configStorage.Load()
 	if err == nil {
-		if input.TTL == 0 {
-			input.TTL = config.DefaultTTL
+		if record.TTL == 0 {
+			record.TTL = config.DefaultTTL
 		}
 	}
 
 	// Ensure record name is fully qualified
-	fullRecordName := u.ensureFullRecordName(input.Name, zone.Name)
-
-	// Check if record already exists
-	existing, _ := u.dnsRepo.FindByName(ctx, zone.ID, fullRecordName)
-	if existing != nil {
-		return nil, domain.ErrDuplicateRecord
+	record.ZoneID = zone.ID
+	record.ZoneName = zone.Name
+	record.Name = ensureFullRecordName(record.Name, zone.Name)
+	if err := checkRecord(ctx, record.Name); err != nil {
+		return nil, err
 	}
 
-	record := &domain.DNSRecord{
-		ZoneID:   zone.ID,
-		ZoneName: zone.Name,
-		Name:     fullRecordName,
-		Type:     input.Type,
-		Content:  input.Content,
-		TTL:      input.TTL,
-		Proxied:  input.Proxied,
-		Priority: input.Priority,
+	// Check if an identical record already exists. Other records with the
+	// same name (round-robin A, multiple MX/TXT, A+AAAA) are allowed.
+	existing, _ := u.dnsRepo.ListRecords(ctx, zone.ID, domain.RecordFilter{
+		Name:    record.Name,
+		Type:    record.Type,
+		Content: record.Content,
+	})
+	if len(existing) > 0 {
+		return nil, domain.ErrDuplicateRecord
 	}
 
 	created, err := u.dnsRepo.CreateRecord(ctx, zone.ID, record)
@@ -124,38 +135,75 @@ func (u *dnsUsecase) CreateRecord(ctx context.Context, input CreateRecordInput)
 	return created, nil
 }
 
-// UpdateRecord updates an existing DNS record
+// UpdateRecord changes the fields of a DNS record that are set in the
+// input and leaves the others as they are
 func (u *dnsUsecase) UpdateRecord(ctx context.Context, input UpdateRecordInput) (*domain.DNSRecord, error) {
 	// Validate record type
-	if !domain.IsValidRecordType(input.Type) {
-		return nil, fmt.Errorf("%w: invalid record type %s", domain.ErrInvalidRecord, input.Type)
+	if input.Type != nil && !domain.IsValidRecordType(*input.Type) {
+		return nil, fmt.Errorf("%w: invalid record type %s", domain.ErrInvalidRecord, *input.Type)
 	}
 
 	// Get zone
-	zone, err := u.zoneRepo.GetZoneByName(ctx, input.ZoneName)
+	zone, err := u.getZone(ctx, input.ZoneName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get zone %s: %w", input.ZoneName, err)
 	}
 
-	// Find existing record
-	fullRecordName := u.ensureFullRecordName(input.Name, zone.Name)
-	existing, err := u.dnsRepo.FindByName(ctx, zone.ID, fullRecordName)
+	// Find existing record, falling back to name and type for older callers
+	selector := input.Selector
+	if selector.IsEmpty() {
+		selector = legacySelector(input)
+	}
+	existing, err := u.resolveRecord(ctx, zone, selector)
 	if err != nil {
 		return nil, err
 	}
 
-	record := &domain.DNSRecord{
-		ZoneID:   zone.ID,
-		ZoneName: zone.Name,
-		Name:     fullRecordName,
-		Type:     input.Type,
-		Content:  input.Content,
-		TTL:      input.TTL,
-		Proxied:  input.Proxied,
-		Priority: input.Priority,
+	record := *existing
+	record.ZoneID = zone.ID
+	record.ZoneName = zone.Name
+	if input.Name != nil && *input.Name != "" {
+		record.Name = ensureFullRecordName(*input.Name, zone.Name)
+		if err := checkRecord(ctx, record.Name); err != nil {
+			return nil, err
+		}
+	}
+	if input.Type != nil {
+		record.Type = *input.Type
+	}
+	if input.Content != nil {
+		// New content replaces the structured data it was parsed into
+		record.Content = *input.Content
+		record.Data = nil
+	}
+	if input.Data != nil {
+		record.Data = input.Data
+	}
+	if input.TTL != nil {
+		record.TTL = *input.TTL
+	}
+	if input.Proxied != nil {
+		record.Proxied = *input.Proxied
+	}
+	if input.Priority != nil {
+		record.Priority = input.Priority
+	}
+	if input.Comment != nil {
+		record.Comment = *input.Comment
+	}
+	if input.Tags != nil {
+		record.Tags = input.Tags
 	}
 
-	updated, err := u.dnsRepo.UpdateRecord(ctx, zone.ID, existing.ID, record)
+	// Validate the record as it will be after the update
+	if err := domain.CompleteRecordData(&record); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateRecord(record); err != nil {
+		return nil, err
+	}
+
+	updated, err := u.dnsRepo.UpdateRecord(ctx, zone.ID, existing.ID, updatePatch(*existing, record, input))
 	if err != nil {
 		return nil, fmt.Errorf("failed to update record: %w", err)
 	}
@@ -163,17 +211,59 @@ func (u *dnsUsecase) UpdateRecord(ctx context.Context, input UpdateRecordInput)
 	return updated, nil
 }
 
-// DeleteRecord deletes a DNS record
-func (u *dnsUsecase) DeleteRecord(ctx context.Context, zoneName, recordName string) error {
+// legacySelector returns the selector of an update input without one,
+// which finds the record by its name and type
+func legacySelector(input UpdateRecordInput) RecordSelector {
+	var selector RecordSelector
+	if input.Name != nil {
+		selector.Name = *input.Name
+	}
+	if input.Type != nil {
+		selector.Type = *input.Type
+	}
+	return selector
+}
+
+// updatePatch returns the fields of an update to send to Cloudflare. The
+// type, content, structured data and priority of a record are sent
+// together whenever its value changes, since Cloudflare derives one from
+// the other. The current tags are always passed on because the client
+// cannot leave them out.
+func updatePatch(existing, record domain.DNSRecord, input UpdateRecordInput) domain.RecordPatch {
+	patch := domain.RecordPatch{
+		TTL:     input.TTL,
+		Proxied: input.Proxied,
+		Comment: input.Comment,
+		Tags:    record.Tags,
+	}
+	if patch.Tags == nil {
+		patch.Tags = []string{}
+	}
+	if record.Name != existing.Name {
+		patch.Name = &record.Name
+	}
+
+	priorityChanged := (record.Priority == nil) != (existing.Priority == nil) ||
+		(record.Priority != nil && *record.Priority != *existing.Priority)
+	if input.Type != nil || input.Content != nil || input.Data != nil || priorityChanged {
+		patch.Type = &record.Type
+		patch.Content = &record.Content
+		patch.Data = record.Data
+		patch.Priority = record.Priority
+	}
+	return patch
+}
+
+// DeleteRecord deletes the DNS record identified by the selector
+func (u *dnsUsecase) DeleteRecord(ctx context.Context, zoneName string, selector RecordSelector) error {
 	// Get zone
-	zone, err := u.zoneRepo.GetZoneByName(ctx, zoneName)
+	zone, err := u.getZone(ctx, zoneName)
 	if err != nil {
 		return fmt.Errorf("failed to get zone %s: %w", zoneName, err)
 	}
 
 	// Find record
-	fullRecordName := u.ensureFullRecordName(recordName, zone.Name)
-	record, err := u.dnsRepo.FindByName(ctx, zone.ID, fullRecordName)
+	record, err := u.resolveRecord(ctx, zone, selector)
 	if err != nil {
 		return err
 	}
@@ -181,15 +271,18 @@ func (u *dnsUsecase) DeleteRecord(ctx context.Context, zoneName, recordName stri
 	return u.dnsRepo.DeleteRecord(ctx, zone.ID, record.ID)
 }
 
-// UpsertRecord creates or updates a DNS record
+// UpsertRecord creates or updates a DNS record.
+// The record to update is matched by name and type; if several records
+// match, ErrAmbiguousRecord is returned instead of picking one.
 func (u *dnsUsecase) UpsertRecord(ctx context.Context, input CreateRecordInput) (*domain.DNSRecord, error) {
-	// Validate record type
-	if !domain.IsValidRecordType(input.Type) {
-		return nil, fmt.Errorf("%w: invalid record type %s", domain.ErrInvalidRecord, input.Type)
+	// Validate record before any Cloudflare call
+	record, err := newRecord(input)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get zone
-	zone, err := u.zoneRepo.GetZoneByName(ctx, input.ZoneName)
+	zone, err := u.getZone(ctx, input.ZoneName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get zone %s: %w", input.ZoneName, err)
 	}
@@ -197,39 +290,96 @@ func (u *dnsUsecase) UpsertRecord(ctx context.Context, input CreateRecordInput)
 	// Apply defaults from config if needed
 	config, err := u.configStorage.Load()
 	if err == nil {
-		if input.TTL == 0 {
-			input.TTL = config.DefaultTTL
+		if record.TTL == 0 {
+			record.TTL = config.DefaultTTL
 		}
 	}
 
 	// Ensure record name is fully qualified
-	fullRecordName := u.ensureFullRecordName(input.Name, zone.Name)
+	record.ZoneID = zone.ID
+	record.ZoneName = zone.Name
+	record.Name = ensureFullRecordName(record.Name, zone.Name)
+	if err := checkRecord(ctx, record.Name); err != nil {
+		return nil, err
+	}
 
 	// Check if record exists
-	existing, err := u.dnsRepo.FindByName(ctx, zone.ID, fullRecordName)
+	existing, err := u.dnsRepo.FindOne(ctx, zone.ID, domain.RecordFilter{
+		Name: record.Name,
+		Type: record.Type,
+	})
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, err
+	}
 
+	if existing != nil {
+		// Update existing record, keeping a comment and tags not given
+		if input.Comment == "" {
+			record.Comment = existing.Comment
+		}
+		if input.Tags == nil {
+			record.Tags = existing.Tags
+		}
+		return u.dnsRepo.UpdateRecord(ctx, zone.ID, existing.ID, domain.FullPatch(*record))
+	}
+
+	// Create new record
+	return u.dnsRepo.CreateRecord(ctx, zone.ID, record)
+}
+
+// newRecord builds the record of a create or upsert input, fills in its
+// structured data and checks it against the rules of its type. The name
+// is not yet qualified with the zone.
+func newRecord(input CreateRecordInput) (*domain.DNSRecord, error) {
 	record := &domain.DNSRecord{
-		ZoneID:   zone.ID,
-		ZoneName: zone.Name,
-		Name:     fullRecordName,
+		Name:     input.Name,
 		Type:     input.Type,
 		Content:  input.Content,
 		TTL:      input.TTL,
 		Proxied:  input.Proxied,
 		Priority: input.Priority,
+		Data:     input.Data,
+		Comment:  input.Comment,
+		Tags:     input.Tags,
+	}
+	if err := domain.CompleteRecordData(record); err != nil {
+		return nil, err
 	}
+	if err := domain.ValidateRecord(*record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
 
-	if err == nil && existing != nil {
-		// Update existing record
-		return u.dnsRepo.UpdateRecord(ctx, zone.ID, existing.ID, record)
+// resolveRecord finds the single record in the zone identified by the
+// selector. Records the caller may not see are not found.
+func (u *dnsUsecase) resolveRecord(ctx context.Context, zone *domain.Zone, selector RecordSelector) (*domain.DNSRecord, error) {
+	var record *domain.DNSRecord
+	var err error
+	switch {
+	case selector.RecordID != "":
+		record, err = u.dnsRepo.GetRecord(ctx, zone.ID, selector.RecordID)
+	case selector.Name == "":
+		return nil, fmt.Errorf("%w: record ID or name is required", domain.ErrInvalidRecord)
+	default:
+		record, err = u.dnsRepo.FindOne(ctx, zone.ID, domain.RecordFilter{
+			Name:    ensureFullRecordName(selector.Name, zone.Name),
+			Type:    selector.Type,
+			Content: selector.Content,
+		})
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	// Create new record
-	return u.dnsRepo.CreateRecord(ctx, zone.ID, record)
+	if !allowedRecord(ctx, record.Name) {
+		return nil, domain.ErrRecordNotFound
+	}
+	return record, nil
 }
 
 // ensureFullRecordName ensures the record name includes the zone name
-func (u *dnsUsecase) ensureFullRecordName(recordName, zoneName string) string {
+func ensureFullRecordName(recordName, zoneName string) string {
 	// If record name already ends with zone name, return as is
 	if strings.HasSuffix(recordName, zoneName) {
 		return recordName
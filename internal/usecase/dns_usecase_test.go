@@ -0,0 +1,187 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cf-dns-bot/external_resource/cloudflare"
+	"cf-dns-bot/external_resource/cloudflare/cloudflaretest"
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/repository"
+	"cf-dns-bot/internal/usecase"
+	"cf-dns-bot/pkg/storage"
+)
+
+// newDNSUsecase returns a usecase on top of an in-memory Cloudflare with
+// the demo zones, and the ID of example.com
+func newDNSUsecase(t *testing.T) (usecase.DNSUsecase, *cloudflaretest.Store, string) {
+	t.Helper()
+	store := cloudflaretest.NewDemoStore()
+	client := cloudflaretest.NewClient(store)
+	zone, err := client.GetZoneByName(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("GetZoneByName: %v", err)
+	}
+	uc := usecase.NewDNSUsecase(repository.NewZoneRepository(client), repository.NewDNSRepository(client), storage.NewJSONStorage(t.TempDir()))
+	return uc, store, zone.ID
+}
+
+// findRecords returns the records of the store with the name and type
+func findRecords(store *cloudflaretest.Store, zoneID, name, recordType string) []cloudflare.DNSRecord {
+	var found []cloudflare.DNSRecord
+	for _, r := range store.Records(zoneID) {
+		if r.Name == name && r.Type == recordType {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+func TestCreateRecord(t *testing.T) {
+	ctx := context.Background()
+	uc, store, zoneID := newDNSUsecase(t)
+	input := usecase.CreateRecordInput{ZoneName: "example.com", Name: "test", Type: "A", Content: "192.0.2.50", TTL: 300}
+
+	record, err := uc.CreateRecord(ctx, input)
+	if err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	if record.Name != "test.example.com" {
+		t.Errorf("got name %s, want test.example.com", record.Name)
+	}
+	if got := findRecords(store, zoneID, "test.example.com", "A"); len(got) != 1 {
+		t.Fatalf("got %d records in Cloudflare, want 1", len(got))
+	}
+
+	if _, err := uc.CreateRecord(ctx, input); !errors.Is(err, domain.ErrDuplicateRecord) {
+		t.Errorf("second create: got error %v, want ErrDuplicateRecord", err)
+	}
+
+	// Another address under the same name is a round-robin record
+	input.Content = "192.0.2.51"
+	if _, err := uc.CreateRecord(ctx, input); err != nil {
+		t.Errorf("round-robin create: %v", err)
+	}
+}
+
+func TestCreateRecordInvalid(t *testing.T) {
+	uc, store, zoneID := newDNSUsecase(t)
+	before := len(store.Records(zoneID))
+
+	_, err := uc.CreateRecord(context.Background(), usecase.CreateRecordInput{ZoneName: "example.com", Name: "bad", Type: "A", Content: "not-an-ip"})
+	if !errors.Is(err, domain.ErrInvalidRecord) {
+		t.Errorf("got error %v, want ErrInvalidRecord", err)
+	}
+	if got := len(store.Records(zoneID)); got != before {
+		t.Errorf("got %d records, want %d", got, before)
+	}
+}
+
+func TestUpdateRecord(t *testing.T) {
+	ctx := context.Background()
+	uc, store, zoneID := newDNSUsecase(t)
+	content := "192.0.2.21"
+
+	// api.example.com has an A and an AAAA record
+	_, err := uc.UpdateRecord(ctx, usecase.UpdateRecordInput{
+		ZoneName: "example.com",
+		Selector: usecase.RecordSelector{Name: "api"},
+		Content:  &content,
+	})
+	if !errors.Is(err, domain.ErrAmbiguousRecord) {
+		t.Fatalf("got error %v, want ErrAmbiguousRecord", err)
+	}
+
+	record, err := uc.UpdateRecord(ctx, usecase.UpdateRecordInput{
+		ZoneName: "example.com",
+		Selector: usecase.RecordSelector{Name: "api", Type: "A"},
+		Content:  &content,
+	})
+	if err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+	if record.Content != content {
+		t.Errorf("got content %s, want %s", record.Content, content)
+	}
+
+	// Fields left out keep their values
+	got := findRecords(store, zoneID, "api.example.com", "A")
+	if len(got) != 1 || got[0].Content != content || got[0].TTL != 300 || got[0].Comment != "API gateway" {
+		t.Errorf("got %+v, want the new content with TTL and comment kept", got)
+	}
+}
+
+func TestUpsertRecord(t *testing.T) {
+	ctx := context.Background()
+	uc, store, zoneID := newDNSUsecase(t)
+
+	// A new name is created
+	if _, err := uc.UpsertRecord(ctx, usecase.CreateRecordInput{ZoneName: "example.com", Name: "home", Type: "A", Content: "192.0.2.60", TTL: 60}); err != nil {
+		t.Fatalf("UpsertRecord create: %v", err)
+	}
+	if got := findRecords(store, zoneID, "home.example.com", "A"); len(got) != 1 {
+		t.Fatalf("got %d records, want 1", len(got))
+	}
+
+	// An existing name and type is updated in place
+	if _, err := uc.UpsertRecord(ctx, usecase.CreateRecordInput{ZoneName: "example.com", Name: "home", Type: "A", Content: "192.0.2.61", TTL: 60}); err != nil {
+		t.Fatalf("UpsertRecord update: %v", err)
+	}
+	got := findRecords(store, zoneID, "home.example.com", "A")
+	if len(got) != 1 || got[0].Content != "192.0.2.61" {
+		t.Fatalf("got %+v, want one record with the new address", got)
+	}
+
+	// Several records with the name and type are not guessed between
+	store.AddRecord(zoneID, cloudflare.DNSRecord{Name: "home.example.com", Type: "A", Content: "192.0.2.62", TTL: 60})
+	_, err := uc.UpsertRecord(ctx, usecase.CreateRecordInput{ZoneName: "example.com", Name: "home", Type: "A", Content: "192.0.2.63", TTL: 60})
+	if !errors.Is(err, domain.ErrAmbiguousRecord) {
+		t.Errorf("got error %v, want ErrAmbiguousRecord", err)
+	}
+}
+
+func TestDeleteRecord(t *testing.T) {
+	ctx := context.Background()
+	uc, store, zoneID := newDNSUsecase(t)
+
+	err := uc.DeleteRecord(ctx, "example.com", usecase.RecordSelector{Name: "api"})
+	if !errors.Is(err, domain.ErrAmbiguousRecord) {
+		t.Fatalf("got error %v, want ErrAmbiguousRecord", err)
+	}
+
+	if err := uc.DeleteRecord(ctx, "example.com", usecase.RecordSelector{Name: "api", Type: "AAAA"}); err != nil {
+		t.Fatalf("DeleteRecord: %v", err)
+	}
+	if got := findRecords(store, zoneID, "api.example.com", "AAAA"); len(got) != 0 {
+		t.Errorf("got %d AAAA records, want 0", len(got))
+	}
+	if got := findRecords(store, zoneID, "api.example.com", "A"); len(got) != 1 {
+		t.Errorf("got %d A records, want the A record kept", len(got))
+	}
+
+	err = uc.DeleteRecord(ctx, "example.com", usecase.RecordSelector{Name: "api", Type: "AAAA"})
+	if !errors.Is(err, domain.ErrRecordNotFound) {
+		t.Errorf("second delete: got error %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestRecordScope(t *testing.T) {
+	uc, _, _ := newDNSUsecase(t)
+	ctx := usecase.WithRecords(context.Background(), []string{"_acme-challenge.*"})
+
+	_, err := uc.CreateRecord(ctx, usecase.CreateRecordInput{ZoneName: "example.com", Name: "www2", Type: "A", Content: "192.0.2.70"})
+	if !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("create outside the scope: got error %v, want ErrUnauthorized", err)
+	}
+
+	if _, err := uc.CreateRecord(ctx, usecase.CreateRecordInput{ZoneName: "example.com", Name: "_acme-challenge", Type: "TXT", Content: "token"}); err != nil {
+		t.Errorf("create inside the scope: %v", err)
+	}
+
+	// Records outside the scope are hidden
+	_, err = uc.GetRecord(ctx, "example.com", usecase.RecordSelector{Name: "mail", Type: "A"})
+	if !errors.Is(err, domain.ErrRecordNotFound) {
+		t.Errorf("get outside the scope: got error %v, want ErrRecordNotFound", err)
+	}
+}
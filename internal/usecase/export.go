@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/zonefile"
+)
+
+// ExportZone renders all records of a zone in the given format.
+// Records are sorted by name and type so exports can be diffed.
+func (u *dnsUsecase) ExportZone(ctx context.Context, zoneName string, format ExportFormat) ([]byte, error) {
+	records, err := u.ListRecords(ctx, zoneName, domain.RecordFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Name != records[j].Name {
+			return records[i].Name < records[j].Name
+		}
+		return records[i].Type < records[j].Type
+	})
+
+	var buf bytes.Buffer
+	switch format {
+	case ExportFormatBIND:
+		err = zonefile.Write(&buf, zoneName, records)
+	case ExportFormatJSON:
+		err = writeJSONExport(&buf, zoneName, records)
+	case ExportFormatCSV:
+		err = writeCSVExport(&buf, records)
+	default:
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to export zone %s: %w", zoneName, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeJSONExport writes records as a ZoneDocument
+func writeJSONExport(buf *bytes.Buffer, zoneName string, records []domain.DNSRecord) error {
+	doc := ZoneDocument{
+		Zone:    zoneName,
+		Records: make([]RecordDocument, len(records)),
+	}
+	for i, r := range records {
+		doc.Records[i] = RecordDocument{
+			Name:     r.Name,
+			Type:     r.Type,
+			Content:  r.Content,
+			TTL:      r.TTL,
+			Proxied:  r.Proxied,
+			Priority: r.Priority,
+			Comment:  r.Comment,
+			Tags:     r.Tags,
+		}
+	}
+
+	encoder := json.NewEncoder(buf)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// writeCSVExport writes one record per row with a header line
+func writeCSVExport(buf *bytes.Buffer, records []domain.DNSRecord) error {
+	w := csv.NewWriter(buf)
+	if err := w.Write([]string{"name", "type", "content", "ttl", "proxied", "priority", "comment", "tags"}); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		priority := ""
+		if r.Priority != nil {
+			priority = strconv.Itoa(int(*r.Priority))
+		}
+		row := []string{r.Name, r.Type, r.Content, strconv.Itoa(r.TTL), strconv.FormatBool(r.Proxied), priority, r.Comment, strings.Join(r.Tags, ",")}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -2,6 +2,10 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"cf-dns-bot/internal/domain"
 )
@@ -13,12 +17,232 @@ type DNSUsecase interface {
 	ListZones(ctx context.Context) ([]domain.Zone, error)
 
 	// Record operations
-	ListRecords(ctx context.Context, zoneName string) ([]domain.DNSRecord, error)
-	GetRecord(ctx context.Context, zoneName, recordName string) (*domain.DNSRecord, error)
+	ListRecords(ctx context.Context, zoneName string, filter domain.RecordFilter) ([]domain.DNSRecord, error)
+	GetRecord(ctx context.Context, zoneName string, selector RecordSelector) (*domain.DNSRecord, error)
 	CreateRecord(ctx context.Context, input CreateRecordInput) (*domain.DNSRecord, error)
 	UpdateRecord(ctx context.Context, input UpdateRecordInput) (*domain.DNSRecord, error)
-	DeleteRecord(ctx context.Context, zoneName, recordName string) error
+	DeleteRecord(ctx context.Context, zoneName string, selector RecordSelector) error
 	UpsertRecord(ctx context.Context, input CreateRecordInput) (*domain.DNSRecord, error)
+
+	// SearchRecords finds the records whose name or content matches the
+	// query across every zone
+	SearchRecords(ctx context.Context, query string) ([]ZoneMatches, error)
+
+	// Bulk operations report a result per record and keep going after
+	// individual failures
+	MatchRecords(ctx context.Context, zoneName string, filter BulkFilter) ([]domain.DNSRecord, error)
+	BulkCreate(ctx context.Context, zoneName string, inputs []CreateRecordInput) (*BulkResult, error)
+	BulkDelete(ctx context.Context, zoneName string, filter BulkFilter) (*BulkResult, error)
+	BulkUpdate(ctx context.Context, zoneName string, filter BulkFilter, changes BulkChanges) (*BulkResult, error)
+
+	// RollbackChange restores the record state from before an audited
+	// change and returns the restored record (nil when a create is undone)
+	RollbackChange(ctx context.Context, change domain.AuditEntry) (*domain.DNSRecord, error)
+
+	// Export operations
+	ExportZone(ctx context.Context, zoneName string, format ExportFormat) ([]byte, error)
+}
+
+// BulkFilter selects the records of a bulk operation. NamePattern is a
+// glob (e.g. "*.dev") matched against the full record name and the name
+// relative to the zone. Empty fields match every record.
+type BulkFilter struct {
+	Type        string
+	NamePattern string
+	Content     string
+}
+
+// IsEmpty returns true if the filter matches every record
+func (f BulkFilter) IsEmpty() bool {
+	return f.Type == "" && f.NamePattern == "" && f.Content == ""
+}
+
+// BulkChanges are the settings applied by BulkUpdate. Nil fields are
+// left unchanged.
+type BulkChanges struct {
+	TTL     *int
+	Proxied *bool
+}
+
+// BulkResult reports the outcome of a bulk operation per record
+type BulkResult struct {
+	ZoneName  string
+	Succeeded []domain.DNSRecord
+	Failed    []FailedRecord
+}
+
+// ExportFormat is the file format of a zone export
+type ExportFormat string
+
+const (
+	ExportFormatBIND ExportFormat = "bind"
+	ExportFormatJSON ExportFormat = "json"
+	ExportFormatCSV  ExportFormat = "csv"
+)
+
+// ParseExportFormat parses a format name, defaulting to BIND when empty
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch ExportFormat(strings.ToLower(name)) {
+	case "", ExportFormatBIND, "zone":
+		return ExportFormatBIND, nil
+	case ExportFormatJSON:
+		return ExportFormatJSON, nil
+	case ExportFormatCSV:
+		return ExportFormatCSV, nil
+	}
+	return "", fmt.Errorf("%w: %s", domain.ErrUnsupportedFormat, name)
+}
+
+// FileExtension returns the file extension used for the format
+func (f ExportFormat) FileExtension() string {
+	if f == ExportFormatBIND {
+		return "zone"
+	}
+	return string(f)
+}
+
+// ContentType returns the MIME type of the format
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportFormatJSON:
+		return "application/json"
+	case ExportFormatCSV:
+		return "text/csv"
+	default:
+		return "text/dns"
+	}
+}
+
+// ZoneDocument is the portable representation of a zone's records used
+// by JSON export and as the desired state for zone sync
+type ZoneDocument struct {
+	Zone    string           `json:"zone" yaml:"zone"`
+	Records []RecordDocument `json:"records" yaml:"records"`
+}
+
+// RecordDocument is a single record in a ZoneDocument.
+// Names may be relative to the zone; "@" is the zone apex.
+type RecordDocument struct {
+	Name     string   `json:"name" yaml:"name"`
+	Type     string   `json:"type" yaml:"type"`
+	Content  string   `json:"content" yaml:"content"`
+	TTL      int      `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	Proxied  bool     `json:"proxied" yaml:"proxied"`
+	Priority *uint16  `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Comment  string   `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Tags     []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// ZoneSyncUsecase defines the interface for declarative zone sync.
+// Plan diffs a desired record set against the live zone; Apply executes a
+// previously computed plan, so nothing changes without a plan ID. An empty
+// zoneName in Apply accepts a plan for any zone.
+type ZoneSyncUsecase interface {
+	Plan(ctx context.Context, doc *ZoneDocument, options SyncOptions) (*SyncPlan, error)
+	Apply(ctx context.Context, zoneName, planID string) (*SyncResult, error)
+}
+
+// SyncOptions controls how a sync plan is computed
+type SyncOptions struct {
+	// AllowDeletes removes live records missing from the desired state.
+	// When false such records are left alone and only reported.
+	AllowDeletes bool
+}
+
+// SyncPlan lists the changes needed to bring a zone to its desired state
+type SyncPlan struct {
+	ID           string
+	ZoneName     string
+	AllowDeletes bool
+	Creates      []domain.DNSRecord
+	Updates      []RecordChange
+	Deletes      []domain.DNSRecord
+	// Unmanaged are live records not in the desired state that are kept
+	// because deletes are disabled
+	Unmanaged []domain.DNSRecord
+	Unchanged int
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// HasChanges returns true if applying the plan would change the zone
+func (p *SyncPlan) HasChanges() bool {
+	return len(p.Creates) > 0 || len(p.Updates) > 0 || len(p.Deletes) > 0
+}
+
+// RecordChange is an update of a single record
+type RecordChange struct {
+	Before domain.DNSRecord
+	After  domain.DNSRecord
+}
+
+// SyncResult reports the outcome of applying a sync plan
+type SyncResult struct {
+	PlanID   string
+	ZoneName string
+	Created  []domain.DNSRecord
+	Updated  []domain.DNSRecord
+	Deleted  []domain.DNSRecord
+	Failed   []FailedRecord
+}
+
+// SnapshotUsecase captures zone snapshots and restores zones from them.
+// Restores go through DNSUsecase, so they are validated and audited like
+// any other change.
+type SnapshotUsecase interface {
+	// TakeSnapshots snapshots every zone and applies the retention policy.
+	// Zones unchanged since their latest snapshot are skipped.
+	TakeSnapshots(ctx context.Context) (int, error)
+	// TakeSnapshot snapshots one zone; it returns false if the zone is
+	// unchanged since its latest snapshot
+	TakeSnapshot(ctx context.Context, zoneName string) (*domain.Snapshot, bool, error)
+	ListSnapshots(ctx context.Context, zoneName string) ([]domain.Snapshot, error)
+	DiffSnapshot(ctx context.Context, zoneName, snapshotID string) (*SnapshotDiff, error)
+	// RestoreSnapshot restores the records with the given IDs (as listed
+	// in the diff), or the whole zone when recordIDs is empty
+	RestoreSnapshot(ctx context.Context, zoneName, snapshotID string, recordIDs []string) (*SyncResult, error)
+}
+
+// SnapshotRetention controls how many snapshots are kept per zone. The
+// newest snapshot is always kept; zero values disable a limit.
+type SnapshotRetention struct {
+	Keep   int
+	MaxAge time.Duration
+}
+
+// SnapshotDiff lists the changes that restoring a snapshot would make
+type SnapshotDiff struct {
+	SnapshotID string
+	ZoneName   string
+	CreatedAt  time.Time
+	// Creates are snapshot records deleted since the snapshot
+	Creates []domain.DNSRecord
+	// Updates are records changed since; Before is the live state and
+	// After the snapshot state
+	Updates []RecordChange
+	// Deletes are live records added since the snapshot
+	Deletes   []domain.DNSRecord
+	Unchanged int
+}
+
+// HasChanges returns true if the zone differs from the snapshot
+func (d *SnapshotDiff) HasChanges() bool {
+	return len(d.Creates) > 0 || len(d.Updates) > 0 || len(d.Deletes) > 0
+}
+
+// RecordSelector identifies a single DNS record within a zone.
+// A record is addressed either by its ID, or by its name plus optionally
+// its type and content when several records share the same name.
+type RecordSelector struct {
+	RecordID string
+	Name     string
+	Type     string
+	Content  string
+}
+
+// IsEmpty returns true if the selector does not identify any record
+func (s RecordSelector) IsEmpty() bool {
+	return s.RecordID == "" && s.Name == ""
 }
 
 // CreateRecordInput represents input for creating a DNS record
@@ -30,16 +254,169 @@ type CreateRecordInput struct {
 	TTL      int
 	Proxied  bool
 	Priority *uint16
+	Data     *domain.RecordData // structured data, instead of content, for SRV, CAA, ...
+	Comment  string
+	Tags     []string
 }
 
-// UpdateRecordInput represents input for updating a DNS record
+// UpdateRecordInput represents input for updating a DNS record.
+// Selector picks the record to update; when it is empty the record is
+// looked up by Name and Type. Only the fields that are set are changed:
+// nil fields and nil Tags keep the current values of the record, while an
+// empty comment or an empty, non-nil tag list clears them.
 type UpdateRecordInput struct {
 	ZoneName string
-	RecordID string
-	Name     string
-	Type     string
-	Content  string
-	TTL      int
-	Proxied  bool
+	Selector RecordSelector
+	Name     *string
+	Type     *string
+	Content  *string
+	TTL      *int
+	Proxied  *bool
 	Priority *uint16
+	Data     *domain.RecordData
+	Comment  *string
+	Tags     []string
+}
+
+// ZoneImportUsecase defines the interface for importing BIND zone files.
+// An import is done in two steps: PreviewImport parses the file and compares
+// it with the live zone, ApplyImport creates the records of a preview.
+type ZoneImportUsecase interface {
+	PreviewImport(ctx context.Context, zoneName string, zoneFile io.Reader) (*ImportPlan, error)
+	ApplyImport(ctx context.Context, plan *ImportPlan) (*ImportResult, error)
+}
+
+// ImportPlan describes what importing a zone file would change
+type ImportPlan struct {
+	ZoneName string
+	ToCreate []domain.DNSRecord
+	Existing []domain.DNSRecord
+	Skipped  []SkippedRecord
+}
+
+// SkippedRecord is a zone file record that will not be imported
+type SkippedRecord struct {
+	Record domain.DNSRecord
+	Reason string
+}
+
+// ImportResult reports the outcome of applying an import plan
+type ImportResult struct {
+	ZoneName string
+	Created  []domain.DNSRecord
+	Existing []domain.DNSRecord
+	Failed   []FailedRecord
+}
+
+// FailedRecord is a record that could not be written to Cloudflare
+type FailedRecord struct {
+	Record domain.DNSRecord
+	Error  string
+}
+
+// ApprovalUsecase holds changes that need the approval of a second user
+// until they are approved, rejected or expire. Approved changes are made
+// through DNSUsecase on behalf of the requester.
+type ApprovalUsecase interface {
+	// RequiresApproval reports whether the policy holds the change for
+	// approval
+	RequiresApproval(change PendingChange) bool
+	// Submit parks a change of the actor in ctx and returns it with its
+	// ID and expiry
+	Submit(ctx context.Context, change PendingChange) (*PendingChange, error)
+	// Approve makes a pending change. It fails with ErrSelfApproval when
+	// the actor in ctx requested the change. A change that fails is
+	// returned with the error and is not kept.
+	Approve(ctx context.Context, id string) (*PendingChange, *ApprovalResult, error)
+	// Reject drops a pending change; requesters can withdraw their own
+	Reject(ctx context.Context, id string) (*PendingChange, error)
+	// ListPending returns the changes waiting for approval, oldest first
+	ListPending(ctx context.Context) ([]PendingChange, error)
+}
+
+// Kinds of changes that can be held for approval
+const (
+	ChangeCreate     = "create"
+	ChangeUpdate     = "update"
+	ChangeDelete     = "delete"
+	ChangeBulkDelete = "bulk_delete"
+	ChangeBulkUpdate = "bulk_update"
+	ChangeRollback   = "rollback"
+	ChangeRestore    = "snapshot_restore"
+	ChangeImport     = "zone_import"
+)
+
+// ApprovalPolicy decides which changes need the approval of a second
+// user. The zero policy holds nothing.
+type ApprovalPolicy struct {
+	Deletes        bool          // single record deletes, including undoing a create
+	BulkChanges    bool          // bulk deletes and updates, imports and restores of several records
+	ProtectedZones []string      // every change to these zones
+	Timeout        time.Duration // how long a change waits for approval
+}
+
+// PendingChange is a change waiting for approval. The input matching
+// Kind is set: Create, Update, Delete, BulkFilter (with BulkChanges for
+// bulk updates), Rollback, Restore or Import.
+type PendingChange struct {
+	ID          string
+	Kind        string
+	ZoneName    string
+	Create      *CreateRecordInput
+	Update      *UpdateRecordInput
+	Delete      *RecordSelector
+	BulkFilter  *BulkFilter
+	BulkChanges *BulkChanges
+	Rollback    *domain.AuditEntry
+	Restore     *SnapshotRestore
+	Import      *ImportPlan
+	// Record is the record an update or delete changes, as it was when
+	// the change was submitted
+	Record      *domain.DNSRecord
+	RequestedBy domain.Actor
+	RequestedAt time.Time
+	ExpiresAt   time.Time
+}
+
+// SnapshotRestore is a restore of a snapshot waiting for approval, with
+// the number of changes it would make when it was submitted
+type SnapshotRestore struct {
+	SnapshotID string
+	RecordIDs  []string // the whole zone when empty
+	Creates    int
+	Updates    int
+	Deletes    int
+}
+
+// NewSnapshotRestore counts the changes of a diff that restoring the
+// records with the given IDs, or the whole zone, would make
+func NewSnapshotRestore(diff *SnapshotDiff, recordIDs []string) SnapshotRestore {
+	restore := SnapshotRestore{SnapshotID: diff.SnapshotID, RecordIDs: recordIDs}
+	if len(recordIDs) == 0 {
+		restore.Creates, restore.Updates, restore.Deletes = len(diff.Creates), len(diff.Updates), len(diff.Deletes)
+		return restore
+	}
+
+	selected := make(map[string]bool, len(recordIDs))
+	for _, id := range recordIDs {
+		selected[id] = true
+	}
+	restore.Creates = len(filterRecords(diff.Creates, selected))
+	restore.Deletes = len(filterRecords(diff.Deletes, selected))
+	for _, change := range diff.Updates {
+		if selected[change.Before.ID] {
+			restore.Updates++
+		}
+	}
+	return restore
+}
+
+// ApprovalResult is the outcome of an approved change: the created,
+// updated or restored record, the result of a bulk change, or that of a
+// snapshot restore or zone import
+type ApprovalResult struct {
+	Record  *domain.DNSRecord
+	Bulk    *BulkResult
+	Restore *SyncResult
+	Import  *ImportResult
 }
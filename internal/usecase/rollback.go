@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cf-dns-bot/internal/domain"
+)
+
+// RollbackChange undoes an audited change: created records are deleted,
+// updates are reverted and deleted records are recreated. The record must
+// still be in the state the change left it in, otherwise
+// ErrRollbackConflict is returned so later changes are not overwritten.
+func (u *dnsUsecase) RollbackChange(ctx context.Context, change domain.AuditEntry) (*domain.DNSRecord, error) {
+	zone, err := u.getZone(ctx, change.ZoneName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get zone %s: %w", change.ZoneName, err)
+	}
+	for _, record := range []*domain.DNSRecord{change.Before, change.After} {
+		if record == nil {
+			continue
+		}
+		if err := checkRecord(ctx, record.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case change.Before == nil && change.After != nil:
+		// Undo a create
+		if _, err := u.currentState(ctx, zone, *change.After); err != nil {
+			return nil, err
+		}
+		if err := u.dnsRepo.DeleteRecord(ctx, zone.ID, change.After.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete record: %w", err)
+		}
+		return nil, nil
+
+	case change.Before != nil && change.After != nil:
+		// Undo an update
+		current, err := u.currentState(ctx, zone, *change.After)
+		if err != nil {
+			return nil, err
+		}
+		restored, err := u.dnsRepo.UpdateRecord(ctx, zone.ID, current.ID, domain.FullPatch(*change.Before))
+		if err != nil {
+			return nil, fmt.Errorf("failed to update record: %w", err)
+		}
+		return restored, nil
+
+	case change.Before != nil:
+		// Undo a delete; the recreated record gets a new ID
+		existing, _ := u.dnsRepo.ListRecords(ctx, zone.ID, domain.RecordFilter{
+			Name:    change.Before.Name,
+			Type:    change.Before.Type,
+			Content: change.Before.Content,
+		})
+		if len(existing) > 0 {
+			return nil, fmt.Errorf("%w: %s %s was recreated already", domain.ErrRollbackConflict, change.Before.Type, change.Before.Name)
+		}
+		record := *change.Before
+		record.ID = ""
+		record.ZoneID = zone.ID
+		restored, err := u.dnsRepo.CreateRecord(ctx, zone.ID, &record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create record: %w", err)
+		}
+		return restored, nil
+
+	default:
+		return nil, fmt.Errorf("%w: change has no record state", domain.ErrInvalidRecord)
+	}
+}
+
+// currentState returns the live record a change produced, or
+// ErrRollbackConflict if it was deleted or modified since
+func (u *dnsUsecase) currentState(ctx context.Context, zone *domain.Zone, after domain.DNSRecord) (*domain.DNSRecord, error) {
+	current, err := u.dnsRepo.GetRecord(ctx, zone.ID, after.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s %s no longer exists", domain.ErrRollbackConflict, after.Type, after.Name)
+		}
+		return nil, err
+	}
+
+	if !sameRecordState(*current, after) {
+		return nil, fmt.Errorf("%w: %s %s was modified after this change", domain.ErrRollbackConflict, after.Type, after.Name)
+	}
+	return current, nil
+}
+
+// sameRecordState compares the user visible fields of two records
+func sameRecordState(a, b domain.DNSRecord) bool {
+	samePriority := (a.Priority == nil) == (b.Priority == nil) &&
+		(a.Priority == nil || *a.Priority == *b.Priority)
+	return strings.EqualFold(a.Name, b.Name) &&
+		a.Type == b.Type &&
+		a.Content == b.Content &&
+		a.TTL == b.TTL &&
+		a.Proxied == b.Proxied &&
+		samePriority &&
+		a.Comment == b.Comment &&
+		slices.Equal(a.Tags, b.Tags)
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+
+	"cf-dns-bot/internal/domain"
+)
+
+// searchWorkers is the number of zones searched at the same time
+const searchWorkers = 8
+
+// ZoneMatches are the records of one zone found by SearchRecords
+type ZoneMatches struct {
+	Zone    domain.Zone        `json:"zone"`
+	Records []domain.DNSRecord `json:"records,omitempty"`
+	Error   string             `json:"error,omitempty"` // set when the zone could not be searched
+}
+
+// SearchRecords finds the records of every zone whose name or content
+// contains the query, ignoring case. An IP address only matches whole
+// addresses, so 10.0.0.1 does not find 10.0.0.10. Zones are searched
+// concurrently; a zone that fails is reported with its error instead of
+// failing the search. Only zones with matches or errors are returned, in
+// the order of ListZones.
+func (u *dnsUsecase) SearchRecords(ctx context.Context, query string) ([]ZoneMatches, error) {
+	return searchRecords(ctx, u, query)
+}
+
+// searchRecords is written against DNSUsecase so that it searches the
+// zones and records the caller may see
+func searchRecords(ctx context.Context, dnsUsecase DNSUsecase, query string) ([]ZoneMatches, error) {
+	query = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(query), "."))
+	if query == "" {
+		return nil, fmt.Errorf("%w: search query is required", domain.ErrInvalidFilter)
+	}
+	matches := recordMatcher(query)
+
+	zones, err := dnsUsecase.ListZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ZoneMatches, len(zones))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(searchWorkers, len(zones)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = searchZone(ctx, dnsUsecase, zones[i], matches)
+			}
+		}()
+	}
+	for i := range zones {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	found := results[:0]
+	for _, r := range results {
+		if len(r.Records) > 0 || r.Error != "" {
+			found = append(found, r)
+		}
+	}
+	return found, nil
+}
+
+// searchZone returns the records of a zone accepted by matches
+func searchZone(ctx context.Context, dnsUsecase DNSUsecase, zone domain.Zone, matches func(domain.DNSRecord) bool) ZoneMatches {
+	result := ZoneMatches{Zone: zone}
+	records, err := dnsUsecase.ListRecords(ctx, zone.Name, domain.RecordFilter{})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	for _, r := range records {
+		if matches(r) {
+			result.Records = append(result.Records, r)
+		}
+	}
+	sort.SliceStable(result.Records, func(i, j int) bool {
+		return result.Records[i].Name < result.Records[j].Name
+	})
+	return result
+}
+
+// recordMatcher returns whether a record matches a lowercase query
+func recordMatcher(query string) func(domain.DNSRecord) bool {
+	addr, err := netip.ParseAddr(query)
+	if err != nil {
+		return func(r domain.DNSRecord) bool {
+			return strings.Contains(strings.ToLower(r.Name), query) ||
+				strings.Contains(strings.ToLower(r.Content), query)
+		}
+	}
+
+	return func(r domain.DNSRecord) bool {
+		return containsAddress(r.Content, addr)
+	}
+}
+
+// containsAddress reports whether text holds the address as a whole, on
+// its own or within other content such as ip4:192.0.2.1 in SPF
+func containsAddress(text string, addr netip.Addr) bool {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return c != '.' && c != ':' && (c < '0' || c > '9') && (c < 'a' || c > 'f')
+	})
+	for _, field := range fields {
+		// Try the field and what follows each colon, as in "ip6:2001:db8::1"
+		for {
+			if a, err := netip.ParseAddr(field); err == nil && a == addr {
+				return true
+			}
+			_, rest, ok := strings.Cut(field, ":")
+			if !ok {
+				break
+			}
+			field = rest
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/usecase"
+)
+
+func TestSearchRecords(t *testing.T) {
+	uc, _, _ := newDNSUsecase(t)
+
+	tests := []struct {
+		query string
+		want  map[string]int // records found per zone
+	}{
+		{"api", map[string]int{"example.com": 2}},
+		{"API.example.com.", map[string]int{"example.com": 2}},
+		{"example.org", map[string]int{"example.org": 2}},
+		{"mail", map[string]int{"example.com": 2}},
+		// Addresses match whole, so 192.0.2.2 finds neither .20 nor .25
+		{"192.0.2.2", map[string]int{}},
+		{"192.0.2.20", map[string]int{"example.com": 1}},
+		{"2001:db8::20", map[string]int{"example.com": 1}},
+		{"nothing-like-this", map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := uc.SearchRecords(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("SearchRecords: %v", err)
+			}
+			got := make(map[string]int)
+			for _, zone := range results {
+				if zone.Error != "" {
+					t.Errorf("zone %s: %s", zone.Zone.Name, zone.Error)
+				}
+				got[zone.Zone.Name] = len(zone.Records)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for zone, n := range tt.want {
+				if got[zone] != n {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchRecordsScope(t *testing.T) {
+	uc, _, _ := newDNSUsecase(t)
+
+	if _, err := uc.SearchRecords(context.Background(), "  "); !errors.Is(err, domain.ErrInvalidFilter) {
+		t.Errorf("empty query: got error %v, want ErrInvalidFilter", err)
+	}
+
+	// Only the zones the caller may use are searched
+	ctx := usecase.WithZones(context.Background(), []string{"example.org"})
+	results, err := uc.SearchRecords(ctx, "example")
+	if err != nil {
+		t.Fatalf("SearchRecords: %v", err)
+	}
+	if len(results) != 1 || results[0].Zone.Name != "example.org" {
+		t.Errorf("got %+v, want example.org only", results)
+	}
+}
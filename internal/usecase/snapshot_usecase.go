@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/pkg/storage"
+)
+
+// snapshotIDFormat names snapshots after their UTC capture time
+const snapshotIDFormat = "20060102T150405Z"
+
+// snapshotUsecase implements SnapshotUsecase on top of DNSUsecase
+type snapshotUsecase struct {
+	dnsUsecase      DNSUsecase
+	snapshotStorage storage.SnapshotStorage
+	retention       SnapshotRetention
+}
+
+// NewSnapshotUsecase creates a new snapshot usecase
+func NewSnapshotUsecase(dnsUsecase DNSUsecase, snapshotStorage storage.SnapshotStorage, retention SnapshotRetention) SnapshotUsecase {
+	return &snapshotUsecase{
+		dnsUsecase:      dnsUsecase,
+		snapshotStorage: snapshotStorage,
+		retention:       retention,
+	}
+}
+
+// TakeSnapshots snapshots every zone. A failing zone does not stop the
+// others; the last error is returned with the number of snapshots taken.
+func (u *snapshotUsecase) TakeSnapshots(ctx context.Context) (int, error) {
+	zones, err := u.dnsUsecase.ListZones(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	taken := 0
+	var lastErr error
+	for _, zone := range zones {
+		_, created, err := u.TakeSnapshot(ctx, zone.Name)
+		if err != nil {
+			log.Printf("[Snapshot] ERROR zone %s: %v", zone.Name, err)
+			lastErr = err
+			continue
+		}
+		if created {
+			taken++
+		}
+	}
+	return taken, lastErr
+}
+
+// TakeSnapshot snapshots a zone unless it is unchanged since the latest
+// snapshot, in which case the latest snapshot is returned
+func (u *snapshotUsecase) TakeSnapshot(ctx context.Context, zoneName string) (*domain.Snapshot, bool, error) {
+	records, err := u.dnsUsecase.ListRecords(ctx, zoneName, domain.RecordFilter{})
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing, err := u.snapshotStorage.ListSnapshots(zoneName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	sum := fingerprint(records)
+	if len(existing) > 0 && existing[0].Fingerprint == sum {
+		return &existing[0], false, nil
+	}
+
+	now := time.Now().UTC()
+	snapshot := domain.Snapshot{
+		ID:          now.Format(snapshotIDFormat),
+		ZoneName:    zoneName,
+		CreatedAt:   now,
+		Fingerprint: sum,
+		RecordCount: len(records),
+		Records:     records,
+	}
+	if err := u.snapshotStorage.SaveSnapshot(snapshot); err != nil {
+		return nil, false, err
+	}
+
+	u.prune(zoneName, append([]domain.Snapshot{snapshot}, existing...))
+	return &snapshot, true, nil
+}
+
+// ListSnapshots returns the snapshots of a zone newest first
+func (u *snapshotUsecase) ListSnapshots(ctx context.Context, zoneName string) ([]domain.Snapshot, error) {
+	return u.snapshotStorage.ListSnapshots(zoneName)
+}
+
+// DiffSnapshot compares a snapshot against the live zone
+func (u *snapshotUsecase) DiffSnapshot(ctx context.Context, zoneName, snapshotID string) (*SnapshotDiff, error) {
+	snapshot, err := u.snapshotStorage.GetSnapshot(zoneName, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := u.dnsUsecase.ListRecords(ctx, zoneName, domain.RecordFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	// A full restore is a sync to the snapshot with deletes enabled
+	plan := &SyncPlan{ZoneName: zoneName, AllowDeletes: true}
+	diffRecords(plan, snapshot.Records, live)
+
+	return &SnapshotDiff{
+		SnapshotID: snapshot.ID,
+		ZoneName:   zoneName,
+		CreatedAt:  snapshot.CreatedAt,
+		Creates:    plan.Creates,
+		Updates:    plan.Updates,
+		Deletes:    plan.Deletes,
+		Unchanged:  plan.Unchanged,
+	}, nil
+}
+
+// RestoreSnapshot restores selected records or the whole zone
+func (u *snapshotUsecase) RestoreSnapshot(ctx context.Context, zoneName, snapshotID string, recordIDs []string) (*SyncResult, error) {
+	diff, err := u.DiffSnapshot(ctx, zoneName, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	creates, updates, deletes := diff.Creates, diff.Updates, diff.Deletes
+	if len(recordIDs) > 0 {
+		selected := make(map[string]bool, len(recordIDs))
+		for _, id := range recordIDs {
+			selected[id] = true
+		}
+		creates = filterRecords(creates, selected)
+		deletes = filterRecords(deletes, selected)
+		updates = nil
+		for _, change := range diff.Updates {
+			if selected[change.Before.ID] {
+				updates = append(updates, change)
+			}
+		}
+		if len(creates)+len(updates)+len(deletes) == 0 {
+			return nil, fmt.Errorf("%w: selected records no longer differ from the snapshot", domain.ErrRecordNotFound)
+		}
+	}
+
+	result := &SyncResult{ZoneName: zoneName}
+	applyChanges(ctx, u.dnsUsecase, result, creates, updates, deletes)
+	return result, nil
+}
+
+// prune deletes snapshots outside the retention policy. snapshots must be
+// sorted newest first; the newest one is always kept.
+func (u *snapshotUsecase) prune(zoneName string, snapshots []domain.Snapshot) {
+	cutoff := time.Time{}
+	if u.retention.MaxAge > 0 {
+		cutoff = time.Now().Add(-u.retention.MaxAge)
+	}
+
+	for i, snapshot := range snapshots {
+		if i == 0 {
+			continue
+		}
+		tooMany := u.retention.Keep > 0 && i >= u.retention.Keep
+		tooOld := !cutoff.IsZero() && snapshot.CreatedAt.Before(cutoff)
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := u.snapshotStorage.DeleteSnapshot(zoneName, snapshot.ID); err != nil {
+			log.Printf("[Snapshot] ERROR pruning %s/%s: %v", zoneName, snapshot.ID, err)
+		}
+	}
+}
+
+// filterRecords returns the records whose ID is selected
+func filterRecords(records []domain.DNSRecord, selected map[string]bool) []domain.DNSRecord {
+	var filtered []domain.DNSRecord
+	for _, r := range records {
+		if selected[r.ID] {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
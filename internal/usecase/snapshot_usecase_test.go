@@ -0,0 +1,196 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/usecase"
+	"cf-dns-bot/pkg/storage"
+)
+
+// changeZone deletes mail.example.com, moves api.example.com and adds
+// new.example.com
+func changeZone(t *testing.T, uc usecase.DNSUsecase) {
+	t.Helper()
+	ctx := context.Background()
+	if err := uc.DeleteRecord(ctx, "example.com", usecase.RecordSelector{Name: "mail", Type: "A"}); err != nil {
+		t.Fatalf("DeleteRecord: %v", err)
+	}
+	content := "192.0.2.21"
+	if _, err := uc.UpdateRecord(ctx, usecase.UpdateRecordInput{ZoneName: "example.com", Selector: usecase.RecordSelector{Name: "api", Type: "A"}, Content: &content}); err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+	if _, err := uc.CreateRecord(ctx, usecase.CreateRecordInput{ZoneName: "example.com", Name: "new", Type: "A", Content: "192.0.2.40"}); err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+}
+
+func TestTakeSnapshot(t *testing.T) {
+	ctx := context.Background()
+	uc, _, _ := newDNSUsecase(t)
+	snapshots := usecase.NewSnapshotUsecase(uc, storage.NewSnapshotStorage(t.TempDir()), usecase.SnapshotRetention{})
+
+	first, created, err := snapshots.TakeSnapshot(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("TakeSnapshot: %v", err)
+	}
+	if !created || first.RecordCount != 7 {
+		t.Errorf("got created %v with %d records, want a new snapshot of 7", created, first.RecordCount)
+	}
+
+	// An unchanged zone returns the latest snapshot
+	again, created, err := snapshots.TakeSnapshot(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("second TakeSnapshot: %v", err)
+	}
+	if created || again.ID != first.ID {
+		t.Errorf("got created %v with ID %s, want the snapshot %s", created, again.ID, first.ID)
+	}
+
+	list, err := snapshots.ListSnapshots(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	if len(list) != 1 {
+		t.Errorf("got %d snapshots, want 1", len(list))
+	}
+}
+
+func TestDiffSnapshot(t *testing.T) {
+	ctx := context.Background()
+	uc, _, _ := newDNSUsecase(t)
+	snapshots := usecase.NewSnapshotUsecase(uc, storage.NewSnapshotStorage(t.TempDir()), usecase.SnapshotRetention{})
+
+	snapshot, _, err := snapshots.TakeSnapshot(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("TakeSnapshot: %v", err)
+	}
+	diff, err := snapshots.DiffSnapshot(ctx, "example.com", snapshot.ID)
+	if err != nil {
+		t.Fatalf("DiffSnapshot: %v", err)
+	}
+	if diff.HasChanges() || diff.Unchanged != 7 {
+		t.Errorf("unchanged zone: got %+v, want no changes", diff)
+	}
+
+	changeZone(t, uc)
+	diff, err = snapshots.DiffSnapshot(ctx, "example.com", snapshot.ID)
+	if err != nil {
+		t.Fatalf("DiffSnapshot: %v", err)
+	}
+	if len(diff.Creates) != 1 || diff.Creates[0].Name != "mail.example.com" {
+		t.Errorf("got creates %+v, want mail.example.com back", diff.Creates)
+	}
+	if len(diff.Updates) != 1 || diff.Updates[0].Before.Content != "192.0.2.21" || diff.Updates[0].After.Content != "192.0.2.20" {
+		t.Errorf("got updates %+v, want api.example.com moved back", diff.Updates)
+	}
+	if len(diff.Deletes) != 1 || diff.Deletes[0].Name != "new.example.com" {
+		t.Errorf("got deletes %+v, want new.example.com removed", diff.Deletes)
+	}
+
+	if _, err := snapshots.DiffSnapshot(ctx, "example.com", "19700101T000000Z"); !errors.Is(err, domain.ErrSnapshotNotFound) {
+		t.Errorf("unknown snapshot: got error %v, want ErrSnapshotNotFound", err)
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	uc, store, zoneID := newDNSUsecase(t)
+	snapshots := usecase.NewSnapshotUsecase(uc, storage.NewSnapshotStorage(t.TempDir()), usecase.SnapshotRetention{})
+
+	snapshot, _, err := snapshots.TakeSnapshot(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("TakeSnapshot: %v", err)
+	}
+	changeZone(t, uc)
+	diff, err := snapshots.DiffSnapshot(ctx, "example.com", snapshot.ID)
+	if err != nil {
+		t.Fatalf("DiffSnapshot: %v", err)
+	}
+
+	// Only the selected record is restored
+	result, err := snapshots.RestoreSnapshot(ctx, "example.com", snapshot.ID, []string{diff.Creates[0].ID})
+	if err != nil {
+		t.Fatalf("selective RestoreSnapshot: %v", err)
+	}
+	if len(result.Created) != 1 || len(result.Updated) != 0 || len(result.Deleted) != 0 {
+		t.Errorf("selective restore: got %+v, want mail.example.com created only", result)
+	}
+	if got := findRecords(store, zoneID, "new.example.com", "A"); len(got) != 1 {
+		t.Errorf("selective restore: got %d new.example.com records, want it kept", len(got))
+	}
+
+	// A selection that no longer differs is not found
+	_, err = snapshots.RestoreSnapshot(ctx, "example.com", snapshot.ID, []string{diff.Creates[0].ID})
+	if !errors.Is(err, domain.ErrRecordNotFound) {
+		t.Errorf("restored selection: got error %v, want ErrRecordNotFound", err)
+	}
+
+	result, err = snapshots.RestoreSnapshot(ctx, "example.com", snapshot.ID, nil)
+	if err != nil {
+		t.Fatalf("full RestoreSnapshot: %v", err)
+	}
+	if len(result.Updated) != 1 || len(result.Deleted) != 1 || len(result.Failed) != 0 {
+		t.Errorf("full restore: got %+v, want one update and one delete", result)
+	}
+	diff, err = snapshots.DiffSnapshot(ctx, "example.com", snapshot.ID)
+	if err != nil {
+		t.Fatalf("DiffSnapshot: %v", err)
+	}
+	if diff.HasChanges() {
+		t.Errorf("after restore: got %+v, want the zone back at the snapshot", diff)
+	}
+}
+
+// seedSnapshots stores three snapshots an hour apart, the newest an hour
+// old, each of a record set other than the demo zone's
+func seedSnapshots(t *testing.T, snapshotStorage storage.SnapshotStorage) {
+	t.Helper()
+	now := time.Now().UTC()
+	for i, id := range []string{"s1", "s2", "s3"} {
+		snapshot := domain.Snapshot{ID: id, ZoneName: "example.com", CreatedAt: now.Add(time.Duration(i-3) * time.Hour), Fingerprint: id}
+		if err := snapshotStorage.SaveSnapshot(snapshot); err != nil {
+			t.Fatalf("SaveSnapshot: %v", err)
+		}
+	}
+}
+
+func TestSnapshotRetention(t *testing.T) {
+	ctx := context.Background()
+	uc, _, _ := newDNSUsecase(t)
+
+	tests := []struct {
+		name      string
+		retention usecase.SnapshotRetention
+		want      int
+	}{
+		{"no limits", usecase.SnapshotRetention{}, 4},
+		{"keep three", usecase.SnapshotRetention{Keep: 3}, 3},
+		{"max age", usecase.SnapshotRetention{MaxAge: 90 * time.Minute}, 2},
+		{"keep one", usecase.SnapshotRetention{Keep: 1, MaxAge: 24 * time.Hour}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshotStorage := storage.NewSnapshotStorage(t.TempDir())
+			seedSnapshots(t, snapshotStorage)
+			snapshots := usecase.NewSnapshotUsecase(uc, snapshotStorage, tt.retention)
+			snapshot, created, err := snapshots.TakeSnapshot(ctx, "example.com")
+			if err != nil || !created {
+				t.Fatalf("TakeSnapshot: created %v, error %v", created, err)
+			}
+
+			// The new snapshot is the newest and always kept
+			list, err := snapshots.ListSnapshots(ctx, "example.com")
+			if err != nil {
+				t.Fatalf("ListSnapshots: %v", err)
+			}
+			if len(list) != tt.want || list[0].ID != snapshot.ID {
+				t.Errorf("got %+v, want %d snapshots starting with %s", list, tt.want, snapshot.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/zonefile"
+)
+
+// zoneImportUsecase implements ZoneImportUsecase on top of DNSUsecase
+type zoneImportUsecase struct {
+	dnsUsecase DNSUsecase
+}
+
+// NewZoneImportUsecase creates a new zone import usecase
+func NewZoneImportUsecase(dnsUsecase DNSUsecase) ZoneImportUsecase {
+	return &zoneImportUsecase{
+		dnsUsecase: dnsUsecase,
+	}
+}
+
+// PreviewImport parses a zone file and sorts its records into those that
+// would be created, those already present in the zone and those skipped
+func (u *zoneImportUsecase) PreviewImport(ctx context.Context, zoneName string, zoneFile io.Reader) (*ImportPlan, error) {
+	records, err := zonefile.Parse(zoneFile, zoneName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidRecord, err)
+	}
+
+	existing, err := u.dnsUsecase.ListRecords(ctx, zoneName, domain.RecordFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ImportPlan{ZoneName: zoneName}
+	var planned []domain.DNSRecord
+
+	for _, record := range records {
+		if reason := u.skipReason(record, zoneName); reason != "" {
+			plan.Skipped = append(plan.Skipped, SkippedRecord{Record: record, Reason: reason})
+			continue
+		}
+
+		if match := findMatchingRecord(existing, record); match != nil {
+			plan.Existing = append(plan.Existing, *match)
+			continue
+		}
+
+		if findMatchingRecord(planned, record) != nil {
+			plan.Skipped = append(plan.Skipped, SkippedRecord{Record: record, Reason: "duplicate in zone file"})
+			continue
+		}
+
+		planned = append(planned, record)
+		plan.ToCreate = append(plan.ToCreate, record)
+	}
+
+	return plan, nil
+}
+
+// ApplyImport creates the records of an import plan. Records that were
+// created by someone else since the preview are reported as existing.
+func (u *zoneImportUsecase) ApplyImport(ctx context.Context, plan *ImportPlan) (*ImportResult, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("%w: import plan is required", domain.ErrInvalidRecord)
+	}
+
+	result := &ImportResult{ZoneName: plan.ZoneName}
+	for _, record := range plan.ToCreate {
+		created, err := u.dnsUsecase.CreateRecord(ctx, CreateRecordInput{
+			ZoneName: plan.ZoneName,
+			Name:     record.Name,
+			Type:     record.Type,
+			Content:  record.Content,
+			TTL:      record.TTL,
+			Priority: record.Priority,
+		})
+		if errors.Is(err, domain.ErrDuplicateRecord) {
+			result.Existing = append(result.Existing, record)
+			continue
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, FailedRecord{Record: record, Error: err.Error()})
+			continue
+		}
+		result.Created = append(result.Created, *created)
+	}
+
+	return result, nil
+}
+
+// skipReason returns why a zone file record cannot be imported, or an
+// empty string if it can
+func (u *zoneImportUsecase) skipReason(record domain.DNSRecord, zoneName string) string {
+	name := strings.ToLower(record.Name)
+	zone := strings.ToLower(zoneName)
+
+	switch {
+	case record.Type == "SOA":
+		return "SOA is managed by Cloudflare"
+	case record.Type == "NS" && name == zone:
+		return "apex NS records are managed by Cloudflare"
+	case name != zone && !strings.HasSuffix(name, "."+zone):
+		return "name is outside the zone"
+	}
+
+	var validationErr *domain.ValidationError
+	if err := domain.ValidateRecord(record); errors.As(err, &validationErr) {
+		return validationErr.Message
+	}
+	return ""
+}
+
+// findMatchingRecord returns the record with the same name, type and content
+func findMatchingRecord(records []domain.DNSRecord, record domain.DNSRecord) *domain.DNSRecord {
+	for i := range records {
+		r := &records[i]
+		if !strings.EqualFold(r.Name, record.Name) || r.Type != record.Type {
+			continue
+		}
+		// TXT data is case sensitive, host names and addresses are not
+		if record.Type == "TXT" {
+			if r.Content == record.Content {
+				return r
+			}
+		} else if strings.EqualFold(strings.TrimSuffix(r.Content, "."), record.Content) {
+			return r
+		}
+	}
+	return nil
+}
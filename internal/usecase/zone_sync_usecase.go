@@ -0,0 +1,362 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cf-dns-bot/internal/domain"
+
+	"gopkg.in/yaml.v3"
+)
+
+// planLifetime is how long a sync plan can be applied after it was made
+const planLifetime = 30 * time.Minute
+
+// storedPlan is a sync plan together with the state of the zone it was
+// computed against
+type storedPlan struct {
+	plan        *SyncPlan
+	fingerprint string
+}
+
+// zoneSyncUsecase implements ZoneSyncUsecase on top of DNSUsecase.
+// Plans are kept in memory until they are applied or expire.
+type zoneSyncUsecase struct {
+	dnsUsecase DNSUsecase
+	plans      map[string]*storedPlan
+	mu         sync.Mutex
+}
+
+// NewZoneSyncUsecase creates a new zone sync usecase
+func NewZoneSyncUsecase(dnsUsecase DNSUsecase) ZoneSyncUsecase {
+	return &zoneSyncUsecase{
+		dnsUsecase: dnsUsecase,
+		plans:      make(map[string]*storedPlan),
+	}
+}
+
+// ParseZoneDocument parses a desired zone state in YAML or JSON
+func ParseZoneDocument(data []byte) (*ZoneDocument, error) {
+	var doc ZoneDocument
+	// JSON is a subset of YAML, so one decoder handles both
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidRecord, err)
+	}
+	return &doc, nil
+}
+
+// Plan computes the changes needed to bring a zone to the desired state
+func (u *zoneSyncUsecase) Plan(ctx context.Context, doc *ZoneDocument, options SyncOptions) (*SyncPlan, error) {
+	if doc == nil || doc.Zone == "" {
+		return nil, fmt.Errorf("%w: zone is required", domain.ErrInvalidZone)
+	}
+
+	desired, err := normalizeDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := u.dnsUsecase.ListRecords(ctx, doc.Zone, domain.RecordFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	plan := &SyncPlan{
+		ID:           id,
+		ZoneName:     doc.Zone,
+		AllowDeletes: options.AllowDeletes,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(planLifetime),
+	}
+	diffRecords(plan, desired, live)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for planID, stored := range u.plans {
+		if now.After(stored.plan.ExpiresAt) {
+			delete(u.plans, planID)
+		}
+	}
+	u.plans[id] = &storedPlan{plan: plan, fingerprint: fingerprint(live)}
+
+	return plan, nil
+}
+
+// Apply executes a plan made by Plan. A plan can be applied once; it is
+// rejected with ErrStalePlan if the zone changed since it was made.
+// Asking for a plan under the wrong zone returns ErrPlanNotFound and
+// keeps the plan.
+func (u *zoneSyncUsecase) Apply(ctx context.Context, zoneName, planID string) (*SyncResult, error) {
+	u.mu.Lock()
+	stored, exists := u.plans[planID]
+	if exists && zoneName != "" && !strings.EqualFold(stored.plan.ZoneName, zoneName) {
+		exists = false
+	} else {
+		delete(u.plans, planID)
+	}
+	u.mu.Unlock()
+
+	if !exists || time.Now().After(stored.plan.ExpiresAt) {
+		return nil, domain.ErrPlanNotFound
+	}
+	plan := stored.plan
+
+	live, err := u.dnsUsecase.ListRecords(ctx, plan.ZoneName, domain.RecordFilter{})
+	if err != nil {
+		return nil, err
+	}
+	if fingerprint(live) != stored.fingerprint {
+		return nil, domain.ErrStalePlan
+	}
+
+	result := &SyncResult{PlanID: plan.ID, ZoneName: plan.ZoneName}
+	applyChanges(ctx, u.dnsUsecase, result, plan.Creates, plan.Updates, plan.Deletes)
+	return result, nil
+}
+
+// applyChanges makes the given changes through dnsUsecase and records the
+// outcome in result. Failed changes do not stop the remaining ones.
+func applyChanges(ctx context.Context, dnsUsecase DNSUsecase, result *SyncResult, creates []domain.DNSRecord, updates []RecordChange, deletes []domain.DNSRecord) {
+	for _, change := range updates {
+		updated, err := dnsUsecase.UpdateRecord(ctx, UpdateRecordInput{
+			ZoneName: result.ZoneName,
+			Selector: RecordSelector{RecordID: change.Before.ID},
+			Content:  &change.After.Content,
+			TTL:      &change.After.TTL,
+			Proxied:  &change.After.Proxied,
+			Priority: change.After.Priority,
+			Comment:  &change.After.Comment,
+			Tags:     change.After.Tags,
+		})
+		if err != nil {
+			result.Failed = append(result.Failed, FailedRecord{Record: change.After, Error: err.Error()})
+			continue
+		}
+		result.Updated = append(result.Updated, *updated)
+	}
+
+	for _, record := range creates {
+		created, err := dnsUsecase.CreateRecord(ctx, CreateRecordInput{
+			ZoneName: result.ZoneName,
+			Name:     record.Name,
+			Type:     record.Type,
+			Content:  record.Content,
+			TTL:      record.TTL,
+			Proxied:  record.Proxied,
+			Priority: record.Priority,
+			Comment:  record.Comment,
+			Tags:     record.Tags,
+		})
+		if err != nil {
+			result.Failed = append(result.Failed, FailedRecord{Record: record, Error: err.Error()})
+			continue
+		}
+		result.Created = append(result.Created, *created)
+	}
+
+	for _, record := range deletes {
+		err := dnsUsecase.DeleteRecord(ctx, result.ZoneName, RecordSelector{RecordID: record.ID})
+		if err != nil {
+			result.Failed = append(result.Failed, FailedRecord{Record: record, Error: err.Error()})
+			continue
+		}
+		result.Deleted = append(result.Deleted, record)
+	}
+}
+
+// normalizeDocument validates the desired records and qualifies their names
+func normalizeDocument(doc *ZoneDocument) ([]domain.DNSRecord, error) {
+	records := make([]domain.DNSRecord, 0, len(doc.Records))
+	for i, r := range doc.Records {
+		record := domain.DNSRecord{
+			ZoneName: doc.Zone,
+			Name:     ensureFullRecordName(strings.TrimSuffix(r.Name, "."), doc.Zone),
+			Type:     strings.ToUpper(r.Type),
+			Content:  r.Content,
+			TTL:      r.TTL,
+			Proxied:  r.Proxied,
+			Priority: r.Priority,
+			Comment:  r.Comment,
+			Tags:     r.Tags,
+		}
+		err := domain.CompleteRecordData(&record)
+		if err == nil {
+			err = domain.ValidateRecord(record)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("record %d (%s): %w", i+1, r.Name, err)
+		}
+
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// diffRecords fills the plan with the changes between desired and live.
+// Records are compared per name and type: identical content is matched
+// first, then remaining records are paired up as content updates.
+func diffRecords(plan *SyncPlan, desired, live []domain.DNSRecord) {
+	key := func(r domain.DNSRecord) string {
+		return strings.ToLower(r.Name) + "|" + r.Type
+	}
+
+	liveByKey := make(map[string][]domain.DNSRecord)
+	for _, r := range live {
+		liveByKey[key(r)] = append(liveByKey[key(r)], r)
+	}
+
+	desiredByKey := make(map[string][]domain.DNSRecord)
+	var keys []string
+	for _, r := range desired {
+		k := key(r)
+		if _, seen := desiredByKey[k]; !seen {
+			keys = append(keys, k)
+		}
+		desiredByKey[k] = append(desiredByKey[k], r)
+	}
+
+	for _, k := range keys {
+		wanted := desiredByKey[k]
+		current := liveByKey[k]
+		delete(liveByKey, k)
+
+		var unmatched []domain.DNSRecord
+		for _, d := range wanted {
+			idx := -1
+			for i, l := range current {
+				if sameContent(l, d) {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				unmatched = append(unmatched, d)
+				continue
+			}
+
+			l := current[idx]
+			current = append(current[:idx], current[idx+1:]...)
+			if after, changed := applyDesired(l, d); changed {
+				plan.Updates = append(plan.Updates, RecordChange{Before: l, After: after})
+			} else {
+				plan.Unchanged++
+			}
+		}
+
+		for _, d := range unmatched {
+			if len(current) == 0 {
+				plan.Creates = append(plan.Creates, d)
+				continue
+			}
+			l := current[0]
+			current = current[1:]
+			after, _ := applyDesired(l, d)
+			plan.Updates = append(plan.Updates, RecordChange{Before: l, After: after})
+		}
+
+		removeRecords(plan, current)
+	}
+
+	// Live records whose name and type do not appear in the desired state
+	var rest []domain.DNSRecord
+	for _, r := range live {
+		if _, left := liveByKey[key(r)]; left {
+			rest = append(rest, r)
+		}
+	}
+	removeRecords(plan, rest)
+}
+
+// removeRecords adds live records missing from the desired state to the
+// plan as deletes, or as unmanaged when deletes are disabled
+func removeRecords(plan *SyncPlan, records []domain.DNSRecord) {
+	if plan.AllowDeletes {
+		plan.Deletes = append(plan.Deletes, records...)
+	} else {
+		plan.Unmanaged = append(plan.Unmanaged, records...)
+	}
+}
+
+// applyDesired returns the live record with the desired values applied and
+// whether anything changed. A desired TTL of 0, an empty comment and nil
+// tags keep the live values.
+func applyDesired(live, desired domain.DNSRecord) (domain.DNSRecord, bool) {
+	after := live
+	changed := false
+
+	if !sameContent(live, desired) {
+		after.Content = desired.Content
+		changed = true
+	}
+	if desired.TTL != 0 && desired.TTL != live.TTL {
+		after.TTL = desired.TTL
+		changed = true
+	}
+	if desired.Proxied != live.Proxied {
+		after.Proxied = desired.Proxied
+		changed = true
+	}
+	if desired.Priority != nil && (live.Priority == nil || *live.Priority != *desired.Priority) {
+		after.Priority = desired.Priority
+		changed = true
+	}
+	if desired.Comment != "" && desired.Comment != live.Comment {
+		after.Comment = desired.Comment
+		changed = true
+	}
+	if desired.Tags != nil && !slices.Equal(desired.Tags, live.Tags) {
+		after.Tags = desired.Tags
+		changed = true
+	}
+
+	return after, changed
+}
+
+// sameContent compares record content; TXT data is case sensitive, host
+// names and addresses are not
+func sameContent(a, b domain.DNSRecord) bool {
+	if a.Type == "TXT" {
+		return a.Content == b.Content
+	}
+	return strings.EqualFold(strings.TrimSuffix(a.Content, "."), strings.TrimSuffix(b.Content, "."))
+}
+
+// fingerprint summarizes the live state of a zone so Apply can detect
+// changes made after the plan
+func fingerprint(records []domain.DNSRecord) string {
+	lines := make([]string, len(records))
+	for i, r := range records {
+		priority := ""
+		if r.Priority != nil {
+			priority = fmt.Sprint(*r.Priority)
+		}
+		lines[i] = fmt.Sprintf("%s|%s|%s|%s|%d|%t|%s|%s|%s", r.ID, r.Name, r.Type, r.Content, r.TTL, r.Proxied, priority, r.Comment, strings.Join(r.Tags, ","))
+	}
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// newRandomID returns a random identifier for plans and audit entries
+func newRandomID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,135 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cf-dns-bot/external_resource/cloudflare"
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/usecase"
+)
+
+// desiredZone is example.com of the demo store with api.example.com moved,
+// blog.example.com added and the other records left out
+const desiredZone = `
+zone: example.com
+records:
+  - name: "@"
+    type: A
+    content: 192.0.2.10
+    ttl: 1
+    proxied: true
+  - name: www
+    type: CNAME
+    content: example.com
+    ttl: 1
+    proxied: true
+  - name: api
+    type: A
+    content: 192.0.2.21
+  - name: blog
+    type: A
+    content: 192.0.2.30
+`
+
+// planZone parses desiredZone and plans it
+func planZone(t *testing.T, sync usecase.ZoneSyncUsecase, options usecase.SyncOptions) *usecase.SyncPlan {
+	t.Helper()
+	doc, err := usecase.ParseZoneDocument([]byte(desiredZone))
+	if err != nil {
+		t.Fatalf("ParseZoneDocument: %v", err)
+	}
+	plan, err := sync.Plan(context.Background(), doc, options)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	return plan
+}
+
+func TestZoneSyncPlan(t *testing.T) {
+	uc, _, _ := newDNSUsecase(t)
+	sync := usecase.NewZoneSyncUsecase(uc)
+
+	plan := planZone(t, sync, usecase.SyncOptions{})
+	if len(plan.Creates) != 1 || plan.Creates[0].Name != "blog.example.com" {
+		t.Errorf("got creates %+v, want blog.example.com", plan.Creates)
+	}
+	if len(plan.Updates) != 1 || plan.Updates[0].Before.Content != "192.0.2.20" || plan.Updates[0].After.Content != "192.0.2.21" {
+		t.Errorf("got updates %+v, want api.example.com moved", plan.Updates)
+	}
+	// The TTL of api.example.com is not given, so it is kept
+	if len(plan.Updates) == 1 && plan.Updates[0].After.TTL != 300 {
+		t.Errorf("got TTL %d, want the live 300", plan.Updates[0].After.TTL)
+	}
+	if plan.Unchanged != 2 {
+		t.Errorf("got %d unchanged, want 2", plan.Unchanged)
+	}
+	// Without deletes the records left out are only reported
+	if len(plan.Deletes) != 0 || len(plan.Unmanaged) != 4 {
+		t.Errorf("got %d deletes and %d unmanaged, want 0 and 4", len(plan.Deletes), len(plan.Unmanaged))
+	}
+
+	plan = planZone(t, sync, usecase.SyncOptions{AllowDeletes: true})
+	if len(plan.Deletes) != 4 || len(plan.Unmanaged) != 0 {
+		t.Errorf("with deletes: got %d deletes and %d unmanaged, want 4 and 0", len(plan.Deletes), len(plan.Unmanaged))
+	}
+}
+
+func TestZoneSyncPlanInvalid(t *testing.T) {
+	uc, _, _ := newDNSUsecase(t)
+	sync := usecase.NewZoneSyncUsecase(uc)
+
+	doc := &usecase.ZoneDocument{Zone: "example.com", Records: []usecase.RecordDocument{{Name: "bad", Type: "A", Content: "not-an-ip"}}}
+	if _, err := sync.Plan(context.Background(), doc, usecase.SyncOptions{}); !errors.Is(err, domain.ErrInvalidRecord) {
+		t.Errorf("invalid record: got error %v, want ErrInvalidRecord", err)
+	}
+	if _, err := sync.Plan(context.Background(), &usecase.ZoneDocument{}, usecase.SyncOptions{}); !errors.Is(err, domain.ErrInvalidZone) {
+		t.Errorf("no zone: got error %v, want ErrInvalidZone", err)
+	}
+}
+
+func TestZoneSyncApply(t *testing.T) {
+	ctx := context.Background()
+	uc, store, zoneID := newDNSUsecase(t)
+	sync := usecase.NewZoneSyncUsecase(uc)
+
+	plan := planZone(t, sync, usecase.SyncOptions{AllowDeletes: true})
+
+	// A plan asked for under another zone is not found and kept
+	if _, err := sync.Apply(ctx, "example.org", plan.ID); !errors.Is(err, domain.ErrPlanNotFound) {
+		t.Fatalf("other zone: got error %v, want ErrPlanNotFound", err)
+	}
+
+	result, err := sync.Apply(ctx, "example.com", plan.ID)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(result.Created) != 1 || len(result.Updated) != 1 || len(result.Deleted) != 4 || len(result.Failed) != 0 {
+		t.Errorf("got %d created, %d updated, %d deleted, %d failed, want 1, 1, 4, 0",
+			len(result.Created), len(result.Updated), len(result.Deleted), len(result.Failed))
+	}
+	if got := len(store.Records(zoneID)); got != 4 {
+		t.Errorf("got %d records, want the 4 desired", got)
+	}
+
+	// A plan is applied once
+	if _, err := sync.Apply(ctx, "example.com", plan.ID); !errors.Is(err, domain.ErrPlanNotFound) {
+		t.Errorf("second apply: got error %v, want ErrPlanNotFound", err)
+	}
+}
+
+func TestZoneSyncApplyStale(t *testing.T) {
+	uc, store, zoneID := newDNSUsecase(t)
+	sync := usecase.NewZoneSyncUsecase(uc)
+
+	plan := planZone(t, sync, usecase.SyncOptions{})
+	store.AddRecord(zoneID, cloudflare.DNSRecord{Name: "late.example.com", Type: "A", Content: "192.0.2.99", TTL: 1})
+
+	if _, err := sync.Apply(context.Background(), "example.com", plan.ID); !errors.Is(err, domain.ErrStalePlan) {
+		t.Errorf("got error %v, want ErrStalePlan", err)
+	}
+	if got := findRecords(store, zoneID, "blog.example.com", "A"); len(got) != 0 {
+		t.Errorf("got %d blog.example.com records, want the stale plan not applied", len(got))
+	}
+}
@@ -0,0 +1,368 @@
+package zonefile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"cf-dns-bot/internal/domain"
+)
+
+// ParseError describes a syntax error in a zone file
+type ParseError struct {
+	Line    int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("zone file line %d: %s", e.Line, e.Message)
+}
+
+// classes lists the DNS classes that may appear in a resource record
+var classes = map[string]bool{"IN": true, "CH": true, "HS": true, "CS": true}
+
+// logicalLine is one resource record or directive, possibly spanning
+// several physical lines when parentheses are used
+type logicalLine struct {
+	number     int
+	tokens     []string
+	blankOwner bool
+}
+
+// Parse reads an RFC 1035 master file and returns its resource records.
+// origin is the initial $ORIGIN, normally the zone name; it may be
+// overridden by $ORIGIN directives in the file. Names are returned fully
+// qualified without the trailing dot. Records without an explicit TTL take
+// the $TTL in effect, or else the TTL of the previous record as in RFC 1035;
+// the first records of a file with neither get 0, which lets the caller
+// apply its default.
+func Parse(r io.Reader, origin string) ([]domain.DNSRecord, error) {
+	lines, err := readLogicalLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	origin = strings.TrimSuffix(strings.ToLower(origin), ".")
+	defaultTTL := 0
+	lastOwner := ""
+	lastTTL := 0
+
+	var records []domain.DNSRecord
+	for _, line := range lines {
+		tokens := line.tokens
+
+		// Directives
+		if strings.HasPrefix(tokens[0], "$") {
+			switch strings.ToUpper(tokens[0]) {
+			case "$ORIGIN":
+				if len(tokens) < 2 {
+					return nil, &ParseError{Line: line.number, Message: "$ORIGIN requires a domain name"}
+				}
+				origin = absoluteName(tokens[1], origin)
+			case "$TTL":
+				if len(tokens) < 2 {
+					return nil, &ParseError{Line: line.number, Message: "$TTL requires a value"}
+				}
+				ttl, err := parseTTL(tokens[1])
+				if err != nil {
+					return nil, &ParseError{Line: line.number, Message: err.Error()}
+				}
+				defaultTTL = ttl
+			default:
+				return nil, &ParseError{Line: line.number, Message: fmt.Sprintf("unsupported directive %s", tokens[0])}
+			}
+			continue
+		}
+
+		// Owner name
+		owner := lastOwner
+		if !line.blankOwner {
+			owner = absoluteName(tokens[0], origin)
+			tokens = tokens[1:]
+		}
+		if owner == "" {
+			return nil, &ParseError{Line: line.number, Message: "record has no owner name"}
+		}
+		lastOwner = owner
+
+		// Optional TTL and class, in either order
+		ttl := -1
+		for i := 0; i < 2 && len(tokens) > 0; i++ {
+			if classes[strings.ToUpper(tokens[0])] {
+				tokens = tokens[1:]
+				continue
+			}
+			if ttl < 0 && unicode.IsDigit(rune(tokens[0][0])) {
+				value, err := parseTTL(tokens[0])
+				if err != nil {
+					return nil, &ParseError{Line: line.number, Message: err.Error()}
+				}
+				ttl = value
+				tokens = tokens[1:]
+			}
+		}
+		if ttl < 0 {
+			ttl = defaultTTL
+			if ttl == 0 {
+				ttl = lastTTL
+			}
+		}
+		lastTTL = ttl
+
+		if len(tokens) < 2 {
+			return nil, &ParseError{Line: line.number, Message: "record is missing a type or data"}
+		}
+
+		record, err := buildRecord(owner, strings.ToUpper(tokens[0]), tokens[1:], ttl, origin)
+		if err != nil {
+			return nil, &ParseError{Line: line.number, Message: err.Error()}
+		}
+		records = append(records, *record)
+	}
+
+	return records, nil
+}
+
+// buildRecord converts presentation format rdata into a domain record
+// using the content conventions of the Cloudflare API
+func buildRecord(owner, recordType string, rdata []string, ttl int, origin string) (*domain.DNSRecord, error) {
+	record := &domain.DNSRecord{
+		Name: owner,
+		Type: recordType,
+		TTL:  ttl,
+	}
+
+	switch recordType {
+	case "CNAME", "NS", "PTR":
+		record.Content = absoluteName(rdata[0], origin)
+	case "MX":
+		if len(rdata) < 2 {
+			return nil, fmt.Errorf("MX record requires a preference and an exchange")
+		}
+		priority, err := parsePriority(rdata[0])
+		if err != nil {
+			return nil, err
+		}
+		record.Priority = &priority
+		record.Content = absoluteName(rdata[1], origin)
+	case "SRV":
+		if len(rdata) < 4 {
+			return nil, fmt.Errorf("SRV record requires priority, weight, port and target")
+		}
+		priority, err := parsePriority(rdata[0])
+		if err != nil {
+			return nil, err
+		}
+		record.Priority = &priority
+		record.Content = fmt.Sprintf("%s %s %s", rdata[1], rdata[2], absoluteName(rdata[3], origin))
+	case "TXT", "SPF":
+		var text strings.Builder
+		for _, part := range rdata {
+			text.WriteString(unquote(part))
+		}
+		record.Content = text.String()
+	default:
+		record.Content = strings.Join(rdata, " ")
+	}
+
+	return record, nil
+}
+
+// readLogicalLines splits the input into logical lines, removing comments
+// and joining lines enclosed in parentheses
+func readLogicalLines(r io.Reader) ([]logicalLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []logicalLine
+	var current logicalLine
+	depth := 0
+	number := 0
+
+	for scanner.Scan() {
+		number++
+		raw := scanner.Text()
+
+		tokens, opened, err := tokenize(raw)
+		if err != nil {
+			return nil, &ParseError{Line: number, Message: err.Error()}
+		}
+
+		if depth == 0 {
+			current = logicalLine{
+				number:     number,
+				blankOwner: len(raw) > 0 && (raw[0] == ' ' || raw[0] == '\t'),
+			}
+		}
+
+		current.tokens = append(current.tokens, tokens...)
+		depth += opened
+		if depth < 0 {
+			return nil, &ParseError{Line: number, Message: "unbalanced parentheses"}
+		}
+		if depth == 0 && len(current.tokens) > 0 {
+			lines = append(lines, current)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read zone file: %w", err)
+	}
+	if depth != 0 {
+		return nil, &ParseError{Line: number, Message: "unclosed parenthesis"}
+	}
+
+	return lines, nil
+}
+
+// tokenize splits a physical line into tokens. Quoted strings are kept as
+// a single token including their quotes. It returns the net number of
+// parentheses opened on the line.
+func tokenize(line string) ([]string, int, error) {
+	var tokens []string
+	var current strings.Builder
+	opened := 0
+	inQuote := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case inQuote:
+			current.WriteByte(ch)
+			if ch == '\\' && i+1 < len(line) {
+				i++
+				current.WriteByte(line[i])
+			} else if ch == '"' {
+				inQuote = false
+				flush()
+			}
+		case ch == '"':
+			flush()
+			inQuote = true
+			current.WriteByte(ch)
+		case ch == ';':
+			flush()
+			return tokens, opened, nil
+		case ch == '(':
+			flush()
+			opened++
+		case ch == ')':
+			flush()
+			opened--
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			flush()
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	if inQuote {
+		return nil, 0, fmt.Errorf("unterminated quoted string")
+	}
+	flush()
+	return tokens, opened, nil
+}
+
+// absoluteName resolves a possibly relative domain name against the origin.
+// The result has no trailing dot.
+func absoluteName(name, origin string) string {
+	if name == "@" {
+		return origin
+	}
+	if name == "." {
+		return "."
+	}
+	if strings.HasSuffix(name, ".") {
+		return strings.TrimSuffix(name, ".")
+	}
+	if origin == "" {
+		return name
+	}
+	return name + "." + origin
+}
+
+// unquote removes surrounding quotes and resolves escapes in a
+// character-string
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			out.WriteByte(s[i])
+			continue
+		}
+		// \DDD decimal escape
+		if i+3 < len(s) && isDigits(s[i+1:i+4]) {
+			value, _ := strconv.Atoi(s[i+1 : i+4])
+			out.WriteByte(byte(value))
+			i += 3
+			continue
+		}
+		i++
+		out.WriteByte(s[i])
+	}
+	return out.String()
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTTL parses a TTL in seconds or with BIND unit suffixes (1h30m, 2d)
+func parseTTL(value string) (int, error) {
+	if n, err := strconv.Atoi(value); err == nil {
+		if n < 0 {
+			return 0, fmt.Errorf("invalid TTL %q", value)
+		}
+		return n, nil
+	}
+
+	units := map[byte]int{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}
+	total := 0
+	number := 0
+	hasNumber := false
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		if ch >= '0' && ch <= '9' {
+			number = number*10 + int(ch-'0')
+			hasNumber = true
+			continue
+		}
+		unit, ok := units[byte(unicode.ToLower(rune(ch)))]
+		if !ok || !hasNumber {
+			return 0, fmt.Errorf("invalid TTL %q", value)
+		}
+		total += number * unit
+		number = 0
+		hasNumber = false
+	}
+	if hasNumber {
+		return 0, fmt.Errorf("invalid TTL %q", value)
+	}
+	return total, nil
+}
+
+// parsePriority parses an MX preference or SRV priority
+func parsePriority(value string) (uint16, error) {
+	n, err := strconv.ParseUint(value, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid priority %q", value)
+	}
+	return uint16(n), nil
+}
@@ -0,0 +1,146 @@
+package zonefile_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/internal/zonefile"
+)
+
+// parse parses the zone file with origin example.com
+func parse(t *testing.T, zone string) []domain.DNSRecord {
+	t.Helper()
+	records, err := zonefile.Parse(strings.NewReader(zone), "example.com")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return records
+}
+
+// record is the part of a parsed record checked by the tests
+type record struct {
+	name    string
+	typ     string
+	ttl     int
+	content string
+}
+
+func checkRecords(t *testing.T, got []domain.DNSRecord, want []record) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d records %+v, want %d", len(got), got, len(want))
+	}
+	for i, w := range want {
+		g := record{got[i].Name, got[i].Type, got[i].TTL, got[i].Content}
+		if g != w {
+			t.Errorf("record %d: got %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestParseNames(t *testing.T) {
+	records := parse(t, `
+$TTL 300
+@             IN A     192.0.2.1
+www              CNAME @
+mail.other.org.  A     192.0.2.2
+$ORIGIN sub.example.com.
+host             A     192.0.2.3
+@                MX    10 mail
+`)
+	checkRecords(t, records, []record{
+		{"example.com", "A", 300, "192.0.2.1"},
+		{"www.example.com", "CNAME", 300, "example.com"},
+		{"mail.other.org", "A", 300, "192.0.2.2"},
+		{"host.sub.example.com", "A", 300, "192.0.2.3"},
+		{"sub.example.com", "MX", 300, "mail.sub.example.com"},
+	})
+	if p := records[4].Priority; p == nil || *p != 10 {
+		t.Errorf("got MX priority %v, want 10", p)
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	records := parse(t, `
+first  A 192.0.2.1
+second 1h30m A 192.0.2.2
+third  A 192.0.2.3
+$TTL 2d
+fourth A 192.0.2.4
+fifth  IN 1W A 192.0.2.5
+`)
+	checkRecords(t, records, []record{
+		// No TTL and no $TTL yet
+		{"first.example.com", "A", 0, "192.0.2.1"},
+		{"second.example.com", "A", 5400, "192.0.2.2"},
+		// The TTL of the previous record
+		{"third.example.com", "A", 5400, "192.0.2.3"},
+		{"fourth.example.com", "A", 172800, "192.0.2.4"},
+		{"fifth.example.com", "A", 604800, "192.0.2.5"},
+	})
+}
+
+func TestParseBlankOwner(t *testing.T) {
+	records := parse(t, "$TTL 60\nwww A 192.0.2.1\n    AAAA 2001:db8::1\n\tIN TXT \"hello\"\n")
+	checkRecords(t, records, []record{
+		{"www.example.com", "A", 60, "192.0.2.1"},
+		{"www.example.com", "AAAA", 60, "2001:db8::1"},
+		{"www.example.com", "TXT", 60, "hello"},
+	})
+}
+
+func TestParseMultiLine(t *testing.T) {
+	records := parse(t, `
+@ 3600 IN SOA ns1.example.com. hostmaster.example.com. (
+        2024010101 ; serial
+        7200       ; refresh
+        3600 1209600 300 )
+txt 300 TXT ( "v=spf1 include:_spf.example.net"
+              " ~all" )
+`)
+	checkRecords(t, records, []record{
+		{"example.com", "SOA", 3600, "ns1.example.com. hostmaster.example.com. 2024010101 7200 3600 1209600 300"},
+		{"txt.example.com", "TXT", 300, "v=spf1 include:_spf.example.net ~all"},
+	})
+}
+
+func TestParseQuotedText(t *testing.T) {
+	records := parse(t, `
+dkim 300 TXT "v=DKIM1; k=rsa; p=MIGf" ; a comment
+esc  300 TXT "say \"hi\"; \059 done"
+`)
+	checkRecords(t, records, []record{
+		{"dkim.example.com", "TXT", 300, "v=DKIM1; k=rsa; p=MIGf"},
+		{"esc.example.com", "TXT", 300, `say "hi"; ; done`},
+	})
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		zone string
+		line int
+	}{
+		{"unsupported directive", "$INCLUDE other.zone\n", 1},
+		{"blank owner first", "  A 192.0.2.1\n", 1},
+		{"missing data", "www 300 A\n", 1},
+		{"bad TTL", "www 5x A 192.0.2.1\n", 1},
+		{"unclosed parenthesis", "@ SOA ns1 hostmaster (\n 1 2 3 4 5\n", 2},
+		{"unterminated quote", "www A 192.0.2.1\nt TXT \"open\n", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := zonefile.Parse(strings.NewReader(tt.zone), "example.com")
+			var parseErr *zonefile.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("got error %v, want a ParseError", err)
+			}
+			if parseErr.Line != tt.line {
+				t.Errorf("got line %d, want %d", parseErr.Line, tt.line)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package zonefile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"cf-dns-bot/internal/domain"
+)
+
+// autoTTL is the TTL Cloudflare applies to records with TTL 1 ("automatic")
+const autoTTL = 300
+
+// maxCharacterString is the longest character-string allowed in TXT data
+const maxCharacterString = 255
+
+// Write renders records as an RFC 1035 master file for the given origin.
+// Names inside the zone are written relative to the origin. Proxied
+// records are marked with the cf_tags comment used by Cloudflare's own
+// export; the comment is ignored by Parse and other BIND tooling.
+func Write(w io.Writer, origin string, records []domain.DNSRecord) error {
+	origin = strings.TrimSuffix(strings.ToLower(origin), ".")
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "$ORIGIN %s.\n", origin)
+	fmt.Fprintf(bw, "$TTL %d\n", autoTTL)
+	fmt.Fprintf(bw, "; %s exported from Cloudflare (%d records)\n\n", origin, len(records))
+
+	for _, r := range records {
+		ttl := r.TTL
+		if ttl <= 1 {
+			ttl = autoTTL
+		}
+
+		line := fmt.Sprintf("%s\t%d\tIN\t%s\t%s", relativeName(r.Name, origin), ttl, r.Type, rdata(r))
+		if r.Proxied {
+			line += " ; cf_tags=cf-proxied:true"
+		}
+		fmt.Fprintln(bw, line)
+	}
+
+	return bw.Flush()
+}
+
+// rdata renders the presentation format of a record's data
+func rdata(r domain.DNSRecord) string {
+	priority := uint16(0)
+	if r.Priority != nil {
+		priority = *r.Priority
+	}
+
+	switch r.Type {
+	case "CNAME", "NS", "PTR":
+		return fqdn(r.Content)
+	case "MX":
+		return fmt.Sprintf("%d %s", priority, fqdn(r.Content))
+	case "SRV":
+		// Cloudflare keeps the priority separate from "weight port target"
+		fields := strings.Fields(r.Content)
+		if len(fields) == 3 {
+			return fmt.Sprintf("%d %s %s %s", priority, fields[0], fields[1], fqdn(fields[2]))
+		}
+		return r.Content
+	case "TXT", "SPF":
+		return quoteText(r.Content)
+	default:
+		return r.Content
+	}
+}
+
+// relativeName returns name relative to origin, "@" for the apex
+func relativeName(name, origin string) string {
+	lower := strings.ToLower(strings.TrimSuffix(name, "."))
+	if lower == origin {
+		return "@"
+	}
+	if strings.HasSuffix(lower, "."+origin) {
+		return name[:len(lower)-len(origin)-1]
+	}
+	return fqdn(name)
+}
+
+// fqdn returns name with a trailing dot
+func fqdn(name string) string {
+	if name == "." || strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// quoteText renders TXT data as one or more quoted character-strings
+func quoteText(text string) string {
+	// Content already in presentation format is kept as is
+	if strings.HasPrefix(text, "\"") && strings.HasSuffix(text, "\"") && len(text) > 1 {
+		return text
+	}
+
+	var parts []string
+	for len(text) > maxCharacterString {
+		parts = append(parts, escapeText(text[:maxCharacterString]))
+		text = text[maxCharacterString:]
+	}
+	parts = append(parts, escapeText(text))
+	return strings.Join(parts, " ")
+}
+
+// escapeText quotes a single character-string
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	return "\"" + s + "\""
+}
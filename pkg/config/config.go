@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -19,11 +20,40 @@ type Config struct {
 	CloudflareAPIToken string
 	CloudflareAPIKey   string
 	CloudflareEmail    string
+	CloudflareAccounts []CloudflareAccount // every account, including the one above
+	FakeCloudflare     bool                // serve demo zones from an in-memory fake of Cloudflare
 
 	// Storage
 	DataDir string
+
+	// Snapshots
+	SnapshotInterval time.Duration // 0 disables scheduled snapshots
+	SnapshotKeep     int           // snapshots kept per zone, 0 for no limit
+	SnapshotMaxAge   time.Duration // 0 for no limit
+
+	// Cache of Cloudflare zones and records
+	CacheZoneTTL   time.Duration // 0 disables the zone cache
+	CacheRecordTTL time.Duration // 0 disables the record cache
+
+	// Changes made in the bot that need the approval of a second user
+	ApprovalDeletes        bool          // single record deletes
+	ApprovalBulkChanges    bool          // bulk deletes and updates
+	ApprovalProtectedZones []string      // every change to these zones
+	ApprovalTimeout        time.Duration // how long a change waits for approval
+}
+
+// CloudflareAccount holds the credentials of a named Cloudflare account
+type CloudflareAccount struct {
+	Name     string
+	APIToken string
+	APIKey   string
+	Email    string
 }
 
+// DefaultAccountName is the name of the account configured with
+// CLOUDFLARE_API_TOKEN or CLOUDFLARE_API_KEY and CLOUDFLARE_EMAIL
+const DefaultAccountName = "default"
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	// Load .env file if exists
@@ -35,6 +65,7 @@ func Load() (*Config, error) {
 		CloudflareAPIKey:   getEnv("CLOUDFLARE_API_KEY", ""),
 		CloudflareEmail:    getEnv("CLOUDFLARE_EMAIL", ""),
 		DataDir:            getEnv("DATA_DIR", "./data"),
+		FakeCloudflare:     getEnv("FAKE_CLOUDFLARE", "") == "true",
 	}
 
 	// Parse allowed users
@@ -53,6 +84,63 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Parse Cloudflare accounts, unless the fake stands in for them
+	if !cfg.FakeCloudflare {
+		if cfg.CloudflareAPIToken != "" || cfg.CloudflareAPIKey != "" {
+			cfg.CloudflareAccounts = append(cfg.CloudflareAccounts, CloudflareAccount{
+				Name:     DefaultAccountName,
+				APIToken: cfg.CloudflareAPIToken,
+				APIKey:   cfg.CloudflareAPIKey,
+				Email:    cfg.CloudflareEmail,
+			})
+		}
+		for _, name := range strings.Split(getEnv("CLOUDFLARE_ACCOUNTS", ""), ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			prefix := "CLOUDFLARE_" + envName(name) + "_"
+			cfg.CloudflareAccounts = append(cfg.CloudflareAccounts, CloudflareAccount{
+				Name:     name,
+				APIToken: getEnv(prefix+"API_TOKEN", ""),
+				APIKey:   getEnv(prefix+"API_KEY", ""),
+				Email:    getEnv(prefix+"EMAIL", ""),
+			})
+		}
+	}
+
+	// Parse snapshot settings
+	var err error
+	if cfg.SnapshotInterval, err = time.ParseDuration(getEnv("SNAPSHOT_INTERVAL", "6h")); err != nil {
+		return nil, fmt.Errorf("invalid SNAPSHOT_INTERVAL: %w", err)
+	}
+	if cfg.SnapshotKeep, err = strconv.Atoi(getEnv("SNAPSHOT_KEEP", "28")); err != nil {
+		return nil, fmt.Errorf("invalid SNAPSHOT_KEEP: %w", err)
+	}
+	if cfg.SnapshotMaxAge, err = time.ParseDuration(getEnv("SNAPSHOT_MAX_AGE", "720h")); err != nil {
+		return nil, fmt.Errorf("invalid SNAPSHOT_MAX_AGE: %w", err)
+	}
+
+	// Parse cache settings
+	if cfg.CacheZoneTTL, err = time.ParseDuration(getEnv("CACHE_ZONE_TTL", "10m")); err != nil {
+		return nil, fmt.Errorf("invalid CACHE_ZONE_TTL: %w", err)
+	}
+	if cfg.CacheRecordTTL, err = time.ParseDuration(getEnv("CACHE_RECORD_TTL", "1m")); err != nil {
+		return nil, fmt.Errorf("invalid CACHE_RECORD_TTL: %w", err)
+	}
+
+	// Parse approval policy
+	cfg.ApprovalDeletes = getEnv("APPROVAL_DELETES", "") == "true"
+	cfg.ApprovalBulkChanges = getEnv("APPROVAL_BULK_CHANGES", "") == "true"
+	for _, zone := range strings.Split(getEnv("APPROVAL_PROTECTED_ZONES", ""), ",") {
+		if zone = strings.TrimSpace(zone); zone != "" {
+			cfg.ApprovalProtectedZones = append(cfg.ApprovalProtectedZones, strings.ToLower(zone))
+		}
+	}
+	if cfg.ApprovalTimeout, err = time.ParseDuration(getEnv("APPROVAL_TIMEOUT", "1h")); err != nil {
+		return nil, fmt.Errorf("invalid APPROVAL_TIMEOUT: %w", err)
+	}
+
 	// Validate
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -67,18 +155,45 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("TELEGRAM_BOT_TOKEN is required")
 	}
 
-	if c.CloudflareAPIToken == "" {
-		if c.CloudflareAPIKey == "" || c.CloudflareEmail == "" {
-			return fmt.Errorf("either CLOUDFLARE_API_TOKEN or both CLOUDFLARE_API_KEY and CLOUDFLARE_EMAIL are required")
+	if c.FakeCloudflare {
+		// No credentials are needed for the fake
+		return nil
+	}
+
+	if len(c.CloudflareAccounts) == 0 {
+		return fmt.Errorf("either CLOUDFLARE_API_TOKEN, both CLOUDFLARE_API_KEY and CLOUDFLARE_EMAIL, or CLOUDFLARE_ACCOUNTS is required")
+	}
+
+	seen := make(map[string]bool)
+	for _, account := range c.CloudflareAccounts {
+		if seen[strings.ToLower(account.Name)] {
+			return fmt.Errorf("cloudflare account %s is configured twice", account.Name)
+		}
+		seen[strings.ToLower(account.Name)] = true
+
+		if account.APIToken == "" && (account.APIKey == "" || account.Email == "") {
+			if account.Name == DefaultAccountName {
+				return fmt.Errorf("both CLOUDFLARE_API_KEY and CLOUDFLARE_EMAIL are required without CLOUDFLARE_API_TOKEN")
+			}
+			prefix := "CLOUDFLARE_" + envName(account.Name) + "_"
+			return fmt.Errorf("either %sAPI_TOKEN or both %sAPI_KEY and %sEMAIL are required for account %s", prefix, prefix, prefix, account.Name)
 		}
 	}
 
 	return nil
 }
 
-// UseAPIToken returns true if API token should be used
-func (c *Config) UseAPIToken() bool {
-	return c.CloudflareAPIToken != ""
+// envName returns the account name as used in environment variable names
+func envName(account string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, account)
 }
 
 func getEnv(key, defaultValue string) string {
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"cf-dns-bot/internal/domain"
+)
+
+// auditStorage implements AuditStorage as an append-only JSON Lines file
+type auditStorage struct {
+	filePath string
+	mu       sync.RWMutex
+}
+
+// NewAuditStorage creates a new audit log stored in dataDir/audit.jsonl
+func NewAuditStorage(dataDir string) AuditStorage {
+	return &auditStorage{
+		filePath: filepath.Join(dataDir, "audit.jsonl"),
+	}
+}
+
+// AppendAuditEntry appends an entry to the audit log
+func (s *auditStorage) AppendAuditEntry(entry domain.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	f, err := os.OpenFile(s.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// GetAuditEntry returns the entry with the given ID
+func (s *auditStorage) GetAuditEntry(id string) (*domain.AuditEntry, error) {
+	entries, err := s.readAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].ID == id {
+			return &entries[i], nil
+		}
+	}
+	return nil, domain.ErrChangeNotFound
+}
+
+// QueryAuditEntries returns matching entries newest first
+func (s *auditStorage) QueryAuditEntries(filter domain.AuditFilter) ([]domain.AuditEntry, int, error) {
+	entries, err := s.readAll()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matches []domain.AuditEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if matchesAuditFilter(entries[i], filter) {
+			matches = append(matches, entries[i])
+		}
+	}
+
+	total := len(matches)
+	if filter.Offset > 0 {
+		if filter.Offset >= len(matches) {
+			return []domain.AuditEntry{}, total, nil
+		}
+		matches = matches[filter.Offset:]
+	}
+	if filter.Limit > 0 && len(matches) > filter.Limit {
+		matches = matches[:filter.Limit]
+	}
+
+	return matches, total, nil
+}
+
+// readAll reads every entry in the audit log in file order
+func (s *auditStorage) readAll() ([]domain.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, err := os.Open(s.filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []domain.AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry domain.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip a partially written line instead of hiding the whole log
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// matchesAuditFilter returns true if the entry matches the filter
+func matchesAuditFilter(entry domain.AuditEntry, filter domain.AuditFilter) bool {
+	if filter.ZoneName != "" && !strings.EqualFold(entry.ZoneName, filter.ZoneName) {
+		return false
+	}
+	if filter.Actor != "" {
+		actor := strings.ToLower(filter.Actor)
+		if !strings.Contains(strings.ToLower(entry.Actor), actor) && strings.ToLower(entry.ActorID) != actor {
+			return false
+		}
+	}
+	if !filter.Since.IsZero() && entry.Timestamp.Before(filter.Since) {
+		return false
+	}
+	if !filter.Until.IsZero() && entry.Timestamp.After(filter.Until) {
+		return false
+	}
+	return true
+}
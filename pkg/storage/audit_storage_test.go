@@ -0,0 +1,77 @@
+package storage_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/pkg/storage"
+)
+
+func TestAuditStorage(t *testing.T) {
+	s := storage.NewAuditStorage(t.TempDir())
+
+	// A log that was never written is empty
+	entries, total, err := s.QueryAuditEntries(domain.AuditFilter{})
+	if err != nil || total != 0 || len(entries) != 0 {
+		t.Fatalf("empty log: got %v, %d, %v", entries, total, err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, e := range []struct{ id, zone, actor string }{
+		{"c1", "example.com", "alice"},
+		{"c2", "example.org", "bob"},
+		{"c3", "example.com", "bob"},
+		{"c4", "example.com", "alice"},
+	} {
+		entry := domain.AuditEntry{ID: e.id, ZoneName: e.zone, Actor: e.actor, Action: domain.AuditActionCreate, Timestamp: start.Add(time.Duration(i) * time.Hour)}
+		if err := s.AppendAuditEntry(entry); err != nil {
+			t.Fatalf("AppendAuditEntry: %v", err)
+		}
+	}
+
+	entry, err := s.GetAuditEntry("c2")
+	if err != nil || entry.ZoneName != "example.org" {
+		t.Errorf("GetAuditEntry: got %+v, %v", entry, err)
+	}
+	if _, err := s.GetAuditEntry("c9"); !errors.Is(err, domain.ErrChangeNotFound) {
+		t.Errorf("got error %v, want ErrChangeNotFound", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter domain.AuditFilter
+		want   []string
+		total  int
+	}{
+		{"all, newest first", domain.AuditFilter{}, []string{"c4", "c3", "c2", "c1"}, 4},
+		{"zone", domain.AuditFilter{ZoneName: "EXAMPLE.COM"}, []string{"c4", "c3", "c1"}, 3},
+		{"actor", domain.AuditFilter{Actor: "bob"}, []string{"c3", "c2"}, 2},
+		{"since", domain.AuditFilter{Since: start.Add(2 * time.Hour)}, []string{"c4", "c3"}, 2},
+		{"page", domain.AuditFilter{Offset: 1, Limit: 2}, []string{"c3", "c2"}, 4},
+		{"past the end", domain.AuditFilter{Offset: 10}, nil, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, total, err := s.QueryAuditEntries(tt.filter)
+			if err != nil {
+				t.Fatalf("QueryAuditEntries: %v", err)
+			}
+			var ids []string
+			for _, e := range entries {
+				ids = append(ids, e.ID)
+			}
+			if total != tt.total || len(ids) != len(tt.want) {
+				t.Fatalf("got %v of %d, want %v of %d", ids, total, tt.want, tt.total)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", ids, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
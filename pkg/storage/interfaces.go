@@ -1,5 +1,7 @@
 package storage
 
+import "cf-dns-bot/internal/domain"
+
 // AccessScope represents where a user is allowed to use the bot
 type AccessScope struct {
 	ChatID   int64 `json:"chat_id"`
@@ -16,10 +18,41 @@ type PendingRequest struct {
 	ThreadID  int    `json:"thread_id"`
 }
 
+// User roles, from least to most privileged
+const (
+	RoleViewer = "viewer" // browses zones and records
+	RoleEditor = "editor" // also creates, edits and deletes records
+	RoleAdmin  = "admin"  // also manages users, API keys and the MCP server
+)
+
 // AllowedUser represents an authorized user with their access scope
 type AllowedUser struct {
 	UserID int64       `json:"user_id"`
 	Scopes []AccessScope `json:"scopes"`
+	// Role is empty for users added before roles existed
+	Role string `json:"role,omitempty"`
+	// Zones the user may use; empty allows every zone
+	Zones []string `json:"zones,omitempty"`
+}
+
+// APIKeyScope restricts what an API key may do. Patterns are matched
+// case-insensitively with path.Match, so *.example.com matches subdomains
+// and _acme-challenge.* matches the challenge records of any zone. Empty
+// lists allow everything.
+type APIKeyScope struct {
+	// Zones the key may use, as zone name patterns
+	Zones []string `json:"zones,omitempty"`
+	// Records the key may see and change, as full record name patterns
+	Records []string `json:"records,omitempty"`
+	// ReadOnly keys may only use the tools that do not change records
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Tools are the MCP tools and REST operations the key may use
+	Tools []string `json:"tools,omitempty"`
+}
+
+// IsEmpty reports whether the scope allows everything
+func (s APIKeyScope) IsEmpty() bool {
+	return len(s.Zones) == 0 && len(s.Records) == 0 && !s.ReadOnly && len(s.Tools) == 0
 }
 
 // Config represents the application configuration stored in JSON
@@ -32,6 +65,12 @@ type Config struct {
 	MCPAPIKeys      []string         `json:"mcp_api_keys"`
 	MCPHTTPPort     string           `json:"mcp_http_port"`
 	MCPHTTPEnabled  bool             `json:"mcp_http_enabled"`
+	// Cloudflare account each user or API key is restricted to; missing
+	// entries may use every account
+	UserAccounts   map[int64]string  `json:"user_accounts,omitempty"`
+	APIKeyAccounts map[string]string `json:"api_key_accounts,omitempty"`
+	// Scope of each API key; missing entries may do everything
+	APIKeyScopes map[string]APIKeyScope `json:"api_key_scopes,omitempty"`
 }
 
 // ConfigStorage defines the interface for configuration storage
@@ -46,6 +85,14 @@ type APIKeyStorage interface {
 	AddAPIKey(key string) error
 	RemoveAPIKey(key string) error
 	IsValidAPIKey(key string) bool
+	// GetAPIKeyAccount returns the account a key is restricted to, or an
+	// empty string for every account
+	GetAPIKeyAccount(key string) string
+	SetAPIKeyAccount(key, account string) error
+	// GetAPIKeyScope returns the scope of a key; the zero scope allows
+	// everything
+	GetAPIKeyScope(key string) APIKeyScope
+	SetAPIKeyScope(key string, scope APIKeyScope) error
 }
 
 // MCPHTTPConfigStorage defines the interface for MCP HTTP server configuration
@@ -70,4 +117,38 @@ type AllowedUserStorage interface {
 	AddAllowedUser(userID int64, scope AccessScope) error
 	RemoveAllowedUser(userID int64) error
 	IsUserAllowed(userID int64, chatID int64, threadID int) bool
+	// GetUserAccount returns the account a user is restricted to, or an
+	// empty string for every account
+	GetUserAccount(userID int64) string
+	SetUserAccount(userID int64, account string) error
+	// GetUserRole returns the stored role of a user, or an empty string
+	// when none was set
+	GetUserRole(userID int64) string
+	// GetUserZones returns the zones a user is restricted to, or nil for
+	// every zone
+	GetUserZones(userID int64) []string
+	SetUserRole(userID int64, role string, zones []string) error
+}
+
+// AuditStorage defines the interface for the DNS change audit log
+type AuditStorage interface {
+	AppendAuditEntry(entry domain.AuditEntry) error
+	// GetAuditEntry returns the entry with the given ID or
+	// domain.ErrChangeNotFound
+	GetAuditEntry(id string) (*domain.AuditEntry, error)
+	// QueryAuditEntries returns matching entries newest first and the
+	// total number of matches before Offset and Limit are applied
+	QueryAuditEntries(filter domain.AuditFilter) ([]domain.AuditEntry, int, error)
+}
+
+// SnapshotStorage defines the interface for zone snapshot storage
+type SnapshotStorage interface {
+	SaveSnapshot(snapshot domain.Snapshot) error
+	// ListSnapshots returns the snapshots of a zone newest first, without
+	// their records
+	ListSnapshots(zoneName string) ([]domain.Snapshot, error)
+	// GetSnapshot returns a snapshot with its records or
+	// domain.ErrSnapshotNotFound
+	GetSnapshot(zoneName, id string) (*domain.Snapshot, error)
+	DeleteSnapshot(zoneName, id string) error
 }
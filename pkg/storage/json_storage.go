@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 )
 
@@ -147,6 +148,8 @@ func (s *jsonStorage) RemoveAPIKey(key string) error {
 	}
 
 	cfg.MCPAPIKeys = newKeys
+	delete(cfg.APIKeyAccounts, key)
+	delete(cfg.APIKeyScopes, key)
 	return s.Save(cfg)
 }
 
@@ -165,6 +168,62 @@ func (s *jsonStorage) IsValidAPIKey(key string) bool {
 	return false
 }
 
+// GetAPIKeyAccount returns the account an API key is restricted to
+func (s *jsonStorage) GetAPIKeyAccount(key string) string {
+	cfg, err := s.Load()
+	if err != nil {
+		return ""
+	}
+	return cfg.APIKeyAccounts[key]
+}
+
+// SetAPIKeyAccount restricts an API key to an account; an empty account
+// lifts the restriction
+func (s *jsonStorage) SetAPIKeyAccount(key, account string) error {
+	cfg, err := s.Load()
+	if err != nil {
+		return err
+	}
+
+	if account == "" {
+		delete(cfg.APIKeyAccounts, key)
+	} else {
+		if cfg.APIKeyAccounts == nil {
+			cfg.APIKeyAccounts = make(map[string]string)
+		}
+		cfg.APIKeyAccounts[key] = account
+	}
+	return s.Save(cfg)
+}
+
+// GetAPIKeyScope returns the scope of an API key
+func (s *jsonStorage) GetAPIKeyScope(key string) APIKeyScope {
+	cfg, err := s.Load()
+	if err != nil {
+		return APIKeyScope{}
+	}
+	return cfg.APIKeyScopes[key]
+}
+
+// SetAPIKeyScope restricts an API key to a scope; an empty scope lifts the
+// restriction
+func (s *jsonStorage) SetAPIKeyScope(key string, scope APIKeyScope) error {
+	cfg, err := s.Load()
+	if err != nil {
+		return err
+	}
+
+	if scope.IsEmpty() {
+		delete(cfg.APIKeyScopes, key)
+	} else {
+		if cfg.APIKeyScopes == nil {
+			cfg.APIKeyScopes = make(map[string]APIKeyScope)
+		}
+		cfg.APIKeyScopes[key] = scope
+	}
+	return s.Save(cfg)
+}
+
 // GetMCPHTTPPort returns the configured MCP HTTP port
 func (s *jsonStorage) GetMCPHTTPPort() (string, error) {
 	cfg, err := s.Load()
@@ -355,6 +414,7 @@ func (s *jsonStorage) RemoveAllowedUser(userID int64) error {
 		}
 	}
 	cfg.AllowedUsers = newUsers
+	delete(cfg.UserAccounts, userID)
 
 	return s.Save(cfg)
 }
@@ -372,6 +432,10 @@ func (s *jsonStorage) IsUserAllowed(userID int64, chatID int64, threadID int) bo
 			// Check if user has specific scope in V2
 			for _, u := range cfg.AllowedUsersV2 {
 				if u.UserID == userID {
+					// A user stored without scopes may use every chat
+					if len(u.Scopes) == 0 {
+						return true
+					}
 					// Check specific scope
 					for _, scope := range u.Scopes {
 						if scope.ChatID == chatID && scope.ThreadID == threadID {
@@ -388,3 +452,94 @@ func (s *jsonStorage) IsUserAllowed(userID int64, chatID int64, threadID int) bo
 
 	return false
 }
+
+// GetUserAccount returns the account a user is restricted to
+func (s *jsonStorage) GetUserAccount(userID int64) string {
+	cfg, err := s.Load()
+	if err != nil {
+		return ""
+	}
+	return cfg.UserAccounts[userID]
+}
+
+// SetUserAccount restricts a user to an account; an empty account lifts
+// the restriction
+func (s *jsonStorage) SetUserAccount(userID int64, account string) error {
+	cfg, err := s.Load()
+	if err != nil {
+		return err
+	}
+
+	if account == "" {
+		delete(cfg.UserAccounts, userID)
+	} else {
+		if cfg.UserAccounts == nil {
+			cfg.UserAccounts = make(map[int64]string)
+		}
+		cfg.UserAccounts[userID] = account
+	}
+	return s.Save(cfg)
+}
+
+// GetUserRole returns the stored role of a user
+func (s *jsonStorage) GetUserRole(userID int64) string {
+	cfg, err := s.Load()
+	if err != nil {
+		return ""
+	}
+	for _, u := range cfg.AllowedUsersV2 {
+		if u.UserID == userID {
+			return u.Role
+		}
+	}
+	return ""
+}
+
+// GetUserZones returns the zones a user is restricted to
+func (s *jsonStorage) GetUserZones(userID int64) []string {
+	cfg, err := s.Load()
+	if err != nil {
+		return nil
+	}
+	for _, u := range cfg.AllowedUsersV2 {
+		if u.UserID == userID {
+			return u.Zones
+		}
+	}
+	return nil
+}
+
+// SetUserRole sets the role and zones of a user; empty zones allow every
+// zone. Users only in the old format are stored without scopes, so they
+// keep access to every chat.
+func (s *jsonStorage) SetUserRole(userID int64, role string, zones []string) error {
+	switch role {
+	case RoleViewer, RoleEditor, RoleAdmin:
+	default:
+		return fmt.Errorf("invalid role %q", role)
+	}
+
+	cfg, err := s.Load()
+	if err != nil {
+		return err
+	}
+
+	for i, u := range cfg.AllowedUsersV2 {
+		if u.UserID == userID {
+			cfg.AllowedUsersV2[i].Role = role
+			cfg.AllowedUsersV2[i].Zones = zones
+			return s.Save(cfg)
+		}
+	}
+
+	if !slices.Contains(cfg.AllowedUsers, userID) {
+		return fmt.Errorf("user %d is not allowed", userID)
+	}
+	cfg.AllowedUsersV2 = append(cfg.AllowedUsersV2, AllowedUser{
+		UserID: userID,
+		Scopes: []AccessScope{},
+		Role:   role,
+		Zones:  zones,
+	})
+	return s.Save(cfg)
+}
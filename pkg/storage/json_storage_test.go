@@ -0,0 +1,101 @@
+package storage_test
+
+import (
+	"reflect"
+	"testing"
+
+	"cf-dns-bot/pkg/storage"
+)
+
+func TestJSONStorageAPIKeys(t *testing.T) {
+	dir := t.TempDir()
+	s := storage.NewJSONStorageWithAPIKeys(dir)
+
+	if err := s.AddAPIKey("mcp_one"); err != nil {
+		t.Fatalf("AddAPIKey: %v", err)
+	}
+	if err := s.AddAPIKey("mcp_one"); err == nil {
+		t.Error("second AddAPIKey succeeded, want an error")
+	}
+	scope := storage.APIKeyScope{Zones: []string{"example.com"}, ReadOnly: true}
+	if err := s.SetAPIKeyScope("mcp_one", scope); err != nil {
+		t.Fatalf("SetAPIKeyScope: %v", err)
+	}
+	if err := s.SetAPIKeyAccount("mcp_one", "staging"); err != nil {
+		t.Fatalf("SetAPIKeyAccount: %v", err)
+	}
+
+	// A new storage on the same directory reads the saved file
+	reopened := storage.NewJSONStorageWithAPIKeys(dir)
+	if !reopened.IsValidAPIKey("mcp_one") || reopened.IsValidAPIKey("mcp_two") {
+		t.Error("IsValidAPIKey does not match the stored keys")
+	}
+	if got := reopened.GetAPIKeyScope("mcp_one"); !reflect.DeepEqual(got, scope) {
+		t.Errorf("got scope %+v, want %+v", got, scope)
+	}
+	if got := reopened.GetAPIKeyAccount("mcp_one"); got != "staging" {
+		t.Errorf("got account %q, want staging", got)
+	}
+
+	// Removing a key drops its scope and account
+	if err := reopened.RemoveAPIKey("mcp_one"); err != nil {
+		t.Fatalf("RemoveAPIKey: %v", err)
+	}
+	if err := reopened.AddAPIKey("mcp_one"); err != nil {
+		t.Fatalf("AddAPIKey after remove: %v", err)
+	}
+	if got := reopened.GetAPIKeyScope("mcp_one"); !got.IsEmpty() {
+		t.Errorf("got scope %+v after remove, want none", got)
+	}
+	if got := reopened.GetAPIKeyAccount("mcp_one"); got != "" {
+		t.Errorf("got account %q after remove, want none", got)
+	}
+	if err := reopened.RemoveAPIKey("mcp_unknown"); err == nil {
+		t.Error("RemoveAPIKey of an unknown key succeeded, want an error")
+	}
+}
+
+func TestJSONStorageAllowedUsers(t *testing.T) {
+	s := storage.NewJSONStorageWithAPIKeys(t.TempDir())
+	group := storage.AccessScope{ChatID: -100, ThreadID: 7}
+
+	if err := s.AddAllowedUser(42, group); err != nil {
+		t.Fatalf("AddAllowedUser: %v", err)
+	}
+	if !s.IsUserAllowed(42, -100, 7) {
+		t.Error("user is not allowed in the added chat and thread")
+	}
+	if s.IsUserAllowed(42, -100, 8) || s.IsUserAllowed(43, -100, 7) {
+		t.Error("user is allowed outside the added scope")
+	}
+
+	if err := s.SetUserRole(42, storage.RoleEditor, []string{"example.com"}); err != nil {
+		t.Fatalf("SetUserRole: %v", err)
+	}
+	if got := s.GetUserRole(42); got != storage.RoleEditor {
+		t.Errorf("got role %q, want editor", got)
+	}
+	if got := s.GetUserZones(42); !reflect.DeepEqual(got, []string{"example.com"}) {
+		t.Errorf("got zones %v, want example.com", got)
+	}
+	if err := s.SetUserRole(42, "owner", nil); err == nil {
+		t.Error("SetUserRole with an unknown role succeeded, want an error")
+	}
+
+	if err := s.RemoveAllowedUser(42); err != nil {
+		t.Fatalf("RemoveAllowedUser: %v", err)
+	}
+	if s.IsUserAllowed(42, -100, 7) {
+		t.Error("removed user is still allowed")
+	}
+}
+
+func TestJSONStorageLoadDefaults(t *testing.T) {
+	cfg, err := storage.NewJSONStorage(t.TempDir()).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.DefaultTTL != 300 || !cfg.DefaultProxied || cfg.MCPHTTPPort != "8875" {
+		t.Errorf("got %+v, want the defaults", cfg)
+	}
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"cf-dns-bot/internal/domain"
+)
+
+// snapshotStorage implements SnapshotStorage with one JSON file per
+// snapshot in dataDir/snapshots/<zone>/<id>.json
+type snapshotStorage struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewSnapshotStorage creates a new snapshot storage in dataDir/snapshots
+func NewSnapshotStorage(dataDir string) SnapshotStorage {
+	return &snapshotStorage{
+		dir: filepath.Join(dataDir, "snapshots"),
+	}
+}
+
+// SaveSnapshot writes a snapshot to disk
+func (s *snapshotStorage) SaveSnapshot(snapshot domain.Snapshot) error {
+	path, err := s.path(snapshot.ZoneName, snapshot.ID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a partial snapshot
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// ListSnapshots returns the snapshots of a zone newest first
+func (s *snapshotStorage) ListSnapshots(zoneName string) ([]domain.Snapshot, error) {
+	zoneDir, err := s.path(zoneName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	files, err := filepath.Glob(filepath.Join(zoneDir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	snapshots := make([]domain.Snapshot, 0, len(files))
+	for _, file := range files {
+		snapshot, err := readSnapshot(file)
+		if err != nil {
+			continue
+		}
+		snapshot.Records = nil
+		snapshots = append(snapshots, *snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// GetSnapshot returns a snapshot with its records
+func (s *snapshotStorage) GetSnapshot(zoneName, id string) (*domain.Snapshot, error) {
+	path, err := s.path(zoneName, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot, err := readSnapshot(path)
+	if os.IsNotExist(err) {
+		return nil, domain.ErrSnapshotNotFound
+	}
+	return snapshot, err
+}
+
+// DeleteSnapshot removes a snapshot
+func (s *snapshotStorage) DeleteSnapshot(zoneName, id string) error {
+	path, err := s.path(zoneName, id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+	return nil
+}
+
+// path returns the file of a snapshot, or the zone directory when id is
+// empty. Names that could escape the snapshot directory are rejected.
+func (s *snapshotStorage) path(zoneName, id string) (string, error) {
+	zoneName = strings.ToLower(zoneName)
+	for _, part := range []string{zoneName, id} {
+		if strings.ContainsAny(part, `/\`) || part == "." || part == ".." {
+			return "", fmt.Errorf("invalid snapshot path %q", part)
+		}
+	}
+	if zoneName == "" {
+		return "", fmt.Errorf("zone name is required")
+	}
+
+	if id == "" {
+		return filepath.Join(s.dir, zoneName), nil
+	}
+	return filepath.Join(s.dir, zoneName, id+".json"), nil
+}
+
+// readSnapshot reads a snapshot file
+func readSnapshot(path string) (*domain.Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot domain.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", filepath.Base(path), err)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,67 @@
+package storage_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cf-dns-bot/internal/domain"
+	"cf-dns-bot/pkg/storage"
+)
+
+func TestSnapshotStorage(t *testing.T) {
+	s := storage.NewSnapshotStorage(t.TempDir())
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []domain.DNSRecord{{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300}}
+
+	for i, id := range []string{"s1", "s2"} {
+		snapshot := domain.Snapshot{ID: id, ZoneName: "example.com", CreatedAt: start.Add(time.Duration(i) * time.Hour), RecordCount: 1, Records: records}
+		if err := s.SaveSnapshot(snapshot); err != nil {
+			t.Fatalf("SaveSnapshot: %v", err)
+		}
+	}
+
+	// Listed newest first, without records
+	snapshots, err := s.ListSnapshots("Example.com")
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].ID != "s2" || snapshots[1].ID != "s1" {
+		t.Fatalf("got %+v, want s2 then s1", snapshots)
+	}
+	if snapshots[0].Records != nil || snapshots[0].RecordCount != 1 {
+		t.Errorf("got %+v, want the count without records", snapshots[0])
+	}
+
+	snapshot, err := s.GetSnapshot("example.com", "s1")
+	if err != nil {
+		t.Fatalf("GetSnapshot: %v", err)
+	}
+	if len(snapshot.Records) != 1 || snapshot.Records[0].Content != "192.0.2.1" {
+		t.Errorf("got records %+v, want the saved record", snapshot.Records)
+	}
+
+	if err := s.DeleteSnapshot("example.com", "s1"); err != nil {
+		t.Fatalf("DeleteSnapshot: %v", err)
+	}
+	if _, err := s.GetSnapshot("example.com", "s1"); !errors.Is(err, domain.ErrSnapshotNotFound) {
+		t.Errorf("got error %v, want ErrSnapshotNotFound", err)
+	}
+	if snapshots, _ := s.ListSnapshots("example.org"); len(snapshots) != 0 {
+		t.Errorf("got %d snapshots of another zone, want 0", len(snapshots))
+	}
+}
+
+func TestSnapshotStorageRejectsPaths(t *testing.T) {
+	s := storage.NewSnapshotStorage(t.TempDir())
+
+	for _, snapshot := range []domain.Snapshot{
+		{ID: "../s1", ZoneName: "example.com"},
+		{ID: "s1", ZoneName: ".."},
+		{ID: "s1", ZoneName: ""},
+	} {
+		if err := s.SaveSnapshot(snapshot); err == nil {
+			t.Errorf("SaveSnapshot(%q, %q) succeeded, want an error", snapshot.ZoneName, snapshot.ID)
+		}
+	}
+}